@@ -0,0 +1,200 @@
+// Package main предоставляет инструмент переноса данных между хранилищами сервиса сокращения URL.
+// Инструмент читает записи из файлового хранилища или базы данных и сохраняет их в другое хранилище
+// с прежними короткими идентификаторами, владельцами и флагами удаления. Сервис на время переноса
+// нужно остановить: файловое хранилище не рассчитано на одновременную запись из нескольких процессов.
+//
+// Использование:
+//
+//	shortener-migrate -from-file=/tmp/short-url-db.json -to-dsn=postgres://... [флаги]
+//
+// Флаги:
+//
+//	-from-file, -from-dsn  хранилище-источник: файл или строка подключения к PostgreSQL
+//	-to-file, -to-dsn      хранилище-приёмник: файл или строка подключения к PostgreSQL
+//	-batch                 количество записей, читаемых и сохраняемых за один раз
+//	-dry-run               только сверить источник с приёмником, ничего не сохраняя
+//	-checkpoint            файл контрольной точки; прерванный перенос продолжится с сохранённой позиции
+//	-verify                после переноса сравнить количество записей и сверить выборку записей
+//	-sample                размер выборки для проверки
+//
+// Итог переноса и проверки выводится в stdout в формате JSON. Код завершения 1 означает ошибку,
+// 2 - конфликтующие записи или непройденную проверку.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/iubondar/url-shortener/internal/app/migrate"
+	"github.com/iubondar/url-shortener/internal/app/storage/file"
+	"github.com/iubondar/url-shortener/internal/app/storage/pg"
+	"github.com/iubondar/url-shortener/internal/logging"
+)
+
+// options содержит параметры запуска инструмента.
+type options struct {
+	fromFile   string // файл хранилища-источника
+	fromDSN    string // строка подключения к базе данных-источнику
+	toFile     string // файл хранилища-приёмника
+	toDSN      string // строка подключения к базе данных-приёмнику
+	batchSize  int    // количество записей в пачке
+	dryRun     bool   // только сверить источник с приёмником
+	checkpoint string // файл контрольной точки
+	verify     bool   // проверить перенос
+	sampleSize int    // размер выборки для проверки
+}
+
+// result представляет итог работы инструмента, выводимый в stdout.
+type result struct {
+	DryRun bool                  `json:"dry_run"`          // перенос выполнялся в режиме проверки
+	Report migrate.Report        `json:"report"`           // итог переноса
+	Verify *migrate.VerifyReport `json:"verify,omitempty"` // итог проверки
+}
+
+// main является точкой входа в инструмент переноса данных.
+func main() {
+	opts, err := parseOptions(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := logging.NewLogger(config.Config{LogFormat: logging.FormatConsole})
+	if err != nil {
+		log.Fatal(err)
+	}
+	zap.ReplaceGlobals(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code, err := run(ctx, opts)
+	if err != nil {
+		zap.L().Sugar().Errorf("Migration failed: %v", err)
+	}
+	// Ошибку синхронизации stderr игнорируем: на части платформ она не поддерживается
+	_ = logger.Sync()
+	os.Exit(code)
+}
+
+// parseOptions разбирает флаги командной строки.
+// Возвращает ошибку, если источник или приёмник не заданы либо заданы неоднозначно.
+func parseOptions(name string, args []string) (options, error) {
+	var opts options
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.fromFile, "from-file", "", "source file storage path")
+	fs.StringVar(&opts.fromDSN, "from-dsn", "", "source database DSN")
+	fs.StringVar(&opts.toFile, "to-file", "", "target file storage path")
+	fs.StringVar(&opts.toDSN, "to-dsn", "", "target database DSN")
+	fs.IntVar(&opts.batchSize, "batch", migrate.DefaultBatchSize, "records per batch")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "compare source with target without writing")
+	fs.StringVar(&opts.checkpoint, "checkpoint", "", "checkpoint file to resume an interrupted migration")
+	fs.BoolVar(&opts.verify, "verify", true, "compare record counts and a sample of records after migration")
+	fs.IntVar(&opts.sampleSize, "sample", migrate.DefaultSampleSize, "records to compare during verification")
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+
+	if (opts.fromFile == "") == (opts.fromDSN == "") {
+		return options{}, errors.New("exactly one of -from-file and -from-dsn is required")
+	}
+	if (opts.toFile == "") == (opts.toDSN == "") {
+		return options{}, errors.New("exactly one of -to-file and -to-dsn is required")
+	}
+	if opts.fromFile != "" && opts.fromFile == opts.toFile || opts.fromDSN != "" && opts.fromDSN == opts.toDSN {
+		return options{}, errors.New("source and target must differ")
+	}
+	return opts, nil
+}
+
+// run переносит записи и проверяет результат. Возвращает код завершения процесса.
+func run(ctx context.Context, opts options) (code int, err error) {
+	source, closeSource, err := openStorage(opts.fromFile, opts.fromDSN)
+	if err != nil {
+		return 1, fmt.Errorf("open source: %w", err)
+	}
+	defer closeStorage("source", closeSource)
+
+	target, closeTarget, err := openStorage(opts.toFile, opts.toDSN)
+	if err != nil {
+		return 1, fmt.Errorf("open target: %w", err)
+	}
+	defer closeStorage("target", closeTarget)
+
+	migrator := migrate.NewMigrator(source, target).
+		WithBatchSize(opts.batchSize).
+		WithDryRun(opts.dryRun).
+		WithCheckpoint(opts.checkpoint)
+
+	out := result{DryRun: opts.dryRun}
+	out.Report, err = migrator.Run(ctx)
+	if err != nil {
+		printResult(out)
+		return 1, err
+	}
+	if len(out.Report.Conflicts) > 0 {
+		code = 2
+	}
+
+	if opts.verify && !opts.dryRun {
+		verify, err := migrator.Verify(ctx, opts.sampleSize)
+		if err != nil {
+			printResult(out)
+			return 1, fmt.Errorf("verify: %w", err)
+		}
+		out.Verify = &verify
+		if !verify.OK() {
+			code = 2
+		}
+	}
+
+	printResult(out)
+	return code, nil
+}
+
+// openStorage открывает файловое хранилище или базу данных.
+// Возвращает хранилище и функцию освобождения его ресурсов.
+func openStorage(path string, dsn string) (migrate.Target, func() error, error) {
+	if path != "" {
+		repo, err := file.NewFileRepository(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, func() error { return nil }, nil
+	}
+
+	db, err := pg.NewDB(dsn, pg.Options{})
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := pg.NewPGRepository(db, 0)
+	if err != nil {
+		return nil, nil, errors.Join(err, db.Close())
+	}
+	return repo, func() error { return errors.Join(repo.Close(), db.Close()) }, nil
+}
+
+// closeStorage освобождает ресурсы хранилища и записывает ошибку в журнал.
+func closeStorage(name string, closeFn func() error) {
+	if err := closeFn(); err != nil {
+		zap.L().Sugar().Errorf("Error closing %s: %v", name, err)
+	}
+}
+
+// printResult выводит итог работы в stdout.
+func printResult(out result) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		zap.L().Sugar().Errorf("Error writing result: %v", err)
+	}
+}
@@ -4,17 +4,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
 
 	"github.com/iubondar/url-shortener/internal/api/handlers"
 	"github.com/iubondar/url-shortener/internal/app/config"
 	"github.com/iubondar/url-shortener/internal/app/router"
 	"github.com/iubondar/url-shortener/internal/app/server"
+	"github.com/iubondar/url-shortener/internal/app/storage/pg"
+	"github.com/iubondar/url-shortener/internal/logging"
+	"github.com/iubondar/url-shortener/internal/tracing"
 
 	_ "net/http/pprof" // подключаем пакет pprof
 )
@@ -25,10 +28,6 @@ var (
 	buildCommit  string
 )
 
-func init() {
-	zap.ReplaceGlobals(zap.Must(zap.NewDevelopment()))
-}
-
 // main является точкой входа в серверное приложение.
 // Функция инициализирует конфигурацию, подключает выбранное хранилище данных,
 // настраивает маршрутизацию и запускает HTTP-сервер.
@@ -39,15 +38,60 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	logger, err := logging.NewLogger(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	zap.ReplaceGlobals(logger)
+	defer func() {
+		// Ошибку синхронизации stderr игнорируем: на части платформ она не поддерживается
+		_ = logger.Sync()
+	}()
+
 	zap.L().Sugar().Debugln(
 		"Config: ",
 		"ServerAddress", config.ServerAddress,
 		"BaseURLAddress", config.BaseURLAddress,
 		"FileStoragePath", config.FileStoragePath,
 		"DatabaseDSN", config.DatabaseDSN,
+		"MigrationMode", config.MigrationMode,
+		"DBMaxOpenConns", config.DBMaxOpenConns,
+		"DBMinConns", config.DBMinConns,
+		"DBConnMaxLifetime", config.DBConnMaxLifetime,
+		"DBConnMaxIdleTime", config.DBConnMaxIdleTime,
+		"DBStatementTimeout", config.DBStatementTimeout,
+		"DBTxTimeout", config.DBTxTimeout,
+		"DBConnectTimeout", config.DBConnectTimeout,
+		"DatabaseReplicas", len(pg.ReplicaDSNs(config.DatabaseReplicaDSNs)),
+		"DBReplicaMaxLag", config.DBReplicaMaxLag,
+		"RetentionDays", config.RetentionDays,
 		"EnableHTTPS", config.EnableHTTPS,
+		"AdminAPIEnabled", len(config.AdminToken) > 0,
+		"URLPolicyFile", config.URLPolicyFile,
+		"URLPolicyEndpoint", config.URLPolicyEndpoint,
+		"MetricsAddress", config.MetricsAddress,
+		"OTLPEndpoint", config.OTLPEndpoint,
+		"LogLevel", config.LogLevel,
+		"LogFormat", config.LogFormat,
+		"LogFile", config.LogFile,
+		"MaxBodySize", config.MaxBodySize,
+		"MaxBatchBodySize", config.MaxBatchBodySize,
+		"MaxBatchItems", config.MaxBatchItems,
+		"MaxDecompressedSize", config.MaxDecompressedSize,
+		"MaxCompressionRatio", config.MaxCompressionRatio,
 	)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), config.OTLPEndpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			zap.L().Sugar().Errorf("Error shutting down tracing: %v", err)
+		}
+	}()
+
 	factory := handlers.NewFactory(config)
 	defer func() {
 		if err := factory.Close(); err != nil {
@@ -55,7 +99,7 @@ func main() {
 		}
 	}()
 
-	router, err := router.NewRouter(factory)
+	router, err := router.NewRouter(factory, config)
 	if err != nil {
 		log.Fatal(err)
 	}
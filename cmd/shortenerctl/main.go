@@ -0,0 +1,118 @@
+// Package main предоставляет утилиту администрирования сервиса сокращения URL.
+// Утилита работает с хранилищем из конфигурации сервиса: флаги, переменные окружения
+// и JSON-файл конфигурации задаются так же, как для сервиса, а команда и её аргументы
+// следуют после флагов конфигурации. Если задана строка подключения к базе данных,
+// используется PostgreSQL, иначе - файловое хранилище.
+//
+// Использование:
+//
+//	shortenerctl [флаги конфигурации] <команда> [аргументы]
+//
+// Команды:
+//
+//	lookup <id>                 показать ссылку
+//	list -user <uuid>           показать ссылки пользователя; -limit ограничивает количество,
+//	                            -deleted include|exclude|only задаёт отбор удаленных ссылок
+//	soft-delete <id>...         пометить ссылки удаленными
+//	restore <id>...             снять отметку об удалении
+//	purge [-yes]                безвозвратно удалить удаленные ссылки; без -yes только подсчитать их
+//	counts                      показать количество записей, удаленных и заблокированных ссылок и пользователей
+//	migrate up|down|status      применить, откатить последнюю миграцию схемы или показать их состояние
+//
+// Каждая команда принимает флаг -o table|json. Миграции схемы запускаются только командой migrate:
+// остальные команды работают с уже подготовленной базой данных.
+// Код завершения 1 означает ошибку, 2 - неверную команду или аргументы.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/iubondar/url-shortener/internal/app/ctl"
+	"github.com/iubondar/url-shortener/internal/app/storage/file"
+	"github.com/iubondar/url-shortener/internal/app/storage/pg"
+	"github.com/iubondar/url-shortener/internal/logging"
+)
+
+// main является точкой входа в утилиту администрирования.
+func main() {
+	conf, args, err := config.NewConfigWithArgs(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := logging.NewLogger(config.Config{LogFormat: logging.FormatConsole})
+	if err != nil {
+		log.Fatal(err)
+	}
+	zap.ReplaceGlobals(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code := 0
+	if err := run(ctx, conf, args); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		code = 1
+		if errors.Is(err, ctl.ErrUsage) {
+			fmt.Fprintln(os.Stderr, ctl.Usage)
+			code = 2
+		}
+	}
+	// Ошибку синхронизации stderr игнорируем: на части платформ она не поддерживается
+	_ = logger.Sync()
+	os.Exit(code)
+}
+
+// run открывает хранилище из конфигурации и выполняет команду.
+func run(ctx context.Context, conf config.Config, args []string) error {
+	if len(conf.DatabaseDSN) == 0 {
+		if len(conf.FileStoragePath) == 0 {
+			return errors.New("neither database DSN nor file storage path is configured")
+		}
+		repo, err := file.NewFileRepository(conf.FileStoragePath)
+		if err != nil {
+			return fmt.Errorf("open file storage: %w", err)
+		}
+		return ctl.NewCLI(repo, os.Stdout).Run(ctx, args)
+	}
+
+	opts, err := pg.OptionsFromConfig(conf)
+	if err != nil {
+		return err
+	}
+	db, err := pg.Open(conf.DatabaseDSN, opts)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			zap.L().Sugar().Errorf("Error closing database connection: %v", err)
+		}
+	}()
+
+	// Запросы хранилища требуют актуальной схемы,
+	// поэтому для управления миграциями хранилище не открываем
+	if len(args) > 0 && args[0] == "migrate" {
+		return ctl.NewCLI(nil, os.Stdout).WithMigrator(db).Run(ctx, args)
+	}
+
+	repo, err := pg.NewPGRepository(db, 0)
+	if err != nil {
+		return fmt.Errorf("open database storage: %w", err)
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			zap.L().Sugar().Errorf("Error closing database storage: %v", err)
+		}
+	}()
+	return ctl.NewCLI(repo, os.Stdout).WithMigrator(db).Run(ctx, args)
+}
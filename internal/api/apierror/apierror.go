@@ -0,0 +1,202 @@
+// Пакет apierror описывает ошибки HTTP API с машиночитаемыми кодами
+// и единообразно записывает их в ответ.
+//
+// Для маршрутов /api/* ошибка передаётся в формате RFC 7807 (application/problem+json)
+// с кодом ошибки и идентификатором запроса. Для остальных маршрутов, например редиректа,
+// ответ остаётся текстовым. Подробности внутренних ошибок клиенту не передаются,
+// а пишутся в журнал вместе с идентификатором запроса.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"go.uber.org/zap"
+)
+
+// ContentType - тип содержимого ответа с описанием проблемы по RFC 7807.
+const ContentType = "application/problem+json"
+
+// apiPrefix - префикс маршрутов, ошибки которых передаются в формате JSON.
+const apiPrefix = "/api/"
+
+// Code - машиночитаемый код ошибки API.
+type Code string
+
+// Коды ошибок API.
+const (
+	CodeInvalidRequest       Code = "invalid_request"        // некорректный запрос
+	CodeInvalidURL           Code = "invalid_url"            // некорректный или запрещённый URL
+	CodeUnauthorized         Code = "unauthorized"           // нет прав на выполнение запроса
+	CodeNotFound             Code = "not_found"              // запись не найдена
+	CodeMethodNotAllowed     Code = "method_not_allowed"     // метод не поддерживается
+	CodeConflict             Code = "conflict"               // конфликт с существующими данными
+	CodeGone                 Code = "gone"                   // запись удалена или заблокирована
+	CodeTooLarge             Code = "too_large"              // превышено ограничение размера
+	CodeUnsupportedMediaType Code = "unsupported_media_type" // неподдерживаемое кодирование тела
+	CodeRateLimited          Code = "rate_limited"           // превышена частота запросов
+	CodeBlocked              Code = "blocked"                // запись заблокирована по юридическим основаниям
+	CodeInternal             Code = "internal"               // внутренняя ошибка сервиса
+	CodeUnavailable          Code = "unavailable"            // зависимость сервиса недоступна
+)
+
+// statuses сопоставляет кодам ошибок HTTP-статусы.
+var statuses = map[Code]int{
+	CodeInvalidRequest:       http.StatusBadRequest,
+	CodeInvalidURL:           http.StatusBadRequest,
+	CodeUnauthorized:         http.StatusUnauthorized,
+	CodeNotFound:             http.StatusNotFound,
+	CodeMethodNotAllowed:     http.StatusMethodNotAllowed,
+	CodeConflict:             http.StatusConflict,
+	CodeGone:                 http.StatusGone,
+	CodeTooLarge:             http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	CodeRateLimited:          http.StatusTooManyRequests,
+	CodeBlocked:              http.StatusUnavailableForLegalReasons,
+	CodeInternal:             http.StatusInternalServerError,
+	CodeUnavailable:          http.StatusServiceUnavailable,
+}
+
+// Status возвращает HTTP-статус кода ошибки.
+// Для неизвестного кода возвращает 500 Internal Server Error.
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error представляет ошибку API.
+// Detail передаётся клиенту, Err - исходная ошибка, которая пишется только в журнал.
+type Error struct {
+	Code   Code   // машиночитаемый код ошибки
+	Detail string // описание ошибки для клиента
+	Err    error  // исходная ошибка
+}
+
+// New создает ошибку API с кодом и описанием для клиента.
+func New(code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+// Wrap создает ошибку API, сохраняя исходную ошибку для журнала.
+func Wrap(code Code, detail string, err error) *Error {
+	return &Error{Code: code, Detail: detail, Err: err}
+}
+
+// Internal создает внутреннюю ошибку. Клиент получает только общее описание.
+func Internal(err error) *Error {
+	return Wrap(CodeInternal, "internal server error", err)
+}
+
+// Error возвращает текстовое описание ошибки вместе с исходной ошибкой.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Detail + ": " + e.Err.Error()
+	}
+	return string(e.Code) + ": " + e.Detail
+}
+
+// Unwrap возвращает исходную ошибку.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status возвращает HTTP-статус ошибки.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// From приводит произвольную ошибку к ошибке API:
+// ошибки API возвращаются как есть, models.ErrorNotFound становится not_found,
+// models.ErrorOriginalURLExists - conflict, models.ErrorDeletionQueueFull - unavailable,
+// превышение размера тела - too_large, остальные ошибки считаются внутренними.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	if errors.Is(err, models.ErrorNotFound) {
+		return Wrap(CodeNotFound, "not found", err)
+	}
+	if errors.Is(err, models.ErrorOriginalURLExists) {
+		return Wrap(CodeConflict, "URL is already shortened", err)
+	}
+	if errors.Is(err, models.ErrorDeletionQueueFull) {
+		return Wrap(CodeUnavailable, "too many pending deletions, retry later", err)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return Wrap(CodeTooLarge, "request body is too large", err)
+	}
+	return Internal(err)
+}
+
+// Problem представляет тело ответа с описанием проблемы по RFC 7807,
+// дополненное кодом ошибки и идентификатором запроса.
+type Problem struct {
+	Type      string `json:"type"`                 // ссылка на описание типа проблемы
+	Title     string `json:"title"`                // краткое описание HTTP-статуса
+	Status    int    `json:"status"`               // HTTP-статус
+	Detail    string `json:"detail,omitempty"`     // описание конкретной ошибки
+	Instance  string `json:"instance,omitempty"`   // путь запроса
+	Code      Code   `json:"code"`                 // машиночитаемый код ошибки
+	RequestID string `json:"request_id,omitempty"` // идентификатор запроса
+}
+
+// NewProblem создает описание проблемы для ошибки API, возникшей при обработке запроса.
+func NewProblem(r *http.Request, e *Error) Problem {
+	status := e.Status()
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Detail,
+		Instance:  r.URL.Path,
+		Code:      e.Code,
+		RequestID: logctx.RequestID(r.Context()),
+	}
+}
+
+// IsAPI сообщает, относится ли запрос к JSON API.
+func IsAPI(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiPrefix)
+}
+
+// Write записывает ошибку в ответ.
+// Для маршрутов /api/* ответ передаётся в формате application/problem+json,
+// для остальных - текстом. Внутренние ошибки пишутся в журнал запроса.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	status := e.Status()
+
+	logger := logctx.FromContext(r.Context())
+	if status >= http.StatusInternalServerError {
+		logger.Error("request failed", zap.String("code", string(e.Code)), zap.Error(e))
+	} else {
+		logger.Debug("request rejected", zap.String("code", string(e.Code)), zap.Error(e))
+	}
+
+	if !IsAPI(r) {
+		http.Error(w, e.Detail, status)
+		return
+	}
+	WriteProblem(w, status, NewProblem(r, e))
+}
+
+// WriteProblem записывает в ответ статус и описание проблемы в формате application/problem+json.
+// Позволяет передать описание, дополненное собственными полями.
+func WriteProblem(w http.ResponseWriter, status int, problem any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		// заголовки уже отправлены, сообщить об ошибке клиенту нельзя
+		zap.L().Debug("error writing problem response", zap.Error(err))
+	}
+}
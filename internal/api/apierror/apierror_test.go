@@ -0,0 +1,160 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ExampleWrite демонстрирует ответ API с описанием проблемы.
+func ExampleWrite() {
+	r := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	w := httptest.NewRecorder()
+
+	Write(w, r, New(CodeInvalidURL, "URL is not valid"))
+
+	fmt.Println(w.Code, w.Header().Get("Content-Type"))
+	fmt.Print(w.Body.String())
+	// Output:
+	// 400 application/problem+json
+	// {"type":"about:blank","title":"Bad Request","status":400,"detail":"URL is not valid","instance":"/api/user/urls","code":"invalid_url"}
+}
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   Code
+		wantStatus int
+		wantDetail string
+	}{
+		{
+			name:       "API error",
+			err:        fmt.Errorf("wrapped: %w", New(CodeConflict, "already exists")),
+			wantCode:   CodeConflict,
+			wantStatus: http.StatusConflict,
+			wantDetail: "already exists",
+		},
+		{
+			name:       "Not found",
+			err:        fmt.Errorf("retrieve: %w", models.ErrorNotFound),
+			wantCode:   CodeNotFound,
+			wantStatus: http.StatusNotFound,
+			wantDetail: "not found",
+		},
+		{
+			name:       "Deletion queue full",
+			err:        fmt.Errorf("delete: %w", models.ErrorDeletionQueueFull),
+			wantCode:   CodeUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+			wantDetail: "too many pending deletions, retry later",
+		},
+		{
+			name:       "Body too large",
+			err:        &http.MaxBytesError{Limit: 10},
+			wantCode:   CodeTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantDetail: "request body is too large",
+		},
+		{
+			name:       "Internal",
+			err:        errors.New("pq: connection refused"),
+			wantCode:   CodeInternal,
+			wantStatus: http.StatusInternalServerError,
+			wantDetail: "internal server error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := From(tt.err)
+			assert.Equal(t, tt.wantCode, e.Code)
+			assert.Equal(t, tt.wantStatus, e.Status())
+			assert.Equal(t, tt.wantDetail, e.Detail)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		err             error
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "API route",
+			path:            "/api/shorten",
+			err:             Wrap(CodeInvalidRequest, "request body is not valid JSON", errors.New("unexpected end of JSON input")),
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: ContentType,
+		},
+		{
+			name:            "Redirect route",
+			path:            "/abc",
+			err:             models.ErrorNotFound,
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "not found\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r = r.WithContext(logctx.WithRequestID(r.Context(), "req-1"))
+			w := httptest.NewRecorder()
+
+			Write(w, r, tt.err)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			if tt.wantContentType != ContentType {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+				return
+			}
+
+			var p Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, Problem{
+				Type:      "about:blank",
+				Title:     "Bad Request",
+				Status:    http.StatusBadRequest,
+				Detail:    "request body is not valid JSON",
+				Instance:  tt.path,
+				Code:      CodeInvalidRequest,
+				RequestID: "req-1",
+			}, p)
+		})
+	}
+}
+
+func TestWrite_InternalHidden(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	r := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	r = r.WithContext(logctx.NewContext(r.Context(), zap.New(core).With(zap.String("request_id", "req-2"))))
+	w := httptest.NewRecorder()
+
+	Write(w, r, errors.New("pq: password authentication failed"))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+
+	entries := logs.FilterMessage("request failed").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "req-2", fields["request_id"])
+	assert.Equal(t, string(CodeInternal), fields["code"])
+	assert.Contains(t, fields["error"], "password authentication failed")
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+)
+
+// AccountStore определяет интерфейс хранилища для удаления учётной записи пользователя.
+type AccountStore interface {
+	// DeleteAccount безвозвратно удаляет все записи пользователя и отзывает его токены.
+	// Возвращает количество удалённых записей.
+	DeleteAccount(ctx context.Context, userID uuid.UUID) (purged int, err error)
+}
+
+// AccountDeletionOut представляет результат удаления учётной записи в ответе API.
+type AccountDeletionOut struct {
+	PurgedURLs int `json:"purged_urls"` // количество безвозвратно удалённых ссылок
+}
+
+// AccountHandler обрабатывает запросы к учётной записи пользователя.
+type AccountHandler struct {
+	store AccountStore // хранилище записей пользователя
+}
+
+// NewAccountHandler создает новый экземпляр AccountHandler.
+func NewAccountHandler(store AccountStore) AccountHandler {
+	return AccountHandler{store: store}
+}
+
+// DeleteAccount обрабатывает HTTP DELETE запрос удаления учётной записи пользователя.
+// Безвозвратно удаляет все ссылки пользователя вместе с историей версий и отзывает его токены:
+// следующие запросы с тем же cookie получат новый идентификатор пользователя.
+// Возвращает статус 200 OK с количеством удалённых ссылок и удаляет cookie аутентификации.
+// Если запрос не содержит действующего cookie аутентификации, возвращает 401 Unauthorized.
+func (handler AccountHandler) DeleteAccount(res http.ResponseWriter, req *http.Request) {
+	authCookie, err := req.Cookie(auth.AuthCookieName)
+	if err != nil {
+		apierror.Write(res, req, apierror.New(apierror.CodeUnauthorized, "auth cookie is required"))
+		return
+	}
+	userID, err := auth.GetUserID(authCookie.Value)
+	if err != nil {
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeUnauthorized, "auth cookie is invalid", err))
+		return
+	}
+
+	purged, err := handler.store.DeleteAccount(req.Context(), userID)
+	if err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+
+	auth.ExpireAuthCookie(res)
+	writeJSON(res, req, http.StatusOK, AccountDeletionOut{PurgedURLs: purged})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleAccountHandler_DeleteAccount демонстрирует удаление учётной записи пользователя.
+func ExampleAccountHandler_DeleteAccount() {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{{ShortURL: "abc", OriginalURL: "http://example.com", UserID: userID}},
+	}
+	handler := NewAccountHandler(repo)
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/user", nil)
+	authCookie, _ := auth.NewAuthCookie(userID)
+	request.AddCookie(authCookie)
+	w := httptest.NewRecorder()
+
+	handler.DeleteAccount(w, request)
+
+	fmt.Println(w.Code)
+	fmt.Println(w.Body.String())
+	// Output:
+	// 200
+	// {"purged_urls":1}
+}
+
+func TestAccountHandler_DeleteAccount(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	tests := []struct {
+		name        string
+		cookie      string // значение cookie аутентификации, пустая строка - без cookie
+		wantCode    int
+		wantPurged  int
+		wantRecords int
+	}{
+		{
+			name:        "Delete own account",
+			cookie:      "valid",
+			wantCode:    http.StatusOK,
+			wantPurged:  2,
+			wantRecords: 1,
+		},
+		{
+			name:        "No cookie",
+			wantCode:    http.StatusUnauthorized,
+			wantRecords: 3,
+		},
+		{
+			name:        "Invalid cookie",
+			cookie:      "garbage",
+			wantCode:    http.StatusUnauthorized,
+			wantRecords: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &simple_storage.SimpleRepository{
+				Records: []models.Record{
+					{ShortURL: "a", OriginalURL: "http://example.com/a", UserID: userID},
+					{ShortURL: "b", OriginalURL: "http://example.com/b", UserID: userID, IsDeleted: true},
+					{ShortURL: "c", OriginalURL: "http://example.com/c", UserID: otherID},
+				},
+			}
+			handler := NewAccountHandler(repo)
+
+			request := httptest.NewRequest(http.MethodDelete, "/api/user", nil)
+			switch tt.cookie {
+			case "valid":
+				authCookie, err := auth.NewAuthCookie(userID)
+				require.NoError(t, err)
+				request.AddCookie(authCookie)
+			case "":
+			default:
+				request.AddCookie(&http.Cookie{Name: auth.AuthCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+
+			handler.DeleteAccount(w, request)
+
+			res := w.Result()
+			defer func() {
+				if err := res.Body.Close(); err != nil {
+					t.Errorf("Error closing response body: %v", err)
+				}
+			}()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+			assert.Len(t, repo.Records, tt.wantRecords)
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, repo.Revoked)
+				return
+			}
+
+			var out AccountDeletionOut
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&out))
+			assert.Equal(t, tt.wantPurged, out.PurgedURLs)
+			assert.Equal(t, []uuid.UUID{userID}, repo.Revoked)
+
+			// cookie аутентификации удаляется
+			cookies := res.Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, auth.AuthCookieName, cookies[0].Name)
+			assert.Negative(t, cookies[0].MaxAge)
+		})
+	}
+}
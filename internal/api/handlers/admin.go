@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"go.uber.org/zap"
+)
+
+// defaultAdminSearchLimit ограничивает выдачу поиска, если лимит не указан в запросе.
+const defaultAdminSearchLimit = 100
+
+// URLModerator определяет интерфейс хранилища для операций модерации.
+type URLModerator interface {
+	// SearchURLs ищет записи, удовлетворяющие фильтру.
+	SearchURLs(ctx context.Context, filter models.SearchFilter) (records []models.Record, err error)
+	// DisableURL блокирует запись с указанной причиной и в той же операции сохраняет запись журнала аудита.
+	DisableURL(ctx context.Context, shortURL string, reason string, legal bool, entry models.AuditEntry) error
+	// RestoreURL снимает блокировку с записи и в той же операции сохраняет запись журнала аудита.
+	RestoreURL(ctx context.Context, shortURL string, entry models.AuditEntry) error
+	// PurgeURL безвозвратно удаляет запись и в той же операции сохраняет запись журнала аудита.
+	PurgeURL(ctx context.Context, shortURL string, entry models.AuditEntry) error
+	// RetrieveAuditLog возвращает журнал действий модератора.
+	RetrieveAuditLog(ctx context.Context) (entries []models.AuditEntry, err error)
+}
+
+// AdminURLOut представляет запись URL в ответах API модерации.
+type AdminURLOut struct {
+	ShortURL       string    `json:"short_url"`                 // короткий идентификатор
+	OriginalURL    string    `json:"original_url"`              // оригинальный URL
+	UserID         uuid.UUID `json:"user_id"`                   // владелец записи
+	IsDeleted      bool      `json:"is_deleted"`                // удалена пользователем
+	DisabledReason string    `json:"disabled_reason,omitempty"` // причина блокировки
+	DisabledLegal  bool      `json:"disabled_legal,omitempty"`  // блокировка по юридическим основаниям
+}
+
+// DisableIn представляет входные данные для блокировки URL.
+type DisableIn struct {
+	Reason string `json:"reason"` // причина блокировки, обязательна
+	Legal  bool   `json:"legal"`  // блокировка по юридическим основаниям (451 вместо 410)
+}
+
+// AdminHandler обрабатывает запросы API модерации.
+// Позволяет искать ссылки, блокировать, разблокировать и безвозвратно удалять их.
+// Все изменяющие операции фиксируются в журнале аудита.
+type AdminHandler struct {
+	moderator URLModerator // хранилище с поддержкой модерации
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler.
+// Принимает хранилище с поддержкой модерации.
+func NewAdminHandler(moderator URLModerator) AdminHandler {
+	return AdminHandler{
+		moderator: moderator,
+	}
+}
+
+// SearchURLs обрабатывает HTTP GET запрос поиска ссылок.
+// Поддерживает параметры запроса original_url (подстрока), user_id, short_url и limit.
+// Возвращает статус 200 OK и массив найденных записей в формате JSON.
+func (handler AdminHandler) SearchURLs(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only GET requests are allowed!"))
+		return
+	}
+
+	query := req.URL.Query()
+	filter := models.SearchFilter{
+		OriginalURL: query.Get("original_url"),
+		ShortURL:    query.Get("short_url"),
+		Limit:       defaultAdminSearchLimit,
+	}
+
+	if userID := query.Get("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidRequest, "user_id is not valid", err))
+			return
+		}
+		filter.UserID = id
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "limit is not valid"))
+			return
+		}
+		filter.Limit = n
+	}
+
+	records, err := handler.moderator.SearchURLs(req.Context(), filter)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(err))
+		return
+	}
+
+	out := make([]AdminURLOut, 0, len(records))
+	for _, r := range records {
+		out = append(out, AdminURLOut{
+			ShortURL:       r.ShortURL,
+			OriginalURL:    r.OriginalURL,
+			UserID:         r.UserID,
+			IsDeleted:      r.IsDeleted,
+			DisabledReason: r.DisabledReason,
+			DisabledLegal:  r.DisabledLegal,
+		})
+	}
+
+	writeJSON(res, req, http.StatusOK, out)
+}
+
+// DisableURL обрабатывает HTTP POST запрос блокировки ссылки.
+// Принимает причину блокировки в теле запроса в формате JSON.
+// Возвращает 204 No Content при успехе или 404 Not Found, если ссылка не найдена.
+func (handler AdminHandler) DisableURL(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only POST requests are allowed!"))
+		return
+	}
+
+	id := chi.URLParam(req, "id")
+	if len(id) == 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find id parameter in query path"))
+		return
+	}
+
+	var in DisableIn
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		readBodyError(res, req, err)
+		return
+	}
+	if in.Reason == "" {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "reason is required"))
+		return
+	}
+
+	entry := auditEntry(req, models.AuditActionDisable, id, in.Reason)
+	err := handler.moderator.DisableURL(req.Context(), id, in.Reason, in.Legal, entry)
+	handler.finish(res, req, err)
+}
+
+// RestoreURL обрабатывает HTTP POST запрос снятия блокировки со ссылки.
+// Возвращает 204 No Content при успехе или 404 Not Found, если ссылка не найдена.
+func (handler AdminHandler) RestoreURL(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only POST requests are allowed!"))
+		return
+	}
+
+	id := chi.URLParam(req, "id")
+	if len(id) == 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find id parameter in query path"))
+		return
+	}
+
+	err := handler.moderator.RestoreURL(req.Context(), id, auditEntry(req, models.AuditActionRestore, id, ""))
+	handler.finish(res, req, err)
+}
+
+// PurgeURL обрабатывает HTTP DELETE запрос безвозвратного удаления ссылки.
+// Возвращает 204 No Content при успехе или 404 Not Found, если ссылка не найдена.
+func (handler AdminHandler) PurgeURL(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodDelete {
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only DELETE requests are allowed!"))
+		return
+	}
+
+	id := chi.URLParam(req, "id")
+	if len(id) == 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find id parameter in query path"))
+		return
+	}
+
+	err := handler.moderator.PurgeURL(req.Context(), id, auditEntry(req, models.AuditActionPurge, id, ""))
+	handler.finish(res, req, err)
+}
+
+// RetrieveAuditLog обрабатывает HTTP GET запрос получения журнала действий модератора.
+// Возвращает статус 200 OK и журнал в формате JSON.
+func (handler AdminHandler) RetrieveAuditLog(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only GET requests are allowed!"))
+		return
+	}
+
+	entries, err := handler.moderator.RetrieveAuditLog(req.Context())
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(err))
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	writeJSON(res, req, http.StatusOK, entries)
+}
+
+// auditEntry формирует запись журнала аудита для изменяющей операции модерации.
+// Имя модератора определяется по токену, с которым пришёл запрос.
+func auditEntry(req *http.Request, action string, shortURL string, details string) models.AuditEntry {
+	return models.AuditEntry{
+		Time:       time.Now().UTC(),
+		Actor:      auth.GetAdminActor(req),
+		RemoteAddr: req.RemoteAddr,
+		Action:     action,
+		ShortURL:   shortURL,
+		Details:    details,
+	}
+}
+
+// finish записывает ответ на изменяющую операцию модерации.
+func (handler AdminHandler) finish(res http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, models.ErrorNotFound) {
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeNotFound, "URL not found", err))
+		return
+	}
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(err))
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON сериализует значение в JSON и записывает его в ответ с указанным статусом.
+func writeJSON(res http.ResponseWriter, req *http.Request, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(err))
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+
+	if _, err := res.Write(resp); err != nil {
+		logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleAdminHandler_DisableURL демонстрирует блокировку ссылки модератором.
+func ExampleAdminHandler_DisableURL() {
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: "https://phishing.example", UserID: uuid.New()},
+		},
+	}
+	handler := NewAdminHandler(repo)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/admin/urls/123/disable",
+		bytes.NewReader([]byte(`{"reason": "phishing"}`)))
+	request = withURLParam(request, "id", "123")
+	w := httptest.NewRecorder()
+
+	handler.DisableURL(w, request)
+
+	res := w.Result()
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			fmt.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+
+	fmt.Println(res.Status)
+	fmt.Println(repo.AuditLog[0].Action, repo.AuditLog[0].ShortURL)
+	// Output:
+	// 204 No Content
+	// disable 123
+}
+
+func TestAdminHandler_SearchURLs(t *testing.T) {
+	userID := uuid.New()
+	records := []models.Record{
+		{ShortURL: "123", OriginalURL: "http://example.com/a", UserID: userID},
+		{ShortURL: "456", OriginalURL: "http://example.com/b", UserID: uuid.New()},
+		{ShortURL: "789", OriginalURL: "http://ya.ru", UserID: userID},
+	}
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantShort []string
+	}{
+		{
+			name:      "By original URL substring",
+			query:     "?original_url=example.com",
+			wantCode:  http.StatusOK,
+			wantShort: []string{"123", "456"},
+		},
+		{
+			name:      "By owner",
+			query:     "?user_id=" + userID.String(),
+			wantCode:  http.StatusOK,
+			wantShort: []string{"123", "789"},
+		},
+		{
+			name:      "By short ID",
+			query:     "?short_url=456",
+			wantCode:  http.StatusOK,
+			wantShort: []string{"456"},
+		},
+		{
+			name:      "With limit",
+			query:     "?limit=1",
+			wantCode:  http.StatusOK,
+			wantShort: []string{"123"},
+		},
+		{
+			name:     "Invalid user ID",
+			query:    "?user_id=abc",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Invalid limit",
+			query:    "?limit=-1",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &simple_storage.SimpleRepository{Records: records}
+			handler := NewAdminHandler(repo)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/admin/urls"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.SearchURLs(w, request)
+
+			res := w.Result()
+			defer func() {
+				if err := res.Body.Close(); err != nil {
+					t.Errorf("Error closing response body: %v", err)
+				}
+			}()
+
+			require.Equal(t, tt.wantCode, res.StatusCode)
+			if res.StatusCode != http.StatusOK {
+				return
+			}
+
+			var out []AdminURLOut
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&out))
+			got := make([]string, 0, len(out))
+			for _, o := range out {
+				got = append(got, o.ShortURL)
+			}
+			assert.Equal(t, tt.wantShort, got)
+		})
+	}
+}
+
+func TestAdminHandler_Moderation(t *testing.T) {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: "http://example.com", UserID: userID},
+			{ShortURL: "456", OriginalURL: "http://ya.ru", UserID: userID},
+		},
+	}
+	handler := NewAdminHandler(repo)
+
+	do := func(method string, id string, body string, fn http.HandlerFunc) int {
+		request := httptest.NewRequest(method, "/api/admin/urls/"+id, bytes.NewReader([]byte(body)))
+		request.Header.Set("Authorization", "Bearer alice-token")
+		w := httptest.NewRecorder()
+		auth.WithAdminToken("alice=alice-token,bob=bob-token")(fn).ServeHTTP(w, withURLParam(request, "id", id))
+		return w.Code
+	}
+
+	t.Run("Disable requires reason", func(t *testing.T) {
+		code := do(http.MethodPost, "123", `{}`, handler.DisableURL)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Disable not found", func(t *testing.T) {
+		code := do(http.MethodPost, "000", `{"reason": "spam"}`, handler.DisableURL)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		code := do(http.MethodPost, "123", `{"reason": "court order", "legal": true}`, handler.DisableURL)
+		require.Equal(t, http.StatusNoContent, code)
+
+		record, err := repo.RetrieveByShortURL(context.Background(), "123")
+		require.NoError(t, err)
+		assert.Equal(t, "court order", record.DisabledReason)
+		assert.True(t, record.DisabledLegal)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		code := do(http.MethodPost, "123", "", handler.RestoreURL)
+		require.Equal(t, http.StatusNoContent, code)
+
+		record, err := repo.RetrieveByShortURL(context.Background(), "123")
+		require.NoError(t, err)
+		assert.False(t, record.IsDisabled())
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		code := do(http.MethodDelete, "456", "", handler.PurgeURL)
+		require.Equal(t, http.StatusNoContent, code)
+
+		_, err := repo.RetrieveByShortURL(context.Background(), "456")
+		assert.ErrorIs(t, err, models.ErrorNotFound)
+	})
+
+	t.Run("Audit log", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/admin/audit", nil)
+		w := httptest.NewRecorder()
+		handler.RetrieveAuditLog(w, request)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var entries []models.AuditEntry
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&entries))
+		require.Len(t, entries, 3)
+
+		actions := []string{entries[0].Action, entries[1].Action, entries[2].Action}
+		assert.Equal(t, []string{models.AuditActionDisable, models.AuditActionRestore, models.AuditActionPurge}, actions)
+		assert.Equal(t, "alice", entries[0].Actor)
+		assert.Equal(t, "court order", entries[0].Details)
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// webhookOwnersTTL - срок, на который запоминается, есть ли у пользователя вебхуки.
+// Вебхук, зарегистрированный через другой экземпляр сервиса, начинает получать события переходов
+// не позже чем через этот срок.
+const webhookOwnersTTL = 10 * time.Second
+
+// clickFilter оборачивает хранилище и не записывает переходы по ссылкам пользователей без вебхуков,
+// чтобы перенаправление не выполняло запись в хранилище. Наличие вебхуков у пользователя
+// запоминается на ttl; регистрация вебхука через этот экземпляр учитывается сразу.
+type clickFilter struct {
+	repository
+	ttl time.Duration
+
+	mu      sync.Mutex
+	owners  map[uuid.UUID]webhookOwner // запомненные результаты проверки наличия вебхуков
+	sweptAt time.Time                  // время последней очистки устаревших записей owners
+}
+
+// webhookOwner - запомненный результат проверки наличия вебхуков у пользователя.
+type webhookOwner struct {
+	hasWebhooks bool      // у пользователя есть вебхуки
+	expires     time.Time // срок, до которого результат считается актуальным
+}
+
+// newClickFilter создает обёртку хранилища repo, запоминающую наличие вебхуков у пользователей на ttl.
+func newClickFilter(repo repository, ttl time.Duration) *clickFilter {
+	return &clickFilter{
+		repository: repo,
+		ttl:        ttl,
+		owners:     map[uuid.UUID]webhookOwner{},
+	}
+}
+
+// RecordClick добавляет событие перехода по ссылке, если у её владельца есть вебхуки.
+func (f *clickFilter) RecordClick(ctx context.Context, record models.Record) error {
+	if record.UserID == uuid.Nil {
+		// у ссылок без владельца вебхуков не бывает
+		return nil
+	}
+	now := time.Now()
+	hasWebhooks, ok := f.lookup(record.UserID, now)
+	if !ok {
+		var err error
+		hasWebhooks, err = f.repository.HasWebhooks(ctx, record.UserID)
+		if err != nil {
+			return err
+		}
+		f.store(record.UserID, hasWebhooks, now)
+	}
+	if !hasWebhooks {
+		return nil
+	}
+	return f.repository.RecordClick(ctx, record)
+}
+
+// CreateWebhook регистрирует вебхук и запоминает, что у пользователя есть вебхуки.
+func (f *clickFilter) CreateWebhook(ctx context.Context, webhook models.Webhook) error {
+	if err := f.repository.CreateWebhook(ctx, webhook); err != nil {
+		return err
+	}
+	f.store(webhook.UserID, true, time.Now())
+	return nil
+}
+
+// lookup возвращает запомненный результат проверки пользователя userID
+// и false, если результата нет или он устарел к моменту now.
+func (f *clickFilter) lookup(userID uuid.UUID, now time.Time) (hasWebhooks bool, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	owner, ok := f.owners[userID]
+	if !ok || !now.Before(owner.expires) {
+		return false, false
+	}
+	return owner.hasWebhooks, true
+}
+
+// store запоминает результат проверки пользователя userID, полученный в момент now.
+// Не чаще раза в ttl удаляет устаревшие записи, чтобы кэш не рос с числом владельцев ссылок.
+func (f *clickFilter) store(userID uuid.UUID, hasWebhooks bool, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now.Sub(f.sweptAt) >= f.ttl {
+		maps.DeleteFunc(f.owners, func(_ uuid.UUID, owner webhookOwner) bool { return !now.Before(owner.expires) })
+		f.sweptAt = now
+	}
+	f.owners[userID] = webhookOwner{hasWebhooks: hasWebhooks, expires: now.Add(f.ttl)}
+}
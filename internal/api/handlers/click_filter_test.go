@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookCheckCounter считает проверки наличия вебхуков в оборачиваемом хранилище.
+type webhookCheckCounter struct {
+	repository
+	checks int
+}
+
+func (c *webhookCheckCounter) HasWebhooks(ctx context.Context, userID uuid.UUID) (bool, error) {
+	c.checks++
+	return c.repository.HasWebhooks(ctx, userID)
+}
+
+func TestClickFilter(t *testing.T) {
+	ctx := context.Background()
+	storage := simple_storage.NewSimpleRepository()
+	counter := &webhookCheckCounter{repository: storage}
+	filter := newClickFilter(counter, time.Minute)
+	userID := uuid.New()
+	record := models.Record{ShortURL: "abc", OriginalURL: "http://example.com", UserID: userID}
+
+	// переходы по ссылкам без владельца не проверяются и не записываются
+	require.NoError(t, filter.RecordClick(ctx, models.Record{ShortURL: "anon"}))
+	assert.Zero(t, counter.checks)
+
+	// отсутствие вебхуков проверяется один раз на ttl
+	for range 3 {
+		require.NoError(t, filter.RecordClick(ctx, record))
+	}
+	assert.Equal(t, 1, counter.checks)
+	assert.Empty(t, storage.Outbox)
+
+	// регистрация вебхука учитывается сразу
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, filter.CreateWebhook(ctx, webhook))
+	require.NoError(t, filter.RecordClick(ctx, record))
+	assert.Equal(t, 1, counter.checks)
+	require.Len(t, storage.Outbox, 1)
+	assert.Equal(t, models.EventLinkClicked, storage.Outbox[0].Event.Type)
+}
+
+func TestClickFilter_Expiry(t *testing.T) {
+	filter := newClickFilter(simple_storage.NewSimpleRepository(), time.Minute)
+	now := time.Now()
+	userID := uuid.New()
+
+	filter.store(userID, true, now)
+	hasWebhooks, ok := filter.lookup(userID, now.Add(time.Second))
+	assert.True(t, ok)
+	assert.True(t, hasWebhooks)
+	_, ok = filter.lookup(userID, now.Add(time.Minute))
+	assert.False(t, ok, "result must be checked again after ttl")
+
+	// устаревшие записи удаляются при следующем сохранении
+	filter.store(uuid.New(), false, now.Add(time.Hour))
+	assert.NotContains(t, filter.owners, userID)
+	assert.Len(t, filter.owners, 1)
+}
@@ -7,55 +7,67 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/iubondar/url-shortener/internal/api/apierror"
 	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"go.uber.org/zap"
 )
 
 // CreateIDHandler обрабатывает запросы на создание сокращенных URL.
 type CreateIDHandler struct {
-	saver   URLSaver // репозиторий для хранения URL
-	baseURL string   // базовый URL для формирования сокращенных ссылок
+	saver   URLSaver       // репозиторий для хранения URL
+	baseURL string         // базовый URL для формирования сокращенных ссылок
+	checker policy.Checker // политика допустимых URL
 }
 
 // NewCreateIDHandler создает новый экземпляр CreateIDHandler.
-// Принимает репозиторий для хранения URL и базовый URL для формирования сокращенных ссылок.
-func NewCreateIDHandler(saver URLSaver, baseURL string) CreateIDHandler {
+// Принимает репозиторий для хранения URL, базовый URL для формирования сокращенных ссылок
+// и политику допустимых URL (nil - без проверки).
+func NewCreateIDHandler(saver URLSaver, baseURL string, checker policy.Checker) CreateIDHandler {
 	return CreateIDHandler{
 		saver:   saver,
 		baseURL: baseURL,
+		checker: checker,
 	}
 }
 
 // CreateID обрабатывает HTTP POST запрос для создания сокращенного URL.
-// Принимает URL в теле запроса, проверяет его валидность и сохраняет в репозитории.
+// Принимает URL в теле запроса, проверяет его валидность и политику допустимых URL
+// и сохраняет в репозитории.
 // Возвращает сокращенный URL в формате "http://{baseURL}/{id}".
 // В случае успеха возвращает статус 201 Created, если URL уже существует - 409 Conflict.
 func (handler CreateIDHandler) CreateID(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
-		http.Error(res, "Only POST requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only POST requests are allowed!"))
 		return
 	}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		http.Error(res, err.Error(), 500)
+		readBodyError(res, req, err)
 		return
 	}
 
 	url, err := url.ParseRequestURI(string(body))
 	if err != nil {
-		http.Error(res, "URL is not valid", http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidURL, "URL is not valid", err))
+		return
+	}
+
+	if !checkPolicy(res, req, handler.checker, url) {
 		return
 	}
 
 	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
 	if err != nil {
-		http.Error(res, "Error setting userID "+err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
 		return
 	}
 
 	id, exists, err := handler.saver.SaveURL(req.Context(), userID, url.String())
 	if err != nil {
-		http.Error(res, "Can't save URL", http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("save URL: %w", err)))
 		return
 	}
 
@@ -71,7 +83,6 @@ func (handler CreateIDHandler) CreateID(res http.ResponseWriter, req *http.Reque
 	result := fmt.Sprintf("http://%s/%s", baseURL, id)
 
 	if _, err := res.Write([]byte(result)); err != nil {
-		http.Error(res, "Error writing response", http.StatusInternalServerError)
-		return
+		logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
 	}
 }
@@ -24,7 +24,7 @@ func ExampleCreateIDHandler_CreateID() {
 
 	// Инициализируем репозиторий и обработчик
 	repo := &simple_storage.SimpleRepository{}
-	handler := NewCreateIDHandler(repo, "127.0.0.1")
+	handler := NewCreateIDHandler(repo, "127.0.0.1", nil)
 
 	// Вызываем обработчик
 	handler.CreateID(w, request)
@@ -137,7 +137,7 @@ func TestCreateIDHandler_CreateID(t *testing.T) {
 			repo := simple_storage.SimpleRepository{
 				Records: test.records,
 			}
-			handler := NewCreateIDHandler(&repo, "127.0.0.1")
+			handler := NewCreateIDHandler(&repo, "127.0.0.1", nil)
 			handler.CreateID(w, request)
 
 			res := w.Result()
@@ -4,23 +4,74 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/go-chi/chi"
 	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
 	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
 )
 
+// deletionRetryAfter - через сколько секунд клиенту стоит повторить удаление, если очередь удалений заполнена.
+const deletionRetryAfter = "5"
+
 // URLDeleter определяет интерфейс для удаления URL из хранилища.
 type URLDeleter interface {
 	// DeleteByShortURLs помечает URL как удаленные.
 	// Принимает идентификатор пользователя и массив коротких идентификаторов.
-	DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string)
+	// Возвращает задачу удаления или models.ErrorDeletionQueueFull, если удаление не может быть принято сейчас.
+	DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error)
+	// RetrieveDeletionJob возвращает задачу удаления пользователя или models.ErrorNotFound.
+	RetrieveDeletionJob(ctx context.Context, userID uuid.UUID, id string) (job models.DeletionJob, err error)
+}
+
+// Состояния задачи удаления в ответе API.
+const (
+	deletionJobPending   = "pending"   // результаты удаления части ссылок ещё неизвестны
+	deletionJobCompleted = "completed" // результаты удаления всех ссылок известны
+)
+
+// DeletionJobOut представляет задачу удаления ссылок в ответе API.
+type DeletionJobOut struct {
+	ID          string                `json:"id"`           // идентификатор задачи
+	Status      string                `json:"status"`       // pending или completed
+	CreatedAt   time.Time             `json:"created_at"`   // время создания задачи
+	CompletedAt *time.Time            `json:"completed_at"` // время завершения задачи или null
+	Items       []models.DeletionItem `json:"items"`        // результаты удаления ссылок
+}
+
+// newDeletionJobOut преобразует задачу удаления хранилища в ответ API.
+func newDeletionJobOut(job models.DeletionJob) DeletionJobOut {
+	out := DeletionJobOut{
+		ID:          job.ID,
+		Status:      deletionJobPending,
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+		Items:       job.Items,
+	}
+	if job.CompletedAt != nil {
+		out.Status = deletionJobCompleted
+	}
+	if out.Items == nil {
+		out.Items = []models.DeletionItem{}
+	}
+	return out
+}
+
+// deletionJobPath возвращает адрес состояния задачи удаления id.
+func deletionJobPath(id string) string {
+	return "/api/user/deletions/" + id
 }
 
 // DeleteUrlsHandler обрабатывает запросы на удаление сокращенных URL.
 // Позволяет пользователю удалить свои сокращенные ссылки.
 type DeleteUrlsHandler struct {
-	deleter URLDeleter // репозиторий для хранения URL
+	deleter  URLDeleter // репозиторий для хранения URL
+	maxItems int        // максимальное количество ссылок в запросе, 0 - без ограничения
 }
 
 // NewDeleteUrlsHandler создает новый экземпляр DeleteUrlsHandler.
@@ -31,19 +82,28 @@ func NewDeleteUrlsHandler(deleter URLDeleter) DeleteUrlsHandler {
 	}
 }
 
+// WithMaxItems возвращает копию обработчика с ограничением количества ссылок в одном запросе.
+func (handler DeleteUrlsHandler) WithMaxItems(maxItems int) DeleteUrlsHandler {
+	handler.maxItems = maxItems
+	return handler
+}
+
 // DeleteUserURLs обрабатывает HTTP DELETE запрос для удаления сокращенных URL.
 // Принимает массив сокращенных URL в теле запроса в формате JSON.
 // Удаляет только те URL, которые принадлежат текущему пользователю.
-// Возвращает статус 202 Accepted в случае успеха.
+// Если тело запроса или количество ссылок превышает ограничения, возвращает 413 Request Entity Too Large.
+// Если хранилище не может принять удаления, возвращает 503 Service Unavailable с заголовком Retry-After.
+// В случае успеха возвращает статус 202 Accepted, задачу удаления в формате JSON
+// и адрес её состояния в заголовке Location.
 func (handler DeleteUrlsHandler) DeleteUserURLs(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodDelete {
-		http.Error(res, "Only DELETE requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only DELETE requests are allowed!"))
 		return
 	}
 
 	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
 	if err != nil {
-		http.Error(res, "Error setting userID "+err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
 		return
 	}
 
@@ -51,20 +111,62 @@ func (handler DeleteUrlsHandler) DeleteUserURLs(res http.ResponseWriter, req *ht
 	// читаем тело запроса
 	_, err = buf.ReadFrom(req.Body)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		readBodyError(res, req, err)
 		return
 	}
 
 	// десериализуем JSON
 	var shortURLs []string
 	if err = json.Unmarshal(buf.Bytes(), &shortURLs); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidRequest, "request body is not valid JSON", err))
+		return
+	}
+	if !checkBatchSize(res, req, len(shortURLs), handler.maxItems) {
 		return
 	}
 
 	// запрос на удаление
-	handler.deleter.DeleteByShortURLs(req.Context(), userID, shortURLs)
+	job, err := handler.deleter.DeleteByShortURLs(req.Context(), userID, shortURLs)
+	if err != nil {
+		writeDeleteError(res, req, err)
+		return
+	}
+
+	// сразу возвращаем статус, результаты удаления доступны по адресу задачи
+	res.Header().Set("Location", deletionJobPath(job.ID))
+	writeJSON(res, req, http.StatusAccepted, newDeletionJobOut(job))
+}
 
-	// сразу возвращаем статус
-	res.WriteHeader(http.StatusAccepted)
+// RetrieveDeletionJob обрабатывает HTTP GET запрос состояния задачи удаления.
+// Возвращает статус 200 OK и задачу с результатом удаления каждой ссылки: deleted, not_owned,
+// not_found или pending, пока удаление не выполнено. Для неизвестной, устаревшей
+// или чужой задачи возвращает 404 Not Found.
+func (handler DeleteUrlsHandler) RetrieveDeletionJob(res http.ResponseWriter, req *http.Request) {
+	id := chi.URLParam(req, "job")
+	if len(id) == 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find job parameter in query path"))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return
+	}
+
+	job, err := handler.deleter.RetrieveDeletionJob(req.Context(), userID, id)
+	if err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+	writeJSON(res, req, http.StatusOK, newDeletionJobOut(job))
+}
+
+// writeDeleteError записывает ответ с ошибкой удаления. Если очередь удалений заполнена,
+// добавляет заголовок Retry-After, чтобы клиент повторил запрос позже.
+func writeDeleteError(res http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, models.ErrorDeletionQueueFull) {
+		res.Header().Set("Retry-After", deletionRetryAfter)
+	}
+	apierror.Write(res, req, err)
 }
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -140,7 +142,118 @@ func TestDeleteUrlsHandler_DeleteUserURLs(t *testing.T) {
 			}()
 
 			assert.Equal(t, test.wantCode, res.StatusCode)
+			// время удаления задается хранилищем, поэтому проверяется только его наличие
+			for i := range repo.Records {
+				assert.Equal(t, repo.Records[i].IsDeleted, repo.Records[i].DeletedAt != nil)
+				repo.Records[i].DeletedAt = nil
+			}
 			assert.ElementsMatch(t, test.wantRecords, repo.Records)
 		})
 	}
 }
+
+// fullQueueRepository имитирует хранилище, очередь удалений которого заполнена.
+type fullQueueRepository struct {
+	*simple_storage.SimpleRepository
+}
+
+func (fullQueueRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (models.DeletionJob, error) {
+	return models.DeletionJob{}, fmt.Errorf("delete: %w", models.ErrorDeletionQueueFull)
+}
+
+func TestDeleteUrlsHandler_QueueFull(t *testing.T) {
+	userID := uuid.New()
+	repo := fullQueueRepository{&simple_storage.SimpleRepository{
+		Records: []models.Record{{ShortURL: "123", OriginalURL: "http://example.com", UserID: userID}},
+	}}
+	authCookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+
+	requests := map[string]struct {
+		request *http.Request
+		handler http.HandlerFunc
+	}{
+		"DeleteUserURLs": {
+			request: httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewReader([]byte(`["123"]`))),
+			handler: NewDeleteUrlsHandler(repo).DeleteUserURLs,
+		},
+		"DeleteLink": {
+			request: withURLParam(httptest.NewRequest(http.MethodDelete, "/api/v2/links/123", nil), "id", "123"),
+			handler: NewLinksHandler(repo, "127.0.0.1").DeleteLink,
+		},
+	}
+	for name, tt := range requests {
+		t.Run(name, func(t *testing.T) {
+			tt.request.AddCookie(authCookie)
+			w := httptest.NewRecorder()
+
+			tt.handler(w, tt.request)
+
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Equal(t, deletionRetryAfter, w.Header().Get("Retry-After"))
+			assert.Contains(t, w.Body.String(), "too many pending deletions")
+			assert.False(t, repo.Records[0].IsDeleted)
+		})
+	}
+}
+
+func TestDeleteUrlsHandler_RetrieveDeletionJob(t *testing.T) {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: "http://example.com", UserID: userID},
+			{ShortURL: "456", OriginalURL: "http://example.org", UserID: uuid.New()},
+		},
+	}
+	handler := NewDeleteUrlsHandler(repo)
+	authCookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewReader([]byte(`["123", "456", "789"]`)))
+	request.AddCookie(authCookie)
+	w := httptest.NewRecorder()
+	handler.DeleteUserURLs(w, request)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var accepted DeletionJobOut
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &accepted))
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/api/user/deletions/"+accepted.ID, location)
+
+	tests := []struct {
+		name       string
+		job        string
+		userID     uuid.UUID
+		wantStatus int
+	}{
+		{name: "Own job", job: accepted.ID, userID: userID, wantStatus: http.StatusOK},
+		{name: "Job of another user", job: accepted.ID, userID: uuid.New(), wantStatus: http.StatusNotFound},
+		{name: "Unknown job", job: uuid.NewString(), userID: userID, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := withURLParam(httptest.NewRequest(http.MethodGet, deletionJobPath(tt.job), nil), "job", tt.job)
+			authCookie, err := auth.NewAuthCookie(tt.userID)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+			w := httptest.NewRecorder()
+
+			handler.RetrieveDeletionJob(w, request)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got DeletionJobOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, accepted.ID, got.ID)
+			assert.Equal(t, deletionJobCompleted, got.Status)
+			assert.NotNil(t, got.CompletedAt)
+			assert.Equal(t, []models.DeletionItem{
+				{ShortURL: "123", Status: models.DeletionDeleted},
+				{ShortURL: "456", Status: models.DeletionNotOwned},
+				{ShortURL: "789", Status: models.DeletionNotFound},
+			}, got.Items)
+		})
+	}
+}
@@ -8,6 +8,8 @@
 //   - UserUrlsHandler: получение списка сокращенных URL пользователя
 //   - DeleteUrlsHandler: удаление сокращенных URL пользователя
 //   - PingHandler: проверка доступности сервиса
+//   - AdminHandler: поиск, блокировка и безвозвратное удаление ссылок модератором
+//   - WebhooksHandler: регистрация, просмотр и удаление вебхуков для событий ссылок пользователя
 //
 // Все обработчики поддерживают аутентификацию пользователей через cookie
 // и возвращают соответствующие HTTP-статусы и заголовки.
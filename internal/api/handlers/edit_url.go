@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+)
+
+// URLEditor определяет интерфейс хранилища для изменения целевого адреса ссылок.
+type URLEditor interface {
+	// RetrieveByShortURL получает запись по короткому идентификатору.
+	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
+	// UpdateOriginalURL меняет целевой адрес ссылки пользователя и добавляет версию в историю.
+	UpdateOriginalURL(ctx context.Context, userID uuid.UUID, shortURL string, url string) (record models.Record, err error)
+	// RetrieveRevisions возвращает историю целевых адресов ссылки пользователя.
+	RetrieveRevisions(ctx context.Context, userID uuid.UUID, shortURL string) (revisions []models.Revision, err error)
+}
+
+// EditURLIn представляет входные данные для изменения целевого адреса ссылки.
+type EditURLIn struct {
+	OriginalURL string `json:"original_url"` // новый оригинальный URL
+}
+
+// RevisionOut представляет версию целевого адреса ссылки.
+type RevisionOut struct {
+	Revision    int       `json:"revision"`     // номер версии
+	OriginalURL string    `json:"original_url"` // оригинальный URL в этой версии
+	CreatedAt   time.Time `json:"created_at"`   // время появления версии
+	Current     bool      `json:"current"`      // версия действует сейчас
+}
+
+// EditURLHandler обрабатывает запросы на изменение целевого адреса ссылок пользователя,
+// просмотр истории адресов и возврат к одной из прежних версий.
+// Пользователь работает только со своими ссылками; чужие ссылки для него не существуют.
+type EditURLHandler struct {
+	editor  URLEditor      // хранилище ссылок
+	baseURL string         // базовый URL для формирования сокращенных ссылок
+	checker policy.Checker // политика допустимых URL
+}
+
+// NewEditURLHandler создает новый экземпляр EditURLHandler.
+// Принимает хранилище ссылок, базовый URL для формирования сокращенных ссылок
+// и политику допустимых URL (nil - без проверки).
+func NewEditURLHandler(editor URLEditor, baseURL string, checker policy.Checker) EditURLHandler {
+	return EditURLHandler{
+		editor:  editor,
+		baseURL: baseURL,
+		checker: checker,
+	}
+}
+
+// UpdateURL обрабатывает HTTP PATCH запрос изменения целевого адреса ссылки.
+// Новый адрес проходит ту же проверку, что и при создании ссылки.
+// Возвращает статус 200 OK и ссылку в формате JSON, 404 Not Found для чужой или отсутствующей ссылки,
+// 409 Conflict, если адрес уже сокращён другой ссылкой или ссылка заблокирована модератором,
+// и 410 Gone для удаленной ссылки.
+func (handler EditURLHandler) UpdateURL(res http.ResponseWriter, req *http.Request) {
+	record, ok := handler.retrieveEditable(res, req)
+	if !ok {
+		return
+	}
+
+	var in EditURLIn
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		readBodyError(res, req, err)
+		return
+	}
+
+	handler.update(res, req, record, in.OriginalURL)
+}
+
+// RetrieveRevisions обрабатывает HTTP GET запрос истории целевых адресов ссылки.
+// Возвращает статус 200 OK и версии от первой к последней в формате JSON
+// или 404 Not Found для чужой или отсутствующей ссылки.
+func (handler EditURLHandler) RetrieveRevisions(res http.ResponseWriter, req *http.Request) {
+	record, ok := retrieveOwn(res, req, handler.editor)
+	if !ok {
+		return
+	}
+
+	revisions, err := handler.editor.RetrieveRevisions(req.Context(), record.UserID, record.ShortURL)
+	if err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+
+	out := make([]RevisionOut, 0, len(revisions))
+	for i, revision := range revisions {
+		out = append(out, RevisionOut{
+			Revision:    revision.Revision,
+			OriginalURL: revision.OriginalURL,
+			CreatedAt:   revision.CreatedAt,
+			Current:     i == len(revisions)-1,
+		})
+	}
+	writeJSON(res, req, http.StatusOK, out)
+}
+
+// Rollback обрабатывает HTTP POST запрос возврата ссылки к одной из прежних версий.
+// Возврат не переписывает историю, а добавляет в неё новую версию с прежним адресом.
+// Возвращает те же статусы, что и UpdateURL, и 404 Not Found для неизвестной версии.
+func (handler EditURLHandler) Rollback(res http.ResponseWriter, req *http.Request) {
+	number, err := strconv.Atoi(chi.URLParam(req, "revision"))
+	if err != nil {
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidRequest, "revision must be a number", err))
+		return
+	}
+
+	record, ok := handler.retrieveEditable(res, req)
+	if !ok {
+		return
+	}
+
+	revisions, err := handler.editor.RetrieveRevisions(req.Context(), record.UserID, record.ShortURL)
+	if err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+	i := slices.IndexFunc(revisions, func(r models.Revision) bool { return r.Revision == number })
+	if i < 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeNotFound, "revision not found"))
+		return
+	}
+
+	handler.update(res, req, record, revisions[i].OriginalURL)
+}
+
+// update проверяет новый адрес политикой, сохраняет его и записывает ответ.
+func (handler EditURLHandler) update(res http.ResponseWriter, req *http.Request, record models.Record, originalURL string) {
+	u, err := url.ParseRequestURI(originalURL)
+	if err != nil {
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidURL, "URL is not valid", err))
+		return
+	}
+
+	if !checkPolicy(res, req, handler.checker, u) {
+		return
+	}
+
+	record, err = handler.editor.UpdateOriginalURL(req.Context(), record.UserID, record.ShortURL, u.String())
+	if err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+
+	baseURL := strings.TrimSuffix(strings.TrimPrefix(handler.baseURL, "http://"), "/")
+	writeJSON(res, req, http.StatusOK, UserUrlsOut{
+		ShortURL:    fmt.Sprintf("http://%s/%s", baseURL, record.ShortURL),
+		OriginalURL: record.OriginalURL,
+	})
+}
+
+// retrieveEditable получает ссылку пользователя и проверяет, что её адрес можно менять:
+// удаленные ссылки не меняются, а заблокированные модератором нельзя перенаправить в обход блокировки.
+// При ошибке записывает ответ и возвращает false.
+func (handler EditURLHandler) retrieveEditable(res http.ResponseWriter, req *http.Request) (models.Record, bool) {
+	record, ok := retrieveOwn(res, req, handler.editor)
+	if !ok {
+		return models.Record{}, false
+	}
+	if record.IsDeleted {
+		apierror.Write(res, req, apierror.New(apierror.CodeGone, "URL was deleted"))
+		return models.Record{}, false
+	}
+	if record.IsDisabled() {
+		apierror.Write(res, req, apierror.New(apierror.CodeConflict, "URL is disabled by moderator"))
+		return models.Record{}, false
+	}
+	return record, true
+}
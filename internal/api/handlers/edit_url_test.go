@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleEditURLHandler_UpdateURL демонстрирует изменение целевого адреса ссылки пользователя.
+func ExampleEditURLHandler_UpdateURL() {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: "https://example.com/old", UserID: userID},
+		},
+	}
+	handler := NewEditURLHandler(repo, "127.0.0.1", nil)
+
+	body := strings.NewReader(`{"original_url": "https://example.com/new"}`)
+	request := withURLParam(httptest.NewRequest(http.MethodPatch, "/api/user/urls/123", body), "id", "123")
+	authCookie, _ := auth.NewAuthCookie(userID)
+	request.AddCookie(authCookie)
+
+	w := httptest.NewRecorder()
+	handler.UpdateURL(w, request)
+
+	fmt.Println(w.Code)
+	fmt.Print(w.Body.String())
+	// Output:
+	// 200
+	// {"short_url":"http://127.0.0.1/123","original_url":"https://example.com/new"}
+}
+
+func TestEditURLHandler(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		revision     string
+		userID       uuid.UUID
+		body         string
+		wantStatus   int
+		wantURL      string
+		wantVersions int
+	}{
+		{
+			name:         "Update own link",
+			method:       http.MethodPatch,
+			id:           "123",
+			userID:       userID,
+			body:         `{"original_url": "https://example.com/new"}`,
+			wantStatus:   http.StatusOK,
+			wantURL:      "https://example.com/new",
+			wantVersions: 2,
+		},
+		{
+			name:         "Update to the same URL",
+			method:       http.MethodPatch,
+			id:           "123",
+			userID:       userID,
+			body:         `{"original_url": "https://example.com/old"}`,
+			wantStatus:   http.StatusOK,
+			wantURL:      "https://example.com/old",
+			wantVersions: 1,
+		},
+		{
+			name:       "Update to URL shortened by another link",
+			method:     http.MethodPatch,
+			id:         "123",
+			userID:     userID,
+			body:       `{"original_url": "https://example.org"}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Update with invalid URL",
+			method:     http.MethodPatch,
+			id:         "123",
+			userID:     userID,
+			body:       `{"original_url": "not a url"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Update link of another user",
+			method:     http.MethodPatch,
+			id:         "123",
+			userID:     uuid.New(),
+			body:       `{"original_url": "https://example.com/new"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Update deleted link",
+			method:     http.MethodPatch,
+			id:         "456",
+			userID:     userID,
+			body:       `{"original_url": "https://example.com/new"}`,
+			wantStatus: http.StatusGone,
+		},
+		{
+			name:       "Update disabled link",
+			method:     http.MethodPatch,
+			id:         "789",
+			userID:     userID,
+			body:       `{"original_url": "https://example.com/new"}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Rollback to the first revision",
+			method:     http.MethodPost,
+			id:         "321",
+			revision:   "1",
+			userID:     userID,
+			wantStatus: http.StatusOK,
+			wantURL:    "https://example.net/v1",
+			// откат добавляет новую версию, а не переписывает историю
+			wantVersions: 3,
+		},
+		{
+			name:       "Rollback to unknown revision",
+			method:     http.MethodPost,
+			id:         "321",
+			revision:   "7",
+			userID:     userID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Rollback to malformed revision",
+			method:     http.MethodPost,
+			id:         "321",
+			revision:   "first",
+			userID:     userID,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			repo := &simple_storage.SimpleRepository{
+				Records: []models.Record{
+					{ShortURL: "123", OriginalURL: "https://example.com/old", UserID: userID},
+					{ShortURL: "456", OriginalURL: "https://example.com/deleted", UserID: userID, IsDeleted: true},
+					{ShortURL: "789", OriginalURL: "https://example.com/disabled", UserID: userID, DisabledReason: "phishing"},
+					{ShortURL: "321", OriginalURL: "https://example.net/v2", UserID: userID},
+					{ShortURL: "000", OriginalURL: "https://example.org", UserID: uuid.New()},
+				},
+				Revisions: []models.Revision{
+					{ShortURL: "321", Revision: 1, OriginalURL: "https://example.net/v1", CreatedAt: created},
+					{ShortURL: "321", Revision: 2, OriginalURL: "https://example.net/v2", CreatedAt: created.Add(time.Hour)},
+				},
+			}
+			handler := NewEditURLHandler(repo, "127.0.0.1", nil)
+			handlers := map[string]http.HandlerFunc{
+				http.MethodPatch: handler.UpdateURL,
+				http.MethodPost:  handler.Rollback,
+			}
+
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("id", tt.id)
+			chiCtx.URLParams.Add("revision", tt.revision)
+			request := httptest.NewRequest(tt.method, "/api/user/urls/"+tt.id, strings.NewReader(tt.body))
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, chiCtx))
+			authCookie, err := auth.NewAuthCookie(tt.userID)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+
+			w := httptest.NewRecorder()
+			handlers[tt.method](w, request)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got UserUrlsOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantURL, got.OriginalURL)
+
+			// история доступна через RetrieveRevisions, последняя версия - текущая
+			request = withURLParam(httptest.NewRequest(http.MethodGet, "/", nil), "id", tt.id)
+			request.AddCookie(authCookie)
+			w = httptest.NewRecorder()
+			handler.RetrieveRevisions(w, request)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var revisions []RevisionOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+			require.Len(t, revisions, tt.wantVersions)
+			last := revisions[len(revisions)-1]
+			assert.Equal(t, tt.wantVersions, last.Revision)
+			assert.Equal(t, tt.wantURL, last.OriginalURL)
+			assert.True(t, last.Current)
+		})
+	}
+}
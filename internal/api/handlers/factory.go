@@ -2,23 +2,48 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
 	"github.com/iubondar/url-shortener/internal/app/config"
 	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+	"github.com/iubondar/url-shortener/internal/app/retention"
 	"github.com/iubondar/url-shortener/internal/app/storage/file"
 	"github.com/iubondar/url-shortener/internal/app/storage/pg"
 	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/iubondar/url-shortener/internal/app/webhook"
+	"github.com/iubondar/url-shortener/internal/limits"
 )
 
+// policyReloadInterval задает период проверки изменений файла политики URL.
+const policyReloadInterval = 10 * time.Second
+
 type repository interface {
 	SaveURL(ctx context.Context, userID uuid.UUID, url string) (id string, exists bool, err error)
 	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
 	RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error)
-	DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string)
+	SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error)
+	ImportURL(ctx context.Context, record models.Record) (id string, err error)
+	DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error)
+	RetrieveDeletionJob(ctx context.Context, userID uuid.UUID, id string) (job models.DeletionJob, err error)
 	CheckStatus(ctx context.Context) error
 	SaveURLs(ctx context.Context, urls []string) (ids []string, err error)
+	UpdateLink(ctx context.Context, userID uuid.UUID, shortURL string, update models.LinkUpdate) (record models.Record, err error)
+	UpdateOriginalURL(ctx context.Context, userID uuid.UUID, shortURL string, url string) (record models.Record, err error)
+	RetrieveRevisions(ctx context.Context, userID uuid.UUID, shortURL string) (revisions []models.Revision, err error)
+	PurgeExpired(ctx context.Context, cutoff time.Time) (purged int, err error)
+	DeleteAccount(ctx context.Context, userID uuid.UUID) (purged int, err error)
+	IsUserRevoked(ctx context.Context, userID uuid.UUID) (revoked bool, err error)
+	HasWebhooks(ctx context.Context, userID uuid.UUID) (exists bool, err error)
+	WebhookStore
+	ClickRecorder
+	webhook.Store
+	URLModerator
 }
 
 // HandlerFactory определяет интерфейс для создания обработчиков HTTP-запросов.
@@ -39,15 +64,35 @@ type HandlerFactory interface {
 	PingHandler() PingHandler
 	// DeleteUrlsHandler создает обработчик для удаления URL пользователя
 	DeleteUrlsHandler() DeleteUrlsHandler
+	// EditURLHandler создает обработчик для изменения целевого адреса ссылок пользователя
+	EditURLHandler() EditURLHandler
+	// TransferHandler создает обработчик для экспорта и импорта ссылок пользователя
+	TransferHandler() TransferHandler
+	// AdminHandler создает обработчик API модерации
+	AdminHandler() AdminHandler
+	// LinksHandler создает обработчик ссылок API v2
+	LinksHandler() LinksHandler
+	// AccountHandler создает обработчик учётной записи пользователя
+	AccountHandler() AccountHandler
+	// WebhooksHandler создает обработчик вебхуков пользователя
+	WebhooksHandler() WebhooksHandler
+	// RevocationChecker возвращает проверку отзыва токенов пользователей
+	RevocationChecker() auth.RevocationChecker
 }
 
 // Factory реализует интерфейс HandlerFactory и создает обработчики HTTP-запросов.
 // Фабрика использует репозиторий для работы с хранилищем данных и базовый URL
 // для формирования коротких ссылок.
 type Factory struct {
-	repo    repository
-	baseURL string
-	db      *pg.DB
+	repo        repository
+	baseURL     string
+	db          *pg.DB
+	storage     io.Closer // хранилище с фоновыми задачами, закрывается до соединения с базой данных
+	checker     policy.Checker
+	listChecker *policy.ListChecker
+	retention   *retention.Job      // удаление записей по истечении срока хранения, nil - записи хранятся бессрочно
+	webhooks    *webhook.Dispatcher // доставка событий ссылок на вебхуки пользователей
+	maxItems    int                 // максимальное количество элементов пакетного запроса
 }
 
 // NewFactory создает новую фабрику обработчиков на основе конфигурации приложения.
@@ -55,58 +100,124 @@ type Factory struct {
 // - PostgreSQL, если указан DatabaseDSN
 // - Файловое хранилище, если указан FileStoragePath
 // - Простое хранилище в памяти в остальных случаях
+//
+// Ограничение количества элементов пакетных запросов берётся из MaxBatchItems или limits.DefaultBatchItems.
+//
+// Если задан RetentionDays, запускает безвозвратное удаление записей, удалённых пользователями
+// или истёкших более RetentionDays дней назад.
+//
+// Запускает доставку событий ссылок из outbox хранилища на вебхуки пользователей.
+//
+// Также собирает политику допустимых URL: схемы http/https, запрет приватных адресов,
+// списки блокировки из файла и внешний сервис проверки, если они заданы в конфигурации.
 func NewFactory(config config.Config) *Factory {
 	var repo repository
 	var db *pg.DB
+	var storage io.Closer
+	var backend string
 
 	if len(config.DatabaseDSN) > 0 {
-		var err error
-		db, err = pg.NewDB(config.DatabaseDSN)
+		opts, err := pg.OptionsFromConfig(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		db, err = pg.NewDB(config.DatabaseDSN, opts)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		repo, err = pg.NewPGRepository(db, 0)
+		backend = "postgres"
+		pgRepo, err := pg.NewPGRepository(db, 0)
 		if err != nil {
-			if err := db.SQLDB.Close(); err != nil {
+			if err := db.Close(); err != nil {
 				log.Printf("Error closing database connection: %v", err)
 			}
 			log.Fatal(err)
 		}
+		repo, storage = pgRepo, pgRepo
 	} else if len(config.FileStoragePath) > 0 {
 		var err error
+		backend = "file"
 		repo, err = file.NewFileRepository(config.FileStoragePath)
 		if err != nil {
 			log.Fatal(err)
 		}
 	} else {
+		backend = "memory"
 		repo = simple_storage.NewSimpleRepository()
 	}
-	return &Factory{repo: repo, baseURL: config.BaseURLAddress, db: db}
+	repo = newClickFilter(newInstrumentedRepository(backend, repo), webhookOwnersTTL)
+
+	f := &Factory{repo: repo, baseURL: config.BaseURLAddress, db: db, storage: storage, maxItems: config.MaxBatchItems}
+	if f.maxItems == 0 {
+		f.maxItems = limits.DefaultBatchItems
+	}
+
+	checkers := []policy.Checker{policy.SchemeChecker, policy.PrivateAddressChecker}
+	if len(config.URLPolicyFile) > 0 {
+		var err error
+		f.listChecker, err = policy.NewListChecker(config.URLPolicyFile, policyReloadInterval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		checkers = append(checkers, f.listChecker)
+	}
+	if len(config.URLPolicyEndpoint) > 0 {
+		checkers = append(checkers, policy.NewHTTPChecker(config.URLPolicyEndpoint, 0, false))
+	}
+	f.checker = policy.Chain(checkers...)
+
+	if config.RetentionDays > 0 {
+		period := time.Duration(config.RetentionDays) * 24 * time.Hour
+		f.retention = retention.Start(f.repo, period, retention.DefaultInterval)
+	}
+	f.webhooks = webhook.Start(f.repo, webhook.DefaultInterval)
+
+	return f
 }
 
 // Close освобождает ресурсы, используемые фабрикой.
 // Должен быть вызван при завершении работы приложения.
 func (f *Factory) Close() error {
+	if f.listChecker != nil {
+		if err := f.listChecker.Close(); err != nil {
+			log.Printf("Error closing URL policy: %v", err)
+		}
+	}
+	if f.retention != nil {
+		// удаление устаревших записей останавливается до закрытия хранилища
+		if err := f.retention.Close(); err != nil {
+			log.Printf("Error stopping retention job: %v", err)
+		}
+	}
+	// доставка событий останавливается до закрытия хранилища
+	if err := f.webhooks.Close(); err != nil {
+		log.Printf("Error stopping webhook dispatcher: %v", err)
+	}
+	var err error
+	if f.storage != nil {
+		// ожидающие удаления сохраняются до закрытия соединения с базой данных
+		err = f.storage.Close()
+	}
 	if f.db != nil {
-		return f.db.SQLDB.Close()
+		return errors.Join(err, f.db.Close())
 	}
-	return nil
+	return err
 }
 
 // CreateIDHandler создает обработчик для генерации короткого идентификатора URL
 func (f *Factory) CreateIDHandler() CreateIDHandler {
-	return NewCreateIDHandler(f.repo, f.baseURL)
+	return NewCreateIDHandler(f.repo, f.baseURL, f.checker)
 }
 
 // ShortenHandler создает обработчик для сокращения URL
 func (f *Factory) ShortenHandler() ShortenHandler {
-	return NewShortenHandler(f.repo, f.baseURL)
+	return NewShortenHandler(f.repo, f.baseURL, f.checker)
 }
 
 // ShortenBatchHandler создает обработчик для пакетного сокращения URL
 func (f *Factory) ShortenBatchHandler() ShortenBatchHandler {
-	return NewShortenBatchHandler(f.repo, f.baseURL)
+	return NewShortenBatchHandler(f.repo, f.baseURL, f.checker).WithMaxItems(f.maxItems)
 }
 
 // UserUrlsHandler создает обработчик для получения списка URL пользователя
@@ -116,15 +227,54 @@ func (f *Factory) UserUrlsHandler() UserUrlsHandler {
 
 // RetrieveURLHandler создает обработчик для получения оригинального URL по короткому идентификатору
 func (f *Factory) RetrieveURLHandler() RetrieveURLHandler {
-	return NewRetrieveURLHandler(f.repo)
+	return NewRetrieveURLHandler(f.repo).WithClickRecorder(f.repo)
 }
 
 // PingHandler создает обработчик для проверки доступности хранилища
 func (f *Factory) PingHandler() PingHandler {
-	return NewPingHandler(f.repo)
+	handler := NewPingHandler(f.repo)
+	if f.db != nil {
+		handler = handler.WithSchemaVersion(f.db)
+	}
+	return handler
 }
 
 // DeleteUrlsHandler создает обработчик для удаления URL пользователя
 func (f *Factory) DeleteUrlsHandler() DeleteUrlsHandler {
-	return NewDeleteUrlsHandler(f.repo)
+	return NewDeleteUrlsHandler(f.repo).WithMaxItems(f.maxItems)
+}
+
+// EditURLHandler создает обработчик для изменения целевого адреса ссылок пользователя
+func (f *Factory) EditURLHandler() EditURLHandler {
+	return NewEditURLHandler(f.repo, f.baseURL, f.checker)
+}
+
+// TransferHandler создает обработчик для экспорта и импорта ссылок пользователя
+func (f *Factory) TransferHandler() TransferHandler {
+	return NewTransferHandler(f.repo, f.checker).WithMaxItems(f.maxItems)
+}
+
+// AdminHandler создает обработчик API модерации
+func (f *Factory) AdminHandler() AdminHandler {
+	return NewAdminHandler(f.repo)
+}
+
+// LinksHandler создает обработчик ссылок API v2
+func (f *Factory) LinksHandler() LinksHandler {
+	return NewLinksHandler(f.repo, f.baseURL)
+}
+
+// AccountHandler создает обработчик учётной записи пользователя
+func (f *Factory) AccountHandler() AccountHandler {
+	return NewAccountHandler(f.repo)
+}
+
+// WebhooksHandler создает обработчик вебхуков пользователя
+func (f *Factory) WebhooksHandler() WebhooksHandler {
+	return NewWebhooksHandler(f.repo, f.checker)
+}
+
+// RevocationChecker возвращает проверку отзыва токенов пользователей
+func (f *Factory) RevocationChecker() auth.RevocationChecker {
+	return f.repo
 }
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/metrics"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedRepository оборачивает хранилище: каждая операция выполняется в отдельном спане
+// трассировки, а её длительность фиксируется в метриках.
+type instrumentedRepository struct {
+	backend string     // название хранилища для метки backend
+	repo    repository // оборачиваемое хранилище
+}
+
+// newInstrumentedRepository создает обёртку хранилища с трассировкой и метриками.
+func newInstrumentedRepository(backend string, repo repository) repository {
+	return instrumentedRepository{backend: backend, repo: repo}
+}
+
+// start начинает спан операции хранилища.
+// Возвращает контекст со спаном и функцию, которая завершает спан и фиксирует длительность операции.
+func (r instrumentedRepository) start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "repository."+operation,
+		trace.WithAttributes(attribute.String("repository.backend", r.backend)),
+	)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		metrics.ObserveRepository(r.backend, operation, start, err)
+	}
+}
+
+// SaveURL вызывает SaveURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) SaveURL(ctx context.Context, userID uuid.UUID, url string) (id string, exists bool, err error) {
+	ctx, done := r.start(ctx, "SaveURL")
+	defer func() { done(err) }()
+	return r.repo.SaveURL(ctx, userID, url)
+}
+
+// RetrieveByShortURL вызывает RetrieveByShortURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error) {
+	ctx, done := r.start(ctx, "RetrieveByShortURL")
+	defer func() { done(err) }()
+	return r.repo.RetrieveByShortURL(ctx, shortURL)
+}
+
+// RetrieveUserURLs вызывает RetrieveUserURLs хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error) {
+	ctx, done := r.start(ctx, "RetrieveUserURLs")
+	defer func() { done(err) }()
+	return r.repo.RetrieveUserURLs(ctx, userID)
+}
+
+// SearchUserURLs вызывает SearchUserURLs хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error) {
+	ctx, done := r.start(ctx, "SearchUserURLs")
+	defer func() { done(err) }()
+	return r.repo.SearchUserURLs(ctx, userID, query)
+}
+
+// ImportURL вызывает ImportURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) ImportURL(ctx context.Context, record models.Record) (id string, err error) {
+	ctx, done := r.start(ctx, "ImportURL")
+	defer func() { done(err) }()
+	return r.repo.ImportURL(ctx, record)
+}
+
+// DeleteByShortURLs вызывает DeleteByShortURLs хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error) {
+	ctx, done := r.start(ctx, "DeleteByShortURLs")
+	defer func() { done(err) }()
+	return r.repo.DeleteByShortURLs(ctx, userID, shortURLs)
+}
+
+// RetrieveDeletionJob вызывает RetrieveDeletionJob хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RetrieveDeletionJob(ctx context.Context, userID uuid.UUID, id string) (job models.DeletionJob, err error) {
+	ctx, done := r.start(ctx, "RetrieveDeletionJob")
+	defer func() { done(err) }()
+	return r.repo.RetrieveDeletionJob(ctx, userID, id)
+}
+
+// PurgeExpired вызывает PurgeExpired хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	ctx, done := r.start(ctx, "PurgeExpired")
+	defer func() { done(err) }()
+	return r.repo.PurgeExpired(ctx, cutoff)
+}
+
+// DeleteAccount вызывает DeleteAccount хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) DeleteAccount(ctx context.Context, userID uuid.UUID) (purged int, err error) {
+	ctx, done := r.start(ctx, "DeleteAccount")
+	defer func() { done(err) }()
+	return r.repo.DeleteAccount(ctx, userID)
+}
+
+// IsUserRevoked вызывает IsUserRevoked хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) IsUserRevoked(ctx context.Context, userID uuid.UUID) (revoked bool, err error) {
+	ctx, done := r.start(ctx, "IsUserRevoked")
+	defer func() { done(err) }()
+	return r.repo.IsUserRevoked(ctx, userID)
+}
+
+// CreateWebhook вызывает CreateWebhook хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) (err error) {
+	ctx, done := r.start(ctx, "CreateWebhook")
+	defer func() { done(err) }()
+	return r.repo.CreateWebhook(ctx, webhook)
+}
+
+// ListWebhooks вызывает ListWebhooks хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) ListWebhooks(ctx context.Context, userID uuid.UUID) (webhooks []models.Webhook, err error) {
+	ctx, done := r.start(ctx, "ListWebhooks")
+	defer func() { done(err) }()
+	return r.repo.ListWebhooks(ctx, userID)
+}
+
+// DeleteWebhook вызывает DeleteWebhook хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) DeleteWebhook(ctx context.Context, userID uuid.UUID, id string) (err error) {
+	ctx, done := r.start(ctx, "DeleteWebhook")
+	defer func() { done(err) }()
+	return r.repo.DeleteWebhook(ctx, userID, id)
+}
+
+// HasWebhooks вызывает HasWebhooks хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) HasWebhooks(ctx context.Context, userID uuid.UUID) (exists bool, err error) {
+	ctx, done := r.start(ctx, "HasWebhooks")
+	defer func() { done(err) }()
+	return r.repo.HasWebhooks(ctx, userID)
+}
+
+// RecordClick вызывает RecordClick хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RecordClick(ctx context.Context, record models.Record) (err error) {
+	ctx, done := r.start(ctx, "RecordClick")
+	defer func() { done(err) }()
+	return r.repo.RecordClick(ctx, record)
+}
+
+// ClaimDeliveries вызывает ClaimDeliveries хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []models.Delivery, err error) {
+	ctx, done := r.start(ctx, "ClaimDeliveries")
+	defer func() { done(err) }()
+	return r.repo.ClaimDeliveries(ctx, limit, lease)
+}
+
+// RetryDelivery вызывает RetryDelivery хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RetryDelivery(ctx context.Context, delivery models.Delivery) (err error) {
+	ctx, done := r.start(ctx, "RetryDelivery")
+	defer func() { done(err) }()
+	return r.repo.RetryDelivery(ctx, delivery)
+}
+
+// CompleteDelivery вызывает CompleteDelivery хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) CompleteDelivery(ctx context.Context, id string) (err error) {
+	ctx, done := r.start(ctx, "CompleteDelivery")
+	defer func() { done(err) }()
+	return r.repo.CompleteDelivery(ctx, id)
+}
+
+// PurgeAbandonedDeliveries вызывает PurgeAbandonedDeliveries хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) PurgeAbandonedDeliveries(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	ctx, done := r.start(ctx, "PurgeAbandonedDeliveries")
+	defer func() { done(err) }()
+	return r.repo.PurgeAbandonedDeliveries(ctx, cutoff)
+}
+
+// CheckStatus вызывает CheckStatus хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) CheckStatus(ctx context.Context) (err error) {
+	ctx, done := r.start(ctx, "CheckStatus")
+	defer func() { done(err) }()
+	return r.repo.CheckStatus(ctx)
+}
+
+// SaveURLs вызывает SaveURLs хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) SaveURLs(ctx context.Context, urls []string) (ids []string, err error) {
+	ctx, done := r.start(ctx, "SaveURLs")
+	defer func() { done(err) }()
+	return r.repo.SaveURLs(ctx, urls)
+}
+
+// UpdateLink вызывает UpdateLink хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) UpdateLink(ctx context.Context, userID uuid.UUID, shortURL string, update models.LinkUpdate) (record models.Record, err error) {
+	ctx, done := r.start(ctx, "UpdateLink")
+	defer func() { done(err) }()
+	return r.repo.UpdateLink(ctx, userID, shortURL, update)
+}
+
+// UpdateOriginalURL вызывает UpdateOriginalURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) UpdateOriginalURL(ctx context.Context, userID uuid.UUID, shortURL string, url string) (record models.Record, err error) {
+	ctx, done := r.start(ctx, "UpdateOriginalURL")
+	defer func() { done(err) }()
+	return r.repo.UpdateOriginalURL(ctx, userID, shortURL, url)
+}
+
+// RetrieveRevisions вызывает RetrieveRevisions хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RetrieveRevisions(ctx context.Context, userID uuid.UUID, shortURL string) (revisions []models.Revision, err error) {
+	ctx, done := r.start(ctx, "RetrieveRevisions")
+	defer func() { done(err) }()
+	return r.repo.RetrieveRevisions(ctx, userID, shortURL)
+}
+
+// SearchURLs вызывает SearchURLs хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) SearchURLs(ctx context.Context, filter models.SearchFilter) (records []models.Record, err error) {
+	ctx, done := r.start(ctx, "SearchURLs")
+	defer func() { done(err) }()
+	return r.repo.SearchURLs(ctx, filter)
+}
+
+// DisableURL вызывает DisableURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) DisableURL(ctx context.Context, shortURL string, reason string, legal bool, entry models.AuditEntry) (err error) {
+	ctx, done := r.start(ctx, "DisableURL")
+	defer func() { done(err) }()
+	return r.repo.DisableURL(ctx, shortURL, reason, legal, entry)
+}
+
+// RestoreURL вызывает RestoreURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RestoreURL(ctx context.Context, shortURL string, entry models.AuditEntry) (err error) {
+	ctx, done := r.start(ctx, "RestoreURL")
+	defer func() { done(err) }()
+	return r.repo.RestoreURL(ctx, shortURL, entry)
+}
+
+// PurgeURL вызывает PurgeURL хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) PurgeURL(ctx context.Context, shortURL string, entry models.AuditEntry) (err error) {
+	ctx, done := r.start(ctx, "PurgeURL")
+	defer func() { done(err) }()
+	return r.repo.PurgeURL(ctx, shortURL, entry)
+}
+
+// RetrieveAuditLog вызывает RetrieveAuditLog хранилища в отдельном спане и фиксирует длительность операции.
+func (r instrumentedRepository) RetrieveAuditLog(ctx context.Context) (entries []models.AuditEntry, err error) {
+	ctx, done := r.start(ctx, "RetrieveAuditLog")
+	defer func() { done(err) }()
+	return r.repo.RetrieveAuditLog(ctx)
+}
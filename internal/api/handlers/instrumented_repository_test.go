@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/iubondar/url-shortener/internal/metrics"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedRepository(t *testing.T) {
+	exporter := tracing.SetupInMemory()
+	repo := newInstrumentedRepository("test", simple_storage.NewSimpleRepository())
+	ctx := context.Background()
+
+	id, _, err := repo.SaveURL(ctx, uuid.New(), "http://example.com")
+	require.NoError(t, err)
+
+	_, err = repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+
+	_, err = repo.RetrieveByShortURL(ctx, "missing")
+	require.Error(t, err)
+
+	// count возвращает число наблюдений гистограммы для операции с заданным результатом.
+	count := func(operation, result string) uint64 {
+		var m dto.Metric
+		h := metrics.RepositoryDuration.WithLabelValues("test", operation, result).(prometheus.Histogram)
+		require.NoError(t, h.Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	assert.Equal(t, uint64(1), count("SaveURL", "ok"))
+	assert.Equal(t, uint64(1), count("RetrieveByShortURL", "ok"))
+	assert.Equal(t, uint64(1), count("RetrieveByShortURL", "not_found"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "repository.SaveURL", spans[0].Name)
+	assert.Equal(t, "repository.RetrieveByShortURL", spans[1].Name)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// LinkStore определяет интерфейс хранилища для работы со ссылками как с ресурсами API v2.
+type LinkStore interface {
+	// RetrieveByShortURL получает запись по короткому идентификатору.
+	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
+	// UpdateLink изменяет свойства ссылки пользователя и возвращает обновлённую запись.
+	UpdateLink(ctx context.Context, userID uuid.UUID, shortURL string, update models.LinkUpdate) (record models.Record, err error)
+	// DeleteByShortURLs помечает ссылки пользователя как удаленные.
+	DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error)
+}
+
+// LinkOut представляет ссылку как ресурс API v2.
+type LinkOut struct {
+	ID          string     `json:"id"`           // короткий идентификатор
+	ShortURL    string     `json:"short_url"`    // сокращенный URL
+	OriginalURL string     `json:"original_url"` // оригинальный URL
+	CreatedAt   time.Time  `json:"created_at"`   // время создания
+	ExpiresAt   *time.Time `json:"expires_at"`   // время окончания действия, null - бессрочно
+	Deleted     bool       `json:"deleted"`      // ссылка удалена пользователем
+	Title       string     `json:"title"`        // заголовок
+	Tags        []string   `json:"tags"`         // теги
+}
+
+// OptionalTime представляет поле времени, которое может отсутствовать в JSON, быть null или задано.
+type OptionalTime struct {
+	Set  bool       // поле присутствует в JSON
+	Time *time.Time // значение поля, nil для null
+}
+
+// UnmarshalJSON разбирает значение поля. Вызывается только для присутствующих полей, в том числе null.
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Time)
+}
+
+// LinkPatchIn представляет входные данные для изменения ссылки.
+// Отсутствующие поля не изменяются, expires_at: null делает ссылку бессрочной.
+type LinkPatchIn struct {
+	Title     *string      `json:"title"`      // новый заголовок
+	Tags      *[]string    `json:"tags"`       // новый набор тегов
+	ExpiresAt OptionalTime `json:"expires_at"` // новый срок действия
+}
+
+// LinksHandler обрабатывает запросы к ссылкам как к ресурсам API v2.
+// Пользователь видит и изменяет только свои ссылки; чужие ссылки для него не существуют.
+type LinksHandler struct {
+	store   LinkStore // хранилище ссылок
+	baseURL string    // базовый URL для формирования коротких ссылок
+}
+
+// NewLinksHandler создает новый экземпляр LinksHandler.
+// Принимает хранилище ссылок и базовый URL для формирования коротких ссылок.
+func NewLinksHandler(store LinkStore, baseURL string) LinksHandler {
+	return LinksHandler{
+		store:   store,
+		baseURL: baseURL,
+	}
+}
+
+// GetLink обрабатывает HTTP GET запрос получения ссылки по идентификатору.
+// Возвращает статус 200 OK и ссылку в формате JSON или 404 Not Found,
+// если ссылки нет или она принадлежит другому пользователю.
+func (handler LinksHandler) GetLink(res http.ResponseWriter, req *http.Request) {
+	record, ok := retrieveOwn(res, req, handler.store)
+	if !ok {
+		return
+	}
+	writeJSON(res, req, http.StatusOK, handler.linkOut(record))
+}
+
+// UpdateLink обрабатывает HTTP PATCH запрос изменения заголовка, тегов и срока действия ссылки.
+// Возвращает статус 200 OK и изменённую ссылку, 404 Not Found для чужой или отсутствующей ссылки,
+// 410 Gone для удаленной ссылки и 400 Bad Request, если срок действия уже прошёл.
+func (handler LinksHandler) UpdateLink(res http.ResponseWriter, req *http.Request) {
+	record, ok := retrieveOwn(res, req, handler.store)
+	if !ok {
+		return
+	}
+	if record.IsDeleted {
+		apierror.Write(res, req, apierror.New(apierror.CodeGone, "link was deleted"))
+		return
+	}
+
+	var in LinkPatchIn
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		readBodyError(res, req, err)
+		return
+	}
+	if in.ExpiresAt.Time != nil && !in.ExpiresAt.Time.After(time.Now()) {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "expires_at must be in the future"))
+		return
+	}
+
+	update := models.LinkUpdate{
+		Title:        in.Title,
+		Tags:         in.Tags,
+		SetExpiresAt: in.ExpiresAt.Set,
+		ExpiresAt:    in.ExpiresAt.Time,
+	}
+	record, err := handler.store.UpdateLink(req.Context(), record.UserID, record.ShortURL, update)
+	if err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+
+	writeJSON(res, req, http.StatusOK, handler.linkOut(record))
+}
+
+// DeleteLink обрабатывает HTTP DELETE запрос удаления ссылки.
+// Как и в API v1, удаление выполняется асинхронно: возвращает статус 202 Accepted
+// с адресом задачи удаления в заголовке Location,
+// 404 Not Found для чужой или отсутствующей ссылки или 503 Service Unavailable,
+// если хранилище не может принять удаление.
+func (handler LinksHandler) DeleteLink(res http.ResponseWriter, req *http.Request) {
+	record, ok := retrieveOwn(res, req, handler.store)
+	if !ok {
+		return
+	}
+	job, err := handler.store.DeleteByShortURLs(req.Context(), record.UserID, []string{record.ShortURL})
+	if err != nil {
+		writeDeleteError(res, req, err)
+		return
+	}
+	res.Header().Set("Location", deletionJobPath(job.ID))
+	res.WriteHeader(http.StatusAccepted)
+}
+
+// recordRetriever определяет получение записи хранилища по короткому идентификатору.
+type recordRetriever interface {
+	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
+}
+
+// retrieveOwn получает ссылку из параметра пути и проверяет, что она принадлежит пользователю.
+// При ошибке записывает ответ и возвращает false.
+func retrieveOwn(res http.ResponseWriter, req *http.Request, retriever recordRetriever) (models.Record, bool) {
+	id := chi.URLParam(req, "id")
+	if len(id) == 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find id parameter in query path"))
+		return models.Record{}, false
+	}
+
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return models.Record{}, false
+	}
+
+	record, err := retriever.RetrieveByShortURL(req.Context(), id)
+	if err == nil && record.UserID != userID {
+		err = models.ErrorNotFound
+	}
+	if err != nil {
+		apierror.Write(res, req, err)
+		return models.Record{}, false
+	}
+	return record, true
+}
+
+// linkOut преобразует запись хранилища в ресурс API v2.
+func (handler LinksHandler) linkOut(record models.Record) LinkOut {
+	baseURL := strings.TrimSuffix(strings.TrimPrefix(handler.baseURL, "http://"), "/")
+	tags := record.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return LinkOut{
+		ID:          record.ShortURL,
+		ShortURL:    fmt.Sprintf("http://%s/%s", baseURL, record.ShortURL),
+		OriginalURL: record.OriginalURL,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+		Deleted:     record.IsDeleted,
+		Title:       record.Title,
+		Tags:        tags,
+	}
+}
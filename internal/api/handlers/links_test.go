@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleLinksHandler_GetLink демонстрирует получение ссылки как ресурса API v2.
+func ExampleLinksHandler_GetLink() {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{
+				ShortURL:    "123",
+				OriginalURL: "https://example.com",
+				UserID:      userID,
+				CreatedAt:   time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+				Title:       "Example",
+			},
+		},
+	}
+	handler := NewLinksHandler(repo, "127.0.0.1")
+
+	request := withURLParam(httptest.NewRequest(http.MethodGet, "/api/v2/links/123", nil), "id", "123")
+	authCookie, _ := auth.NewAuthCookie(userID)
+	request.AddCookie(authCookie)
+
+	w := httptest.NewRecorder()
+	handler.GetLink(w, request)
+
+	fmt.Println(w.Code)
+	fmt.Print(w.Body.String())
+	// Output:
+	// 200
+	// {"id":"123","short_url":"http://127.0.0.1/123","original_url":"https://example.com","created_at":"2025-03-01T12:00:00Z","expires_at":null,"deleted":false,"title":"Example","tags":[]}
+}
+
+func TestLinksHandler(t *testing.T) {
+	userID := uuid.New()
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		method     string
+		id         string
+		userID     uuid.UUID
+		body       string
+		wantStatus int
+		wantLink   *LinkOut
+	}{
+		{
+			name:       "Get own link",
+			method:     http.MethodGet,
+			id:         "123",
+			userID:     userID,
+			wantStatus: http.StatusOK,
+			wantLink: &LinkOut{
+				ID: "123", ShortURL: "http://127.0.0.1/123", OriginalURL: "https://example.com",
+				Title: "Old", Tags: []string{"a"},
+			},
+		},
+		{
+			name:       "Get link of another user",
+			method:     http.MethodGet,
+			id:         "123",
+			userID:     uuid.New(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Get unknown link",
+			method:     http.MethodGet,
+			id:         "999",
+			userID:     userID,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Patch title and expiry",
+			method:     http.MethodPatch,
+			id:         "123",
+			userID:     userID,
+			body:       `{"title": "New", "expires_at": "` + future.Format(time.RFC3339) + `"}`,
+			wantStatus: http.StatusOK,
+			wantLink: &LinkOut{
+				ID: "123", ShortURL: "http://127.0.0.1/123", OriginalURL: "https://example.com",
+				Title: "New", Tags: []string{"a"}, ExpiresAt: &future,
+			},
+		},
+		{
+			name:       "Patch tags and clear expiry",
+			method:     http.MethodPatch,
+			id:         "123",
+			userID:     userID,
+			body:       `{"tags": [], "expires_at": null}`,
+			wantStatus: http.StatusOK,
+			wantLink: &LinkOut{
+				ID: "123", ShortURL: "http://127.0.0.1/123", OriginalURL: "https://example.com",
+				Title: "Old", Tags: []string{},
+			},
+		},
+		{
+			name:       "Patch expiry in the past",
+			method:     http.MethodPatch,
+			id:         "123",
+			userID:     userID,
+			body:       `{"expires_at": "2000-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Patch deleted link",
+			method:     http.MethodPatch,
+			id:         "456",
+			userID:     userID,
+			body:       `{"title": "New"}`,
+			wantStatus: http.StatusGone,
+		},
+		{
+			name:       "Delete own link",
+			method:     http.MethodDelete,
+			id:         "123",
+			userID:     userID,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "Delete link of another user",
+			method:     http.MethodDelete,
+			id:         "123",
+			userID:     uuid.New(),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &simple_storage.SimpleRepository{
+				Records: []models.Record{
+					{ShortURL: "123", OriginalURL: "https://example.com", UserID: userID, Title: "Old", Tags: []string{"a"}},
+					{ShortURL: "456", OriginalURL: "https://example.org", UserID: userID, IsDeleted: true},
+				},
+			}
+			handler := NewLinksHandler(repo, "127.0.0.1")
+			handlers := map[string]http.HandlerFunc{
+				http.MethodGet:    handler.GetLink,
+				http.MethodPatch:  handler.UpdateLink,
+				http.MethodDelete: handler.DeleteLink,
+			}
+
+			request := httptest.NewRequest(tt.method, "/api/v2/links/"+tt.id, strings.NewReader(tt.body))
+			request = withURLParam(request, "id", tt.id)
+			authCookie, err := auth.NewAuthCookie(tt.userID)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+
+			w := httptest.NewRecorder()
+			handlers[tt.method](w, request)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.method == http.MethodDelete && tt.wantStatus == http.StatusAccepted {
+				assert.True(t, repo.Records[0].IsDeleted)
+			}
+			if tt.wantLink == nil {
+				return
+			}
+
+			var got LinkOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, *tt.wantLink, got)
+		})
+	}
+}
+
+func TestOptionalTime_UnmarshalJSON(t *testing.T) {
+	var in LinkPatchIn
+	require.NoError(t, json.Unmarshal([]byte(`{"title": "x"}`), &in))
+	assert.False(t, in.ExpiresAt.Set)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"expires_at": null}`), &in))
+	assert.True(t, in.ExpiresAt.Set)
+	assert.Nil(t, in.ExpiresAt.Time)
+}
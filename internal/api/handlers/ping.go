@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
 )
 
 // StatusChecker определяет интерфейс для проверки состояния хранилища.
@@ -13,10 +16,23 @@ type StatusChecker interface {
 	CheckStatus(ctx context.Context) error
 }
 
+// SchemaVersioner определяет интерфейс для получения версии схемы базы данных.
+type SchemaVersioner interface {
+	// SchemaVersion возвращает версию последней применённой миграции схемы.
+	SchemaVersion(ctx context.Context) (version int64, err error)
+}
+
+// PingOut представляет ответ проверки доступности сервиса.
+type PingOut struct {
+	Status        string `json:"status"`                   // состояние сервиса, всегда "ok"
+	SchemaVersion *int64 `json:"schema_version,omitempty"` // версия схемы базы данных, если хранилище - база данных
+}
+
 // PingHandler обрабатывает запросы для проверки доступности сервиса.
 // Используется для проверки работоспособности сервера и его подключения к хранилищу.
 type PingHandler struct {
-	checker StatusChecker // интерфейс для проверки статуса хранилища
+	checker   StatusChecker   // интерфейс для проверки статуса хранилища
+	versioner SchemaVersioner // версия схемы базы данных, nil - хранилище без схемы
 }
 
 // NewPingHandler создает новый экземпляр PingHandler.
@@ -27,20 +43,35 @@ func NewPingHandler(checker StatusChecker) PingHandler {
 	}
 }
 
+// WithSchemaVersion возвращает копию, которая добавляет в ответ версию схемы базы данных.
+func (handler PingHandler) WithSchemaVersion(versioner SchemaVersioner) PingHandler {
+	handler.versioner = versioner
+	return handler
+}
+
 // Ping обрабатывает HTTP GET запрос для проверки доступности сервиса.
 // Проверяет подключение к хранилищу данных.
-// Возвращает статус 200 OK в случае успеха или 500 Internal Server Error при ошибке.
+// Возвращает статус 200 OK с PingOut в случае успеха или 500 Internal Server Error при ошибке.
 func (handler PingHandler) Ping(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
-		http.Error(res, "Only GET requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only GET requests are allowed!"))
 		return
 	}
 
 	err := handler.checker.CheckStatus(req.Context())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		apierror.Write(res, req, apierror.Internal(err))
 		return
 	}
 
-	res.WriteHeader(http.StatusOK)
+	out := PingOut{Status: "ok"}
+	if handler.versioner != nil {
+		version, err := handler.versioner.SchemaVersion(req.Context())
+		if err != nil {
+			apierror.Write(res, req, apierror.Internal(fmt.Errorf("get schema version: %w", err)))
+			return
+		}
+		out.SchemaVersion = &version
+	}
+	writeJSON(res, req, http.StatusOK, out)
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -110,3 +111,59 @@ func TestPingHandler_Ping(t *testing.T) {
 		})
 	}
 }
+
+// stubVersioner возвращает заданную версию схемы или ошибку.
+type stubVersioner struct {
+	version int64
+	err     error
+}
+
+func (v stubVersioner) SchemaVersion(ctx context.Context) (int64, error) {
+	return v.version, v.err
+}
+
+func TestPingHandler_SchemaVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		versioner SchemaVersioner
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:     "Without database",
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "With schema version",
+			versioner: stubVersioner{version: 20261018100003},
+			wantCode:  http.StatusOK,
+			wantBody:  `{"status":"ok","schema_version":20261018100003}`,
+		},
+		{
+			name:      "Schema version error",
+			versioner: stubVersioner{err: errors.New("connection refused")},
+			wantCode:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mocks.NewMockStatusChecker(ctrl)
+			m.EXPECT().CheckStatus(gomock.Any()).Return(nil)
+
+			handler := NewPingHandler(m)
+			if tt.versioner != nil {
+				handler = handler.WithSchemaVersion(tt.versioner)
+			}
+			w := httptest.NewRecorder()
+			handler.Ping(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/limits"
+)
+
+// readBodyError отвечает на ошибку чтения или разбора тела запроса:
+// 413 Request Entity Too Large с кодом too_large при превышении ограничения размера,
+// иначе 400 Bad Request с кодом invalid_request.
+func readBodyError(res http.ResponseWriter, req *http.Request, err error) {
+	if limit, ok := limits.TooLarge(err); ok {
+		limits.WriteTooLarge(res, req, "request body is too large", limit)
+		return
+	}
+	apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidRequest, "request body is not valid", err))
+}
+
+// checkBatchSize проверяет количество элементов пакетного запроса.
+// Если оно превышает maxItems, записывает в ответ 413 Request Entity Too Large с кодом too_large.
+// Неположительный maxItems отключает проверку.
+// Возвращает false, если обработку запроса нужно прекратить.
+func checkBatchSize(res http.ResponseWriter, req *http.Request, count int, maxItems int) bool {
+	if maxItems <= 0 || count <= maxItems {
+		return true
+	}
+	limits.WriteTooLarge(res, req, "too many items in batch", int64(maxItems))
+	return false
+}
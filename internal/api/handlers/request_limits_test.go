@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/iubondar/url-shortener/internal/limits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimits(t *testing.T) {
+	repo := simple_storage.NewSimpleRepository()
+	batch := `[{"correlation_id": "1", "original_url": "https://a.example"},` +
+		`{"correlation_id": "2", "original_url": "https://b.example"},` +
+		`{"correlation_id": "3", "original_url": "https://c.example"}]`
+
+	tests := []struct {
+		name       string
+		method     string
+		handler    http.HandlerFunc
+		bodyLimit  int64
+		body       string
+		wantCode   int
+		wantDetail string
+		wantLimit  int64
+	}{
+		{
+			name:       "CreateID body too large",
+			method:     http.MethodPost,
+			handler:    NewCreateIDHandler(repo, "127.0.0.1", nil).CreateID,
+			bodyLimit:  16,
+			body:       "https://example.com/" + strings.Repeat("a", 32),
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "request body is too large",
+			wantLimit:  16,
+		},
+		{
+			name:       "Shorten body too large",
+			method:     http.MethodPost,
+			handler:    NewShortenHandler(repo, "127.0.0.1", nil).Shorten,
+			bodyLimit:  16,
+			body:       `{"url": "https://example.com/` + strings.Repeat("a", 32) + `"}`,
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "request body is too large",
+			wantLimit:  16,
+		},
+		{
+			name:       "ShortenBatch too many items",
+			method:     http.MethodPost,
+			handler:    NewShortenBatchHandler(repo, "127.0.0.1", nil).WithMaxItems(2).ShortenBatch,
+			bodyLimit:  limits.DefaultBatchBodySize,
+			body:       batch,
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "too many items in batch",
+			wantLimit:  2,
+		},
+		{
+			name:      "ShortenBatch within item limit",
+			method:    http.MethodPost,
+			handler:   NewShortenBatchHandler(repo, "127.0.0.1", nil).WithMaxItems(3).ShortenBatch,
+			bodyLimit: limits.DefaultBatchBodySize,
+			body:      batch,
+			wantCode:  http.StatusCreated,
+		},
+		{
+			name:       "ShortenBatch body too large",
+			method:     http.MethodPost,
+			handler:    NewShortenBatchHandler(repo, "127.0.0.1", nil).ShortenBatch,
+			bodyLimit:  64,
+			body:       batch,
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "request body is too large",
+			wantLimit:  64,
+		},
+		{
+			name:       "DeleteUserURLs too many items",
+			method:     http.MethodDelete,
+			handler:    NewDeleteUrlsHandler(repo).WithMaxItems(1).DeleteUserURLs,
+			bodyLimit:  limits.DefaultBatchBodySize,
+			body:       `["123", "456"]`,
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "too many items in batch",
+			wantLimit:  1,
+		},
+		{
+			name:       "DeleteUserURLs body too large",
+			method:     http.MethodDelete,
+			handler:    NewDeleteUrlsHandler(repo).DeleteUserURLs,
+			bodyLimit:  8,
+			body:       `["123", "456"]`,
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantDetail: "request body is too large",
+			wantLimit:  8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			// длина тела неизвестна, ограничение срабатывает при чтении
+			request.ContentLength = -1
+			w := httptest.NewRecorder()
+			limits.Body(tt.bodyLimit)(tt.handler).ServeHTTP(w, request)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusRequestEntityTooLarge {
+				return
+			}
+
+			var out limits.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+			assert.Equal(t, apierror.CodeTooLarge, out.Code)
+			assert.Equal(t, tt.wantDetail, out.Detail)
+			assert.Equal(t, tt.wantLimit, out.Limit)
+		})
+	}
+}
@@ -2,10 +2,16 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
 	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"github.com/iubondar/url-shortener/internal/metrics"
+	"go.uber.org/zap"
 )
 
 // URLRetriever определяет интерфейс для получения URL из хранилища.
@@ -15,10 +21,17 @@ type URLRetriever interface {
 	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
 }
 
+// ClickRecorder определяет интерфейс хранилища для записи переходов по ссылкам.
+type ClickRecorder interface {
+	// RecordClick добавляет событие перехода по ссылке с записью record для вебхуков её владельца.
+	RecordClick(ctx context.Context, record models.Record) error
+}
+
 // RetrieveURLHandler обрабатывает запросы на получение оригинального URL по сокращенному идентификатору.
 // Выполняет перенаправление на оригинальный URL или возвращает ошибку, если URL не найден или удален.
 type RetrieveURLHandler struct {
-	repo URLRetriever // репозиторий для хранения URL
+	repo     URLRetriever  // репозиторий для хранения URL
+	recorder ClickRecorder // запись переходов, nil - переходы не записываются
 }
 
 // NewRetrieveURLHandler создает новый экземпляр RetrieveURLHandler.
@@ -29,34 +42,71 @@ func NewRetrieveURLHandler(repo URLRetriever) RetrieveURLHandler {
 	}
 }
 
+// WithClickRecorder возвращает копию, которая записывает успешные переходы по ссылкам.
+func (handler RetrieveURLHandler) WithClickRecorder(recorder ClickRecorder) RetrieveURLHandler {
+	handler.recorder = recorder
+	return handler
+}
+
 // RetrieveURL обрабатывает HTTP GET запрос для получения оригинального URL.
 // Принимает сокращенный идентификатор в параметре пути.
 // Возвращает:
 // - 307 Temporary Redirect с оригинальным URL в заголовке Location при успехе
-// - 410 Gone если URL был удален
-// - 400 Bad Request если URL не найден или параметр id отсутствует
+// - 451 Unavailable For Legal Reasons если URL заблокирован модератором по юридическим основаниям
+// - 410 Gone если URL был удален, заблокирован модератором или срок его действия истёк
+// - 404 Not Found если URL не найден
+// - 400 Bad Request если параметр id отсутствует
 func (handler RetrieveURLHandler) RetrieveURL(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
-		http.Error(res, "Only GET requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only GET requests are allowed!"))
 		return
 	}
 
 	id := chi.URLParam(req, "id")
 	if len(id) == 0 {
-		http.Error(res, "Can't find id parameter in query path", http.StatusBadRequest)
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find id parameter in query path"))
 		return
 	}
 
 	record, err := handler.repo.RetrieveByShortURL(req.Context(), id)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		if errors.Is(err, models.ErrorNotFound) {
+			metrics.Redirects.WithLabelValues(metrics.RedirectMiss).Inc()
+		}
+		apierror.Write(res, req, err)
 		return
 	}
 
-	if record.IsDeleted {
-		res.WriteHeader(http.StatusGone)
+	expired := record.IsExpired(time.Now())
+	if record.IsDisabled() || record.IsDeleted || expired {
+		metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
+	} else {
+		metrics.Redirects.WithLabelValues(metrics.RedirectHit).Inc()
+	}
+
+	if record.IsDisabled() {
+		code := apierror.CodeGone
+		if record.DisabledLegal {
+			code = apierror.CodeBlocked
+		}
+		apierror.Write(res, req, apierror.New(code, record.DisabledReason))
+	} else if record.IsDeleted {
+		apierror.Write(res, req, apierror.New(apierror.CodeGone, "URL was deleted"))
+	} else if expired {
+		apierror.Write(res, req, apierror.New(apierror.CodeGone, "URL has expired"))
 	} else {
+		handler.recordClick(req, record)
 		res.Header().Add("Location", record.OriginalURL)
 		res.WriteHeader(http.StatusTemporaryRedirect)
 	}
 }
+
+// recordClick записывает переход по ссылке. Ошибка записи не мешает перенаправлению и только логируется.
+func (handler RetrieveURLHandler) recordClick(req *http.Request, record models.Record) {
+	if handler.recorder == nil {
+		return
+	}
+	if err := handler.recorder.RecordClick(req.Context(), record); err != nil {
+		logctx.FromContext(req.Context()).Error("error recording click", zap.String("short_url", record.ShortURL), zap.Error(err))
+	}
+}
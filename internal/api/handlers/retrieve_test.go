@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
@@ -69,6 +71,7 @@ func ExampleRetrieveURLHandler_RetrieveURL() {
 
 func TestRetrieveURLHandler_RetrieveURL(t *testing.T) {
 	userID := uuid.New()
+	expired := time.Now().Add(-time.Hour)
 	type want struct {
 		code     int
 		location string
@@ -124,6 +127,33 @@ func TestRetrieveURLHandler_RetrieveURL(t *testing.T) {
 				location: "",
 			},
 		},
+		{
+			name:   "Test disabled URL",
+			method: http.MethodGet,
+			id:     "789",
+			want: want{
+				code:     http.StatusGone,
+				location: "",
+			},
+		},
+		{
+			name:   "Test legally blocked URL",
+			method: http.MethodGet,
+			id:     "000",
+			want: want{
+				code:     http.StatusUnavailableForLegalReasons,
+				location: "",
+			},
+		},
+		{
+			name:   "Test expired URL",
+			method: http.MethodGet,
+			id:     "exp",
+			want: want{
+				code:     http.StatusGone,
+				location: "",
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -140,6 +170,25 @@ func TestRetrieveURLHandler_RetrieveURL(t *testing.T) {
 						UserID:      userID,
 						IsDeleted:   true,
 					},
+					{
+						ShortURL:       "789",
+						OriginalURL:    "http://phishing.example",
+						UserID:         userID,
+						DisabledReason: "phishing",
+					},
+					{
+						ShortURL:       "000",
+						OriginalURL:    "http://blocked.example",
+						UserID:         userID,
+						DisabledReason: "court order",
+						DisabledLegal:  true,
+					},
+					{
+						ShortURL:    "exp",
+						OriginalURL: "http://expired.example",
+						UserID:      userID,
+						ExpiresAt:   &expired,
+					},
 				},
 			}
 			handler := NewRetrieveURLHandler(&repo)
@@ -200,8 +249,7 @@ func TestRetrieveURLHandler_WithNoIdParameter(t *testing.T) {
 
 func TestRetrieveURLHandler_WithNoURL(t *testing.T) {
 	handler := NewRetrieveURLHandler(simple_storage.NewSimpleRepository())
-	request := httptest.NewRequest(http.MethodGet, "/", nil)
-	request.SetPathValue("id", "123")
+	request := withURLParam(httptest.NewRequest(http.MethodGet, "/", nil), "id", "123")
 
 	// создаём новый Recorder
 	w := httptest.NewRecorder()
@@ -215,5 +263,65 @@ func TestRetrieveURLHandler_WithNoURL(t *testing.T) {
 		}
 	}()
 
-	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "not found\n", w.Body.String())
+}
+
+// clickRecorderFunc позволяет использовать функцию в качестве ClickRecorder.
+type clickRecorderFunc func(ctx context.Context, record models.Record) error
+
+func (f clickRecorderFunc) RecordClick(ctx context.Context, record models.Record) error {
+	return f(ctx, record)
+}
+
+func TestRetrieveURLHandler_WithClickRecorder(t *testing.T) {
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: testURL, UserID: uuid.New()},
+			{ShortURL: "456", OriginalURL: "http://abc.com", UserID: uuid.New(), IsDeleted: true},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		err        error
+		wantCode   int
+		wantClicks []string
+	}{
+		{
+			name:       "Redirect is recorded",
+			id:         "123",
+			wantCode:   http.StatusTemporaryRedirect,
+			wantClicks: []string{"123"},
+		},
+		{
+			name:     "Deleted URL is not recorded",
+			id:       "456",
+			wantCode: http.StatusGone,
+		},
+		{
+			name:       "Recording error does not fail redirect",
+			id:         "123",
+			err:        errors.New("connection refused"),
+			wantCode:   http.StatusTemporaryRedirect,
+			wantClicks: []string{"123"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var clicks []string
+			recorder := clickRecorderFunc(func(ctx context.Context, record models.Record) error {
+				clicks = append(clicks, record.ShortURL)
+				return tt.err
+			})
+			handler := NewRetrieveURLHandler(repo).WithClickRecorder(recorder)
+
+			w := httptest.NewRecorder()
+			handler.RetrieveURL(w, withURLParam(httptest.NewRequest(http.MethodGet, "/", nil), "id", tt.id))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantClicks, clicks)
+		})
+	}
 }
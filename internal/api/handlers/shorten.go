@@ -8,7 +8,11 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/iubondar/url-shortener/internal/api/apierror"
 	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"go.uber.org/zap"
 )
 
 // ShortenIn представляет входные данные для создания сокращенного URL.
@@ -24,16 +28,19 @@ type ShortenOut struct {
 // ShortenHandler обрабатывает запросы на создание сокращенного URL.
 // Позволяет создать сокращенную ссылку для одного URL.
 type ShortenHandler struct {
-	saver   URLSaver // репозиторий для хранения URL
-	baseURL string   // базовый URL для формирования сокращенных ссылок
+	saver   URLSaver       // репозиторий для хранения URL
+	baseURL string         // базовый URL для формирования сокращенных ссылок
+	checker policy.Checker // политика допустимых URL
 }
 
 // NewShortenHandler создает новый экземпляр ShortenHandler.
-// Принимает репозиторий для хранения URL и базовый URL для формирования сокращенных ссылок.
-func NewShortenHandler(saver URLSaver, baseURL string) ShortenHandler {
+// Принимает репозиторий для хранения URL, базовый URL для формирования сокращенных ссылок
+// и политику допустимых URL (nil - без проверки).
+func NewShortenHandler(saver URLSaver, baseURL string, checker policy.Checker) ShortenHandler {
 	return ShortenHandler{
 		saver:   saver,
 		baseURL: baseURL,
+		checker: checker,
 	}
 }
 
@@ -43,7 +50,7 @@ func NewShortenHandler(saver URLSaver, baseURL string) ShortenHandler {
 // Возвращает статус 201 Created для нового URL или 409 Conflict если URL уже существует.
 func (handler ShortenHandler) Shorten(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
-		http.Error(res, "Only POST requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only POST requests are allowed!"))
 		return
 	}
 
@@ -52,31 +59,35 @@ func (handler ShortenHandler) Shorten(res http.ResponseWriter, req *http.Request
 	// читаем тело запроса
 	_, err := buf.ReadFrom(req.Body)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		readBodyError(res, req, err)
 		return
 	}
 
 	// десериализуем JSON
 	if err = json.Unmarshal(buf.Bytes(), &in); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidRequest, "request body is not valid JSON", err))
 		return
 	}
 
 	url, err := url.ParseRequestURI(in.URL)
 	if err != nil {
-		http.Error(res, "URL is not valid", http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidURL, "URL is not valid", err))
+		return
+	}
+
+	if !checkPolicy(res, req, handler.checker, url) {
 		return
 	}
 
 	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
 	if err != nil {
-		http.Error(res, "Error setting userID "+err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
 		return
 	}
 
 	id, exists, err := handler.saver.SaveURL(req.Context(), userID, url.String())
 	if err != nil {
-		http.Error(res, "Can't save URL", http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("save URL: %w", err)))
 		return
 	}
 
@@ -87,7 +98,7 @@ func (handler ShortenHandler) Shorten(res http.ResponseWriter, req *http.Request
 
 	resp, err := json.Marshal(out)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		apierror.Write(res, req, apierror.Internal(err))
 		return
 	}
 
@@ -99,7 +110,6 @@ func (handler ShortenHandler) Shorten(res http.ResponseWriter, req *http.Request
 	}
 
 	if _, err := res.Write(resp); err != nil {
-		http.Error(res, "Error writing response", http.StatusInternalServerError)
-		return
+		logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
 	}
 }
@@ -7,6 +7,9 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/policy"
 )
 
 // ShortenBatchIn представляет входные данные для пакетного создания сокращенных URL.
@@ -31,32 +34,47 @@ type URLBatchSaver interface {
 // ShortenBatchHandler обрабатывает запросы на пакетное создание сокращенных URL.
 // Позволяет создать несколько сокращенных URL за один запрос.
 type ShortenBatchHandler struct {
-	saver   URLBatchSaver // репозиторий для хранения URL
-	baseURL string        // базовый URL для формирования сокращенных ссылок
+	saver    URLBatchSaver  // репозиторий для хранения URL
+	baseURL  string         // базовый URL для формирования сокращенных ссылок
+	checker  policy.Checker // политика допустимых URL
+	maxItems int            // максимальное количество URL в запросе, 0 - без ограничения
 }
 
 // NewShortenBatchHandler создает новый экземпляр ShortenBatchHandler.
-// Принимает репозиторий для хранения URL и базовый URL для формирования сокращенных ссылок.
-func NewShortenBatchHandler(saver URLBatchSaver, baseURL string) ShortenBatchHandler {
+// Принимает репозиторий для хранения URL, базовый URL для формирования сокращенных ссылок
+// и политику допустимых URL (nil - без проверки).
+func NewShortenBatchHandler(saver URLBatchSaver, baseURL string, checker policy.Checker) ShortenBatchHandler {
 	return ShortenBatchHandler{
 		saver:   saver,
 		baseURL: baseURL,
+		checker: checker,
 	}
 }
 
+// WithMaxItems возвращает копию обработчика с ограничением количества URL в одном запросе.
+func (handler ShortenBatchHandler) WithMaxItems(maxItems int) ShortenBatchHandler {
+	handler.maxItems = maxItems
+	return handler
+}
+
 // ShortenBatch обрабатывает HTTP POST запрос для пакетного создания сокращенных URL.
 // Принимает массив URL в теле запроса в формате JSON.
 // Возвращает массив созданных сокращенных URL в формате JSON.
+// Если хотя бы один URL не проходит политику допустимых URL, весь пакет отклоняется.
+// Если тело запроса или количество URL превышает ограничения, возвращает 413 Request Entity Too Large.
 // Возвращает статус 201 Created в случае успеха.
 func (handler ShortenBatchHandler) ShortenBatch(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
-		http.Error(res, "Only POST requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only POST requests are allowed!"))
 		return
 	}
 
 	var in []ShortenBatchIn
 	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		readBodyError(res, req, err)
+		return
+	}
+	if !checkBatchSize(res, req, len(in), handler.maxItems) {
 		return
 	}
 
@@ -65,7 +83,10 @@ func (handler ShortenBatchHandler) ShortenBatch(res http.ResponseWriter, req *ht
 		// Проверяем URL
 		URL, err := url.ParseRequestURI(elem.OriginalURL)
 		if err != nil {
-			http.Error(res, err.Error(), http.StatusBadRequest)
+			apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidURL, fmt.Sprintf("URL %q is not valid", elem.OriginalURL), err))
+			return
+		}
+		if !checkPolicy(res, req, handler.checker, URL) {
 			return
 		}
 		urls = append(urls, URL.String())
@@ -73,7 +94,7 @@ func (handler ShortenBatchHandler) ShortenBatch(res http.ResponseWriter, req *ht
 
 	ids, err := handler.saver.SaveURLs(req.Context(), urls)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("save URLs: %w", err)))
 		return
 	}
 
@@ -89,7 +110,7 @@ func (handler ShortenBatchHandler) ShortenBatch(res http.ResponseWriter, req *ht
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(res).Encode(out); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		apierror.Write(res, req, apierror.Internal(err))
 		return
 	}
 }
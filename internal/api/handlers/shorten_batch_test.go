@@ -31,7 +31,7 @@ func ExampleShortenBatchHandler_ShortenBatch() {
 
 	// Инициализируем репозиторий и обработчик
 	repo := &simple_storage.SimpleRepository{}
-	handler := NewShortenBatchHandler(repo, "127.0.0.1")
+	handler := NewShortenBatchHandler(repo, "127.0.0.1", nil)
 
 	// Вызываем обработчик
 	handler.ShortenBatch(w, request)
@@ -178,7 +178,7 @@ func TestShortenBatchHandler_ShortenBatch(t *testing.T) {
 			repo := simple_storage.SimpleRepository{
 				Records: test.fields.records,
 			}
-			handler := NewShortenBatchHandler(&repo, "127.0.0.1")
+			handler := NewShortenBatchHandler(&repo, "127.0.0.1", nil)
 			handler.ShortenBatch(w, request)
 
 			res := w.Result()
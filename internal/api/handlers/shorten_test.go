@@ -33,7 +33,7 @@ func ExampleShortenHandler_Shorten() {
 
 	// Инициализируем репозиторий и обработчик
 	repo := &simple_storage.SimpleRepository{}
-	handler := NewShortenHandler(repo, "127.0.0.1")
+	handler := NewShortenHandler(repo, "127.0.0.1", nil)
 
 	// Вызываем обработчик
 	handler.Shorten(w, request)
@@ -174,7 +174,7 @@ func TestShortenHandler_Shorten(t *testing.T) {
 			repo := simple_storage.SimpleRepository{
 				Records: test.fields.records,
 			}
-			handler := NewShortenHandler(&repo, "127.0.0.1")
+			handler := NewShortenHandler(&repo, "127.0.0.1", nil)
 			handler.Shorten(w, request)
 
 			res := w.Result()
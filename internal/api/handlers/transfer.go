@@ -0,0 +1,543 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"go.uber.org/zap"
+)
+
+// exportPageSize задаёт количество записей, которое экспорт читает из хранилища за один запрос.
+const exportPageSize = 500
+
+// Форматы экспорта и импорта ссылок.
+const (
+	formatCSV    = "csv"    // CSV с заголовком
+	formatJSON   = "json"   // массив JSON
+	formatNDJSON = "ndjson" // объект JSON на строку
+)
+
+// transferContentTypes сопоставляет формат экспорта и импорта с типом содержимого.
+var transferContentTypes = map[string]string{
+	formatCSV:    "text/csv",
+	formatJSON:   "application/json",
+	formatNDJSON: "application/x-ndjson",
+}
+
+// csvColumns - столбцы CSV в порядке экспорта. Теги в CSV разделяются символом ';'.
+var csvColumns = []string{"id", "original_url", "created_at", "expires_at", "title", "tags", "deleted"}
+
+// Результаты импорта строки.
+const (
+	ImportCreated  = "created"  // ссылка создана с прежним коротким идентификатором
+	ImportRenamed  = "renamed"  // прежний идентификатор занят или недопустим, ссылка создана с новым
+	ImportConflict = "conflict" // адрес уже сокращён другой ссылкой
+	ImportSkipped  = "skipped"  // удаленная ссылка не импортируется
+	ImportInvalid  = "invalid"  // строка содержит некорректные данные
+	ImportFailed   = "failed"   // ссылку не удалось сохранить
+)
+
+// URLTransferer определяет интерфейс хранилища для экспорта и импорта ссылок пользователя.
+type URLTransferer interface {
+	// SearchUserURLs возвращает страницу URL пользователя.
+	SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error)
+	// ImportURL сохраняет импортированную запись и возвращает её короткий идентификатор.
+	ImportURL(ctx context.Context, record models.Record) (id string, err error)
+}
+
+// TransferRecord представляет ссылку в экспортируемых и импортируемых данных.
+type TransferRecord struct {
+	ID          string     `json:"id"`                   // короткий идентификатор
+	OriginalURL string     `json:"original_url"`         // оригинальный URL
+	CreatedAt   time.Time  `json:"created_at"`           // время создания
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // время окончания действия
+	Title       string     `json:"title,omitempty"`      // заголовок
+	Tags        []string   `json:"tags,omitempty"`       // теги
+	Deleted     bool       `json:"deleted"`              // ссылка удалена пользователем
+}
+
+// ImportRowOut представляет результат импорта одной строки.
+type ImportRowOut struct {
+	Row         int    `json:"row"`             // номер строки данных, начиная с 1
+	ID          string `json:"id,omitempty"`    // короткий идентификатор созданной или конфликтующей ссылки
+	OriginalURL string `json:"original_url"`    // оригинальный URL из строки
+	Status      string `json:"status"`          // результат импорта
+	Error       string `json:"error,omitempty"` // причина, если строка не импортирована
+}
+
+// ImportOut представляет итог импорта ссылок.
+type ImportOut struct {
+	Created   int            `json:"created"`   // количество созданных ссылок, включая переименованные
+	Conflicts int            `json:"conflicts"` // количество строк с уже сокращёнными адресами
+	Skipped   int            `json:"skipped"`   // количество пропущенных удаленных ссылок
+	Failed    int            `json:"failed"`    // количество некорректных и несохранённых строк
+	Rows      []ImportRowOut `json:"rows"`      // результаты по строкам
+}
+
+// TransferHandler обрабатывает запросы на экспорт и импорт ссылок пользователя.
+// Работает через интерфейс хранилища и поэтому поддерживает все хранилища.
+type TransferHandler struct {
+	transferer URLTransferer  // хранилище ссылок
+	checker    policy.Checker // политика допустимых URL
+	maxItems   int            // максимальное количество импортируемых строк
+}
+
+// NewTransferHandler создает новый экземпляр TransferHandler.
+// Принимает хранилище ссылок и политику допустимых URL (nil - без проверки).
+func NewTransferHandler(transferer URLTransferer, checker policy.Checker) TransferHandler {
+	return TransferHandler{
+		transferer: transferer,
+		checker:    checker,
+	}
+}
+
+// WithMaxItems возвращает копию обработчика с ограничением количества импортируемых строк в одном запросе.
+func (handler TransferHandler) WithMaxItems(maxItems int) TransferHandler {
+	handler.maxItems = maxItems
+	return handler
+}
+
+// Export обрабатывает HTTP GET запрос выгрузки всех ссылок пользователя, включая удаленные.
+// Формат задаётся параметром format: csv, json (по умолчанию) или ndjson.
+// Записи читаются из хранилища страницами и передаются клиенту по мере чтения.
+// Возвращает статус 200 OK или 400 Bad Request для неизвестного формата.
+func (handler TransferHandler) Export(res http.ResponseWriter, req *http.Request) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = formatJSON
+	}
+	contentType, ok := transferContentTypes[format]
+	if !ok {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "format must be one of csv, json, ndjson"))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return
+	}
+
+	// первую страницу читаем до отправки заголовков, чтобы ошибка хранилища вернулась статусом
+	query := models.URLQuery{Limit: exportPageSize}
+	records, next, err := handler.transferer.SearchUserURLs(req.Context(), userID, query)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("export user URLs: %w", err)))
+		return
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="urls.%s"`, format))
+	res.WriteHeader(http.StatusOK)
+
+	w := newTransferWriter(format, res)
+	for {
+		for _, record := range records {
+			if err := w.Write(transferRecord(record)); err != nil {
+				logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
+			return
+		}
+		// ResponseController доходит до исходного ответа через Unwrap обёрток middleware
+		if err := http.NewResponseController(res).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logctx.FromContext(req.Context()).Debug("error flushing response", zap.Error(err))
+			return
+		}
+		if next == nil {
+			break
+		}
+
+		query.After = next
+		records, next, err = handler.transferer.SearchUserURLs(req.Context(), userID, query)
+		if err != nil {
+			// заголовки уже отправлены: обрываем выгрузку, клиент получит неполный документ
+			logctx.FromContext(req.Context()).Error("error exporting user URLs", zap.Error(err))
+			return
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
+	}
+}
+
+// Import обрабатывает HTTP POST запрос загрузки ссылок пользователя в формате экспорта.
+// Формат задаётся параметром format или заголовком Content-Type.
+// Ссылки сохраняют короткие идентификаторы, если те свободны; удаленные ссылки пропускаются.
+// Возвращает статус 200 OK и результат по каждой строке, 400 Bad Request для некорректного документа,
+// 413 Request Entity Too Large при превышении количества строк и 415 Unsupported Media Type
+// для неизвестного формата.
+func (handler TransferHandler) Import(res http.ResponseWriter, req *http.Request) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(req.Header.Get("Content-Type"))
+	}
+	if _, ok := transferContentTypes[format]; !ok {
+		apierror.Write(res, req, apierror.New(apierror.CodeUnsupportedMediaType, "format must be one of csv, json, ndjson"))
+		return
+	}
+
+	rows, err := readTransferRecords(format, req.Body)
+	if err != nil {
+		readBodyError(res, req, err)
+		return
+	}
+	if !checkBatchSize(res, req, len(rows), handler.maxItems) {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return
+	}
+
+	out := ImportOut{Rows: make([]ImportRowOut, 0, len(rows))}
+	for i, row := range rows {
+		result := handler.importRow(req.Context(), userID, row)
+		result.Row = i + 1
+		switch result.Status {
+		case ImportCreated, ImportRenamed:
+			out.Created++
+		case ImportConflict:
+			out.Conflicts++
+		case ImportSkipped:
+			out.Skipped++
+		default:
+			out.Failed++
+		}
+		out.Rows = append(out.Rows, result)
+	}
+
+	writeJSON(res, req, http.StatusOK, out)
+}
+
+// importRow проверяет и сохраняет одну импортируемую ссылку.
+func (handler TransferHandler) importRow(ctx context.Context, userID uuid.UUID, row transferRow) ImportRowOut {
+	out := ImportRowOut{OriginalURL: row.OriginalURL}
+	if row.err != nil {
+		out.Status, out.Error = ImportInvalid, row.err.Error()
+		return out
+	}
+	if row.Deleted {
+		out.Status = ImportSkipped
+		return out
+	}
+
+	u, err := url.ParseRequestURI(row.OriginalURL)
+	if err != nil {
+		out.Status, out.Error = ImportInvalid, "URL is not valid"
+		return out
+	}
+	if handler.checker != nil {
+		if err := handler.checker.Check(ctx, u); err != nil {
+			out.Status, out.Error = ImportFailed, "Can't check URL"
+			if policy.IsRejected(err) {
+				out.Status, out.Error = ImportInvalid, err.Error()
+			}
+			return out
+		}
+	}
+
+	id, err := handler.transferer.ImportURL(ctx, models.Record{
+		ShortURL:    row.ID,
+		OriginalURL: u.String(),
+		UserID:      userID,
+		CreatedAt:   row.CreatedAt,
+		ExpiresAt:   row.ExpiresAt,
+		Title:       row.Title,
+		Tags:        row.Tags,
+	})
+	out.ID = id
+	switch {
+	case errors.Is(err, models.ErrorOriginalURLExists):
+		out.Status, out.Error = ImportConflict, "URL is already shortened"
+	case err != nil:
+		logctx.FromContext(ctx).Error("error importing URL", zap.Error(err))
+		out.Status, out.Error = ImportFailed, "internal server error"
+	case row.ID != "" && id != row.ID:
+		out.Status = ImportRenamed
+	default:
+		out.Status = ImportCreated
+	}
+	return out
+}
+
+// transferRecord преобразует запись хранилища в экспортируемую ссылку.
+func transferRecord(record models.Record) TransferRecord {
+	return TransferRecord{
+		ID:          record.ShortURL,
+		OriginalURL: record.OriginalURL,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+		Title:       record.Title,
+		Tags:        record.Tags,
+		Deleted:     record.IsDeleted,
+	}
+}
+
+// importFormat определяет формат импорта по заголовку Content-Type или возвращает пустую строку.
+func importFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	for format, t := range transferContentTypes {
+		if t == mediaType {
+			return format
+		}
+	}
+	return ""
+}
+
+// transferWriter последовательно записывает экспортируемые ссылки в ответ.
+type transferWriter interface {
+	// Write записывает очередную ссылку.
+	Write(record TransferRecord) error
+	// Flush передаёт буферизованные данные в ответ.
+	Flush() error
+	// Close завершает документ.
+	Close() error
+}
+
+// newTransferWriter создает запись экспортируемых ссылок в указанном формате.
+func newTransferWriter(format string, w io.Writer) transferWriter {
+	switch format {
+	case formatCSV:
+		return &csvTransferWriter{w: csv.NewWriter(w)}
+	case formatNDJSON:
+		return ndjsonTransferWriter{encoder: json.NewEncoder(w)}
+	default:
+		return &jsonTransferWriter{w: w}
+	}
+}
+
+// csvTransferWriter записывает ссылки в CSV, начиная с заголовка.
+type csvTransferWriter struct {
+	w      *csv.Writer
+	header bool // заголовок уже записан
+}
+
+// Write записывает ссылку строкой CSV.
+func (t *csvTransferWriter) Write(record TransferRecord) error {
+	if err := t.writeHeader(); err != nil {
+		return err
+	}
+	var expiresAt string
+	if record.ExpiresAt != nil {
+		expiresAt = record.ExpiresAt.Format(time.RFC3339Nano)
+	}
+	return t.w.Write([]string{
+		record.ID,
+		record.OriginalURL,
+		record.CreatedAt.Format(time.RFC3339Nano),
+		expiresAt,
+		record.Title,
+		strings.Join(record.Tags, ";"),
+		strconv.FormatBool(record.Deleted),
+	})
+}
+
+// Flush передаёт накопленные строки в ответ.
+func (t *csvTransferWriter) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// Close записывает заголовок, если ссылок не было, и сбрасывает буфер.
+func (t *csvTransferWriter) Close() error {
+	if err := t.writeHeader(); err != nil {
+		return err
+	}
+	return t.Flush()
+}
+
+// writeHeader записывает заголовок CSV при первом вызове.
+func (t *csvTransferWriter) writeHeader() error {
+	if t.header {
+		return nil
+	}
+	t.header = true
+	return t.w.Write(csvColumns)
+}
+
+// jsonTransferWriter записывает ссылки элементами массива JSON.
+type jsonTransferWriter struct {
+	w     io.Writer
+	count int // количество записанных ссылок
+}
+
+// Write записывает ссылку очередным элементом массива.
+func (t *jsonTransferWriter) Write(record TransferRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	prefix := ","
+	if t.count == 0 {
+		prefix = "["
+	}
+	t.count++
+	_, err = io.WriteString(t.w, prefix+string(data))
+	return err
+}
+
+// Flush ничего не делает: элементы записываются без буфера.
+func (t *jsonTransferWriter) Flush() error {
+	return nil
+}
+
+// Close закрывает массив.
+func (t *jsonTransferWriter) Close() error {
+	suffix := "]\n"
+	if t.count == 0 {
+		suffix = "[]\n"
+	}
+	_, err := io.WriteString(t.w, suffix)
+	return err
+}
+
+// ndjsonTransferWriter записывает каждую ссылку отдельной строкой JSON.
+type ndjsonTransferWriter struct {
+	encoder *json.Encoder
+}
+
+// Write записывает ссылку строкой JSON.
+func (t ndjsonTransferWriter) Write(record TransferRecord) error {
+	return t.encoder.Encode(record)
+}
+
+// Flush ничего не делает: строки записываются без буфера.
+func (t ndjsonTransferWriter) Flush() error {
+	return nil
+}
+
+// Close ничего не делает: строки записываются целиком.
+func (t ndjsonTransferWriter) Close() error {
+	return nil
+}
+
+// transferRow представляет импортируемую ссылку и ошибку разбора её строки.
+type transferRow struct {
+	TransferRecord
+	err error // строка не разобрана, ссылка не импортируется
+}
+
+// readTransferRecords читает импортируемые ссылки в указанном формате.
+// Ошибки в отдельных полях CSV сохраняются в строках, ошибки структуры документа возвращаются.
+func readTransferRecords(format string, r io.Reader) ([]transferRow, error) {
+	switch format {
+	case formatCSV:
+		return readCSVRecords(r)
+	case formatNDJSON:
+		rows := make([]transferRow, 0)
+		decoder := json.NewDecoder(r)
+		for {
+			var row transferRow
+			err := decoder.Decode(&row.TransferRecord)
+			if errors.Is(err, io.EOF) {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+	default:
+		var records []TransferRecord
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, err
+		}
+		rows := make([]transferRow, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, transferRow{TransferRecord: record})
+		}
+		return rows, nil
+	}
+}
+
+// readCSVRecords читает ссылки из CSV. Первая строка - заголовок с именами столбцов
+// из csvColumns в любом порядке; обязателен только столбец original_url.
+func readCSVRecords(r io.Reader) ([]transferRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []transferRow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["original_url"]; !ok {
+		return nil, errors.New("CSV header has no original_url column")
+	}
+
+	rows := make([]transferRow, 0)
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+
+		var row transferRow
+		row.ID = field("id")
+		row.OriginalURL = field("original_url")
+		row.Title = field("title")
+		if tags := field("tags"); tags != "" {
+			row.Tags = strings.Split(tags, ";")
+		}
+		if value := field("created_at"); value != "" {
+			row.CreatedAt, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				row.err = errors.New("created_at must be an RFC 3339 timestamp")
+			}
+		}
+		if value := field("expires_at"); value != "" {
+			expiresAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				row.err = errors.New("expires_at must be an RFC 3339 timestamp")
+			}
+			row.ExpiresAt = &expiresAt
+		}
+		if value := field("deleted"); value != "" {
+			row.Deleted, err = strconv.ParseBool(value)
+			if err != nil {
+				row.err = errors.New("deleted must be true or false")
+			}
+		}
+		rows = append(rows, row)
+	}
+}
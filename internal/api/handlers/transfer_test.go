@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/iubondar/url-shortener/internal/compress"
+	"github.com/iubondar/url-shortener/internal/logging"
+	"github.com/iubondar/url-shortener/internal/metrics"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleTransferHandler_Export демонстрирует выгрузку ссылок пользователя в CSV.
+func ExampleTransferHandler_Export() {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: "https://example.com", UserID: userID, CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), Tags: []string{"a", "b"}},
+		},
+	}
+	handler := NewTransferHandler(repo, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/user/urls/export?format=csv", nil)
+	authCookie, _ := auth.NewAuthCookie(userID)
+	request.AddCookie(authCookie)
+
+	w := httptest.NewRecorder()
+	handler.Export(w, request)
+
+	fmt.Println(w.Code)
+	fmt.Print(w.Body.String())
+	// Output:
+	// 200
+	// id,original_url,created_at,expires_at,title,tags,deleted
+	// 123,https://example.com,2025-03-01T12:00:00Z,,,a;b,false
+}
+
+func TestTransferHandler_RoundTrip(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := created.Add(30 * 24 * time.Hour)
+	userID := uuid.New()
+	source := &simple_storage.SimpleRepository{}
+	for i := range exportPageSize + 2 {
+		source.Records = append(source.Records, models.Record{
+			ShortURL:    fmt.Sprintf("id%04d", i),
+			OriginalURL: fmt.Sprintf("https://example.com/%d", i),
+			UserID:      userID,
+			CreatedAt:   created.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	source.Records[0].ExpiresAt = &expires
+	source.Records[0].Title = "Заголовок, с запятой"
+	source.Records[0].Tags = []string{"работа", "docs"}
+	source.Records[1].IsDeleted = true
+
+	for _, format := range []string{formatCSV, formatJSON, formatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			authCookie, err := auth.NewAuthCookie(userID)
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/urls/export?format="+format, nil)
+			request.AddCookie(authCookie)
+			w := httptest.NewRecorder()
+			NewTransferHandler(source, nil).Export(w, request)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, transferContentTypes[format], w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "urls."+format)
+
+			// другой пользователь загружает выгрузку в пустое хранилище
+			target := &simple_storage.SimpleRepository{}
+			request = httptest.NewRequest(http.MethodPost, "/api/user/urls/import", strings.NewReader(w.Body.String()))
+			request.Header.Set("Content-Type", transferContentTypes[format])
+			w = httptest.NewRecorder()
+			NewTransferHandler(target, nil).Import(w, request)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var out ImportOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+			assert.Equal(t, exportPageSize+1, out.Created)
+			assert.Equal(t, 1, out.Skipped)
+			assert.Zero(t, out.Failed)
+			assert.Zero(t, out.Conflicts)
+
+			require.Len(t, target.Records, exportPageSize+1)
+			got := target.Records[0]
+			assert.Equal(t, "id0000", got.ShortURL)
+			assert.True(t, created.Equal(got.CreatedAt))
+			require.NotNil(t, got.ExpiresAt)
+			assert.True(t, expires.Equal(*got.ExpiresAt))
+			assert.Equal(t, "Заголовок, с запятой", got.Title)
+			assert.Equal(t, []string{"работа", "docs"}, got.Tags)
+			assert.NotEqual(t, userID, got.UserID)
+		})
+	}
+}
+
+func TestTransferHandler_Import(t *testing.T) {
+	userID := uuid.New()
+	body := `id,original_url,created_at,deleted
+new,https://example.com/new,,
+taken,https://example.com/renamed,,
+,https://example.com/exists,,
+old,https://example.com/old,,true
+bad,not a url,,
+late,https://example.com/late,yesterday,
+local,http://127.0.0.1/admin,,
+`
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "taken", OriginalURL: "https://example.org", UserID: uuid.New()},
+			{ShortURL: "exists", OriginalURL: "https://example.com/exists", UserID: userID},
+		},
+	}
+	handler := NewTransferHandler(repo, privateAddressChecker)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/user/urls/import?format=csv", strings.NewReader(body))
+	authCookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+	request.AddCookie(authCookie)
+	w := httptest.NewRecorder()
+	handler.Import(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var out ImportOut
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	statuses := make([]string, 0, len(out.Rows))
+	for i, row := range out.Rows {
+		assert.Equal(t, i+1, row.Row)
+		statuses = append(statuses, row.Status)
+	}
+	assert.Equal(t, []string{
+		ImportCreated, ImportRenamed, ImportConflict, ImportSkipped, ImportInvalid, ImportInvalid, ImportInvalid,
+	}, statuses)
+	assert.Equal(t, ImportOut{Created: 2, Conflicts: 1, Skipped: 1, Failed: 3, Rows: out.Rows}, out)
+	assert.Equal(t, "new", out.Rows[0].ID)
+	assert.NotEqual(t, "taken", out.Rows[1].ID)
+	assert.Equal(t, "exists", out.Rows[2].ID)
+	assert.Equal(t, "created_at must be an RFC 3339 timestamp", out.Rows[5].Error)
+
+	record, err := repo.RetrieveByShortURL(request.Context(), "new")
+	require.NoError(t, err)
+	assert.Equal(t, userID, record.UserID)
+}
+
+func TestTransferHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		maxItems    int
+		wantStatus  int
+	}{
+		{
+			name:       "Export unknown format",
+			method:     http.MethodGet,
+			target:     "/api/user/urls/export?format=xml",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Import unknown content type",
+			method:      http.MethodPost,
+			target:      "/api/user/urls/import",
+			contentType: "application/xml",
+			body:        "<urls/>",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Import malformed JSON",
+			method:      http.MethodPost,
+			target:      "/api/user/urls/import",
+			contentType: "application/json",
+			body:        `[{"original_url": `,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:       "Import CSV without original_url column",
+			method:     http.MethodPost,
+			target:     "/api/user/urls/import?format=csv",
+			body:       "id,url\n1,https://example.com\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Import too many rows",
+			method:      http.MethodPost,
+			target:      "/api/user/urls/import",
+			contentType: "application/x-ndjson",
+			body:        "{\"original_url\": \"https://a.ru\"}\n{\"original_url\": \"https://b.ru\"}\n",
+			maxItems:    1,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTransferHandler(&simple_storage.SimpleRepository{}, nil).WithMaxItems(tt.maxItems)
+			handlers := map[string]http.HandlerFunc{
+				http.MethodGet:  handler.Export,
+				http.MethodPost: handler.Import,
+			}
+
+			request := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handlers[tt.method](w, request)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+// pagedTransferer отдаёт первую страницу сразу, а вторую - только после закрытия release.
+type pagedTransferer struct {
+	URLTransferer
+	pages    [][]models.Record
+	release  chan struct{}
+	released atomic.Bool
+}
+
+// SearchUserURLs возвращает страницы по порядку, задерживая все страницы после первой.
+func (p *pagedTransferer) SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) ([]models.Record, *models.Cursor, error) {
+	i := 0
+	if query.After != nil {
+		i, _ = strconv.Atoi(query.After.ShortURL)
+		select {
+		case <-p.release:
+			p.released.Store(true)
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	var next *models.Cursor
+	if i+1 < len(p.pages) {
+		next = &models.Cursor{ShortURL: strconv.Itoa(i + 1)}
+	}
+	return p.pages[i], next, nil
+}
+
+// TestTransferHandler_ExportStreams проверяет, что за цепочкой middleware роутера
+// каждая страница экспорта отправляется клиенту до чтения следующей.
+func TestTransferHandler_ExportStreams(t *testing.T) {
+	userID := uuid.New()
+	authCookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		format   string
+		encoding string
+	}{
+		{format: formatNDJSON, encoding: "identity"},
+		{format: formatJSON, encoding: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			repo := &pagedTransferer{
+				pages: [][]models.Record{
+					{{ShortURL: "first", OriginalURL: "https://example.com/1", UserID: userID}},
+					{{ShortURL: "last", OriginalURL: "https://example.com/2", UserID: userID}},
+				},
+				release: make(chan struct{}),
+			}
+			var once sync.Once
+			release := func() { once.Do(func() { close(repo.release) }) }
+			// не даём тесту зависнуть, если первая страница так и не дойдёт до клиента
+			timer := time.AfterFunc(5*time.Second, release)
+			defer timer.Stop()
+
+			handler := NewTransferHandler(repo, nil)
+			chain := tracing.WithTracing(logging.WithLogging(metrics.WithMetrics(compress.WithCompression(http.HandlerFunc(handler.Export)))))
+			srv := httptest.NewServer(chain)
+			defer srv.Close()
+
+			request, err := http.NewRequest(http.MethodGet, srv.URL+"/api/user/urls/export?format="+tt.format, nil)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+			request.Header.Set("Accept-Encoding", tt.encoding)
+			resp, err := http.DefaultClient.Do(request)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("Error closing response body: %v", err)
+				}
+			}()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			body := io.Reader(resp.Body)
+			if tt.encoding == "gzip" {
+				require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+				zr, err := gzip.NewReader(resp.Body)
+				require.NoError(t, err)
+				body = zr
+			}
+			reader := bufio.NewReader(body)
+
+			chunk, err := reader.ReadString('}')
+			require.NoError(t, err)
+			assert.Contains(t, chunk, `"id":"first"`)
+			assert.False(t, repo.released.Load(), "first page must reach the client before the last page is read")
+
+			release()
+			rest, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Contains(t, string(rest), `"id":"last"`)
+		})
+	}
+}
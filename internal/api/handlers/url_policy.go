@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+)
+
+// checkPolicy проверяет URL политикой допустимых ссылок.
+// При отказе записывает в ответ 400 Bad Request с кодом invalid_url и причиной,
+// при ошибке проверки - 503 Service Unavailable с кодом unavailable.
+// Возвращает false, если обработку запроса нужно прекратить.
+// Если checker равен nil, проверка не выполняется.
+func checkPolicy(res http.ResponseWriter, req *http.Request, checker policy.Checker, u *url.URL) bool {
+	if checker == nil {
+		return true
+	}
+
+	err := checker.Check(req.Context(), u)
+	if err == nil {
+		return true
+	}
+
+	if policy.IsRejected(err) {
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidURL, err.Error(), err))
+		return false
+	}
+
+	apierror.Write(res, req, apierror.Wrap(apierror.CodeUnavailable, "Can't check URL", fmt.Errorf("URL policy check: %w", err)))
+	return false
+}
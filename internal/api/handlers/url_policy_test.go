@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/iubondar/url-shortener/internal/app/policy"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+)
+
+// privateAddressChecker - policy.PrivateAddressChecker, разрешающий любые имена хостов в публичный адрес,
+// чтобы тесты не зависели от DNS.
+var privateAddressChecker = policy.NewPrivateAddressChecker(policy.ResolverFunc(
+	func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.IPv4(93, 184, 215, 14)}}, nil
+	}))
+
+func TestURLPolicy(t *testing.T) {
+	checker := policy.Chain(policy.SchemeChecker, privateAddressChecker)
+	failing := policy.CheckerFunc(func(ctx context.Context, u *url.URL) error {
+		return errors.New("policy service is down")
+	})
+
+	tests := []struct {
+		name     string
+		handler  func(repo *simple_storage.SimpleRepository, checker policy.Checker) http.HandlerFunc
+		body     string
+		checker  policy.Checker
+		wantCode int
+	}{
+		{
+			name: "CreateID allowed",
+			handler: func(repo *simple_storage.SimpleRepository, checker policy.Checker) http.HandlerFunc {
+				return NewCreateIDHandler(repo, "127.0.0.1", checker).CreateID
+			},
+			body:     "https://practicum.yandex.ru/",
+			checker:  checker,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name: "CreateID rejects loopback",
+			handler: func(repo *simple_storage.SimpleRepository, checker policy.Checker) http.HandlerFunc {
+				return NewCreateIDHandler(repo, "127.0.0.1", checker).CreateID
+			},
+			body:     "http://127.0.0.1:8080/admin",
+			checker:  checker,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "CreateID checker failure",
+			handler: func(repo *simple_storage.SimpleRepository, checker policy.Checker) http.HandlerFunc {
+				return NewCreateIDHandler(repo, "127.0.0.1", checker).CreateID
+			},
+			body:     "https://practicum.yandex.ru/",
+			checker:  failing,
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name: "Shorten rejects scheme",
+			handler: func(repo *simple_storage.SimpleRepository, checker policy.Checker) http.HandlerFunc {
+				return NewShortenHandler(repo, "127.0.0.1", checker).Shorten
+			},
+			body:     `{"url": "ftp://example.com/file"}`,
+			checker:  checker,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "ShortenBatch rejects whole batch",
+			handler: func(repo *simple_storage.SimpleRepository, checker policy.Checker) http.HandlerFunc {
+				return NewShortenBatchHandler(repo, "127.0.0.1", checker).ShortenBatch
+			},
+			body: `[{"correlation_id": "1", "original_url": "https://example.com"},
+				{"correlation_id": "2", "original_url": "http://localhost/"}]`,
+			checker:  checker,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := simple_storage.NewSimpleRepository()
+			request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+
+			tt.handler(repo, tt.checker)(w, request)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusCreated {
+				assert.Empty(t, repo.Records, "rejected URLs must not be saved")
+			}
+		})
+	}
+}
@@ -5,16 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
 	"github.com/iubondar/url-shortener/internal/app/auth"
 	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"go.uber.org/zap"
 )
 
-// UserURLRepository представляет интерфейс для работы с репозиторием URL.
+// maxUserURLsLimit ограничивает размер страницы списка URL пользователя.
+const maxUserURLsLimit = 1000
+
+// UserURLsRetriever представляет интерфейс для постраничного получения URL пользователя.
 type UserURLsRetriever interface {
-	RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error)
+	SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error)
 }
 
 // UserUrlsHandler обрабатывает запросы на получение списка сокращенных URL пользователя.
@@ -42,21 +51,38 @@ type UserUrlsOut struct {
 // RetrieveUserURLs обрабатывает HTTP GET запрос для получения списка сокращенных URL пользователя.
 // Возвращает список сокращенных URL в формате JSON.
 // Возвращает статус 200 OK если есть URL, 204 No Content если список пуст.
+//
+// Параметры запроса:
+//   - limit - размер страницы от 1 до 1000, без него возвращаются все ссылки
+//   - cursor - курсор следующей страницы из предыдущего ответа
+//   - q - подстрока оригинального URL
+//   - deleted - include (по умолчанию), exclude или only
+//   - created_after, created_before - границы времени создания в формате RFC 3339
+//   - sort - created_at (по умолчанию), original_url; префикс "-" задаёт обратный порядок
+//
+// Если есть следующая страница, её курсор передаётся в заголовке X-Next-Cursor,
+// а адрес - в заголовке Link с rel="next". Некорректные параметры отклоняются с 400 Bad Request.
 func (handler UserUrlsHandler) RetrieveUserURLs(res http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
-		http.Error(res, "Only GET requests are allowed!", http.StatusMethodNotAllowed)
+		apierror.Write(res, req, apierror.New(apierror.CodeMethodNotAllowed, "Only GET requests are allowed!"))
+		return
+	}
+
+	query, err := parseURLQuery(req.URL.Query())
+	if err != nil {
+		apierror.Write(res, req, err)
 		return
 	}
 
 	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
 	if err != nil {
-		http.Error(res, "Error setting userID "+err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
 		return
 	}
 
-	records, err := handler.retriever.RetrieveUserURLs(req.Context(), userID)
+	records, next, err := handler.retriever.SearchUserURLs(req.Context(), userID, query)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("retrieve user URLs: %w", err)))
 		return
 	}
 
@@ -71,10 +97,18 @@ func (handler UserUrlsHandler) RetrieveUserURLs(res http.ResponseWriter, req *ht
 	}
 	resp, err := json.Marshal(out)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		apierror.Write(res, req, apierror.Internal(err))
 		return
 	}
 
+	if next != nil {
+		cursor := next.Encode()
+		params := req.URL.Query()
+		params.Set("cursor", cursor)
+		res.Header().Set("X-Next-Cursor", cursor)
+		res.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, params.Encode()))
+	}
+
 	res.Header().Set("Content-Type", "application/json")
 	if len(out) == 0 {
 		res.WriteHeader(http.StatusNoContent)
@@ -83,7 +117,57 @@ func (handler UserUrlsHandler) RetrieveUserURLs(res http.ResponseWriter, req *ht
 	}
 
 	if _, err := res.Write(resp); err != nil {
-		http.Error(res, "Error writing response", http.StatusInternalServerError)
-		return
+		logctx.FromContext(req.Context()).Debug("error writing response", zap.Error(err))
+	}
+}
+
+// parseURLQuery разбирает параметры выборки ссылок пользователя из строки запроса.
+// Возвращает ошибку API invalid_request, если параметр задан некорректно.
+func parseURLQuery(params url.Values) (query models.URLQuery, err error) {
+	query.Contains = params.Get("q")
+
+	if limit := params.Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > maxUserURLsLimit {
+			return query, apierror.New(apierror.CodeInvalidRequest, fmt.Sprintf("limit must be a number from 1 to %d", maxUserURLsLimit))
+		}
 	}
+
+	switch deleted := models.DeletedFilter(params.Get("deleted")); deleted {
+	case "", models.DeletedInclude, models.DeletedExclude, models.DeletedOnly:
+		query.Deleted = deleted
+	default:
+		return query, apierror.New(apierror.CodeInvalidRequest, "deleted must be one of include, exclude, only")
+	}
+
+	for name, bound := range map[string]*time.Time{"created_after": &query.CreatedFrom, "created_before": &query.CreatedTo} {
+		if value := params.Get(name); value != "" {
+			*bound, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, apierror.Wrap(apierror.CodeInvalidRequest, name+" must be an RFC 3339 timestamp", err)
+			}
+		}
+	}
+
+	sort := params.Get("sort")
+	query.Desc = strings.HasPrefix(sort, "-")
+	switch field := models.URLSort(strings.TrimPrefix(sort, "-")); field {
+	case "", models.SortCreatedAt, models.SortOriginalURL:
+		query.Sort = field
+	default:
+		return query, apierror.New(apierror.CodeInvalidRequest, "sort must be one of created_at, original_url with optional - prefix")
+	}
+
+	if cursor := params.Get("cursor"); cursor != "" {
+		after, err := models.DecodeCursor(cursor)
+		if err != nil {
+			return query, apierror.Wrap(apierror.CodeInvalidRequest, "cursor is not valid", err)
+		}
+		if after.Sort != query.SortField() || after.Desc != query.Desc {
+			return query, apierror.New(apierror.CodeInvalidRequest, "cursor was issued for another sort order")
+		}
+		query.After = &after
+	}
+
+	return query, nil
 }
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/iubondar/url-shortener/internal/app/auth"
@@ -191,3 +193,72 @@ func TestUserUrlsHandler_RetrieveUserURLs(t *testing.T) {
 		})
 	}
 }
+
+func TestUserUrlsHandler_Pagination(t *testing.T) {
+	userID := uuid.New()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &simple_storage.SimpleRepository{}
+	for i := range 5 {
+		repo.Records = append(repo.Records, models.Record{
+			ShortURL:    fmt.Sprintf("id%d", i),
+			OriginalURL: fmt.Sprintf("http://example.com/%d", i),
+			UserID:      userID,
+			CreatedAt:   created.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	handler := NewUserUrlsHandler(repo, "http://127.0.0.1")
+	authCookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+
+	// идём по ссылкам из заголовка Link, пока есть следующая страница
+	var got []string
+	target := "/api/user/urls?limit=2&sort=-created_at"
+	for pages := 0; target != ""; pages++ {
+		require.Less(t, pages, 3)
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		request.AddCookie(authCookie)
+		w := httptest.NewRecorder()
+		handler.RetrieveUserURLs(w, request)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var out []UserUrlsOut
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		for _, o := range out {
+			got = append(got, o.OriginalURL)
+		}
+
+		target = ""
+		if link := w.Header().Get("Link"); link != "" {
+			assert.NotEmpty(t, w.Header().Get("X-Next-Cursor"))
+			require.True(t, strings.HasSuffix(link, `>; rel="next"`))
+			target = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+	want := []string{
+		"http://example.com/4", "http://example.com/3", "http://example.com/2",
+		"http://example.com/1", "http://example.com/0",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestUserUrlsHandler_InvalidQuery(t *testing.T) {
+	cursor := models.Cursor{Sort: models.SortOriginalURL, ShortURL: "id0"}.Encode()
+	tests := []string{
+		"limit=0",
+		"limit=1001",
+		"limit=abc",
+		"deleted=maybe",
+		"created_after=yesterday",
+		"sort=title",
+		"cursor=garbage",
+		"cursor=" + cursor + "&sort=created_at",
+	}
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/user/urls?"+query, nil)
+			w := httptest.NewRecorder()
+			NewUserUrlsHandler(simple_storage.NewSimpleRepository(), "http://127.0.0.1").RetrieveUserURLs(w, request)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
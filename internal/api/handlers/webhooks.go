@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+)
+
+// maxWebhooksPerUser ограничивает количество вебхуков одного пользователя.
+const maxWebhooksPerUser = 10
+
+// WebhookStore определяет интерфейс хранилища вебхуков пользователей.
+type WebhookStore interface {
+	// CreateWebhook регистрирует вебхук пользователя.
+	CreateWebhook(ctx context.Context, webhook models.Webhook) error
+	// ListWebhooks возвращает вебхуки пользователя в порядке регистрации.
+	ListWebhooks(ctx context.Context, userID uuid.UUID) (webhooks []models.Webhook, err error)
+	// DeleteWebhook удаляет вебхук пользователя вместе с его недоставленными событиями.
+	// Возвращает ErrorNotFound, если у пользователя нет такого вебхука.
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, id string) error
+}
+
+// WebhookIn представляет входные данные для регистрации вебхука.
+type WebhookIn struct {
+	URL string `json:"url"` // адрес получателя событий
+}
+
+// WebhookOut представляет вебхук в ответе API.
+// Ключ подписи возвращается только при регистрации.
+type WebhookOut struct {
+	ID        string    `json:"id"`               // идентификатор вебхука
+	URL       string    `json:"url"`              // адрес получателя событий
+	CreatedAt time.Time `json:"created_at"`       // время регистрации
+	Secret    string    `json:"secret,omitempty"` // ключ подписи HMAC-SHA256 доставок
+}
+
+// WebhooksHandler обрабатывает запросы к вебхукам пользователя.
+// На вебхуки доставляются события создания, удаления ссылок пользователя и переходов по ним.
+type WebhooksHandler struct {
+	store   WebhookStore   // хранилище вебхуков
+	checker policy.Checker // политика допустимых адресов получателей
+}
+
+// NewWebhooksHandler создает новый экземпляр WebhooksHandler.
+// Принимает хранилище вебхуков и политику допустимых адресов получателей (nil - без проверки).
+func NewWebhooksHandler(store WebhookStore, checker policy.Checker) WebhooksHandler {
+	return WebhooksHandler{
+		store:   store,
+		checker: checker,
+	}
+}
+
+// CreateWebhook обрабатывает HTTP POST запрос регистрации вебхука.
+// Принимает адрес получателя в формате JSON. Возвращает статус 201 Created, вебхук
+// с ключом подписи, который больше не выдаётся, и адрес вебхука в заголовке Location.
+// Возвращает 400 Bad Request для некорректного или запрещённого адреса
+// и 409 Conflict, если у пользователя уже maxWebhooksPerUser вебхуков.
+func (handler WebhooksHandler) CreateWebhook(res http.ResponseWriter, req *http.Request) {
+	var in WebhookIn
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		readBodyError(res, req, err)
+		return
+	}
+
+	u, err := url.ParseRequestURI(in.URL)
+	if err != nil {
+		apierror.Write(res, req, apierror.Wrap(apierror.CodeInvalidURL, "URL is not valid", err))
+		return
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidURL, "webhook URL must be an absolute http or https URL"))
+		return
+	}
+	if !checkPolicy(res, req, handler.checker, u) {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return
+	}
+
+	webhooks, err := handler.store.ListWebhooks(req.Context(), userID)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("list webhooks: %w", err)))
+		return
+	}
+	if len(webhooks) >= maxWebhooksPerUser {
+		apierror.Write(res, req, apierror.New(apierror.CodeConflict,
+			fmt.Sprintf("at most %d webhooks can be registered", maxWebhooksPerUser)))
+		return
+	}
+
+	webhook, err := models.NewWebhook(userID, u.String(), time.Now().UTC())
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("create webhook: %w", err)))
+		return
+	}
+	if err := handler.store.CreateWebhook(req.Context(), webhook); err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("save webhook: %w", err)))
+		return
+	}
+
+	out := newWebhookOut(webhook)
+	out.Secret = webhook.Secret
+	res.Header().Set("Location", "/api/user/webhooks/"+webhook.ID)
+	writeJSON(res, req, http.StatusCreated, out)
+}
+
+// ListWebhooks обрабатывает HTTP GET запрос списка вебхуков пользователя.
+// Возвращает статус 200 OK и вебхуки в порядке регистрации без ключей подписи.
+func (handler WebhooksHandler) ListWebhooks(res http.ResponseWriter, req *http.Request) {
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return
+	}
+
+	webhooks, err := handler.store.ListWebhooks(req.Context(), userID)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("list webhooks: %w", err)))
+		return
+	}
+
+	out := make([]WebhookOut, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		out = append(out, newWebhookOut(webhook))
+	}
+	writeJSON(res, req, http.StatusOK, out)
+}
+
+// DeleteWebhook обрабатывает HTTP DELETE запрос удаления вебхука.
+// Недоставленные события вебхука удаляются вместе с ним.
+// Возвращает статус 204 No Content или 404 Not Found для чужого или отсутствующего вебхука.
+func (handler WebhooksHandler) DeleteWebhook(res http.ResponseWriter, req *http.Request) {
+	id := chi.URLParam(req, "id")
+	if len(id) == 0 {
+		apierror.Write(res, req, apierror.New(apierror.CodeInvalidRequest, "Can't find id parameter in query path"))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromAuthCookieOrSetNew(res, req)
+	if err != nil {
+		apierror.Write(res, req, apierror.Internal(fmt.Errorf("set user ID: %w", err)))
+		return
+	}
+
+	if err := handler.store.DeleteWebhook(req.Context(), userID, id); err != nil {
+		apierror.Write(res, req, err)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// newWebhookOut преобразует вебхук хранилища в ответ API без ключа подписи.
+func newWebhookOut(webhook models.Webhook) WebhookOut {
+	return WebhookOut{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleWebhooksHandler_ListWebhooks демонстрирует получение списка вебхуков пользователя.
+func ExampleWebhooksHandler_ListWebhooks() {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Webhooks: []models.Webhook{{
+			ID:        "0b6c8a1e-1f6d-4a4e-9c1e-3d2b1f0e9a7c",
+			UserID:    userID,
+			URL:       "https://example.com/hook",
+			Secret:    "secret",
+			CreatedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		}},
+	}
+	handler := NewWebhooksHandler(repo, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/user/webhooks", nil)
+	authCookie, _ := auth.NewAuthCookie(userID)
+	request.AddCookie(authCookie)
+	w := httptest.NewRecorder()
+
+	handler.ListWebhooks(w, request)
+
+	fmt.Println(w.Code)
+	fmt.Println(w.Body.String())
+	// Output:
+	// 200
+	// [{"id":"0b6c8a1e-1f6d-4a4e-9c1e-3d2b1f0e9a7c","url":"https://example.com/hook","created_at":"2026-10-18T12:00:00Z"}]
+}
+
+func TestWebhooksHandler_CreateWebhook(t *testing.T) {
+	userID := uuid.New()
+	full := make([]models.Webhook, maxWebhooksPerUser)
+	for i := range full {
+		full[i] = models.Webhook{ID: uuid.NewString(), UserID: userID, URL: "https://example.com/hook"}
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		webhooks []models.Webhook
+		wantCode int
+	}{
+		{
+			name:     "Valid URL",
+			body:     `{"url": "https://example.com/hook"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Invalid JSON",
+			body:     `{"url": `,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Relative URL",
+			body:     `{"url": "/hook"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Unsupported scheme",
+			body:     `{"url": "ftp://example.com/hook"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Private address",
+			body:     `{"url": "http://127.0.0.1:8080/hook"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Too many webhooks",
+			body:     `{"url": "https://example.com/hook"}`,
+			webhooks: full,
+			wantCode: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &simple_storage.SimpleRepository{Webhooks: append([]models.Webhook(nil), tt.webhooks...)}
+			handler := NewWebhooksHandler(repo, privateAddressChecker)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/webhooks", strings.NewReader(tt.body))
+			authCookie, err := auth.NewAuthCookie(userID)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+			w := httptest.NewRecorder()
+
+			handler.CreateWebhook(w, request)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusCreated {
+				assert.Len(t, repo.Webhooks, len(tt.webhooks))
+				return
+			}
+
+			var out WebhookOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+			require.Len(t, repo.Webhooks, 1)
+			stored := repo.Webhooks[0]
+			assert.Equal(t, userID, stored.UserID)
+			assert.Equal(t, stored.ID, out.ID)
+			assert.Equal(t, "https://example.com/hook", out.URL)
+			assert.Equal(t, stored.Secret, out.Secret)
+			assert.NotEmpty(t, out.Secret)
+			assert.Equal(t, "/api/user/webhooks/"+out.ID, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestWebhooksHandler_ListWebhooks(t *testing.T) {
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Webhooks: []models.Webhook{
+			{ID: "1", UserID: userID, URL: "https://example.com/a", Secret: "secret"},
+			{ID: "2", UserID: uuid.New(), URL: "https://example.com/b", Secret: "secret"},
+		},
+	}
+	handler := NewWebhooksHandler(repo, nil)
+
+	for _, tt := range []struct {
+		name    string
+		userID  uuid.UUID
+		wantIDs []string
+	}{
+		{name: "Own webhooks", userID: userID, wantIDs: []string{"1"}},
+		{name: "No webhooks", userID: uuid.New(), wantIDs: []string{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/user/webhooks", nil)
+			authCookie, err := auth.NewAuthCookie(tt.userID)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+			w := httptest.NewRecorder()
+
+			handler.ListWebhooks(w, request)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var out []WebhookOut
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+			ids := make([]string, 0, len(out))
+			for _, webhook := range out {
+				ids = append(ids, webhook.ID)
+				// ключ подписи выдаётся только при регистрации
+				assert.Empty(t, webhook.Secret)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestWebhooksHandler_DeleteWebhook(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name     string
+		id       string
+		userID   uuid.UUID
+		wantCode int
+		wantLeft int
+	}{
+		{name: "Own webhook", id: "1", userID: userID, wantCode: http.StatusNoContent, wantLeft: 0},
+		{name: "Other user's webhook", id: "1", userID: uuid.New(), wantCode: http.StatusNotFound, wantLeft: 1},
+		{name: "Unknown webhook", id: "2", userID: userID, wantCode: http.StatusNotFound, wantLeft: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &simple_storage.SimpleRepository{
+				Webhooks: []models.Webhook{{ID: "1", UserID: userID, URL: "https://example.com/hook"}},
+				Outbox:   []models.Delivery{{ID: "d1", WebhookID: "1"}},
+			}
+			handler := NewWebhooksHandler(repo, nil)
+
+			request := withURLParam(httptest.NewRequest(http.MethodDelete, "/api/user/webhooks/"+tt.id, nil), "id", tt.id)
+			authCookie, err := auth.NewAuthCookie(tt.userID)
+			require.NoError(t, err)
+			request.AddCookie(authCookie)
+			w := httptest.NewRecorder()
+
+			handler.DeleteWebhook(w, request)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Len(t, repo.Webhooks, tt.wantLeft)
+			assert.Len(t, repo.Outbox, tt.wantLeft)
+		})
+	}
+}
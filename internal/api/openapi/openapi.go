@@ -0,0 +1,104 @@
+// Пакет openapi содержит описание HTTP API сервиса в формате OpenAPI 3
+// и middleware, проверяющий JSON-тела запросов по схемам из этого описания.
+//
+// Документ встроен в бинарный файл и отдаётся по адресу /api/openapi.json.
+// Проверка поддерживает подмножество JSON Schema, используемое в документе:
+// типы, nullable, обязательные поля, вложенные объекты и массивы, minLength, enum
+// и форматы uri и date-time.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// document - описание API в формате OpenAPI 3.
+//
+//go:embed openapi.json
+var document []byte
+
+// schemaRefPrefix - префикс ссылок на схемы из раздела components.
+const schemaRefPrefix = "#/components/schemas/"
+
+// Schema описывает JSON Schema значения в подмножестве, поддерживаемом проверкой.
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`       // ссылка на схему из components
+	Type       string             `json:"type,omitempty"`       // тип значения
+	Format     string             `json:"format,omitempty"`     // формат строки
+	Nullable   bool               `json:"nullable,omitempty"`   // допускается null
+	Required   []string           `json:"required,omitempty"`   // обязательные поля объекта
+	Properties map[string]*Schema `json:"properties,omitempty"` // поля объекта
+	Items      *Schema            `json:"items,omitempty"`      // схема элементов массива
+	MinLength  int                `json:"minLength,omitempty"`  // минимальная длина строки
+	Enum       []string           `json:"enum,omitempty"`       // допустимые значения строки
+	AllOf      []*Schema          `json:"allOf,omitempty"`      // схемы, которым значение должно соответствовать одновременно
+}
+
+// MediaType описывает содержимое тела запроса или ответа.
+type MediaType struct {
+	Schema *Schema `json:"schema"` // схема содержимого
+}
+
+// RequestBody описывает тело запроса операции.
+type RequestBody struct {
+	Required bool                 `json:"required"` // тело обязательно
+	Content  map[string]MediaType `json:"content"`  // содержимое по типам
+}
+
+// Operation описывает операцию API: метод и маршрут.
+type Operation struct {
+	OperationID string       `json:"operationId"`           // идентификатор операции
+	RequestBody *RequestBody `json:"requestBody,omitempty"` // тело запроса
+}
+
+// Spec представляет разобранный документ OpenAPI.
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"` // операции по маршрутам и методам
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"` // именованные схемы
+	} `json:"components"`
+
+	raw []byte // исходный документ
+}
+
+// Load разбирает встроенный документ OpenAPI.
+func Load() (*Spec, error) {
+	spec := &Spec{raw: document}
+	if err := json.Unmarshal(document, spec); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	return spec, nil
+}
+
+// ServeHTTP отдаёт документ OpenAPI.
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(s.raw)
+}
+
+// Operation возвращает операцию для метода и шаблона маршрута chi
+// или nil, если операция не описана.
+func (s *Spec) Operation(method, pattern string) *Operation {
+	return s.Paths[pattern][strings.ToLower(method)]
+}
+
+// Resolve возвращает схему, на которую ссылается schema, или саму schema, если это не ссылка.
+// Возвращает nil для ссылки на неизвестную схему.
+func (s *Spec) Resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.Components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)]
+	}
+	return schema
+}
+
+// jsonSchema возвращает схему JSON-тела запроса операции или nil, если тело не JSON.
+func (o *Operation) jsonSchema() *Schema {
+	if o == nil || o.RequestBody == nil {
+		return nil
+	}
+	return o.RequestBody.Content["application/json"].Schema
+}
@@ -0,0 +1,228 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleSpec_Validate демонстрирует ответ на тело запроса, не соответствующее схеме.
+func ExampleSpec_Validate() {
+	spec, err := Load()
+	if err != nil {
+		panic(err)
+	}
+
+	r := chi.NewRouter()
+	r.With(spec.Validate).Post("/api/shorten", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(`{"url": 42}`)))
+
+	var problem ValidationProblem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		panic(err)
+	}
+	fmt.Println(w.Code, problem.Code)
+	fmt.Println(problem.Errors[0].Field, problem.Errors[0].Message)
+	// Output:
+	// 400 invalid_request
+	// url must be a string
+}
+
+func TestLoad(t *testing.T) {
+	spec, err := Load()
+	require.NoError(t, err)
+
+	// все ссылки на схемы должны разрешаться
+	var check func(name string, schema *Schema)
+	check = func(name string, schema *Schema) {
+		if schema == nil {
+			return
+		}
+		require.NotNil(t, spec.Resolve(schema), "%s: unresolved reference %q", name, schema.Ref)
+		for _, sub := range schema.AllOf {
+			check(name, sub)
+		}
+		for field, property := range schema.Properties {
+			check(name+"."+field, property)
+		}
+		check(name+"[]", schema.Items)
+	}
+	for name, schema := range spec.Components.Schemas {
+		check(name, schema)
+	}
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			check(method+" "+path, operation.jsonSchema())
+		}
+	}
+}
+
+func TestSpec_ValidateValue(t *testing.T) {
+	spec, err := Load()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		schema string
+		body   string
+		want   []FieldError
+	}{
+		{
+			name:   "Valid ShortenIn",
+			schema: "ShortenIn",
+			body:   `{"url": "https://example.com"}`,
+		},
+		{
+			name:   "Missing url",
+			schema: "ShortenIn",
+			body:   `{}`,
+			want:   []FieldError{{Field: "url", Message: "is required"}},
+		},
+		{
+			name:   "Invalid url",
+			schema: "ShortenIn",
+			body:   `{"url": "not a url"}`,
+			want:   []FieldError{{Field: "url", Message: "must be a valid URL"}},
+		},
+		{
+			name:   "Body is not an object",
+			schema: "ShortenIn",
+			body:   `["https://example.com"]`,
+			want:   []FieldError{{Message: "must be an object"}},
+		},
+		{
+			name:   "Empty reason",
+			schema: "DisableIn",
+			body:   `{"reason": "", "legal": "yes"}`,
+			want: []FieldError{
+				{Field: "legal", Message: "must be a boolean"},
+				{Field: "reason", Message: "must not be empty"},
+			},
+		},
+		{
+			name:   "Null value",
+			schema: "ShortenBatchIn",
+			body:   `{"correlation_id": null, "original_url": "https://example.com"}`,
+			want:   []FieldError{{Field: "correlation_id", Message: "must be a string"}},
+		},
+		{
+			name:   "Integer",
+			schema: "TooLargeProblem",
+			body:   `{"type": "about:blank", "title": "t", "status": 413.5, "code": "too_large", "limit": 10}`,
+			want:   []FieldError{{Field: "status", Message: "must be an integer"}},
+		},
+		{
+			name:   "Enum",
+			schema: "Problem",
+			body:   `{"type": "about:blank", "title": "t", "status": 400, "code": "oops"}`,
+			want:   []FieldError{{Field: "code", Message: "must be one of [invalid_request invalid_url unauthorized not_found method_not_allowed conflict gone too_large unsupported_media_type rate_limited blocked internal unavailable]"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(tt.body))
+			decoder.UseNumber()
+			var value any
+			require.NoError(t, decoder.Decode(&value))
+
+			assert.Equal(t, tt.want, spec.ValidateValue(spec.Components.Schemas[tt.schema], value))
+		})
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	spec, err := Load()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantErrors []FieldError
+	}{
+		{
+			name:       "Valid batch",
+			method:     http.MethodPost,
+			path:       "/api/shorten/batch",
+			body:       `[{"correlation_id": "1", "original_url": "https://example.com"}]`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid batch item",
+			method:     http.MethodPost,
+			path:       "/api/shorten/batch",
+			body:       `[{"correlation_id": "1", "original_url": "https://example.com"}, {"correlation_id": "2"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []FieldError{{Field: "[1].original_url", Message: "is required"}},
+		},
+		{
+			name:       "Invalid JSON",
+			method:     http.MethodPost,
+			path:       "/api/shorten",
+			body:       `{"url": `,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Operation without JSON body",
+			method:     http.MethodPost,
+			path:       "/",
+			body:       `https://example.com`,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				received = string(body)
+			}
+			r := chi.NewRouter()
+			r.With(spec.Validate).Method(tt.method, tt.path, http.HandlerFunc(handler))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				// обработчик получает исходное тело запроса
+				assert.Equal(t, tt.body, received)
+				return
+			}
+
+			assert.Equal(t, apierror.ContentType, w.Header().Get("Content-Type"))
+			var problem ValidationProblem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, apierror.CodeInvalidRequest, problem.Code)
+			assert.Equal(t, tt.wantErrors, problem.Errors)
+		})
+	}
+}
+
+func TestSpec_ServeHTTP(t *testing.T) {
+	spec, err := Load()
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	spec.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(document), w.Body.String())
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/limits"
+)
+
+// FieldError описывает несоответствие поля тела запроса схеме.
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // путь к полю, например [0].original_url; пустой для тела целиком
+	Message string `json:"message"`         // описание ошибки
+}
+
+// ValidationProblem представляет тело ответа 400 Bad Request
+// с ошибками проверки тела запроса по полям.
+type ValidationProblem struct {
+	apierror.Problem
+	Errors []FieldError `json:"errors"` // ошибки по полям
+}
+
+// Validate проверяет JSON-тело запроса по схеме операции, найденной по методу и шаблону маршрута chi.
+// Должен подключаться к маршрутам через chi.Router.With, чтобы шаблон маршрута был уже известен:
+// middleware вложенного маршрутизатора выполняются до выбора маршрута.
+//
+// Некорректный JSON отклоняется с кодом invalid_request, несоответствие схеме -
+// ответом ValidationProblem с ошибками по полям. Запросы к операциям без JSON-тела
+// передаются дальше без проверки.
+func (s *Spec) Validate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var schema *Schema
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			schema = s.Operation(r.Method, rctx.RoutePattern()).jsonSchema()
+		}
+		if schema == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			if limit, ok := limits.TooLarge(err); ok {
+				limits.WriteTooLarge(w, r, "request body is too large", limit)
+				return
+			}
+			apierror.Write(w, r, apierror.Wrap(apierror.CodeInvalidRequest, "request body is not valid", err))
+			return
+		}
+		// возвращаем тело для следующих обработчиков
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			apierror.Write(w, r, apierror.Wrap(apierror.CodeInvalidRequest, "request body is not valid JSON", err))
+			return
+		}
+
+		if errs := s.ValidateValue(schema, value); len(errs) > 0 {
+			problem := ValidationProblem{
+				Problem: apierror.NewProblem(r, apierror.New(apierror.CodeInvalidRequest, "request body does not match schema")),
+				Errors:  errs,
+			}
+			apierror.WriteProblem(w, problem.Status, problem)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ValidateValue проверяет значение, разобранное из JSON с json.Decoder.UseNumber, по схеме.
+// Возвращает ошибки по полям или nil, если значение соответствует схеме.
+func (s *Spec) ValidateValue(schema *Schema, value any) []FieldError {
+	var errs []FieldError
+	s.validate(schema, value, "", &errs)
+	return errs
+}
+
+// validate проверяет значение по схеме и добавляет найденные ошибки в errs.
+func (s *Spec) validate(schema *Schema, value any, path string, errs *[]FieldError) {
+	schema = s.Resolve(schema)
+	if schema == nil {
+		return
+	}
+	for _, sub := range schema.AllOf {
+		s.validate(sub, value, path, errs)
+	}
+
+	if value == nil && schema.Nullable {
+		return
+	}
+
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				*errs = append(*errs, FieldError{Field: fieldPath(path, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if v, ok := object[name]; ok {
+				s.validate(schema.Properties[name], v, fieldPath(path, name), errs)
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		for i, v := range array {
+			s.validate(schema.Items, v, path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if len(str) < schema.MinLength {
+			if schema.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters long", schema.MinLength)
+			}
+			return
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, str) {
+			fail("must be one of %v", schema.Enum)
+			return
+		}
+		switch schema.Format {
+		case "uri":
+			if _, err := url.ParseRequestURI(str); err != nil {
+				fail("must be a valid URL")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("must be a date-time in RFC 3339 format")
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			fail("must be an integer")
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			fail("must be a number")
+		}
+	}
+}
+
+// fieldPath добавляет имя поля к пути.
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+)
+
+// defaultAdminActor - имя модератора для токена, заданного без имени.
+const defaultAdminActor = "admin"
+
+// adminActorKey - ключ контекста, под которым хранится имя модератора.
+type adminActorKey struct{}
+
+// AdminCredential связывает токен модератора с его именем в журнале аудита.
+type AdminCredential struct {
+	Actor string // имя модератора
+	Token string // токен доступа
+}
+
+// ParseAdminTokens разбирает список токенов модераторов вида "alice=token1,bob=token2".
+// Имя отделяется от токена первым знаком "=", за которым следует токен: в bearer-токене (RFC 6750)
+// "=" встречается только как завершающее выравнивание, поэтому такой знак не может быть частью токена.
+// Элемент без такого разделителя - токен без имени, он получает имя "admin",
+// даже если содержит ":" или завершающие "=". Пустые элементы пропускаются.
+func ParseAdminTokens(spec string) []AdminCredential {
+	var credentials []AdminCredential
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		actor, token, ok := strings.Cut(item, "=")
+		if !ok || token == "" || strings.HasPrefix(token, "=") {
+			actor, token = defaultAdminActor, item
+		}
+		actor, token = strings.TrimSpace(actor), strings.TrimSpace(token)
+		if actor == "" || token == "" {
+			continue
+		}
+		credentials = append(credentials, AdminCredential{Actor: actor, Token: token})
+	}
+	return credentials
+}
+
+// WithAdminToken создает middleware, пропускающий только запросы с токеном модератора
+// в заголовке "Authorization: Bearer <token>".
+// Список токенов задаётся в формате ParseAdminTokens. Имя модератора, которому принадлежит токен,
+// сохраняется в контексте запроса и попадает в журнал аудита.
+// Токен сравнивается со всеми известными токенами за постоянное время.
+// Если токены не заданы, все запросы отклоняются.
+func WithAdminToken(spec string) func(http.Handler) http.Handler {
+	credentials := ParseAdminTokens(spec)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			actor := ""
+			for _, c := range credentials {
+				if subtle.ConstantTimeCompare([]byte(given), []byte(c.Token)) == 1 && actor == "" {
+					actor = c.Actor
+				}
+			}
+			if !ok || actor == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				apierror.Write(w, r, apierror.New(apierror.CodeUnauthorized, "Unauthorized"))
+				return
+			}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminActorKey{}, actor)))
+		})
+	}
+}
+
+// GetAdminActor возвращает имя модератора, чей токен принял WithAdminToken,
+// или пустую строку, если запрос не прошёл через middleware.
+func GetAdminActor(r *http.Request) string {
+	actor, _ := r.Context().Value(adminActorKey{}).(string)
+	return actor
+}
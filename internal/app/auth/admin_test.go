@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAdminToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		token    string
+		header   string
+		wantCode int
+	}{
+		{
+			name:     "Valid token",
+			token:    "secret",
+			header:   "Bearer secret",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Wrong token",
+			token:    "secret",
+			header:   "Bearer wrong",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "No header",
+			token:    "secret",
+			header:   "",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Not a bearer token",
+			token:    "secret",
+			header:   "secret",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Second actor token",
+			token:    "alice=first, bob=second",
+			header:   "Bearer second",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Actor name is not a token",
+			token:    "alice=first",
+			header:   "Bearer alice",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Empty configured token",
+			token:    "",
+			header:   "Bearer ",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/admin/urls", nil)
+			if tt.header != "" {
+				request.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			WithAdminToken(tt.token)(next).ServeHTTP(w, request)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestParseAdminTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []AdminCredential
+	}{
+		{
+			name: "named and legacy tokens",
+			spec: " alice=first, ,bob = second,legacy,=orphan",
+			want: []AdminCredential{
+				{Actor: "alice", Token: "first"},
+				{Actor: "bob", Token: "second"},
+				{Actor: defaultAdminActor, Token: "legacy"},
+			},
+		},
+		{
+			name: "legacy token with colon",
+			spec: "s3cr:et:token",
+			want: []AdminCredential{{Actor: defaultAdminActor, Token: "s3cr:et:token"}},
+		},
+		{
+			name: "legacy token with padding",
+			spec: "dG9rZW4=,YWI==",
+			want: []AdminCredential{
+				{Actor: defaultAdminActor, Token: "dG9rZW4="},
+				{Actor: defaultAdminActor, Token: "YWI=="},
+			},
+		},
+		{
+			name: "named token with colon and padding",
+			spec: "alice=a:b==",
+			want: []AdminCredential{{Actor: "alice", Token: "a:b=="}},
+		},
+		{
+			name: "empty",
+			spec: " , ",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseAdminTokens(tt.spec))
+		})
+	}
+}
+
+func TestGetAdminActor(t *testing.T) {
+	var actor string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = GetAdminActor(r)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/urls", nil)
+	assert.Empty(t, GetAdminActor(request))
+
+	// Имя модератора берётся из токена, а не из заголовков запроса.
+	request.Header.Set("Authorization", "Bearer second")
+	request.Header.Set("X-Admin-Actor", "mallory")
+	WithAdminToken("alice=first,bob=second")(next).ServeHTTP(httptest.NewRecorder(), request)
+	assert.Equal(t, "bob", actor)
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+)
+
+// RevocationChecker сообщает, отозваны ли токены пользователя.
+type RevocationChecker interface {
+	IsUserRevoked(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// WithRevocation создает middleware, не принимающий токены пользователей, удаливших учётную запись.
+// Cookie аутентификации отозванного пользователя убирается из запроса, поэтому обработчики
+// выдают новый идентификатор, как при первом обращении.
+// Отзыв окончателен, поэтому отозванные пользователи запоминаются и повторно не проверяются.
+// Пользователи, чьи токены не отозваны, запоминаются на ttl; при ttl <= 0 они проверяются на каждом запросе.
+// Если проверить отзыв не удалось, запрос отклоняется с кодом 503.
+func WithRevocation(checker RevocationChecker, ttl time.Duration) func(http.Handler) http.Handler {
+	cache := newRevocationCache(ttl)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authCookie, err := r.Cookie(AuthCookieName)
+			if err != nil {
+				h.ServeHTTP(w, r)
+				return
+			}
+			userID, err := GetUserID(authCookie.Value)
+			if err != nil {
+				// невалидный токен заменят обработчики
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			now := time.Now()
+			isRevoked, ok := cache.lookup(userID, now)
+			if !ok {
+				isRevoked, err = checker.IsUserRevoked(r.Context(), userID)
+				if err != nil {
+					apierror.Write(w, r, apierror.Wrap(apierror.CodeUnavailable, "cannot check authorization", err))
+					return
+				}
+				cache.store(userID, isRevoked, now)
+			}
+			if !isRevoked {
+				h.ServeHTTP(w, r)
+				return
+			}
+			h.ServeHTTP(w, withoutAuthCookie(r))
+		})
+	}
+}
+
+// revocationCache запоминает результаты проверки отзыва токенов:
+// отозванных пользователей навсегда, остальных - на ttl.
+type revocationCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	revoked map[uuid.UUID]struct{}  // пользователи, чьи токены отозваны
+	active  map[uuid.UUID]time.Time // срок, до которого токены пользователя считаются действующими
+	sweptAt time.Time               // время последней очистки устаревших записей active
+}
+
+// newRevocationCache создает кэш результатов проверки отзыва со сроком жизни ttl для действующих пользователей.
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:     ttl,
+		revoked: map[uuid.UUID]struct{}{},
+		active:  map[uuid.UUID]time.Time{},
+	}
+}
+
+// lookup возвращает запомненный результат проверки пользователя userID
+// и false, если результата нет или он устарел к моменту now.
+func (c *revocationCache) lookup(userID uuid.UUID, now time.Time) (revoked bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.revoked[userID]; ok {
+		return true, true
+	}
+	expires, ok := c.active[userID]
+	return false, ok && now.Before(expires)
+}
+
+// store запоминает результат проверки пользователя userID, полученный в момент now.
+// Не чаще раза в ttl удаляет устаревшие записи о действующих пользователях,
+// чтобы кэш не рос с числом выданных токенов.
+func (c *revocationCache) store(userID uuid.UUID, revoked bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revoked {
+		c.revoked[userID] = struct{}{}
+		delete(c.active, userID)
+		return
+	}
+	if c.ttl <= 0 {
+		return
+	}
+	if now.Sub(c.sweptAt) >= c.ttl {
+		maps.DeleteFunc(c.active, func(_ uuid.UUID, expires time.Time) bool { return !now.Before(expires) })
+		c.sweptAt = now
+	}
+	c.active[userID] = now.Add(c.ttl)
+}
+
+// withoutAuthCookie возвращает копию запроса без cookie аутентификации.
+func withoutAuthCookie(r *http.Request) *http.Request {
+	r = r.Clone(r.Context())
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != AuthCookieName {
+			r.AddCookie(cookie)
+		}
+	}
+	return r
+}
+
+// ExpireAuthCookie удаляет cookie аутентификации в браузере пользователя.
+func ExpireAuthCookie(res http.ResponseWriter) {
+	http.SetCookie(res, &http.Cookie{
+		Name:     AuthCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// revocationStub отзывает токены пользователей из revoked и считает проверки.
+type revocationStub struct {
+	revoked map[uuid.UUID]bool
+	err     error
+	checks  int
+}
+
+func (s *revocationStub) IsUserRevoked(ctx context.Context, userID uuid.UUID) (bool, error) {
+	s.checks++
+	return s.revoked[userID], s.err
+}
+
+func TestWithRevocation(t *testing.T) {
+	activeID := uuid.New()
+	revokedID := uuid.New()
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID // uuid.Nil - запрос без cookie аутентификации
+		err        error
+		wantCode   int
+		wantCookie bool // обработчик получает cookie аутентификации
+	}{
+		{
+			name:       "Active user",
+			userID:     activeID,
+			wantCode:   http.StatusOK,
+			wantCookie: true,
+		},
+		{
+			name:       "Revoked user",
+			userID:     revokedID,
+			wantCode:   http.StatusOK,
+			wantCookie: false,
+		},
+		{
+			name:       "No cookie",
+			wantCode:   http.StatusOK,
+			wantCookie: false,
+		},
+		{
+			name:     "Storage unavailable",
+			userID:   activeID,
+			err:      errors.New("connection refused"),
+			wantCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &revocationStub{revoked: map[uuid.UUID]bool{revokedID: true}, err: tt.err}
+			var gotCookie bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := r.Cookie(AuthCookieName)
+				gotCookie = err == nil
+				// остальные cookie запроса сохраняются
+				_, err = r.Cookie("theme")
+				assert.NoError(t, err)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+			request.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+			if tt.userID != uuid.Nil {
+				authCookie, err := NewAuthCookie(tt.userID)
+				require.NoError(t, err)
+				request.AddCookie(authCookie)
+			}
+			w := httptest.NewRecorder()
+
+			WithRevocation(checker, 0)(next).ServeHTTP(w, request)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantCookie, gotCookie)
+		})
+	}
+}
+
+func TestWithRevocation_Cache(t *testing.T) {
+	tests := []struct {
+		name       string
+		revoked    bool
+		ttl        time.Duration
+		wantChecks int
+	}{
+		{
+			name:       "Revoked user is remembered",
+			revoked:    true,
+			wantChecks: 1,
+		},
+		{
+			name:       "Active user is remembered for ttl",
+			ttl:        time.Minute,
+			wantChecks: 1,
+		},
+		{
+			name:       "Active user is checked on every request without ttl",
+			wantChecks: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			checker := &revocationStub{revoked: map[uuid.UUID]bool{userID: tt.revoked}}
+			handler := WithRevocation(checker, tt.ttl)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for range 3 {
+				request := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+				authCookie, err := NewAuthCookie(userID)
+				require.NoError(t, err)
+				request.AddCookie(authCookie)
+				handler.ServeHTTP(httptest.NewRecorder(), request)
+			}
+
+			assert.Equal(t, tt.wantChecks, checker.checks)
+		})
+	}
+}
+
+func TestRevocationCache_Expiry(t *testing.T) {
+	cache := newRevocationCache(time.Minute)
+	now := time.Now()
+	activeID, revokedID := uuid.New(), uuid.New()
+
+	cache.store(activeID, false, now)
+	cache.store(revokedID, true, now)
+
+	_, ok := cache.lookup(activeID, now.Add(time.Second))
+	assert.True(t, ok)
+	_, ok = cache.lookup(activeID, now.Add(time.Minute))
+	assert.False(t, ok, "active user must be checked again after ttl")
+	revoked, ok := cache.lookup(revokedID, now.Add(time.Hour))
+	assert.True(t, ok)
+	assert.True(t, revoked)
+
+	// устаревшие записи удаляются при следующем сохранении
+	cache.store(uuid.New(), false, now.Add(time.Hour))
+	assert.NotContains(t, cache.active, activeID)
+	assert.Len(t, cache.active, 1)
+}
+
+func TestExpireAuthCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	ExpireAuthCookie(w)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, AuthCookieName, cookies[0].Name)
+	assert.Empty(t, cookies[0].Value)
+	assert.Negative(t, cookies[0].MaxAge)
+}
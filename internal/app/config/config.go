@@ -22,6 +22,69 @@ type Config struct {
 	FileStoragePath string `json:"file_storage_path" env:"FILE_STORAGE_PATH"` // путь к файлу хранилища
 	DatabaseDSN     string `json:"database_dsn" env:"DATABASE_DSN"`           // строка подключения к базе данных
 	EnableHTTPS     bool   `json:"enable_https" env:"ENABLE_HTTPS"`           // флаг для включения HTTPS
+	AdminToken      string `json:"admin_token" env:"ADMIN_TOKEN"`             // токены модераторов вида "имя=токен,...", пустой - API модерации отключено
+	// MigrationMode - режим миграций схемы базы данных при запуске: auto, check-only или off; по умолчанию auto
+	MigrationMode string `json:"migration_mode" env:"MIGRATION_MODE"`
+	// Пул соединений с базой данных: максимальное количество открытых соединений, количество соединений,
+	// которые пул держит открытыми без нагрузки, время жизни и простоя соединения в секундах.
+	// Нулевые значения заменяются значениями по умолчанию.
+	DBMaxOpenConns    int `json:"db_max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	DBMinConns        int `json:"db_min_conns" env:"DB_MIN_CONNS"`
+	DBConnMaxLifetime int `json:"db_conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime int `json:"db_conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// DBStatementTimeout - ограничение времени одного запроса к базе данных в миллисекундах
+	DBStatementTimeout int `json:"db_statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// DBTxTimeout - ограничение времени транзакции и пакетной загрузки в базу данных в миллисекундах
+	DBTxTimeout int `json:"db_tx_timeout" env:"DB_TX_TIMEOUT"`
+	// DBConnectTimeout - сколько секунд ждать доступности базы данных при запуске
+	DBConnectTimeout int `json:"db_connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// DatabaseReplicaDSNs - строки подключения к репликам базы данных через запятую;
+	// если заданы, переходы по ссылкам и списки ссылок пользователя читаются с реплик
+	DatabaseReplicaDSNs string `json:"database_replica_dsns" env:"DATABASE_REPLICA_DSNS"`
+	// DBReplicaMaxLag - допустимое отставание реплики в миллисекундах, при большем отставании реплика не используется
+	DBReplicaMaxLag int `json:"db_replica_max_lag" env:"DB_REPLICA_MAX_LAG"`
+	// RetentionDays - через сколько дней после удаления или окончания действия записи удаляются безвозвратно;
+	// 0 - записи хранятся бессрочно
+	RetentionDays int `json:"retention_days" env:"RETENTION_DAYS"`
+	// URLPolicyFile - путь к JSON-файлу со списками блокировки URL, перечитывается при изменении
+	URLPolicyFile string `json:"url_policy_file" env:"URL_POLICY_FILE"`
+	// URLPolicyEndpoint - адрес внешнего сервиса проверки URL
+	URLPolicyEndpoint string `json:"url_policy_endpoint" env:"URL_POLICY_ENDPOINT"`
+	// Ограничения частоты запросов: скорость в запросах (для пакетов - в URL) в секунду
+	// и ёмкость корзины. Нулевая скорость отключает ограничение. Лимит действует
+	// для каждого IP-адреса и для каждого пользователя с cookie авторизации.
+	RateLimitCreate        float64 `json:"rate_limit_create" env:"RATE_LIMIT_CREATE"`
+	RateLimitCreateBurst   int     `json:"rate_limit_create_burst" env:"RATE_LIMIT_CREATE_BURST"`
+	RateLimitBatch         float64 `json:"rate_limit_batch" env:"RATE_LIMIT_BATCH"`
+	RateLimitBatchBurst    int     `json:"rate_limit_batch_burst" env:"RATE_LIMIT_BATCH_BURST"`
+	RateLimitRedirect      float64 `json:"rate_limit_redirect" env:"RATE_LIMIT_REDIRECT"`
+	RateLimitRedirectBurst int     `json:"rate_limit_redirect_burst" env:"RATE_LIMIT_REDIRECT_BURST"`
+	// MetricsAddress - адрес отдельного сервера для /metrics; если пуст, метрики отдаются основным сервером
+	MetricsAddress string `json:"metrics_address" env:"METRICS_ADDRESS"`
+	// OTLPEndpoint - URL коллектора OpenTelemetry для экспорта трейсов; если пуст, трейсы не экспортируются
+	OTLPEndpoint string `json:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// LogLevel - минимальный уровень журнала: debug, info, warn, error; по умолчанию info
+	LogLevel string `json:"log_level" env:"LOG_LEVEL"`
+	// LogFormat - формат журнала: json или console; по умолчанию json
+	LogFormat string `json:"log_format" env:"LOG_FORMAT"`
+	// LogFile - путь к файлу журнала с ротацией; если пуст, журнал пишется в stderr
+	LogFile string `json:"log_file" env:"LOG_FILE"`
+	// LogMaxSize - размер файла журнала в мегабайтах, после которого выполняется ротация
+	LogMaxSize int `json:"log_max_size" env:"LOG_MAX_SIZE"`
+	// LogMaxBackups - количество хранимых старых файлов журнала
+	LogMaxBackups int `json:"log_max_backups" env:"LOG_MAX_BACKUPS"`
+	// LogMaxAge - срок хранения старых файлов журнала в днях
+	LogMaxAge int `json:"log_max_age" env:"LOG_MAX_AGE"`
+	// MaxBodySize - максимальный размер тела запроса в байтах для создания одной ссылки и API модерации
+	MaxBodySize int64 `json:"max_body_size" env:"MAX_BODY_SIZE"`
+	// MaxBatchBodySize - максимальный размер тела пакетных запросов в байтах
+	MaxBatchBodySize int64 `json:"max_batch_body_size" env:"MAX_BATCH_BODY_SIZE"`
+	// MaxBatchItems - максимальное количество элементов в пакетном запросе
+	MaxBatchItems int `json:"max_batch_items" env:"MAX_BATCH_ITEMS"`
+	// MaxDecompressedSize - максимальный размер распакованного тела сжатого запроса в байтах
+	MaxDecompressedSize int64 `json:"max_decompressed_size" env:"MAX_DECOMPRESSED_SIZE"`
+	// MaxCompressionRatio - максимально допустимое отношение размера распакованного тела запроса к сжатому
+	MaxCompressionRatio int64 `json:"max_compression_ratio" env:"MAX_COMPRESSION_RATIO"`
 }
 
 const (
@@ -35,6 +98,14 @@ const (
 // Приоритет: переменные окружения > флаги командной строки > значения по умолчанию.
 // Возвращает указатель на Config и ошибку, если она возникла.
 func NewConfig(progname string, args []string) (Config, error) {
+	c, _, err := NewConfigWithArgs(progname, args)
+	return c, err
+}
+
+// NewConfigWithArgs создает конфигурацию так же, как NewConfig, и дополнительно возвращает
+// аргументы, оставшиеся после флагов. Используется утилитами с подкомандами,
+// которые принимают те же флаги конфигурации, что и сервер.
+func NewConfigWithArgs(progname string, args []string) (Config, []string, error) {
 	// Создаем FlagSet и регистрируем все флаги
 	flags := flag.NewFlagSet(progname, flag.ContinueOnError)
 
@@ -47,20 +118,53 @@ func NewConfig(progname string, args []string) (Config, error) {
 	flags.StringVar(&flagValues.BaseURLAddress, "b", "", "base address to construct short URL")
 	flags.StringVar(&flagValues.FileStoragePath, "f", "", "path to storage file")
 	flags.StringVar(&flagValues.DatabaseDSN, "d", "", "database DSN")
+	flags.StringVar(&flagValues.MigrationMode, "migration-mode", "", "database migrations on startup: auto, check-only or off")
+	flags.IntVar(&flagValues.DBMaxOpenConns, "db-max-open-conns", 0, "max open database connections")
+	flags.IntVar(&flagValues.DBMinConns, "db-min-conns", 0, "database connections to keep open when idle")
+	flags.IntVar(&flagValues.DBConnMaxLifetime, "db-conn-max-lifetime", 0, "database connection lifetime in seconds")
+	flags.IntVar(&flagValues.DBConnMaxIdleTime, "db-conn-max-idle-time", 0, "database connection idle time in seconds")
+	flags.IntVar(&flagValues.DBStatementTimeout, "db-statement-timeout", 0, "database statement timeout in milliseconds")
+	flags.IntVar(&flagValues.DBTxTimeout, "db-tx-timeout", 0, "database transaction and bulk load timeout in milliseconds")
+	flags.IntVar(&flagValues.DBConnectTimeout, "db-connect-timeout", 0, "seconds to wait for the database on startup")
+	flags.StringVar(&flagValues.DatabaseReplicaDSNs, "database-replica-dsns", "", "comma-separated database replica DSNs")
+	flags.IntVar(&flagValues.DBReplicaMaxLag, "db-replica-max-lag", 0, "max database replica lag in milliseconds")
+	flags.IntVar(&flagValues.RetentionDays, "retention-days", 0, "days to keep deleted and expired URLs before purging them, 0 - keep forever")
 	flags.BoolVar(&flagValues.EnableHTTPS, "s", false, "enable HTTPS")
+	flags.StringVar(&flagValues.AdminToken, "admin-token", "", "admin API tokens as name=token pairs separated by commas; an item without a name is a token for \"admin\"")
+	flags.StringVar(&flagValues.URLPolicyFile, "url-policy-file", "", "path to URL blocklist file")
+	flags.StringVar(&flagValues.URLPolicyEndpoint, "url-policy-endpoint", "", "URL policy checker service endpoint")
+	flags.Float64Var(&flagValues.RateLimitCreate, "rate-create", 0, "create requests per second per client")
+	flags.IntVar(&flagValues.RateLimitCreateBurst, "rate-create-burst", 0, "create requests burst per client")
+	flags.Float64Var(&flagValues.RateLimitBatch, "rate-batch", 0, "batch URLs per second per client")
+	flags.IntVar(&flagValues.RateLimitBatchBurst, "rate-batch-burst", 0, "batch URLs burst per client")
+	flags.Float64Var(&flagValues.RateLimitRedirect, "rate-redirect", 0, "redirects per second per client")
+	flags.IntVar(&flagValues.RateLimitRedirectBurst, "rate-redirect-burst", 0, "redirects burst per client")
+	flags.StringVar(&flagValues.MetricsAddress, "metrics-address", "", "separate address to serve metrics")
+	flags.StringVar(&flagValues.OTLPEndpoint, "otlp-endpoint", "", "OpenTelemetry collector URL to export traces")
+	flags.StringVar(&flagValues.LogLevel, "log-level", "", "log level: debug, info, warn, error")
+	flags.StringVar(&flagValues.LogFormat, "log-format", "", "log format: json or console")
+	flags.StringVar(&flagValues.LogFile, "log-file", "", "log file path, stderr if empty")
+	flags.IntVar(&flagValues.LogMaxSize, "log-max-size", 0, "log file size in megabytes before rotation")
+	flags.IntVar(&flagValues.LogMaxBackups, "log-max-backups", 0, "number of rotated log files to keep")
+	flags.IntVar(&flagValues.LogMaxAge, "log-max-age", 0, "days to keep rotated log files")
+	flags.Int64Var(&flagValues.MaxBodySize, "max-body-size", 0, "max request body size in bytes")
+	flags.Int64Var(&flagValues.MaxBatchBodySize, "max-batch-body-size", 0, "max batch request body size in bytes")
+	flags.IntVar(&flagValues.MaxBatchItems, "max-batch-items", 0, "max number of items in a batch request")
+	flags.Int64Var(&flagValues.MaxDecompressedSize, "max-decompressed-size", 0, "max decompressed request body size in bytes")
+	flags.Int64Var(&flagValues.MaxCompressionRatio, "max-compression-ratio", 0, "max ratio of decompressed to compressed request body size")
 	flags.StringVar(&shortConfig, "c", "", "config path (short)")
 	flags.StringVar(&longConfig, "config", "", "config path (long)")
 
 	// Парсим флаги
 	err := flags.Parse(args)
 	if err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
 
 	// Получаем путь к конфигурационному файлу
 	configPath, err := getConfigPath(shortConfig, longConfig)
 	if err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
 
 	// Создаем конфиг из дефолтных значений
@@ -75,7 +179,7 @@ func NewConfig(progname string, args []string) (Config, error) {
 		// Пытаемся загрузить из файла
 		fc, err := loadConfigFromFile(configPath)
 		if err != nil {
-			return Config{}, err
+			return Config{}, nil, err
 		}
 		// Перезаписываем значения
 		c.overrideWith(fc, true)
@@ -89,7 +193,7 @@ func NewConfig(progname string, args []string) (Config, error) {
 	var envValues Config
 	err = env.Parse(&envValues)
 	if err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
 	if _, ok := os.LookupEnv("SERVER_ADDRESS"); ok {
 		c.ServerAddress = envValues.ServerAddress
@@ -103,11 +207,110 @@ func NewConfig(progname string, args []string) (Config, error) {
 	if _, ok := os.LookupEnv("DATABASE_DSN"); ok {
 		c.DatabaseDSN = envValues.DatabaseDSN
 	}
+	if _, ok := os.LookupEnv("MIGRATION_MODE"); ok {
+		c.MigrationMode = envValues.MigrationMode
+	}
+	if _, ok := os.LookupEnv("DB_MAX_OPEN_CONNS"); ok {
+		c.DBMaxOpenConns = envValues.DBMaxOpenConns
+	}
+	if _, ok := os.LookupEnv("DB_MIN_CONNS"); ok {
+		c.DBMinConns = envValues.DBMinConns
+	}
+	if _, ok := os.LookupEnv("DB_CONN_MAX_LIFETIME"); ok {
+		c.DBConnMaxLifetime = envValues.DBConnMaxLifetime
+	}
+	if _, ok := os.LookupEnv("DB_CONN_MAX_IDLE_TIME"); ok {
+		c.DBConnMaxIdleTime = envValues.DBConnMaxIdleTime
+	}
+	if _, ok := os.LookupEnv("DB_STATEMENT_TIMEOUT"); ok {
+		c.DBStatementTimeout = envValues.DBStatementTimeout
+	}
+	if _, ok := os.LookupEnv("DB_TX_TIMEOUT"); ok {
+		c.DBTxTimeout = envValues.DBTxTimeout
+	}
+	if _, ok := os.LookupEnv("DB_CONNECT_TIMEOUT"); ok {
+		c.DBConnectTimeout = envValues.DBConnectTimeout
+	}
+	if _, ok := os.LookupEnv("DATABASE_REPLICA_DSNS"); ok {
+		c.DatabaseReplicaDSNs = envValues.DatabaseReplicaDSNs
+	}
+	if _, ok := os.LookupEnv("DB_REPLICA_MAX_LAG"); ok {
+		c.DBReplicaMaxLag = envValues.DBReplicaMaxLag
+	}
+	if _, ok := os.LookupEnv("RETENTION_DAYS"); ok {
+		c.RetentionDays = envValues.RetentionDays
+	}
 	if _, ok := os.LookupEnv("ENABLE_HTTPS"); ok {
 		c.EnableHTTPS = envValues.EnableHTTPS
 	}
+	if _, ok := os.LookupEnv("ADMIN_TOKEN"); ok {
+		c.AdminToken = envValues.AdminToken
+	}
+	if _, ok := os.LookupEnv("URL_POLICY_FILE"); ok {
+		c.URLPolicyFile = envValues.URLPolicyFile
+	}
+	if _, ok := os.LookupEnv("URL_POLICY_ENDPOINT"); ok {
+		c.URLPolicyEndpoint = envValues.URLPolicyEndpoint
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_CREATE"); ok {
+		c.RateLimitCreate = envValues.RateLimitCreate
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_CREATE_BURST"); ok {
+		c.RateLimitCreateBurst = envValues.RateLimitCreateBurst
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_BATCH"); ok {
+		c.RateLimitBatch = envValues.RateLimitBatch
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_BATCH_BURST"); ok {
+		c.RateLimitBatchBurst = envValues.RateLimitBatchBurst
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_REDIRECT"); ok {
+		c.RateLimitRedirect = envValues.RateLimitRedirect
+	}
+	if _, ok := os.LookupEnv("RATE_LIMIT_REDIRECT_BURST"); ok {
+		c.RateLimitRedirectBurst = envValues.RateLimitRedirectBurst
+	}
+	if _, ok := os.LookupEnv("METRICS_ADDRESS"); ok {
+		c.MetricsAddress = envValues.MetricsAddress
+	}
+	if _, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_ENDPOINT"); ok {
+		c.OTLPEndpoint = envValues.OTLPEndpoint
+	}
+	if _, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		c.LogLevel = envValues.LogLevel
+	}
+	if _, ok := os.LookupEnv("LOG_FORMAT"); ok {
+		c.LogFormat = envValues.LogFormat
+	}
+	if _, ok := os.LookupEnv("LOG_FILE"); ok {
+		c.LogFile = envValues.LogFile
+	}
+	if _, ok := os.LookupEnv("LOG_MAX_SIZE"); ok {
+		c.LogMaxSize = envValues.LogMaxSize
+	}
+	if _, ok := os.LookupEnv("LOG_MAX_BACKUPS"); ok {
+		c.LogMaxBackups = envValues.LogMaxBackups
+	}
+	if _, ok := os.LookupEnv("LOG_MAX_AGE"); ok {
+		c.LogMaxAge = envValues.LogMaxAge
+	}
+	if _, ok := os.LookupEnv("MAX_BODY_SIZE"); ok {
+		c.MaxBodySize = envValues.MaxBodySize
+	}
+	if _, ok := os.LookupEnv("MAX_BATCH_BODY_SIZE"); ok {
+		c.MaxBatchBodySize = envValues.MaxBatchBodySize
+	}
+	if _, ok := os.LookupEnv("MAX_BATCH_ITEMS"); ok {
+		c.MaxBatchItems = envValues.MaxBatchItems
+	}
+	if _, ok := os.LookupEnv("MAX_DECOMPRESSED_SIZE"); ok {
+		c.MaxDecompressedSize = envValues.MaxDecompressedSize
+	}
+	if _, ok := os.LookupEnv("MAX_COMPRESSION_RATIO"); ok {
+		c.MaxCompressionRatio = envValues.MaxCompressionRatio
+	}
 
-	return c, nil
+	return c, flags.Args(), nil
 }
 
 // getConfigPath определяет путь к конфигурационному файлу из флагов и переменных окружения.
@@ -173,6 +376,105 @@ func (c *Config) overrideWith(o Config, updateEnableHTTPS bool) {
 	if o.DatabaseDSN != "" {
 		c.DatabaseDSN = o.DatabaseDSN
 	}
+	if o.MigrationMode != "" {
+		c.MigrationMode = o.MigrationMode
+	}
+	if o.DBMaxOpenConns != 0 {
+		c.DBMaxOpenConns = o.DBMaxOpenConns
+	}
+	if o.DBMinConns != 0 {
+		c.DBMinConns = o.DBMinConns
+	}
+	if o.DBConnMaxLifetime != 0 {
+		c.DBConnMaxLifetime = o.DBConnMaxLifetime
+	}
+	if o.DBConnMaxIdleTime != 0 {
+		c.DBConnMaxIdleTime = o.DBConnMaxIdleTime
+	}
+	if o.DBStatementTimeout != 0 {
+		c.DBStatementTimeout = o.DBStatementTimeout
+	}
+	if o.DBTxTimeout != 0 {
+		c.DBTxTimeout = o.DBTxTimeout
+	}
+	if o.DBConnectTimeout != 0 {
+		c.DBConnectTimeout = o.DBConnectTimeout
+	}
+	if o.DatabaseReplicaDSNs != "" {
+		c.DatabaseReplicaDSNs = o.DatabaseReplicaDSNs
+	}
+	if o.DBReplicaMaxLag != 0 {
+		c.DBReplicaMaxLag = o.DBReplicaMaxLag
+	}
+	if o.RetentionDays != 0 {
+		c.RetentionDays = o.RetentionDays
+	}
+	if o.AdminToken != "" {
+		c.AdminToken = o.AdminToken
+	}
+	if o.URLPolicyFile != "" {
+		c.URLPolicyFile = o.URLPolicyFile
+	}
+	if o.URLPolicyEndpoint != "" {
+		c.URLPolicyEndpoint = o.URLPolicyEndpoint
+	}
+	if o.RateLimitCreate != 0 {
+		c.RateLimitCreate = o.RateLimitCreate
+	}
+	if o.RateLimitCreateBurst != 0 {
+		c.RateLimitCreateBurst = o.RateLimitCreateBurst
+	}
+	if o.RateLimitBatch != 0 {
+		c.RateLimitBatch = o.RateLimitBatch
+	}
+	if o.RateLimitBatchBurst != 0 {
+		c.RateLimitBatchBurst = o.RateLimitBatchBurst
+	}
+	if o.RateLimitRedirect != 0 {
+		c.RateLimitRedirect = o.RateLimitRedirect
+	}
+	if o.RateLimitRedirectBurst != 0 {
+		c.RateLimitRedirectBurst = o.RateLimitRedirectBurst
+	}
+	if o.MetricsAddress != "" {
+		c.MetricsAddress = o.MetricsAddress
+	}
+	if o.OTLPEndpoint != "" {
+		c.OTLPEndpoint = o.OTLPEndpoint
+	}
+	if o.LogLevel != "" {
+		c.LogLevel = o.LogLevel
+	}
+	if o.LogFormat != "" {
+		c.LogFormat = o.LogFormat
+	}
+	if o.LogFile != "" {
+		c.LogFile = o.LogFile
+	}
+	if o.LogMaxSize != 0 {
+		c.LogMaxSize = o.LogMaxSize
+	}
+	if o.LogMaxBackups != 0 {
+		c.LogMaxBackups = o.LogMaxBackups
+	}
+	if o.LogMaxAge != 0 {
+		c.LogMaxAge = o.LogMaxAge
+	}
+	if o.MaxBodySize != 0 {
+		c.MaxBodySize = o.MaxBodySize
+	}
+	if o.MaxBatchBodySize != 0 {
+		c.MaxBatchBodySize = o.MaxBatchBodySize
+	}
+	if o.MaxBatchItems != 0 {
+		c.MaxBatchItems = o.MaxBatchItems
+	}
+	if o.MaxDecompressedSize != 0 {
+		c.MaxDecompressedSize = o.MaxDecompressedSize
+	}
+	if o.MaxCompressionRatio != 0 {
+		c.MaxCompressionRatio = o.MaxCompressionRatio
+	}
 	// Обновляем EnableHTTPS только если updateEnableHTTPS == true
 	if updateEnableHTTPS {
 		c.EnableHTTPS = o.EnableHTTPS
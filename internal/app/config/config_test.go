@@ -56,6 +56,182 @@ func TestConfig_Load(t *testing.T) {
 				EnableHTTPS:     false,
 			},
 		},
+		{
+			name: "Admin token from flag and env",
+			args: []string{"-admin-token", "flag-token"},
+			envVars: map[string]string{
+				"ADMIN_TOKEN": "env-token",
+			},
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				EnableHTTPS:     false,
+				AdminToken:      "env-token",
+			},
+		},
+		{
+			name: "Rate limits from flags and env",
+			args: []string{"-rate-create", "2.5", "-rate-create-burst", "10", "-rate-batch", "100"},
+			envVars: map[string]string{
+				"RATE_LIMIT_BATCH":          "50",
+				"RATE_LIMIT_BATCH_BURST":    "500",
+				"RATE_LIMIT_REDIRECT":       "20",
+				"RATE_LIMIT_REDIRECT_BURST": "40",
+			},
+			want: Config{
+				ServerAddress:          defaultAddress,
+				BaseURLAddress:         defaultAddress,
+				FileStoragePath:        defaultStoragePath,
+				DatabaseDSN:            defaultDatabaseDSN(),
+				RateLimitCreate:        2.5,
+				RateLimitCreateBurst:   10,
+				RateLimitBatch:         50,
+				RateLimitBatchBurst:    500,
+				RateLimitRedirect:      20,
+				RateLimitRedirectBurst: 40,
+			},
+		},
+		{
+			name:    "Metrics address from env",
+			args:    nil,
+			envVars: map[string]string{"METRICS_ADDRESS": ":9090"},
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				MetricsAddress:  ":9090",
+			},
+		},
+		{
+			name:    "OTLP endpoint from flag",
+			args:    []string{"-otlp-endpoint", "http://collector:4318"},
+			envVars: nil,
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				OTLPEndpoint:    "http://collector:4318",
+			},
+		},
+		{
+			name: "Logging from flags and env",
+			args: []string{"-log-level", "debug", "-log-file", "/var/log/shortener.log", "-log-max-size", "100"},
+			envVars: map[string]string{
+				"LOG_FORMAT":      "console",
+				"LOG_MAX_BACKUPS": "3",
+				"LOG_MAX_AGE":     "7",
+			},
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				LogLevel:        "debug",
+				LogFormat:       "console",
+				LogFile:         "/var/log/shortener.log",
+				LogMaxSize:      100,
+				LogMaxBackups:   3,
+				LogMaxAge:       7,
+			},
+		},
+		{
+			name:    "Body limits from flags and env",
+			args:    []string{"-max-body-size", "1024", "-max-batch-items", "50"},
+			envVars: map[string]string{"MAX_BATCH_BODY_SIZE": "1048576"},
+			want: Config{
+				ServerAddress:    defaultAddress,
+				BaseURLAddress:   defaultAddress,
+				FileStoragePath:  defaultStoragePath,
+				DatabaseDSN:      defaultDatabaseDSN(),
+				MaxBodySize:      1024,
+				MaxBatchBodySize: 1048576,
+				MaxBatchItems:    50,
+			},
+		},
+		{
+			name:    "Decompression limits from flags and env",
+			args:    []string{"-max-decompressed-size", "2048"},
+			envVars: map[string]string{"MAX_COMPRESSION_RATIO": "20"},
+			want: Config{
+				ServerAddress:       defaultAddress,
+				BaseURLAddress:      defaultAddress,
+				FileStoragePath:     defaultStoragePath,
+				DatabaseDSN:         defaultDatabaseDSN(),
+				MaxDecompressedSize: 2048,
+				MaxCompressionRatio: 20,
+			},
+		},
+		{
+			name:    "Migration mode from flag",
+			args:    []string{"-migration-mode", "check-only"},
+			envVars: nil,
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				MigrationMode:   "check-only",
+			},
+		},
+		{
+			name:    "Migration mode from env",
+			args:    []string{"-migration-mode", "check-only"},
+			envVars: map[string]string{"MIGRATION_MODE": "off"},
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				MigrationMode:   "off",
+			},
+		},
+		{
+			name:    "Database pool from flags and env",
+			args:    []string{"-db-max-open-conns", "50", "-db-statement-timeout", "2000", "-db-connect-timeout", "10"},
+			envVars: map[string]string{"DB_MIN_CONNS": "10", "DB_CONN_MAX_LIFETIME": "600", "DB_CONN_MAX_IDLE_TIME": "60", "DB_TX_TIMEOUT": "60000"},
+			want: Config{
+				ServerAddress:      defaultAddress,
+				BaseURLAddress:     defaultAddress,
+				FileStoragePath:    defaultStoragePath,
+				DatabaseDSN:        defaultDatabaseDSN(),
+				DBMaxOpenConns:     50,
+				DBMinConns:         10,
+				DBConnMaxLifetime:  600,
+				DBConnMaxIdleTime:  60,
+				DBStatementTimeout: 2000,
+				DBTxTimeout:        60000,
+				DBConnectTimeout:   10,
+			},
+		},
+		{
+			name:    "Database replicas from flags and env",
+			args:    []string{"-database-replica-dsns", "host=replica1,host=replica2"},
+			envVars: map[string]string{"DB_REPLICA_MAX_LAG": "500"},
+			want: Config{
+				ServerAddress:       defaultAddress,
+				BaseURLAddress:      defaultAddress,
+				FileStoragePath:     defaultStoragePath,
+				DatabaseDSN:         defaultDatabaseDSN(),
+				DatabaseReplicaDSNs: "host=replica1,host=replica2",
+				DBReplicaMaxLag:     500,
+			},
+		},
+		{
+			name:    "Retention from env takes precedence over flags",
+			args:    []string{"-retention-days", "30"},
+			envVars: map[string]string{"RETENTION_DAYS": "90"},
+			want: Config{
+				ServerAddress:   defaultAddress,
+				BaseURLAddress:  defaultAddress,
+				FileStoragePath: defaultStoragePath,
+				DatabaseDSN:     defaultDatabaseDSN(),
+				RetentionDays:   90,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -64,7 +240,36 @@ func TestConfig_Load(t *testing.T) {
 			os.Unsetenv("BASE_URL")
 			os.Unsetenv("FILE_STORAGE_PATH")
 			os.Unsetenv("DATABASE_DSN")
+			os.Unsetenv("MIGRATION_MODE")
+			os.Unsetenv("DB_MAX_OPEN_CONNS")
+			os.Unsetenv("DB_MIN_CONNS")
+			os.Unsetenv("DB_CONN_MAX_LIFETIME")
+			os.Unsetenv("DB_CONN_MAX_IDLE_TIME")
+			os.Unsetenv("DB_STATEMENT_TIMEOUT")
+			os.Unsetenv("DB_TX_TIMEOUT")
+			os.Unsetenv("DB_CONNECT_TIMEOUT")
+			os.Unsetenv("DATABASE_REPLICA_DSNS")
+			os.Unsetenv("DB_REPLICA_MAX_LAG")
+			os.Unsetenv("RETENTION_DAYS")
 			os.Unsetenv("ENABLE_HTTPS")
+			os.Unsetenv("ADMIN_TOKEN")
+			os.Unsetenv("RATE_LIMIT_BATCH")
+			os.Unsetenv("RATE_LIMIT_BATCH_BURST")
+			os.Unsetenv("RATE_LIMIT_REDIRECT")
+			os.Unsetenv("RATE_LIMIT_REDIRECT_BURST")
+			os.Unsetenv("METRICS_ADDRESS")
+			os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+			os.Unsetenv("LOG_LEVEL")
+			os.Unsetenv("LOG_FORMAT")
+			os.Unsetenv("LOG_FILE")
+			os.Unsetenv("LOG_MAX_SIZE")
+			os.Unsetenv("LOG_MAX_BACKUPS")
+			os.Unsetenv("LOG_MAX_AGE")
+			os.Unsetenv("MAX_BODY_SIZE")
+			os.Unsetenv("MAX_BATCH_BODY_SIZE")
+			os.Unsetenv("MAX_BATCH_ITEMS")
+			os.Unsetenv("MAX_DECOMPRESSED_SIZE")
+			os.Unsetenv("MAX_COMPRESSION_RATIO")
 
 			// Устанавливаем переменные окружения только если они заданы в тесте
 			if tt.envVars != nil {
@@ -83,3 +83,19 @@ func ExampleNewConfig_withEnvVars() {
 	// Database DSN: host=env user=env password=env dbname=env
 	// Enable HTTPS: false
 }
+
+// ExampleNewConfigWithArgs демонстрирует разбор флагов конфигурации, за которыми следует подкоманда.
+func ExampleNewConfigWithArgs() {
+	args := []string{"-f", "custom/path.txt", "lookup", "-o", "json", "abc"}
+	config, rest, err := NewConfigWithArgs("Example", args)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Printf("Storage Path: %s\n", config.FileStoragePath)
+	fmt.Printf("Command: %v\n", rest)
+	// Output:
+	// Storage Path: custom/path.txt
+	// Command: [lookup -o json abc]
+}
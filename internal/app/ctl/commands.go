@@ -0,0 +1,219 @@
+package ctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// Результаты изменения отметки об удалении.
+const (
+	resultDeleted  = "deleted"   // запись помечена удаленной
+	resultRestored = "restored"  // отметка об удалении снята
+	resultNotFound = "not found" // запись не найдена
+)
+
+// listPageSize - количество записей, которое команда list читает из хранилища за один запрос.
+const listPageSize = 500
+
+// changeOut представляет результат изменения одной записи.
+type changeOut struct {
+	ID     string `json:"id"`     // короткий идентификатор
+	Result string `json:"result"` // результат изменения
+}
+
+// purgeOut представляет результат безвозвратного удаления.
+type purgeOut struct {
+	Purged int  `json:"purged"`  // количество удаленных или подлежащих удалению записей
+	DryRun bool `json:"dry_run"` // записи только подсчитаны
+}
+
+// lookup выводит запись по короткому идентификатору.
+func (c CLI) lookup(ctx context.Context, args []string) error {
+	fs, format := newFlagSet("lookup")
+	ids, err := parseFlags(fs, format, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	record, err := c.storage.RetrieveByShortURL(ctx, ids[0])
+	if err != nil {
+		return fmt.Errorf("lookup %q: %w", ids[0], err)
+	}
+	return c.printRecords(*format, record)
+}
+
+// list выводит ссылки пользователя в порядке создания.
+func (c CLI) list(ctx context.Context, args []string) error {
+	fs, format := newFlagSet("list")
+	user := fs.String("user", "", "user ID")
+	limit := fs.Int("limit", 0, "maximum number of links, 0 - all")
+	deleted := fs.String("deleted", string(models.DeletedInclude), "deleted links: include, exclude or only")
+	if _, err := parseFlags(fs, format, args, 0, 0); err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(*user)
+	if err != nil {
+		return fmt.Errorf("%w: list: -user must be a UUID", ErrUsage)
+	}
+	filter := models.DeletedFilter(*deleted)
+	if filter != models.DeletedInclude && filter != models.DeletedExclude && filter != models.DeletedOnly {
+		return fmt.Errorf("%w: list: -deleted must be include, exclude or only", ErrUsage)
+	}
+
+	records := make([]models.Record, 0)
+	query := models.URLQuery{Deleted: filter, Limit: listPageSize}
+	for {
+		if *limit > 0 {
+			query.Limit = min(listPageSize, *limit-len(records))
+		}
+		page, next, err := c.storage.SearchUserURLs(ctx, userID, query)
+		if err != nil {
+			return fmt.Errorf("list user URLs: %w", err)
+		}
+		records = append(records, page...)
+		if next == nil || (*limit > 0 && len(records) >= *limit) {
+			break
+		}
+		query.After = next
+	}
+	return c.printRecords(*format, records...)
+}
+
+// setDeleted помечает записи удаленными или снимает отметку об удалении.
+// Обрабатывает все записи и возвращает ошибку, если какие-то из них не найдены.
+func (c CLI) setDeleted(ctx context.Context, name string, args []string, deleted bool) error {
+	fs, format := newFlagSet(name)
+	ids, err := parseFlags(fs, format, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	result := resultRestored
+	if deleted {
+		result = resultDeleted
+	}
+	out := make([]changeOut, 0, len(ids))
+	missing := 0
+	for _, id := range ids {
+		err := c.storage.SetDeleted(ctx, id, deleted)
+		switch {
+		case errors.Is(err, models.ErrorNotFound):
+			out = append(out, changeOut{ID: id, Result: resultNotFound})
+			missing++
+		case err != nil:
+			return fmt.Errorf("%s %q: %w", name, id, err)
+		default:
+			out = append(out, changeOut{ID: id, Result: result})
+		}
+	}
+
+	if err := c.print(*format, out, []string{"ID", "RESULT"}, func(add func(...string)) {
+		for _, o := range out {
+			add(o.ID, o.Result)
+		}
+	}); err != nil {
+		return err
+	}
+	if missing > 0 {
+		return fmt.Errorf("%s: %d of %d links not found: %w", name, missing, len(out), models.ErrorNotFound)
+	}
+	return nil
+}
+
+// purge безвозвратно удаляет удаленные записи. Без флага -yes только подсчитывает их.
+func (c CLI) purge(ctx context.Context, args []string) error {
+	fs, format := newFlagSet("purge")
+	yes := fs.Bool("yes", false, "confirm permanent removal")
+	if _, err := parseFlags(fs, format, args, 0, 0); err != nil {
+		return err
+	}
+
+	out := purgeOut{DryRun: !*yes}
+	if *yes {
+		purged, err := c.storage.PurgeDeleted(ctx)
+		if err != nil {
+			return fmt.Errorf("purge deleted URLs: %w", err)
+		}
+		out.Purged = purged
+	} else {
+		counts, err := c.storage.CountURLs(ctx)
+		if err != nil {
+			return fmt.Errorf("count URLs: %w", err)
+		}
+		out.Purged = counts.Deleted
+	}
+
+	return c.print(*format, out, []string{"PURGED", "DRY RUN"}, func(add func(...string)) {
+		add(strconv.Itoa(out.Purged), strconv.FormatBool(out.DryRun))
+	})
+}
+
+// counts выводит сводку по записям хранилища.
+func (c CLI) counts(ctx context.Context, args []string) error {
+	fs, format := newFlagSet("counts")
+	if _, err := parseFlags(fs, format, args, 0, 0); err != nil {
+		return err
+	}
+
+	counts, err := c.storage.CountURLs(ctx)
+	if err != nil {
+		return fmt.Errorf("count URLs: %w", err)
+	}
+	return c.print(*format, counts, []string{"TOTAL", "DELETED", "DISABLED", "USERS"}, func(add func(...string)) {
+		add(strconv.Itoa(counts.Total), strconv.Itoa(counts.Deleted), strconv.Itoa(counts.Disabled), strconv.Itoa(counts.Users))
+	})
+}
+
+// migrate применяет, откатывает миграции схемы или выводит их состояние.
+func (c CLI) migrate(ctx context.Context, args []string) error {
+	fs, format := newFlagSet("migrate")
+	direction, err := parseFlags(fs, format, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if c.migrator == nil {
+		return errors.New("migrate: migrations are only available for the database storage")
+	}
+
+	switch direction[0] {
+	case "up":
+		applied, err := c.migrator.MigrateUp(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate up: %w", err)
+		}
+		return c.print(*format, applied, []string{"APPLIED"}, func(add func(...string)) {
+			for _, name := range applied {
+				add(name)
+			}
+		})
+	case "down":
+		reverted, err := c.migrator.MigrateDown(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate down: %w", err)
+		}
+		return c.print(*format, map[string]string{"reverted": reverted}, []string{"REVERTED"}, func(add func(...string)) {
+			add(reverted)
+		})
+	case "status":
+		statuses, err := c.migrator.MigrationStatus(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate status: %w", err)
+		}
+		return c.print(*format, statuses, []string{"VERSION", "NAME", "APPLIED AT"}, func(add func(...string)) {
+			for _, s := range statuses {
+				appliedAt := "pending"
+				if s.AppliedAt != nil {
+					appliedAt = formatTime(*s.AppliedAt)
+				}
+				add(strconv.FormatInt(s.Version, 10), s.Name, appliedAt)
+			}
+		})
+	default:
+		return fmt.Errorf("%w: migrate: direction must be up, down or status", ErrUsage)
+	}
+}
@@ -0,0 +1,134 @@
+// Package ctl реализует команды утилиты администрирования сервиса сокращения URL:
+// поиск ссылки, список ссылок пользователя, мягкое удаление и восстановление,
+// безвозвратное удаление удаленных ссылок, сводку по хранилищу и управление миграциями схемы.
+// Результаты выводятся таблицей или в формате JSON.
+package ctl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/pg"
+)
+
+// Usage описывает команды утилиты.
+const Usage = `Commands:
+  lookup <id>                          show a link
+  list -user <uuid> [-limit n] [-deleted include|exclude|only]
+                                       list links of a user
+  soft-delete <id>...                  mark links as deleted
+  restore <id>...                      clear the deleted mark
+  purge [-yes]                         permanently remove deleted links; without -yes only counts them
+  counts                               show record counts
+  migrate up|down|status               manage database schema migrations
+
+Every command accepts -o table|json.`
+
+// ErrUsage возвращается, когда команда или её аргументы заданы неверно.
+var ErrUsage = errors.New("invalid usage")
+
+// Storage определяет интерфейс хранилища, с которым работают команды.
+type Storage interface {
+	// RetrieveByShortURL получает запись по короткому идентификатору.
+	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
+	// SearchUserURLs возвращает страницу URL пользователя.
+	SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error)
+	// SetDeleted помечает запись удаленной или снимает отметку об удалении.
+	SetDeleted(ctx context.Context, shortURL string, deleted bool) error
+	// PurgeDeleted безвозвратно удаляет все удаленные записи.
+	PurgeDeleted(ctx context.Context) (purged int, err error)
+	// CountURLs возвращает сводку по записям хранилища.
+	CountURLs(ctx context.Context) (counts models.URLCounts, err error)
+}
+
+// Migrator определяет интерфейс управления схемой базы данных.
+type Migrator interface {
+	// MigrateUp применяет все неприменённые миграции.
+	MigrateUp(ctx context.Context) (applied []string, err error)
+	// MigrateDown откатывает последнюю применённую миграцию.
+	MigrateDown(ctx context.Context) (reverted string, err error)
+	// MigrationStatus возвращает состояние миграций.
+	MigrationStatus(ctx context.Context) (statuses []pg.MigrationStatus, err error)
+}
+
+// CLI выполняет команды утилиты администрирования.
+type CLI struct {
+	storage  Storage   // хранилище ссылок
+	migrator Migrator  // управление схемой, nil - хранилище без схемы
+	out      io.Writer // вывод результатов
+}
+
+// NewCLI создает новый экземпляр CLI, который работает с хранилищем storage
+// и выводит результаты в out.
+func NewCLI(storage Storage, out io.Writer) CLI {
+	return CLI{
+		storage: storage,
+		out:     out,
+	}
+}
+
+// WithMigrator возвращает копию с управлением схемой базы данных для команды migrate.
+func (c CLI) WithMigrator(migrator Migrator) CLI {
+	c.migrator = migrator
+	return c
+}
+
+// Run выполняет команду, заданную первым аргументом, с остальными аргументами.
+// Возвращает ошибку, обёрнутую в ErrUsage, если команда неизвестна или её аргументы неверны.
+func (c CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: command is required", ErrUsage)
+	}
+
+	commands := map[string]func(ctx context.Context, args []string) error{
+		"lookup":      c.lookup,
+		"list":        c.list,
+		"soft-delete": func(ctx context.Context, args []string) error { return c.setDeleted(ctx, "soft-delete", args, true) },
+		"restore":     func(ctx context.Context, args []string) error { return c.setDeleted(ctx, "restore", args, false) },
+		"purge":       c.purge,
+		"counts":      c.counts,
+		"migrate":     c.migrate,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+	}
+	return command(ctx, args[1:])
+}
+
+// newFlagSet создает набор флагов команды с флагом формата вывода -o.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("o", formatTable, "output format: table or json")
+	return fs, format
+}
+
+// parseFlags разбирает флаги команды, которые могут стоять и после позиционных аргументов,
+// и проверяет формат вывода и количество позиционных аргументов.
+// Возвращает позиционные аргументы.
+func parseFlags(fs *flag.FlagSet, format *string, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUsage, fs.Name(), err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if *format != formatTable && *format != formatJSON {
+		return nil, fmt.Errorf("%w: %s: output format must be table or json", ErrUsage, fs.Name())
+	}
+	if len(positional) < minArgs || (maxArgs >= 0 && len(positional) > maxArgs) {
+		return nil, fmt.Errorf("%w: %s: unexpected number of arguments", ErrUsage, fs.Name())
+	}
+	return positional, nil
+}
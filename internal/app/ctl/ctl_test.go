@@ -0,0 +1,239 @@
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/pg"
+	"github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testUser    = uuid.MustParse("5b0d1f0a-3c2e-4d7b-9a51-1a2b3c4d5e6f")
+	testCreated = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// newTestRepo создает хранилище с тремя ссылками пользователя, одна из которых удалена.
+func newTestRepo() *simple.SimpleRepository {
+	return &simple.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "aaa", OriginalURL: "http://example.com/a", UserID: testUser, CreatedAt: testCreated},
+			{ShortURL: "bbb", OriginalURL: "http://example.com/b", UserID: testUser, CreatedAt: testCreated.Add(time.Minute), IsDeleted: true},
+			{ShortURL: "ccc", OriginalURL: "http://example.com/c", UserID: testUser, CreatedAt: testCreated.Add(2 * time.Minute)},
+		},
+	}
+}
+
+// fakeMigrator возвращает заданные результаты миграций.
+type fakeMigrator struct {
+	applied  []string
+	reverted string
+	statuses []pg.MigrationStatus
+}
+
+func (m fakeMigrator) MigrateUp(ctx context.Context) ([]string, error) {
+	return m.applied, nil
+}
+
+func (m fakeMigrator) MigrateDown(ctx context.Context) (string, error) {
+	return m.reverted, nil
+}
+
+func (m fakeMigrator) MigrationStatus(ctx context.Context) ([]pg.MigrationStatus, error) {
+	return m.statuses, nil
+}
+
+func TestCLI_Run(t *testing.T) {
+	migrator := fakeMigrator{
+		applied:  []string{"00002_tags.sql"},
+		reverted: "00002_tags.sql",
+		statuses: []pg.MigrationStatus{
+			{Version: 1, Name: "00001_init.sql", Applied: true, AppliedAt: &testCreated},
+			{Version: 2, Name: "00002_tags.sql"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		migrator Migrator
+		want     string
+		wantErr  error
+		anyErr   bool
+	}{
+		{
+			name: "lookup table",
+			args: []string{"lookup", "aaa"},
+			want: "ID   ORIGINAL URL          USER                                  CREATED AT            DELETED  DISABLED\n" +
+				"aaa  http://example.com/a  5b0d1f0a-3c2e-4d7b-9a51-1a2b3c4d5e6f  2024-05-01T12:00:00Z  false    -\n",
+		},
+		{
+			name:    "lookup missing",
+			args:    []string{"lookup", "zzz"},
+			wantErr: models.ErrorNotFound,
+		},
+		{
+			name: "list excluding deleted",
+			args: []string{"list", "-user", testUser.String(), "-deleted", "exclude", "-o", "json"},
+			want: "aaa,ccc",
+		},
+		{
+			name: "list with limit",
+			args: []string{"list", "-user", testUser.String(), "-limit", "2", "-o", "json"},
+			want: "aaa,bbb",
+		},
+		{
+			name: "soft-delete",
+			args: []string{"soft-delete", "aaa"},
+			want: "ID   RESULT\naaa  deleted\n",
+		},
+		{
+			name:    "restore with missing link",
+			args:    []string{"restore", "bbb", "zzz"},
+			want:    "ID   RESULT\nbbb  restored\nzzz  not found\n",
+			wantErr: models.ErrorNotFound,
+		},
+		{
+			name: "purge without confirmation",
+			args: []string{"purge"},
+			want: "PURGED  DRY RUN\n1       true\n",
+		},
+		{
+			name: "purge",
+			args: []string{"purge", "-yes", "-o", "json"},
+			want: "{\n  \"purged\": 1,\n  \"dry_run\": false\n}\n",
+		},
+		{
+			name: "counts",
+			args: []string{"counts"},
+			want: "TOTAL  DELETED  DISABLED  USERS\n3      1        0         1\n",
+		},
+		{
+			name:     "migrate status",
+			args:     []string{"migrate", "status"},
+			migrator: migrator,
+			want:     "VERSION  NAME            APPLIED AT\n1        00001_init.sql  2024-05-01T12:00:00Z\n2        00002_tags.sql  pending\n",
+		},
+		{
+			name:     "migrate up",
+			args:     []string{"migrate", "up", "-o", "json"},
+			migrator: migrator,
+			want:     "[\n  \"00002_tags.sql\"\n]\n",
+		},
+		{
+			name:     "migrate down",
+			args:     []string{"migrate", "down"},
+			migrator: migrator,
+			want:     "REVERTED\n00002_tags.sql\n",
+		},
+		{
+			name:   "migrate without database",
+			args:   []string{"migrate", "up"},
+			anyErr: true,
+		},
+		{
+			name:    "no command",
+			args:    nil,
+			wantErr: ErrUsage,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"drop"},
+			wantErr: ErrUsage,
+		},
+		{
+			name:    "invalid format",
+			args:    []string{"counts", "-o", "xml"},
+			wantErr: ErrUsage,
+		},
+		{
+			name:    "invalid user",
+			args:    []string{"list", "-user", "nobody"},
+			wantErr: ErrUsage,
+		},
+		{
+			name:    "missing id",
+			args:    []string{"lookup"},
+			wantErr: ErrUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cli := NewCLI(newTestRepo(), out)
+			if tt.migrator != nil {
+				cli = cli.WithMigrator(tt.migrator)
+			}
+
+			err := cli.Run(context.Background(), tt.args)
+
+			switch {
+			case tt.anyErr:
+				require.Error(t, err)
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			default:
+				require.NoError(t, err)
+			}
+			if tt.want == "" {
+				return
+			}
+			if tt.args[0] == "list" {
+				var records []models.Record
+				require.NoError(t, json.Unmarshal(out.Bytes(), &records))
+				ids := ""
+				for i, r := range records {
+					if i > 0 {
+						ids += ","
+					}
+					ids += r.ShortURL
+				}
+				assert.Equal(t, tt.want, ids)
+				return
+			}
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestCLI_RunChangesStorage(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo()
+	cli := NewCLI(repo, &bytes.Buffer{})
+
+	require.NoError(t, cli.Run(ctx, []string{"purge"}))
+	assert.Len(t, repo.Records, 3, "purge without -yes must not remove records")
+
+	require.NoError(t, cli.Run(ctx, []string{"soft-delete", "ccc"}))
+	record, err := repo.RetrieveByShortURL(ctx, "ccc")
+	require.NoError(t, err)
+	assert.True(t, record.IsDeleted)
+
+	require.NoError(t, cli.Run(ctx, []string{"purge", "-yes"}))
+	assert.Len(t, repo.Records, 1)
+	assert.Equal(t, "aaa", repo.Records[0].ShortURL)
+}
+
+func ExampleCLI_Run() {
+	cli := NewCLI(newTestRepo(), os.Stdout)
+	if err := cli.Run(context.Background(), []string{"counts", "-o", "json"}); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {
+	//   "total": 3,
+	//   "deleted": 1,
+	//   "disabled": 0,
+	//   "users": 1
+	// }
+}
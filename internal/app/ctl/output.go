@@ -0,0 +1,58 @@
+package ctl
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// Форматы вывода.
+const (
+	formatTable = "table" // таблица с выровненными столбцами
+	formatJSON  = "json"  // JSON с отступами
+)
+
+// print выводит значение v в формате JSON или таблицей с заголовком header,
+// строки которой добавляет функция rows.
+func (c CLI) print(format string, v any, header []string, rows func(add func(...string))) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	add := func(cells ...string) {
+		_, _ = w.Write([]byte(strings.Join(cells, "\t") + "\n"))
+	}
+	add(header...)
+	rows(add)
+	return w.Flush()
+}
+
+// printRecords выводит записи хранилища.
+func (c CLI) printRecords(format string, records ...models.Record) error {
+	var v any = records
+	if len(records) == 1 {
+		v = records[0]
+	}
+	header := []string{"ID", "ORIGINAL URL", "USER", "CREATED AT", "DELETED", "DISABLED"}
+	return c.print(format, v, header, func(add func(...string)) {
+		for _, r := range records {
+			disabled := "-"
+			if r.IsDisabled() {
+				disabled = r.DisabledReason
+			}
+			add(r.ShortURL, r.OriginalURL, r.UserID.String(), formatTime(r.CreatedAt), strconv.FormatBool(r.IsDeleted), disabled)
+		}
+	})
+}
+
+// formatTime форматирует время для таблицы.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// loadCheckpoint читает состояние переноса из файла контрольной точки.
+// Если файла нет, перенос начинается сначала.
+func loadCheckpoint(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Report{}, nil
+	}
+	if err != nil {
+		return Report{}, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return Report{}, fmt.Errorf("decode checkpoint %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// saveCheckpoint сохраняет состояние переноса. Запись выполняется во временный файл,
+// который затем атомарно заменяет прежний, поэтому прерывание не портит контрольную точку.
+func saveCheckpoint(path string, report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replace checkpoint: %w", err)
+	}
+	return nil
+}
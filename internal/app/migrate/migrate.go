@@ -0,0 +1,192 @@
+// Package migrate переносит записи URL из одного хранилища в другое.
+// Записи читаются пачками в порядке возрастания короткого идентификатора и сохраняются без изменений:
+// с прежними идентификаторами, владельцами, флагами удаления и блокировки.
+// Позиция переноса сохраняется в файле контрольной точки, поэтому прерванный перенос можно продолжить.
+// История целевых адресов и журнал аудита модератора не переносятся.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"go.uber.org/zap"
+)
+
+// DefaultBatchSize - количество записей, которое по умолчанию читается и сохраняется за один раз.
+const DefaultBatchSize = 1000
+
+// Source определяет интерфейс хранилища, из которого переносятся записи.
+type Source interface {
+	// ScanURLs возвращает до limit записей с короткими идентификаторами больше after
+	// в порядке возрастания идентификаторов.
+	ScanURLs(ctx context.Context, after string, limit int) (records []models.Record, err error)
+}
+
+// Target определяет интерфейс хранилища, в которое переносятся записи.
+type Target interface {
+	Source
+	// RetrieveByShortURL получает запись по короткому идентификатору.
+	RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error)
+	// CopyURLs сохраняет записи без изменений, пропуская записи с занятыми идентификаторами или адресами,
+	// и возвращает идентификаторы сохранённых записей.
+	CopyURLs(ctx context.Context, records []models.Record) (copied []string, err error)
+}
+
+// Report представляет итог переноса.
+type Report struct {
+	Read      int      `json:"read"`      // прочитано записей из источника
+	Copied    int      `json:"copied"`    // сохранено записей в приёмник
+	Present   int      `json:"present"`   // записи уже были в приёмнике, например после прерванного переноса
+	Conflicts []string `json:"conflicts"` // идентификаторы записей, чей идентификатор или адрес в приёмнике занят другой записью
+	After     string   `json:"after"`     // идентификатор последней обработанной записи
+}
+
+// Migrator переносит записи из Source в Target.
+type Migrator struct {
+	source     Source // хранилище-источник
+	target     Target // хранилище-приёмник
+	batchSize  int    // количество записей в пачке
+	dryRun     bool   // только проверить перенос, ничего не сохраняя
+	checkpoint string // путь к файлу контрольной точки, пустой - без контрольной точки
+}
+
+// NewMigrator создает новый экземпляр Migrator для переноса записей из source в target.
+func NewMigrator(source Source, target Target) Migrator {
+	return Migrator{
+		source:    source,
+		target:    target,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// WithBatchSize возвращает копию с указанным количеством записей в пачке.
+// Неположительное значение заменяется значением по умолчанию.
+func (m Migrator) WithBatchSize(batchSize int) Migrator {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	m.batchSize = batchSize
+	return m
+}
+
+// WithDryRun возвращает копию, которая только сверяет источник с приёмником:
+// записи не сохраняются, а контрольная точка читается, но не обновляется.
+func (m Migrator) WithDryRun(dryRun bool) Migrator {
+	m.dryRun = dryRun
+	return m
+}
+
+// WithCheckpoint возвращает копию, которая продолжает перенос с позиции из файла path
+// и обновляет его после каждой пачки.
+func (m Migrator) WithCheckpoint(path string) Migrator {
+	m.checkpoint = path
+	return m
+}
+
+// Run переносит записи пачками. Ошибка чтения или сохранения пачки прерывает перенос;
+// к этому моменту контрольная точка указывает на последнюю полностью обработанную пачку.
+// Конфликтующие записи не прерывают перенос и перечисляются в отчёте.
+func (m Migrator) Run(ctx context.Context) (report Report, err error) {
+	if m.checkpoint != "" {
+		report, err = loadCheckpoint(m.checkpoint)
+		if err != nil {
+			return Report{}, err
+		}
+		if report.After != "" {
+			zap.L().Sugar().Infof("Resuming migration after %q: %d records already read", report.After, report.Read)
+		}
+	}
+	if report.Conflicts == nil {
+		report.Conflicts = make([]string, 0)
+	}
+
+	for {
+		batch, err := m.source.ScanURLs(ctx, report.After, m.batchSize)
+		if err != nil {
+			return report, fmt.Errorf("read source after %q: %w", report.After, err)
+		}
+		if len(batch) == 0 {
+			return report, nil
+		}
+
+		if err := m.migrateBatch(ctx, batch, &report); err != nil {
+			return report, err
+		}
+		report.Read += len(batch)
+		report.After = batch[len(batch)-1].ShortURL
+
+		if m.checkpoint != "" && !m.dryRun {
+			if err := saveCheckpoint(m.checkpoint, report); err != nil {
+				return report, err
+			}
+		}
+		zap.L().Sugar().Infow("Migrated batch",
+			"read", report.Read, "copied", report.Copied, "present", report.Present,
+			"conflicts", len(report.Conflicts), "after", report.After, "dryRun", m.dryRun)
+
+		if len(batch) < m.batchSize {
+			return report, nil
+		}
+	}
+}
+
+// migrateBatch сохраняет пачку записей и раскладывает несохранённые записи
+// на уже перенесённые и конфликтующие. В режиме проверки все записи только сверяются с приёмником.
+func (m Migrator) migrateBatch(ctx context.Context, batch []models.Record, report *Report) error {
+	pending := batch
+	if !m.dryRun {
+		copied, err := m.target.CopyURLs(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("write batch after %q: %w", report.After, err)
+		}
+		report.Copied += len(copied)
+		pending = slices.DeleteFunc(slices.Clone(batch), func(r models.Record) bool {
+			return slices.Contains(copied, r.ShortURL)
+		})
+	}
+
+	for _, record := range pending {
+		existing, err := m.target.RetrieveByShortURL(ctx, record.ShortURL)
+		switch {
+		case errors.Is(err, models.ErrorNotFound) && m.dryRun:
+			// в режиме проверки запись была бы сохранена
+			report.Copied++
+		case errors.Is(err, models.ErrorNotFound):
+			// идентификатор свободен, значит, адрес занят другой записью
+			report.Conflicts = append(report.Conflicts, record.ShortURL)
+		case err != nil:
+			return fmt.Errorf("read target record %q: %w", record.ShortURL, err)
+		case SameRecord(record, existing):
+			report.Present++
+		default:
+			report.Conflicts = append(report.Conflicts, record.ShortURL)
+		}
+	}
+	return nil
+}
+
+// SameRecord сообщает, совпадают ли записи во всех переносимых полях.
+// Время сравнивается с точностью до микросекунды, которую сохраняет PostgreSQL;
+// отсутствующие теги равны пустому списку.
+func SameRecord(a, b models.Record) bool {
+	sameTime := func(a, b time.Time) bool {
+		return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+	}
+	sameExpiry := (a.ExpiresAt == nil && b.ExpiresAt == nil) ||
+		(a.ExpiresAt != nil && b.ExpiresAt != nil && sameTime(*a.ExpiresAt, *b.ExpiresAt))
+
+	return a.ShortURL == b.ShortURL &&
+		a.OriginalURL == b.OriginalURL &&
+		a.UserID == b.UserID &&
+		a.IsDeleted == b.IsDeleted &&
+		a.DisabledReason == b.DisabledReason &&
+		a.DisabledLegal == b.DisabledLegal &&
+		sameTime(a.CreatedAt, b.CreatedAt) &&
+		sameExpiry &&
+		a.Title == b.Title &&
+		slices.Equal(a.Tags, b.Tags)
+}
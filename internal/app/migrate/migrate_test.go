@@ -0,0 +1,209 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleMigrator_Run демонстрирует перенос записей между хранилищами.
+func ExampleMigrator_Run() {
+	source := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "a1", OriginalURL: "https://example.com/a", UserID: uuid.New()},
+			{ShortURL: "b2", OriginalURL: "https://example.com/b", UserID: uuid.New(), IsDeleted: true},
+		},
+	}
+	target := simple_storage.NewSimpleRepository()
+
+	report, err := NewMigrator(source, target).WithBatchSize(1).Run(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(report.Read, report.Copied, report.After)
+	fmt.Println(target.Records[1].ShortURL, target.Records[1].IsDeleted)
+	// Output:
+	// 2 2 b2
+	// b2 true
+}
+
+// sourceRecords возвращает n записей разных пользователей с заполненными полями.
+func sourceRecords(n int) []models.Record {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	records := make([]models.Record, 0, n)
+	for i := range n {
+		records = append(records, models.Record{
+			ShortURL:    fmt.Sprintf("id%03d", i),
+			OriginalURL: fmt.Sprintf("https://example.com/%d", i),
+			UserID:      uuid.New(),
+			IsDeleted:   i%3 == 0,
+			CreatedAt:   created.Add(time.Duration(i) * time.Minute),
+			Tags:        []string{"migrated"},
+		})
+	}
+	return records
+}
+
+func TestMigrator_Run(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        func(source []models.Record) []models.Record
+		dryRun        bool
+		wantCopied    int
+		wantPresent   int
+		wantConflicts []string
+		wantTarget    int
+	}{
+		{
+			name:       "Empty target",
+			wantCopied: 10,
+			wantTarget: 10,
+		},
+		{
+			name:        "Target with migrated records",
+			target:      func(source []models.Record) []models.Record { return source[:4] },
+			wantCopied:  6,
+			wantPresent: 4,
+			wantTarget:  10,
+		},
+		{
+			name: "Target with conflicting records",
+			target: func([]models.Record) []models.Record {
+				return []models.Record{
+					{ShortURL: "id001", OriginalURL: "https://example.org"},
+					{ShortURL: "other", OriginalURL: "https://example.com/2"},
+				}
+			},
+			wantCopied:    8,
+			wantConflicts: []string{"id001", "id002"},
+			wantTarget:    10,
+		},
+		{
+			name: "Dry run",
+			target: func([]models.Record) []models.Record {
+				return []models.Record{{ShortURL: "id001", OriginalURL: "https://example.org"}}
+			},
+			dryRun:        true,
+			wantCopied:    9,
+			wantConflicts: []string{"id001"},
+			wantTarget:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &simple_storage.SimpleRepository{Records: sourceRecords(10)}
+			target := simple_storage.NewSimpleRepository()
+			if tt.target != nil {
+				target.Records = append(target.Records, tt.target(source.Records)...)
+			}
+
+			report, err := NewMigrator(source, target).WithBatchSize(3).WithDryRun(tt.dryRun).Run(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, 10, report.Read)
+			assert.Equal(t, "id009", report.After)
+			assert.Equal(t, tt.wantCopied, report.Copied)
+			assert.Equal(t, tt.wantPresent, report.Present)
+			assert.ElementsMatch(t, tt.wantConflicts, report.Conflicts)
+			assert.Len(t, target.Records, tt.wantTarget)
+		})
+	}
+}
+
+// failingTarget отказывает в сохранении пачки с записью failOn.
+type failingTarget struct {
+	*simple_storage.SimpleRepository
+	failOn string
+}
+
+// CopyURLs возвращает ошибку, если в пачке есть запись failOn.
+func (t failingTarget) CopyURLs(ctx context.Context, records []models.Record) ([]string, error) {
+	for _, r := range records {
+		if r.ShortURL == t.failOn {
+			return nil, errors.New("target is unavailable")
+		}
+	}
+	return t.SimpleRepository.CopyURLs(ctx, records)
+}
+
+func TestMigrator_Checkpoint(t *testing.T) {
+	ctx := context.Background()
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	source := &simple_storage.SimpleRepository{Records: sourceRecords(10)}
+	target := simple_storage.NewSimpleRepository()
+
+	// перенос прерывается на третьей пачке, контрольная точка указывает на конец второй
+	failing := failingTarget{SimpleRepository: target, failOn: "id007"}
+	report, err := NewMigrator(source, failing).WithBatchSize(3).WithCheckpoint(checkpoint).Run(ctx)
+	require.Error(t, err)
+	assert.Equal(t, 6, report.Read)
+	assert.FileExists(t, checkpoint)
+
+	saved, err := loadCheckpoint(checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "id005", saved.After)
+
+	// повторный запуск продолжает с контрольной точки и не читает перенесённые записи заново
+	report, err = NewMigrator(source, target).WithBatchSize(3).WithCheckpoint(checkpoint).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 10, report.Read)
+	assert.Equal(t, 10, report.Copied)
+	assert.Zero(t, report.Present)
+	assert.Len(t, target.Records, 10)
+
+	// режим проверки не меняет контрольную точку
+	require.NoError(t, os.Remove(checkpoint))
+	_, err = NewMigrator(source, target).WithDryRun(true).WithCheckpoint(checkpoint).Run(ctx)
+	require.NoError(t, err)
+	assert.NoFileExists(t, checkpoint)
+}
+
+func TestMigrator_Verify(t *testing.T) {
+	ctx := context.Background()
+	source := &simple_storage.SimpleRepository{Records: sourceRecords(25)}
+	target := simple_storage.NewSimpleRepository()
+	migrator := NewMigrator(source, target).WithBatchSize(4)
+
+	_, err := migrator.Run(ctx)
+	require.NoError(t, err)
+
+	report, err := migrator.Verify(ctx, 10)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 25, report.SourceCount)
+	assert.Equal(t, 25, report.TargetCount)
+	assert.Equal(t, 10, report.Sampled)
+
+	// выборка больше источника сверяет все записи и находит отличия
+	target.Records[3].IsDeleted = !target.Records[3].IsDeleted
+	target.Records = target.Records[:24]
+	report, err = migrator.Verify(ctx, 100)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, 25, report.Sampled)
+	assert.ElementsMatch(t, []string{"id003", "id024"}, report.Mismatches)
+}
+
+func TestSameRecord(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	record := models.Record{ShortURL: "a", OriginalURL: "https://example.com", CreatedAt: created}
+
+	stored := record
+	stored.CreatedAt = created.Truncate(time.Microsecond).In(time.FixedZone("MSK", 3*60*60))
+	stored.Tags = []string{}
+	assert.True(t, SameRecord(record, stored))
+
+	stored.IsDeleted = true
+	assert.False(t, SameRecord(record, stored))
+}
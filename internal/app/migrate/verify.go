@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// DefaultSampleSize - количество записей, которое по умолчанию сверяется при проверке переноса.
+const DefaultSampleSize = 100
+
+// VerifyReport представляет итог проверки переноса.
+type VerifyReport struct {
+	SourceCount int      `json:"source_count"` // записей в источнике
+	TargetCount int      `json:"target_count"` // записей в приёмнике
+	Sampled     int      `json:"sampled"`      // сверено записей из выборки
+	Mismatches  []string `json:"mismatches"`   // идентификаторы записей, отсутствующих в приёмнике или отличающихся от источника
+}
+
+// OK сообщает, прошла ли проверка: в приёмнике не меньше записей, чем в источнике,
+// и все записи выборки перенесены без изменений. Приёмник может содержать и собственные записи,
+// поэтому превышение количества записей ошибкой не считается.
+func (r VerifyReport) OK() bool {
+	return r.TargetCount >= r.SourceCount && len(r.Mismatches) == 0
+}
+
+// Verify сравнивает количество записей в источнике и приёмнике и сверяет
+// случайную выборку из sampleSize записей источника с записями приёмника.
+// Неположительный sampleSize заменяется значением по умолчанию.
+func (m Migrator) Verify(ctx context.Context, sampleSize int) (report VerifyReport, err error) {
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+
+	// выборка строится за один проход по источнику методом резервуара
+	sample := make([]models.Record, 0, sampleSize)
+	report.SourceCount, err = m.scan(ctx, m.source, func(seen int, r models.Record) {
+		if len(sample) < sampleSize {
+			sample = append(sample, r)
+		} else if i := rand.IntN(seen + 1); i < sampleSize {
+			sample[i] = r
+		}
+	})
+	if err != nil {
+		return report, fmt.Errorf("read source: %w", err)
+	}
+
+	report.TargetCount, err = m.scan(ctx, m.target, func(int, models.Record) {})
+	if err != nil {
+		return report, fmt.Errorf("read target: %w", err)
+	}
+
+	report.Mismatches = make([]string, 0)
+	for _, record := range sample {
+		existing, err := m.target.RetrieveByShortURL(ctx, record.ShortURL)
+		if err != nil && !errors.Is(err, models.ErrorNotFound) {
+			return report, fmt.Errorf("read target record %q: %w", record.ShortURL, err)
+		}
+		if err != nil || !SameRecord(record, existing) {
+			report.Mismatches = append(report.Mismatches, record.ShortURL)
+		}
+		report.Sampled++
+	}
+	return report, nil
+}
+
+// scan обходит все записи хранилища пачками, передавая fn каждую запись вместе с количеством
+// уже пройденных записей, и возвращает общее количество записей.
+func (m Migrator) scan(ctx context.Context, s Source, fn func(seen int, r models.Record)) (count int, err error) {
+	after := ""
+	for {
+		batch, err := s.ScanURLs(ctx, after, m.batchSize)
+		if err != nil {
+			return count, err
+		}
+		for _, r := range batch {
+			fn(count, r)
+			count++
+		}
+		if len(batch) < m.batchSize {
+			return count, nil
+		}
+		after = batch[len(batch)-1].ShortURL
+	}
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrorDeletionQueueFull возвращается, когда хранилище не может принять удаления в очередь:
+// очередь заполнена или хранилище закрывается. Запрос на удаление можно повторить позже.
+var ErrorDeletionQueueFull = errors.New("deletion queue is full")
+
+// DeletionJobTTL задает, сколько хранилище помнит завершённую задачу удаления.
+const DeletionJobTTL = time.Hour
+
+// DeletionStatus описывает результат удаления одной ссылки.
+type DeletionStatus string
+
+// Результаты удаления ссылки.
+const (
+	DeletionPending  DeletionStatus = "pending"   // удаление ещё не выполнено
+	DeletionDeleted  DeletionStatus = "deleted"   // ссылка удалена
+	DeletionNotOwned DeletionStatus = "not_owned" // ссылка принадлежит другому пользователю и не удалена
+	DeletionNotFound DeletionStatus = "not_found" // ссылки с таким идентификатором нет
+)
+
+// DeletionItem представляет удаление одной ссылки в задаче.
+type DeletionItem struct {
+	ShortURL string         `json:"short_url"` // короткий идентификатор URL
+	Status   DeletionStatus `json:"status"`    // результат удаления
+}
+
+// DeletionJob представляет задачу удаления ссылок пользователя.
+// Задача завершена, когда известен результат удаления каждой ссылки.
+type DeletionJob struct {
+	ID          string         // идентификатор задачи
+	UserID      uuid.UUID      // пользователь, запросивший удаление
+	CreatedAt   time.Time      // время создания задачи
+	CompletedAt *time.Time     // время завершения задачи, nil - задача выполняется
+	Items       []DeletionItem // удаляемые ссылки без повторов в порядке запроса
+}
+
+// NewDeletionJob создает задачу удаления ссылок shortURLs пользователя userID.
+// Повторяющиеся идентификаторы включаются в задачу один раз, все удаления ожидают выполнения.
+func NewDeletionJob(userID uuid.UUID, shortURLs []string, now time.Time) DeletionJob {
+	job := DeletionJob{ID: uuid.NewString(), UserID: userID, CreatedAt: now}
+	seen := make(map[string]bool, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		if !seen[shortURL] {
+			seen[shortURL] = true
+			job.Items = append(job.Items, DeletionItem{ShortURL: shortURL, Status: DeletionPending})
+		}
+	}
+	if len(job.Items) == 0 {
+		job.CompletedAt = &now
+	}
+	return job
+}
+
+// Resolve записывает результат удаления ссылки shortURL и завершает задачу,
+// если результаты всех удалений известны.
+func (j *DeletionJob) Resolve(shortURL string, status DeletionStatus, now time.Time) {
+	for i := range j.Items {
+		if j.Items[i].ShortURL == shortURL {
+			j.Items[i].Status = status
+		}
+	}
+	if j.CompletedAt == nil && !slices.ContainsFunc(j.Items, func(item DeletionItem) bool { return item.Status == DeletionPending }) {
+		j.CompletedAt = &now
+	}
+}
+
+// Expired сообщает, что задача завершена более DeletionJobTTL назад и её можно забыть.
+func (j DeletionJob) Expired(now time.Time) bool {
+	return j.CompletedAt != nil && now.Sub(*j.CompletedAt) > DeletionJobTTL
+}
+
+// DeletionStatusOf возвращает результат удаления пользователем userID ссылки с записью r
+// в синхронном хранилище. r равна nil, если ссылки нет.
+func DeletionStatusOf(r *Record, userID uuid.UUID) DeletionStatus {
+	switch {
+	case r == nil:
+		return DeletionNotFound
+	case r.UserID != userID:
+		return DeletionNotOwned
+	default:
+		return DeletionDeleted
+	}
+}
+
+// FindDeletionJob возвращает задачу удаления id пользователя userID из списка jobs
+// или ErrorNotFound, если такой задачи нет или она принадлежит другому пользователю.
+func FindDeletionJob(jobs []DeletionJob, userID uuid.UUID, id string) (DeletionJob, error) {
+	for _, job := range jobs {
+		if job.ID == id && job.UserID == userID {
+			return job, nil
+		}
+	}
+	return DeletionJob{}, ErrorNotFound
+}
+
+// PruneDeletionJobs удаляет из списка jobs задачи, срок хранения которых истёк.
+func PruneDeletionJobs(jobs []DeletionJob, now time.Time) []DeletionJob {
+	return slices.DeleteFunc(jobs, func(job DeletionJob) bool { return job.Expired(now) })
+}
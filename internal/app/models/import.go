@@ -0,0 +1,18 @@
+package models
+
+// maxShortURLLength - наибольшая длина короткого идентификатора, которую принимают все хранилища.
+const maxShortURLLength = 10
+
+// ValidShortURL проверяет, можно ли сохранить импортируемый короткий идентификатор как есть:
+// он должен быть непустым, не длиннее 10 символов и состоять из латинских букв, цифр, '-' и '_'.
+func ValidShortURL(id string) bool {
+	if len(id) == 0 || len(id) > maxShortURLLength {
+		return false
+	}
+	for _, c := range id {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
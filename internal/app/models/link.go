@@ -0,0 +1,28 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// LinkUpdate описывает изменение пользователем свойств ссылки.
+// Поля со значением nil не изменяются.
+type LinkUpdate struct {
+	Title        *string    // новый заголовок
+	Tags         *[]string  // новый набор тегов
+	SetExpiresAt bool       // изменить срок действия
+	ExpiresAt    *time.Time // новый срок действия, nil при SetExpiresAt - бессрочно
+}
+
+// Apply применяет изменение к записи.
+func (u LinkUpdate) Apply(r *Record) {
+	if u.Title != nil {
+		r.Title = *u.Title
+	}
+	if u.Tags != nil {
+		r.Tags = slices.Clone(*u.Tags)
+	}
+	if u.SetExpiresAt {
+		r.ExpiresAt = u.ExpiresAt
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Действия модератора, фиксируемые в журнале аудита.
+const (
+	AuditActionDisable = "disable" // блокировка ссылки
+	AuditActionRestore = "restore" // снятие блокировки
+	AuditActionPurge   = "purge"   // безвозвратное удаление
+)
+
+// AuditEntry представляет запись журнала действий модератора.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`              // время действия
+	Actor      string    `json:"actor"`             // кто выполнил действие
+	RemoteAddr string    `json:"remote_addr"`       // адрес, с которого пришёл запрос
+	Action     string    `json:"action"`            // тип действия
+	ShortURL   string    `json:"short_url"`         // короткий идентификатор URL
+	Details    string    `json:"details,omitempty"` // дополнительные сведения, например причина блокировки
+}
+
+// SearchFilter описывает условия поиска записей модератором.
+// Пустые поля не участвуют в фильтрации.
+type SearchFilter struct {
+	OriginalURL string    // подстрока оригинального URL
+	UserID      uuid.UUID // владелец записи
+	ShortURL    string    // короткий идентификатор
+	Limit       int       // максимальное количество записей, 0 - без ограничения
+}
+
+// Match проверяет, удовлетворяет ли запись условиям фильтра.
+func (f SearchFilter) Match(r Record) bool {
+	if f.OriginalURL != "" && !strings.Contains(r.OriginalURL, f.OriginalURL) {
+		return false
+	}
+	if f.UserID != uuid.Nil && r.UserID != f.UserID {
+		return false
+	}
+	if f.ShortURL != "" && r.ShortURL != f.ShortURL {
+		return false
+	}
+	return true
+}
+
+// URLCounts представляет сводку по записям хранилища.
+type URLCounts struct {
+	Total    int `json:"total"`    // всего записей
+	Deleted  int `json:"deleted"`  // удаленных пользователями записей
+	Disabled int `json:"disabled"` // заблокированных модератором записей
+	Users    int `json:"users"`    // пользователей, у которых есть записи
+}
+
+// CountRecords подсчитывает сводку по записям.
+// Используется хранилищами, которые держат все записи в памяти.
+func CountRecords(records []Record) URLCounts {
+	counts := URLCounts{Total: len(records)}
+	users := make(map[uuid.UUID]bool)
+	for _, r := range records {
+		if r.IsDeleted {
+			counts.Deleted++
+		}
+		if r.IsDisabled() {
+			counts.Disabled++
+		}
+		users[r.UserID] = true
+	}
+	counts.Users = len(users)
+	return counts
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrorInvalidCursor возвращается, когда курсор постраничной выдачи не удаётся разобрать.
+var ErrorInvalidCursor = errors.New("invalid cursor")
+
+// URLSort задаёт поле сортировки ссылок пользователя.
+type URLSort string
+
+// Поля сортировки ссылок пользователя.
+const (
+	SortCreatedAt   URLSort = "created_at"   // по времени создания
+	SortOriginalURL URLSort = "original_url" // по оригинальному URL
+)
+
+// DeletedFilter задаёт, попадают ли в выдачу удаленные ссылки.
+type DeletedFilter string
+
+// Режимы отбора удаленных ссылок.
+const (
+	DeletedInclude DeletedFilter = "include" // удаленные и действующие ссылки
+	DeletedExclude DeletedFilter = "exclude" // только действующие ссылки
+	DeletedOnly    DeletedFilter = "only"    // только удаленные ссылки
+)
+
+// Cursor указывает на последнюю выданную ссылку; следующая страница начинается после неё.
+// Курсор действителен только для того порядка сортировки, с которым он был выдан.
+type Cursor struct {
+	Sort        URLSort   `json:"s"`           // поле сортировки
+	Desc        bool      `json:"d,omitempty"` // обратный порядок
+	CreatedAt   time.Time `json:"c"`           // время создания последней ссылки
+	OriginalURL string    `json:"o,omitempty"` // оригинальный URL последней ссылки
+	ShortURL    string    `json:"id"`          // короткий идентификатор последней ссылки
+}
+
+// Encode возвращает непрозрачное строковое представление курсора для передачи клиенту.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает курсор, полученный от клиента.
+// Возвращает ErrorInvalidCursor, если строка не является курсором.
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrorInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ShortURL == "" {
+		return Cursor{}, ErrorInvalidCursor
+	}
+	if c.Sort != SortCreatedAt && c.Sort != SortOriginalURL {
+		return Cursor{}, ErrorInvalidCursor
+	}
+	return c, nil
+}
+
+// URLQuery описывает выборку ссылок пользователя: фильтры, порядок и размер страницы.
+// Пустые поля не участвуют в фильтрации. Ссылки с равным ключом сортировки
+// упорядочиваются по короткому идентификатору, поэтому порядок выдачи однозначен.
+type URLQuery struct {
+	Contains    string        // подстрока оригинального URL
+	Deleted     DeletedFilter // отбор удаленных ссылок, пустое значение - DeletedInclude
+	CreatedFrom time.Time     // ссылки, созданные не раньше этого момента
+	CreatedTo   time.Time     // ссылки, созданные раньше этого момента
+	Sort        URLSort       // поле сортировки, пустое значение - SortCreatedAt
+	Desc        bool          // обратный порядок
+	Limit       int           // размер страницы, 0 - без ограничения
+	After       *Cursor       // курсор предыдущей страницы
+}
+
+// SortField возвращает поле сортировки с учётом значения по умолчанию.
+func (q URLQuery) SortField() URLSort {
+	if q.Sort == "" {
+		return SortCreatedAt
+	}
+	return q.Sort
+}
+
+// Match проверяет, удовлетворяет ли запись фильтрам выборки и находится ли она после курсора.
+func (q URLQuery) Match(r Record) bool {
+	if q.Contains != "" && !strings.Contains(r.OriginalURL, q.Contains) {
+		return false
+	}
+	if (q.Deleted == DeletedExclude && r.IsDeleted) || (q.Deleted == DeletedOnly && !r.IsDeleted) {
+		return false
+	}
+	if !q.CreatedFrom.IsZero() && r.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !r.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
+	if q.After != nil {
+		return q.compare(r, cursorRecord(*q.After)) > 0
+	}
+	return true
+}
+
+// CursorOf возвращает курсор, указывающий на запись r.
+func (q URLQuery) CursorOf(r Record) Cursor {
+	c := Cursor{Sort: q.SortField(), Desc: q.Desc, CreatedAt: r.CreatedAt, ShortURL: r.ShortURL}
+	if c.Sort == SortOriginalURL {
+		c.OriginalURL = r.OriginalURL
+	}
+	return c
+}
+
+// Page отбирает, сортирует и ограничивает записи по условиям выборки.
+// Используется хранилищами, которые держат все записи в памяти.
+// Возвращает страницу и курсор следующей страницы или nil, если страница последняя.
+func (q URLQuery) Page(records []Record) (page []Record, next *Cursor) {
+	page = make([]Record, 0)
+	for _, r := range records {
+		if q.Match(r) {
+			page = append(page, r)
+		}
+	}
+	slices.SortFunc(page, q.compare)
+	return q.Cut(page)
+}
+
+// Cut обрезает упорядоченные записи до размера страницы.
+// Возвращает страницу и курсор следующей страницы или nil, если записей больше нет.
+func (q URLQuery) Cut(records []Record) (page []Record, next *Cursor) {
+	if q.Limit <= 0 || len(records) <= q.Limit {
+		return records, nil
+	}
+	page = records[:q.Limit]
+	c := q.CursorOf(page[len(page)-1])
+	return page, &c
+}
+
+// ScanPage возвращает до limit записей с короткими идентификаторами больше after,
+// упорядоченных по короткому идентификатору. Используется хранилищами, которые держат
+// все записи в памяти, для последовательного обхода при переносе данных.
+func ScanPage(records []Record, after string, limit int) []Record {
+	page := make([]Record, 0)
+	for _, r := range records {
+		if r.ShortURL > after {
+			page = append(page, r)
+		}
+	}
+	slices.SortFunc(page, func(a, b Record) int { return cmp.Compare(a.ShortURL, b.ShortURL) })
+	if limit > 0 && len(page) > limit {
+		page = page[:limit]
+	}
+	return page
+}
+
+// compare сравнивает записи по полю сортировки, а при равенстве - по короткому идентификатору.
+func (q URLQuery) compare(a, b Record) int {
+	var result int
+	if q.SortField() == SortOriginalURL {
+		result = strings.Compare(a.OriginalURL, b.OriginalURL)
+	} else {
+		result = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if result == 0 {
+		result = cmp.Compare(a.ShortURL, b.ShortURL)
+	}
+	if q.Desc {
+		return -result
+	}
+	return result
+}
+
+// cursorRecord возвращает запись с ключом сортировки курсора для сравнения с другими записями.
+func cursorRecord(c Cursor) Record {
+	return Record{CreatedAt: c.CreatedAt, OriginalURL: c.OriginalURL, ShortURL: c.ShortURL}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,8 +12,44 @@ var ErrorNotFound = errors.New("not found")
 
 // Record представляет запись URL в хранилище.
 type Record struct {
-	ShortURL    string    `json:"short_url"`    // короткий идентификатор URL
-	OriginalURL string    `json:"original_url"` // оригинальный URL
-	UserID      uuid.UUID `json:"user_id"`      // идентификатор пользователя
-	IsDeleted   bool      `json:"is_deleted"`   // флаг удаления
+	ShortURL       string     `json:"short_url"`                 // короткий идентификатор URL
+	OriginalURL    string     `json:"original_url"`              // оригинальный URL
+	UserID         uuid.UUID  `json:"user_id"`                   // идентификатор пользователя
+	IsDeleted      bool       `json:"is_deleted"`                // флаг удаления
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`      // время удаления пользователем, nil - не удалена
+	DisabledReason string     `json:"disabled_reason,omitempty"` // причина блокировки модератором
+	DisabledLegal  bool       `json:"disabled_legal,omitempty"`  // блокировка по юридическим основаниям
+	CreatedAt      time.Time  `json:"created_at"`                // время создания
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`      // время окончания действия ссылки, nil - бессрочно
+	Title          string     `json:"title,omitempty"`           // заголовок, заданный пользователем
+	Tags           []string   `json:"tags,omitempty"`            // теги, заданные пользователем
+}
+
+// IsDisabled сообщает, заблокирована ли запись модератором.
+func (r Record) IsDisabled() bool {
+	return r.DisabledReason != ""
+}
+
+// IsExpired сообщает, истёк ли срок действия записи к моменту now.
+func (r Record) IsExpired(now time.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
+}
+
+// SetDeleted помечает запись удаленной в момент now или снимает отметку об удалении.
+// Время удаления уже удаленной записи не меняется.
+func (r *Record) SetDeleted(deleted bool, now time.Time) {
+	switch {
+	case !deleted:
+		r.DeletedAt = nil
+	case !r.IsDeleted || r.DeletedAt == nil:
+		r.DeletedAt = &now
+	}
+	r.IsDeleted = deleted
+}
+
+// OutlivedRetention сообщает, что запись удалена пользователем или истекла раньше cutoff
+// и её можно безвозвратно удалить.
+func (r Record) OutlivedRetention(cutoff time.Time) bool {
+	return (r.IsDeleted && r.DeletedAt != nil && r.DeletedAt.Before(cutoff)) ||
+		(r.ExpiresAt != nil && r.ExpiresAt.Before(cutoff))
 }
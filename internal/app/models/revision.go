@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrorOriginalURLExists возвращается, когда новый оригинальный URL уже сокращён другой ссылкой.
+var ErrorOriginalURLExists = errors.New("original URL already exists")
+
+// Revision представляет версию целевого адреса ссылки.
+// Первая версия - адрес, с которым ссылка была создана.
+type Revision struct {
+	ShortURL    string    `json:"short_url"`    // короткий идентификатор URL
+	Revision    int       `json:"revision"`     // номер версии, начиная с 1
+	OriginalURL string    `json:"original_url"` // оригинальный URL в этой версии
+	CreatedAt   time.Time `json:"created_at"`   // время появления версии
+}
+
+// InitialRevision возвращает первую версию ссылки, построенную по записи хранилища.
+// Используется, пока целевой адрес ссылки ни разу не менялся и история пуста.
+func InitialRevision(r Record) Revision {
+	return Revision{
+		ShortURL:    r.ShortURL,
+		Revision:    1,
+		OriginalURL: r.OriginalURL,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+// NextRevisions возвращает версии, которые нужно добавить в историю ссылки r
+// при смене целевого адреса на url. Если история пуста, вместе с новой версией
+// возвращается первая версия, чтобы исходный адрес не потерялся.
+func NextRevisions(history []Revision, r Record, url string, now time.Time) []Revision {
+	if len(history) == 0 {
+		history = []Revision{InitialRevision(r)}
+		return append(history, Revision{ShortURL: r.ShortURL, Revision: 2, OriginalURL: url, CreatedAt: now})
+	}
+	last := history[len(history)-1]
+	return []Revision{{ShortURL: r.ShortURL, Revision: last.Revision + 1, OriginalURL: url, CreatedAt: now}}
+}
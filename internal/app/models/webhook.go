@@ -0,0 +1,156 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType описывает тип события жизненного цикла ссылки.
+type EventType string
+
+// Типы событий жизненного цикла ссылки.
+const (
+	EventLinkCreated EventType = "link.created" // ссылка создана пользователем или импортирована
+	EventLinkDeleted EventType = "link.deleted" // ссылка удалена пользователем
+	EventLinkClicked EventType = "link.clicked" // выполнен переход по ссылке
+)
+
+// Event представляет событие жизненного цикла ссылки, доставляемое вебхукам её владельца.
+type Event struct {
+	ID          string    `json:"id"`           // идентификатор события, общий для всех доставок
+	Type        EventType `json:"type"`         // тип события
+	ShortURL    string    `json:"short_url"`    // короткий идентификатор ссылки
+	OriginalURL string    `json:"original_url"` // оригинальный URL
+	OccurredAt  time.Time `json:"occurred_at"`  // время события
+}
+
+// NewEvent создает событие типа eventType для ссылки с записью r.
+func NewEvent(eventType EventType, r Record, now time.Time) Event {
+	return Event{
+		ID:          uuid.NewString(),
+		Type:        eventType,
+		ShortURL:    r.ShortURL,
+		OriginalURL: r.OriginalURL,
+		OccurredAt:  now,
+	}
+}
+
+// Webhook представляет адрес, на который доставляются события ссылок пользователя.
+type Webhook struct {
+	ID        string    `json:"id"`         // идентификатор вебхука
+	UserID    uuid.UUID `json:"user_id"`    // владелец вебхука
+	URL       string    `json:"url"`        // адрес получателя событий
+	Secret    string    `json:"secret"`     // ключ подписи HMAC доставок
+	CreatedAt time.Time `json:"created_at"` // время регистрации
+}
+
+// NewWebhook создает вебхук пользователя userID с адресом url и случайным ключом подписи.
+func NewWebhook(userID uuid.UUID, url string, now time.Time) (Webhook, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Webhook{}, err
+	}
+	return Webhook{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		URL:       url,
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: now,
+	}, nil
+}
+
+// Delivery представляет доставку события одному вебхуку - запись outbox.
+// Доставка создаётся вместе с изменением, породившим событие, и удаляется после успешной доставки.
+type Delivery struct {
+	ID            string    `json:"id"`              // идентификатор доставки
+	WebhookID     string    `json:"webhook_id"`      // вебхук получателя
+	Event         Event     `json:"event"`           // доставляемое событие
+	Attempts      int       `json:"attempts"`        // количество неудачных попыток
+	NextAttemptAt time.Time `json:"next_attempt_at"` // время следующей попытки
+	LastError     string    `json:"last_error"`      // ошибка последней попытки
+	Abandoned     bool      `json:"abandoned"`       // попытки исчерпаны, доставка больше не выполняется
+	URL           string    `json:"-"`               // адрес вебхука, заполняется при выдаче доставки
+	Secret        string    `json:"-"`               // ключ подписи вебхука, заполняется при выдаче доставки
+}
+
+// Due сообщает, что доставку пора выполнить к моменту now.
+func (d Delivery) Due(now time.Time) bool {
+	return !d.Abandoned && !d.NextAttemptAt.After(now)
+}
+
+// NewDeliveries создает доставки события event каждому вебхуку пользователя userID из списка webhooks.
+// Идентификаторы доставкам назначает хранилище.
+func NewDeliveries(webhooks []Webhook, userID uuid.UUID, event Event) []Delivery {
+	var deliveries []Delivery
+	for _, w := range webhooks {
+		if w.UserID == userID {
+			deliveries = append(deliveries, Delivery{WebhookID: w.ID, Event: event, NextAttemptAt: event.OccurredAt})
+		}
+	}
+	return deliveries
+}
+
+// ClaimDeliveries выбирает из списка deliveries не больше limit доставок, которые пора выполнить,
+// и откладывает их следующую попытку на lease, чтобы их не выдать повторно во время доставки.
+// Возвращает копии выбранных доставок с адресом и ключом подписи вебхука.
+func ClaimDeliveries(deliveries []Delivery, webhooks []Webhook, now time.Time, limit int, lease time.Duration) []Delivery {
+	var claimed []Delivery
+	for i := range deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		d := &deliveries[i]
+		j := slices.IndexFunc(webhooks, func(w Webhook) bool { return w.ID == d.WebhookID })
+		if !d.Due(now) || j < 0 {
+			continue
+		}
+		d.NextAttemptAt = now.Add(lease)
+		out := *d
+		out.URL, out.Secret = webhooks[j].URL, webhooks[j].Secret
+		claimed = append(claimed, out)
+	}
+	return claimed
+}
+
+// UpdateDelivery записывает в список deliveries результат неудачной попытки доставки d
+// или возвращает ErrorNotFound, если доставки нет.
+func UpdateDelivery(deliveries []Delivery, d Delivery) error {
+	i := slices.IndexFunc(deliveries, func(stored Delivery) bool { return stored.ID == d.ID })
+	if i < 0 {
+		return ErrorNotFound
+	}
+	deliveries[i].Attempts = d.Attempts
+	deliveries[i].NextAttemptAt = d.NextAttemptAt
+	deliveries[i].LastError = d.LastError
+	deliveries[i].Abandoned = d.Abandoned
+	return nil
+}
+
+// DeleteWebhooks удаляет из списка webhooks вебхуки, для которых del возвращает true,
+// а из списка deliveries - их доставки. Возвращает оставшиеся вебхуки, доставки и количество удалённых вебхуков.
+func DeleteWebhooks(webhooks []Webhook, deliveries []Delivery, del func(w Webhook) bool) ([]Webhook, []Delivery, int) {
+	deleted := make(map[string]bool)
+	webhooks = slices.DeleteFunc(webhooks, func(w Webhook) bool {
+		if del(w) {
+			deleted[w.ID] = true
+			return true
+		}
+		return false
+	})
+	deliveries = slices.DeleteFunc(deliveries, func(d Delivery) bool { return deleted[d.WebhookID] })
+	return webhooks, deliveries, len(deleted)
+}
+
+// PurgeAbandoned удаляет из списка deliveries доставки, попытки которых исчерпаны,
+// а последняя попытка была раньше cutoff. Возвращает оставшиеся доставки и количество удалённых.
+func PurgeAbandoned(deliveries []Delivery, cutoff time.Time) ([]Delivery, int) {
+	n := len(deliveries)
+	deliveries = slices.DeleteFunc(deliveries, func(d Delivery) bool {
+		return d.Abandoned && d.NextAttemptAt.Before(cutoff)
+	})
+	return deliveries, n - len(deliveries)
+}
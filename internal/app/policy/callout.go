@@ -0,0 +1,107 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"go.uber.org/zap"
+)
+
+// defaultCalloutTimeout ограничивает время ожидания ответа внешнего сервиса проверки.
+const defaultCalloutTimeout = 2 * time.Second
+
+// CalloutIn представляет тело запроса к внешнему сервису проверки.
+type CalloutIn struct {
+	URL string `json:"url"` // проверяемый URL
+}
+
+// CalloutOut представляет ответ внешнего сервиса проверки.
+type CalloutOut struct {
+	Allowed bool   `json:"allowed"`          // разрешён ли URL
+	Reason  string `json:"reason,omitempty"` // причина отказа
+}
+
+// HTTPChecker проверяет URL, отправляя POST запрос во внешний сервис.
+// Сервис принимает CalloutIn и отвечает CalloutOut со статусом 200 OK.
+type HTTPChecker struct {
+	endpoint   string       // адрес сервиса проверки
+	client     *http.Client // HTTP-клиент с таймаутом
+	failClosed bool         // отклонять URL, если сервис недоступен
+}
+
+// NewHTTPChecker создает проверку через внешний сервис.
+// Если timeout равен нулю, используется значение по умолчанию.
+// Если failClosed установлен, ошибки обращения к сервису возвращаются вызывающему,
+// иначе URL пропускается, а ошибка только логируется.
+func NewHTTPChecker(endpoint string, timeout time.Duration, failClosed bool) *HTTPChecker {
+	if timeout == 0 {
+		timeout = defaultCalloutTimeout
+	}
+	return &HTTPChecker{
+		endpoint:   endpoint,
+		client:     &http.Client{Timeout: timeout},
+		failClosed: failClosed,
+	}
+}
+
+// Check отправляет URL во внешний сервис и возвращает отказ, если сервис его не разрешил.
+func (c *HTTPChecker) Check(ctx context.Context, u *url.URL) error {
+	out, err := c.call(ctx, u)
+	if err != nil {
+		if c.failClosed {
+			return err
+		}
+		zap.L().Sugar().Errorf("URL policy callout failed, allowing URL: %v", err)
+		return nil
+	}
+
+	if !out.Allowed {
+		if out.Reason == "" {
+			out.Reason = "rejected by policy service"
+		}
+		return &RejectedError{Reason: out.Reason}
+	}
+	return nil
+}
+
+// call выполняет запрос к внешнему сервису.
+func (c *HTTPChecker) call(ctx context.Context, u *url.URL) (CalloutOut, error) {
+	body, err := json.Marshal(CalloutIn{URL: u.String()})
+	if err != nil {
+		return CalloutOut{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return CalloutOut{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Передаём контекст трассировки сервису проверки
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return CalloutOut{}, fmt.Errorf("policy callout: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			zap.L().Sugar().Errorf("error closing response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return CalloutOut{}, fmt.Errorf("policy callout: unexpected status %d", resp.StatusCode)
+	}
+
+	var out CalloutOut
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return CalloutOut{}, fmt.Errorf("policy callout: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubServer запускает локальный сервис проверки, отклоняющий URL с подстрокой "phish".
+func newStubServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in CalloutIn
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		out := CalloutOut{Allowed: true}
+		if strings.Contains(in.URL, "phish") {
+			out = CalloutOut{Allowed: false, Reason: "known phishing"}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			t.Errorf("Error encoding response: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPChecker(t *testing.T) {
+	srv := newStubServer(t)
+	c := NewHTTPChecker(srv.URL, time.Second, true)
+
+	assert.NoError(t, check(t, c, "http://example.com"))
+
+	err := check(t, c, "http://phish.example.com")
+	require.True(t, IsRejected(err))
+	assert.Contains(t, err.Error(), "known phishing")
+}
+
+func TestHTTPChecker_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	failClosed := NewHTTPChecker(srv.URL, time.Second, true)
+	err := check(t, failClosed, "http://example.com")
+	assert.Error(t, err)
+	assert.False(t, IsRejected(err))
+
+	failOpen := NewHTTPChecker(srv.URL, time.Second, false)
+	assert.NoError(t, check(t, failOpen, "http://example.com"))
+}
+
+func TestHTTPChecker_PropagatesTraceContext(t *testing.T) {
+	tracing.SetupInMemory()
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(CalloutOut{Allowed: true}); err != nil {
+			t.Errorf("Error encoding response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, span := tracing.Start(context.Background(), "Shorten")
+	defer span.End()
+
+	u, err := url.Parse("http://example.com")
+	require.NoError(t, err)
+	require.NoError(t, NewHTTPChecker(srv.URL, time.Second, true).Check(ctx, u))
+	assert.Contains(t, traceparent, tracing.TraceID(ctx))
+}
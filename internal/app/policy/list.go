@@ -0,0 +1,217 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ListFile описывает формат JSON-файла со списками блокировки.
+// Хосты сравниваются без учёта регистра и покрывают все поддомены.
+// Регулярные выражения применяются к URL целиком.
+// Совпадение с разрешающим списком отменяет совпадение с блок-листом.
+type ListFile struct {
+	BlockHosts    []string `json:"block_hosts"`    // запрещённые хосты
+	BlockPatterns []string `json:"block_patterns"` // запрещённые шаблоны URL
+	AllowHosts    []string `json:"allow_hosts"`    // хосты-исключения из блок-листа
+	AllowPatterns []string `json:"allow_patterns"` // шаблоны-исключения из блок-листа
+}
+
+// rules представляет скомпилированные списки блокировки.
+type rules struct {
+	blockHosts    []string
+	blockPatterns []*regexp.Regexp
+	allowHosts    []string
+	allowPatterns []*regexp.Regexp
+}
+
+// ListChecker проверяет URL по спискам хостов и регулярных выражений из файла.
+// Периодически перечитывает файл при изменении времени модификации.
+type ListChecker struct {
+	path    string        // путь к файлу со списками
+	mu      sync.RWMutex  // защищает rules и modTime
+	rules   rules         // действующие правила
+	modTime time.Time     // время модификации загруженного файла
+	done    chan struct{} // закрывается при остановке перезагрузки
+	once    sync.Once     // защищает от повторного закрытия done
+}
+
+// NewListChecker загружает списки из файла и, если interval больше нуля,
+// запускает фоновую перезагрузку файла с указанным интервалом.
+// Возвращает ошибку, если файл не удалось прочитать или разобрать.
+func NewListChecker(path string, interval time.Duration) (*ListChecker, error) {
+	c := &ListChecker{
+		path: path,
+		done: make(chan struct{}),
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go c.watch(interval)
+	}
+
+	return c, nil
+}
+
+// Check отклоняет URL, совпадающий с блок-листом и не совпадающий с разрешающим списком.
+func (c *ListChecker) Check(ctx context.Context, u *url.URL) error {
+	c.mu.RLock()
+	r := c.rules
+	c.mu.RUnlock()
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	raw := u.String()
+
+	if matchHost(r.allowHosts, host) || matchPattern(r.allowPatterns, raw) {
+		return nil
+	}
+	if matchHost(r.blockHosts, host) {
+		return Reject("host %q is blocked", host)
+	}
+	if matchPattern(r.blockPatterns, raw) {
+		return Reject("URL matches a blocked pattern")
+	}
+	return nil
+}
+
+// Reload перечитывает файл со списками.
+// При ошибке действующие правила не изменяются.
+func (c *ListChecker) Reload() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	var lf ListFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return fmt.Errorf("parse policy file: %w", err)
+	}
+
+	r, err := compile(lf)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.rules = r
+	c.modTime = info.ModTime()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Close останавливает фоновую перезагрузку файла.
+func (c *ListChecker) Close() error {
+	c.once.Do(func() { close(c.done) })
+	return nil
+}
+
+// watch перечитывает файл, если время его модификации изменилось.
+func (c *ListChecker) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(c.path)
+			if err != nil {
+				zap.L().Sugar().Errorf("cannot stat policy file: %v", err)
+				continue
+			}
+
+			c.mu.RLock()
+			changed := !info.ModTime().Equal(c.modTime)
+			c.mu.RUnlock()
+
+			if !changed {
+				continue
+			}
+			if err := c.Reload(); err != nil {
+				zap.L().Sugar().Errorf("cannot reload policy file, keeping previous rules: %v", err)
+				continue
+			}
+			zap.L().Sugar().Infoln("policy file reloaded:", c.path)
+		}
+	}
+}
+
+// compile нормализует хосты и компилирует регулярные выражения.
+func compile(lf ListFile) (rules, error) {
+	var r rules
+	var err error
+
+	r.blockHosts = normalizeHosts(lf.BlockHosts)
+	r.allowHosts = normalizeHosts(lf.AllowHosts)
+
+	if r.blockPatterns, err = compilePatterns(lf.BlockPatterns); err != nil {
+		return rules{}, err
+	}
+	if r.allowPatterns, err = compilePatterns(lf.AllowPatterns); err != nil {
+		return rules{}, err
+	}
+
+	return r, nil
+}
+
+// normalizeHosts приводит хосты к нижнему регистру и отбрасывает пустые значения.
+func normalizeHosts(hosts []string) []string {
+	out := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		h = strings.ToLower(strings.Trim(strings.TrimSpace(h), "."))
+		if h != "" {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// compilePatterns компилирует список регулярных выражений.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// matchHost проверяет совпадение хоста или его родительского домена со списком.
+func matchHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern проверяет совпадение URL хотя бы с одним шаблоном.
+func matchPattern(patterns []*regexp.Regexp, raw string) bool {
+	for _, re := range patterns {
+		if re.MatchString(raw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePolicyFile(t *testing.T, path string, content string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestListChecker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicyFile(t, path, `{
+		"block_hosts": ["evil.com", "Phish.Example."],
+		"block_patterns": ["^https?://[^/]+/login\\.php"],
+		"allow_hosts": ["good.evil.com"]
+	}`, time.Now())
+
+	c, err := NewListChecker(path, 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		url      string
+		rejected bool
+	}{
+		{url: "http://example.com", rejected: false},
+		{url: "http://evil.com/x", rejected: true},
+		{url: "https://www.evil.com/", rejected: true},
+		{url: "https://notevil.com/", rejected: false},
+		{url: "https://good.evil.com/", rejected: false},
+		{url: "http://phish.example/", rejected: true},
+		{url: "http://bank.example/login.php?a=1", rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := check(t, c, tt.url)
+			assert.Equal(t, tt.rejected, IsRejected(err), err)
+		})
+	}
+}
+
+func TestListChecker_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewListChecker(filepath.Join(dir, "missing.json"), 0)
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "bad.json")
+	writePolicyFile(t, path, `{"block_patterns": ["("]}`, time.Now())
+	_, err = NewListChecker(path, 0)
+	assert.Error(t, err)
+}
+
+func TestListChecker_HotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	start := time.Now().Add(-time.Hour)
+	writePolicyFile(t, path, `{"block_hosts": ["evil.com"]}`, start)
+
+	c, err := NewListChecker(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, c.Close())
+	}()
+
+	assert.True(t, IsRejected(check(t, c, "http://evil.com")))
+	assert.False(t, IsRejected(check(t, c, "http://bad.org")))
+
+	// Невалидный файл не должен сбрасывать действующие правила
+	writePolicyFile(t, path, `{"block_hosts": [`, start.Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, IsRejected(check(t, c, "http://evil.com")))
+
+	writePolicyFile(t, path, `{"block_hosts": ["bad.org"]}`, start.Add(2*time.Minute))
+	assert.Eventually(t, func() bool {
+		return IsRejected(check(t, c, "http://bad.org")) && !IsRejected(check(t, c, "http://evil.com"))
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,165 @@
+// Package policy предоставляет проверку URL перед сокращением.
+// Позволяет отклонять ссылки с недопустимыми схемами, ведущие на приватные
+// и loopback адреса, попадающие в блок-листы или отклонённые внешним сервисом проверки.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Checker определяет интерфейс проверки URL перед сохранением.
+type Checker interface {
+	// Check проверяет URL. Возвращает *RejectedError, если URL запрещён политикой,
+	// или другую ошибку, если проверку выполнить не удалось.
+	Check(ctx context.Context, u *url.URL) error
+}
+
+// CheckerFunc позволяет использовать обычную функцию в качестве Checker.
+type CheckerFunc func(ctx context.Context, u *url.URL) error
+
+// Check вызывает f(ctx, u).
+func (f CheckerFunc) Check(ctx context.Context, u *url.URL) error {
+	return f(ctx, u)
+}
+
+// RejectedError возвращается, когда URL запрещён политикой.
+type RejectedError struct {
+	Reason string // причина отказа
+}
+
+// Error возвращает текстовое описание причины отказа.
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("URL is not allowed: %s", e.Reason)
+}
+
+// Reject создает ошибку отказа с указанной причиной.
+func Reject(format string, args ...any) error {
+	return &RejectedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// IsRejected сообщает, является ли ошибка отказом политики.
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// chain последовательно применяет несколько проверок.
+type chain []Checker
+
+// Chain объединяет проверки в одну. Проверки выполняются по порядку
+// до первой ошибки. Nil-проверки пропускаются.
+func Chain(checkers ...Checker) Checker {
+	c := make(chain, 0, len(checkers))
+	for _, checker := range checkers {
+		if checker != nil {
+			c = append(c, checker)
+		}
+	}
+	return c
+}
+
+// Check применяет все проверки цепочки по порядку.
+func (c chain) Check(ctx context.Context, u *url.URL) error {
+	for _, checker := range c {
+		if err := checker.Check(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemeChecker разрешает только URL со схемами http и https.
+var SchemeChecker Checker = CheckerFunc(func(ctx context.Context, u *url.URL) error {
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return nil
+	default:
+		return Reject("scheme %q is not supported", u.Scheme)
+	}
+})
+
+// Resolver разрешает имя хоста в IP-адреса.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ResolverFunc позволяет использовать обычную функцию в качестве Resolver.
+type ResolverFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+// LookupIPAddr вызывает f(ctx, host).
+func (f ResolverFunc) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return f(ctx, host)
+}
+
+// blockedNetworks - диапазоны адресов, не входящие в стандартные проверки net.IP:
+// "эта сеть" 0.0.0.0/8 и общее адресное пространство операторов (CGNAT) 100.64.0.0/10.
+var blockedNetworks = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+}
+
+// IsBlockedIP сообщает, что адрес ip недоступен для ссылок и вебхуков:
+// loopback, приватный, link-local, неопределённый, из сети 0.0.0.0/8 или CGNAT 100.64.0.0/10.
+// IPv4-адреса, записанные как IPv6, проверяются как IPv4.
+func IsBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPrivateAddressChecker создает проверку, запрещающую URL, указывающие на localhost,
+// а также хосты, среди адресов которых есть запрещённые IsBlockedIP.
+// Имя хоста разрешается resolver с учётом контекста проверки; nil - net.DefaultResolver.
+// Хост, который не существует, отклоняется; ошибка разрешения имени возвращается вызывающему.
+func NewPrivateAddressChecker(resolver Resolver) Checker {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return CheckerFunc(func(ctx context.Context, u *url.URL) error {
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		if host == "" {
+			return Reject("host is empty")
+		}
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return Reject("host %q is a loopback address", host)
+		}
+
+		if ip := net.ParseIP(host); ip != nil {
+			if IsBlockedIP(ip) {
+				return Reject("host %q is a private address", host)
+			}
+			return nil
+		}
+
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return Reject("host %q cannot be resolved", host)
+		}
+		if err != nil {
+			return fmt.Errorf("resolve host %q: %w", host, err)
+		}
+		for _, addr := range addrs {
+			if IsBlockedIP(addr.IP) {
+				return Reject("host %q resolves to a private address", host)
+			}
+		}
+		return nil
+	})
+}
+
+// PrivateAddressChecker запрещает URL, указывающие на localhost, на запрещённые IsBlockedIP адреса
+// и на хосты, разрешающиеся в такие адреса через net.DefaultResolver.
+var PrivateAddressChecker = NewPrivateAddressChecker(nil)
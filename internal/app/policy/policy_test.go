@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func check(t *testing.T, c Checker, raw string) error {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return c.Check(context.Background(), u)
+}
+
+func TestSchemeChecker(t *testing.T) {
+	assert.NoError(t, check(t, SchemeChecker, "http://example.com"))
+	assert.NoError(t, check(t, SchemeChecker, "HTTPS://example.com"))
+	assert.True(t, IsRejected(check(t, SchemeChecker, "ftp://example.com")))
+	assert.True(t, IsRejected(check(t, SchemeChecker, "javascript:alert(1)")))
+}
+
+// staticResolver разрешает имена хостов по таблице; отсутствующие имена не существуют.
+func staticResolver(hosts map[string][]string) Resolver {
+	return ResolverFunc(func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "dns-failure.test" {
+			return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+		}
+		ips, ok := hosts[host]
+		if !ok {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		addrs := make([]net.IPAddr, 0, len(ips))
+		for _, ip := range ips {
+			addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		return addrs, nil
+	})
+}
+
+func TestPrivateAddressChecker(t *testing.T) {
+	checker := NewPrivateAddressChecker(staticResolver(map[string][]string{
+		"example.com":       {"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"},
+		"internal.test":     {"10.0.0.5"},
+		"metadata.test":     {"169.254.169.254"},
+		"loopback.test":     {"127.0.0.1"},
+		"mixed.test":        {"93.184.215.14", "192.168.1.1"},
+		"cgnat.test":        {"100.64.1.1"},
+		"this-network.test": {"0.1.2.3"},
+	}))
+
+	tests := []struct {
+		url      string
+		rejected bool
+		err      bool // проверку выполнить не удалось
+	}{
+		{url: "http://example.com", rejected: false},
+		{url: "http://8.8.8.8/", rejected: false},
+		{url: "http://100.128.0.1/", rejected: false},
+		{url: "http://localhost:8080/", rejected: true},
+		{url: "http://app.localhost/", rejected: true},
+		{url: "http://127.0.0.1/", rejected: true},
+		{url: "http://10.1.2.3/", rejected: true},
+		{url: "http://192.168.0.1/", rejected: true},
+		{url: "http://169.254.169.254/latest/meta-data", rejected: true},
+		{url: "http://0.0.0.0/", rejected: true},
+		{url: "http://0.1.2.3/", rejected: true},
+		{url: "http://100.64.0.1/", rejected: true},
+		{url: "http://100.127.255.254/", rejected: true},
+		{url: "http://[::1]:80/", rejected: true},
+		{url: "http://[fd00::1]/", rejected: true},
+		{url: "http://[::ffff:127.0.0.1]/", rejected: true},
+		{url: "http://internal.test/", rejected: true},
+		{url: "http://metadata.test/latest/meta-data", rejected: true},
+		{url: "http://loopback.test:8080/", rejected: true},
+		{url: "http://mixed.test/", rejected: true},
+		{url: "http://cgnat.test/", rejected: true},
+		{url: "http://this-network.test/", rejected: true},
+		{url: "http://missing.test/", rejected: true},
+		{url: "http://dns-failure.test/", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := check(t, checker, tt.url)
+			assert.Equal(t, tt.rejected, IsRejected(err), err)
+			if tt.err {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestPrivateAddressChecker_HonoursContext(t *testing.T) {
+	checker := NewPrivateAddressChecker(ResolverFunc(func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	u, err := url.Parse("http://example.com")
+	require.NoError(t, err)
+	err = checker.Check(ctx, u)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, IsRejected(err))
+}
+
+func TestChain(t *testing.T) {
+	calls := 0
+	counting := CheckerFunc(func(ctx context.Context, u *url.URL) error {
+		calls++
+		return nil
+	})
+
+	c := Chain(SchemeChecker, nil, counting)
+	assert.NoError(t, check(t, c, "http://example.com"))
+	assert.Equal(t, 1, calls)
+
+	assert.True(t, IsRejected(check(t, c, "ftp://example.com")))
+	assert.Equal(t, 1, calls, "chain must stop on first rejection")
+
+	failing := CheckerFunc(func(ctx context.Context, u *url.URL) error {
+		return errors.New("boom")
+	})
+	err := check(t, Chain(failing), "http://example.com")
+	assert.Error(t, err)
+	assert.False(t, IsRejected(err))
+}
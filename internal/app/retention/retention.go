@@ -0,0 +1,87 @@
+// Package retention предоставляет фоновое безвозвратное удаление записей по истечении срока хранения.
+// Записи, удалённые пользователями или истёкшие, хранятся заданный срок, чтобы модератор
+// мог их восстановить, после чего удаляются из хранилища вместе с историей версий.
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iubondar/url-shortener/internal/metrics"
+)
+
+// DefaultInterval задает период запуска удаления устаревших записей.
+const DefaultInterval = time.Hour
+
+// Purger безвозвратно удаляет записи, удалённые пользователями или истёкшие раньше cutoff.
+type Purger interface {
+	PurgeExpired(ctx context.Context, cutoff time.Time) (purged int, err error)
+}
+
+// Job периодически удаляет записи, срок хранения которых истёк.
+// Job нужно остановить методом Close до закрытия хранилища.
+type Job struct {
+	purger Purger
+	period time.Duration      // срок хранения удалённых и истёкших записей
+	cancel context.CancelFunc // прерывает выполняющееся удаление при остановке
+	done   chan struct{}      // закрывается после остановки
+	once   sync.Once
+}
+
+// Start запускает удаление записей хранилища purger, удалённых или истёкших более period назад:
+// сразу и далее с интервалом interval.
+func Start(purger Purger, period time.Duration, interval time.Duration) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &Job{
+		purger: purger,
+		period: period,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go j.run(ctx, interval)
+	return j
+}
+
+// RunOnce удаляет записи, срок хранения которых истёк к текущему моменту.
+// Возвращает количество удалённых записей.
+func (j *Job) RunOnce(ctx context.Context) (purged int, err error) {
+	purged, err = j.purger.PurgeExpired(ctx, time.Now().UTC().Add(-j.period))
+	metrics.RetentionPurged.Add(float64(purged))
+	return purged, err
+}
+
+// run удаляет устаревшие записи с интервалом interval, пока задача не остановлена.
+func (j *Job) run(ctx context.Context, interval time.Duration) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := j.RunOnce(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			// записи, которые не удалось удалить, будут удалены при следующем запуске
+			zap.L().Sugar().Errorw("Cannot purge expired URLs, will retry", "purged", purged, "error", err)
+		case purged > 0:
+			zap.L().Sugar().Infow("Purged URLs after retention period", "purged", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close прерывает выполняющееся удаление и останавливает задачу. Повторные вызовы безопасны.
+func (j *Job) Close() error {
+	j.once.Do(func() {
+		j.cancel()
+		<-j.done
+	})
+	return nil
+}
@@ -0,0 +1,84 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+)
+
+// purgeRecorder запоминает границы сроков хранения, с которыми вызывалось удаление.
+type purgeRecorder struct {
+	mu      sync.Mutex
+	cutoffs []time.Time
+	err     error
+}
+
+func (r *purgeRecorder) PurgeExpired(ctx context.Context, cutoff time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cutoffs = append(r.cutoffs, cutoff)
+	return 0, r.err
+}
+
+func (r *purgeRecorder) calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cutoffs)
+}
+
+func TestJob_RunOnce(t *testing.T) {
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+	recent := now.Add(-time.Hour)
+	userID := uuid.New()
+	repo := &simple_storage.SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "old", UserID: userID, IsDeleted: true, DeletedAt: &old},
+			{ShortURL: "recent", UserID: userID, IsDeleted: true, DeletedAt: &recent},
+			{ShortURL: "expired", UserID: userID, ExpiresAt: &old},
+			{ShortURL: "active", UserID: userID, ExpiresAt: &recent},
+			{ShortURL: "kept", UserID: userID},
+		},
+		Revisions: []models.Revision{{ShortURL: "old", Revision: 1}, {ShortURL: "kept", Revision: 1}},
+	}
+
+	j := &Job{purger: repo, period: 24 * time.Hour}
+	purged, err := j.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	var left []string
+	for _, r := range repo.Records {
+		left = append(left, r.ShortURL)
+	}
+	assert.Equal(t, []string{"recent", "active", "kept"}, left)
+	assert.Equal(t, []models.Revision{{ShortURL: "kept", Revision: 1}}, repo.Revisions)
+}
+
+func TestJob_Run(t *testing.T) {
+	recorder := &purgeRecorder{err: errors.New("connection refused")}
+	j := Start(recorder, time.Hour, 10*time.Millisecond)
+
+	// ошибка удаления не останавливает задачу
+	assert.Eventually(t, func() bool { return recorder.calls() >= 2 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, j.Close())
+	require.NoError(t, j.Close())
+
+	calls := recorder.calls()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, calls, recorder.calls())
+
+	// граница срока хранения отстоит от момента запуска на period
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), recorder.cutoffs[0], time.Second)
+}
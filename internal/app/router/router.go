@@ -5,37 +5,132 @@ package router
 import (
 	"net/http"
 	"net/http/pprof"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/iubondar/url-shortener/internal/api/handlers"
+	"github.com/iubondar/url-shortener/internal/api/openapi"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/app/config"
 	"github.com/iubondar/url-shortener/internal/compress"
+	"github.com/iubondar/url-shortener/internal/limits"
 	"github.com/iubondar/url-shortener/internal/logging"
+	"github.com/iubondar/url-shortener/internal/metrics"
+	"github.com/iubondar/url-shortener/internal/ratelimit"
+	"github.com/iubondar/url-shortener/internal/tracing"
 )
 
+// revocationCacheTTL - срок, на который запоминается, что токены пользователя не отозваны.
+const revocationCacheTTL = 5 * time.Second
+
 // NewRouter создает и настраивает маршрутизатор для обработки HTTP-запросов.
-// Принимает фабрику хендлеров для создания обработчиков запросов.
+// Принимает фабрику хендлеров для создания обработчиков запросов и конфигурацию приложения.
 // Настраивает все необходимые маршруты и middleware:
+//   - Трассировка запросов с передачей контекста в заголовке traceparent
 //   - Логирование запросов
-//   - Сжатие ответов
+//   - Сбор метрик запросов и эндпоинт /metrics, если метрики не вынесены на отдельный адрес
+//   - Сжатие ответов (br, zstd, gzip, deflate) и распаковка запросов
+//   - Отказ в приёме токенов пользователей, удаливших учётную запись, на маршрутах /api/user и /api/v2
+//   - Ограничение размера тела запросов для обычных и пакетных маршрутов
+//   - Ограничение частоты создания ссылок, пакетного создания и переходов
+//   - Проверка JSON-тел запросов по схемам OpenAPI и отдача спецификации /api/openapi.json
 //   - Обработка создания коротких ссылок
 //   - Обработка пакетного создания ссылок
 //   - Получение списка ссылок пользователя
 //   - Получение оригинального URL по короткому идентификатору
 //   - Проверка доступности хранилища
-//   - Удаление ссылок пользователя
+//   - Удаление ссылок пользователя и состояние задач удаления
+//   - Удаление учётной записи пользователя вместе со всеми его ссылками
+//   - Регистрация, просмотр и удаление вебхуков для событий ссылок пользователя
+//   - API v2 под /api/v2: получение, изменение и удаление ссылки как ресурса
+//   - API модерации под /api/admin, если задан токен модератора
 //
 // Возвращает настроенный маршрутизатор и ошибку, если она возникла.
-func NewRouter(factory handlers.HandlerFactory) (chi.Router, error) {
+func NewRouter(factory handlers.HandlerFactory, config config.Config) (chi.Router, error) {
 	r := chi.NewRouter()
 
-	r.Use(logging.WithLogging, compress.WithGzipCompression)
-	r.Post("/", factory.CreateIDHandler().CreateID)
-	r.Post("/api/shorten", factory.ShortenHandler().Shorten)
-	r.Post("/api/shorten/batch", factory.ShortenBatchHandler().ShortenBatch)
-	r.Get("/api/user/urls", factory.UserUrlsHandler().RetrieveUserURLs)
-	r.Get("/{id}", factory.RetrieveURLHandler().RetrieveURL)
-	r.Get("/ping", factory.PingHandler().Ping)
-	r.Delete("/api/user/urls", factory.DeleteUrlsHandler().DeleteUserURLs)
+	r.Use(tracing.WithTracing, logging.WithLogging, metrics.WithMetrics, compress.New(compress.Options{
+		MaxDecompressedSize: config.MaxDecompressedSize,
+		MaxCompressionRatio: config.MaxCompressionRatio,
+	}))
+
+	spec, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Ограничения размера тела запроса
+	bodyLimit := limits.Body(config.MaxBodySize)
+	batchBodyLimit := limits.Body(config.MaxBatchBodySize)
+	if config.MaxBatchBodySize == 0 {
+		batchBodyLimit = limits.Body(limits.DefaultBatchBodySize)
+	}
+
+	// Ограничители частоты запросов используют общее хранилище корзин
+	store := ratelimit.NewMemoryStore()
+	createLimit := rateLimit("create", store, config.RateLimitCreate, config.RateLimitCreateBurst, nil)
+	batchLimit := rateLimit("batch", store, config.RateLimitBatch, config.RateLimitBatchBurst, ratelimit.JSONArrayCost)
+	// импорт расходует ту же корзину, что и пакетное сокращение, по токену за строку
+	importLimit := rateLimit("batch", store, config.RateLimitBatch, config.RateLimitBatchBurst, ratelimit.RecordsCost)
+	redirectLimit := rateLimit("redirect", store, config.RateLimitRedirect, config.RateLimitRedirectBurst, nil)
+
+	r.With(bodyLimit).With(createLimit...).Post("/", tracing.Handler("CreateID", factory.CreateIDHandler().CreateID))
+	r.With(bodyLimit).With(createLimit...).With(spec.Validate).Post("/api/shorten", tracing.Handler("Shorten", factory.ShortenHandler().Shorten))
+	r.With(batchBodyLimit).With(batchLimit...).With(spec.Validate).Post("/api/shorten/batch", tracing.Handler("ShortenBatch", factory.ShortenBatchHandler().ShortenBatch))
+	r.With(redirectLimit...).Get("/{id}", tracing.Handler("RetrieveURL", factory.RetrieveURLHandler().RetrieveURL))
+	r.Get("/ping", tracing.Handler("Ping", factory.PingHandler().Ping))
+	r.Get("/api/openapi.json", tracing.Handler("OpenAPI", spec.ServeHTTP))
+
+	// Маршруты, работающие с данными пользователя, не принимают отозванные токены
+	r.Group(func(r chi.Router) {
+		r.Use(auth.WithRevocation(factory.RevocationChecker(), revocationCacheTTL))
+
+		r.Get("/api/user/urls", tracing.Handler("RetrieveUserURLs", factory.UserUrlsHandler().RetrieveUserURLs))
+		r.With(batchBodyLimit, spec.Validate).Delete("/api/user/urls", tracing.Handler("DeleteUserURLs", factory.DeleteUrlsHandler().DeleteUserURLs))
+		r.Get("/api/user/deletions/{job}", tracing.Handler("RetrieveDeletionJob", factory.DeleteUrlsHandler().RetrieveDeletionJob))
+		r.Delete("/api/user", tracing.Handler("DeleteAccount", factory.AccountHandler().DeleteAccount))
+
+		// Вебхуки для событий ссылок пользователя
+		webhooks := factory.WebhooksHandler()
+		r.With(bodyLimit, spec.Validate).Post("/api/user/webhooks", tracing.Handler("CreateWebhook", webhooks.CreateWebhook))
+		r.Get("/api/user/webhooks", tracing.Handler("ListWebhooks", webhooks.ListWebhooks))
+		r.Delete("/api/user/webhooks/{id}", tracing.Handler("DeleteWebhook", webhooks.DeleteWebhook))
+
+		// Экспорт и импорт ссылок пользователя
+		transfer := factory.TransferHandler()
+		r.Get("/api/user/urls/export", tracing.Handler("ExportUserURLs", transfer.Export))
+		r.With(batchBodyLimit).With(importLimit...).Post("/api/user/urls/import", tracing.Handler("ImportUserURLs", transfer.Import))
+
+		// Изменение целевого адреса ссылок пользователя
+		edit := factory.EditURLHandler()
+		r.With(bodyLimit).With(createLimit...).With(spec.Validate).Patch("/api/user/urls/{id}", tracing.Handler("UpdateUserURL", edit.UpdateURL))
+		r.Get("/api/user/urls/{id}/revisions", tracing.Handler("RetrieveURLRevisions", edit.RetrieveRevisions))
+		r.With(createLimit...).Post("/api/user/urls/{id}/revisions/{revision}/rollback", tracing.Handler("RollbackUserURL", edit.Rollback))
+
+		// API v2: ссылки как ресурсы
+		links := factory.LinksHandler()
+		r.Get("/api/v2/links/{id}", tracing.Handler("GetLink", links.GetLink))
+		r.With(bodyLimit, spec.Validate).Patch("/api/v2/links/{id}", tracing.Handler("UpdateLink", links.UpdateLink))
+		r.Delete("/api/v2/links/{id}", tracing.Handler("DeleteLink", links.DeleteLink))
+	})
+
+	// API модерации доступно только при заданном токене
+	if len(config.AdminToken) > 0 {
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Use(auth.WithAdminToken(config.AdminToken), bodyLimit)
+			admin := factory.AdminHandler()
+			r.Get("/urls", tracing.Handler("SearchURLs", admin.SearchURLs))
+			r.With(spec.Validate).Post("/urls/{id}/disable", tracing.Handler("DisableURL", admin.DisableURL))
+			r.Post("/urls/{id}/restore", tracing.Handler("RestoreURL", admin.RestoreURL))
+			r.Delete("/urls/{id}", tracing.Handler("PurgeURL", admin.PurgeURL))
+			r.Get("/audit", tracing.Handler("RetrieveAuditLog", admin.RetrieveAuditLog))
+		})
+	}
+
+	// Метрики отдаются основным сервером, если не задан отдельный адрес
+	if len(config.MetricsAddress) == 0 {
+		r.Method(http.MethodGet, "/metrics", metrics.Handler())
+	}
 
 	// Подключаем pprof
 	r.Mount("/debug/pprof", pprofRouter())
@@ -43,6 +138,21 @@ func NewRouter(factory handlers.HandlerFactory) (chi.Router, error) {
 	return r, nil
 }
 
+// rateLimit возвращает middleware ограничения частоты запросов для группы маршрутов
+// или пустой список, если ограничение не задано.
+func rateLimit(name string, store ratelimit.Store, rate float64, burst int, cost ratelimit.CostFunc) []func(http.Handler) http.Handler {
+	limit := ratelimit.Limit{Rate: rate, Burst: burst}
+	if !limit.Enabled() {
+		return nil
+	}
+
+	limiter := ratelimit.NewLimiter(name, store, limit)
+	if cost != nil {
+		limiter.WithCost(cost)
+	}
+	return []func(http.Handler) http.Handler{limiter.Middleware}
+}
+
 // pprofRouter возвращает роутер с pprof-эндпоинтами
 func pprofRouter() http.Handler {
 	r := chi.NewRouter()
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/api/handlers"
+	"github.com/iubondar/url-shortener/internal/api/openapi"
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/limits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewRouter_OpenAPIRoutes проверяет, что маршруты роутера и операции спецификации OpenAPI совпадают.
+// Маршруты pprof служебные и в спецификацию не входят.
+func TestNewRouter_OpenAPIRoutes(t *testing.T) {
+	factory := handlers.NewFactory(config.Config{AdminToken: "secret"})
+	defer func() {
+		require.NoError(t, factory.Close())
+	}()
+	r, err := NewRouter(factory, config.Config{AdminToken: "secret"})
+	require.NoError(t, err)
+
+	var routes []string
+	err = chi.Walk(r, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, "/debug/pprof") {
+			routes = append(routes, method+" "+route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(routes)
+
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+	var operations []string
+	for path, item := range spec.Paths {
+		for method := range item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+
+	assert.Equal(t, operations, routes)
+}
+
+// TestOpenAPISchemas проверяет, что поля схем спецификации совпадают с JSON-полями структур обработчиков.
+func TestOpenAPISchemas(t *testing.T) {
+	types := map[string]reflect.Type{
+		"ShortenIn":          reflect.TypeOf(handlers.ShortenIn{}),
+		"ShortenOut":         reflect.TypeOf(handlers.ShortenOut{}),
+		"ShortenBatchIn":     reflect.TypeOf(handlers.ShortenBatchIn{}),
+		"ShortenBatchOut":    reflect.TypeOf(handlers.ShortenBatchOut{}),
+		"UserUrlsOut":        reflect.TypeOf(handlers.UserUrlsOut{}),
+		"AdminURLOut":        reflect.TypeOf(handlers.AdminURLOut{}),
+		"DisableIn":          reflect.TypeOf(handlers.DisableIn{}),
+		"EditURLIn":          reflect.TypeOf(handlers.EditURLIn{}),
+		"RevisionOut":        reflect.TypeOf(handlers.RevisionOut{}),
+		"TransferRecord":     reflect.TypeOf(handlers.TransferRecord{}),
+		"ImportRowOut":       reflect.TypeOf(handlers.ImportRowOut{}),
+		"ImportOut":          reflect.TypeOf(handlers.ImportOut{}),
+		"PingOut":            reflect.TypeOf(handlers.PingOut{}),
+		"LinkOut":            reflect.TypeOf(handlers.LinkOut{}),
+		"LinkPatchIn":        reflect.TypeOf(handlers.LinkPatchIn{}),
+		"DeletionJobOut":     reflect.TypeOf(handlers.DeletionJobOut{}),
+		"DeletionItem":       reflect.TypeOf(models.DeletionItem{}),
+		"AccountDeletionOut": reflect.TypeOf(handlers.AccountDeletionOut{}),
+		"WebhookIn":          reflect.TypeOf(handlers.WebhookIn{}),
+		"WebhookOut":         reflect.TypeOf(handlers.WebhookOut{}),
+		"WebhookEvent":       reflect.TypeOf(models.Event{}),
+		"AuditEntry":         reflect.TypeOf(models.AuditEntry{}),
+		"Problem":            reflect.TypeOf(apierror.Problem{}),
+		"ValidationProblem":  reflect.TypeOf(openapi.ValidationProblem{}),
+		"FieldError":         reflect.TypeOf(openapi.FieldError{}),
+		"TooLargeProblem":    reflect.TypeOf(limits.Problem{}),
+	}
+
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	for name := range spec.Components.Schemas {
+		assert.Contains(t, types, name, "schema %s has no Go type in test", name)
+	}
+	for name, typ := range types {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := spec.Components.Schemas[name]
+			require.True(t, ok, "schema %s is missing in OpenAPI document", name)
+			assert.ElementsMatch(t, jsonFields(typ), schemaFields(spec, schema))
+		})
+	}
+}
+
+// jsonFields возвращает имена JSON-полей структуры с учётом встроенных структур.
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// schemaFields возвращает имена полей схемы объекта с учётом allOf.
+func schemaFields(spec *openapi.Spec, schema *openapi.Schema) []string {
+	schema = spec.Resolve(schema)
+	var fields []string
+	for _, sub := range schema.AllOf {
+		fields = append(fields, schemaFields(spec, sub)...)
+	}
+	for name := range schema.Properties {
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// TestNewRouter_Flush проверяет, что Flush обработчика проходит через все middleware роутера
+// и клиент получает начало ответа до завершения обработчика.
+func TestNewRouter_Flush(t *testing.T) {
+	factory := handlers.NewFactory(config.Config{})
+	defer func() {
+		require.NoError(t, factory.Close())
+	}()
+	r, err := NewRouter(factory, config.Config{})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	r.Get("/test/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "first\n")
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = io.WriteString(w, "second\n")
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, encoding := range []string{"identity", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, srv.URL+"/test/stream", nil)
+			require.NoError(t, err)
+			request.Header.Set("Accept-Encoding", encoding)
+			resp, err := http.DefaultClient.Do(request)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Errorf("Error closing response body: %v", err)
+				}
+			}()
+
+			body := io.Reader(resp.Body)
+			if encoding == "gzip" {
+				require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+				zr, err := gzip.NewReader(resp.Body)
+				require.NoError(t, err)
+				body = zr
+			}
+
+			// первая строка приходит, пока обработчик ждёт release
+			line, err := bufio.NewReader(body).ReadString('\n')
+			require.NoError(t, err)
+			assert.Equal(t, "first\n", line)
+			release <- struct{}{}
+		})
+	}
+}
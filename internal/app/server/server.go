@@ -15,13 +15,15 @@ import (
 	"golang.org/x/crypto/acme/autocert"
 
 	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/iubondar/url-shortener/internal/metrics"
 )
 
 // Server представляет HTTP/HTTPS сервер приложения.
 type Server struct {
-	config config.Config
-	router http.Handler
-	server *http.Server
+	config        config.Config
+	router        http.Handler
+	server        *http.Server
+	metricsServer *http.Server // отдельный сервер метрик, если задан MetricsAddress
 }
 
 // New создает новый экземпляр Server.
@@ -36,12 +38,26 @@ func New(config config.Config, router http.Handler) *Server {
 // Start запускает HTTP или HTTPS сервер в отдельной горутине.
 // Если EnableHTTPS=true, запускается HTTPS сервер с автоматическим получением сертификатов
 // или использованием локальных сертификатов для localhost/IP.
+// Если задан MetricsAddress, дополнительно запускает сервер метрик на этом адресе.
 // Возвращает ошибку, если сервер завершился с ошибкой.
 func (s *Server) Start() error {
 	zap.L().Sugar().Debugln("Starting serving requests: ", s.config.ServerAddress)
 
 	// Канал для обработки ошибок сервера
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, 3)
+
+	if len(s.config.MetricsAddress) > 0 {
+		s.metricsServer = &http.Server{
+			Addr:    s.config.MetricsAddress,
+			Handler: metrics.Handler(),
+		}
+		go func() {
+			zap.L().Sugar().Debugln("Starting serving metrics: ", s.config.MetricsAddress)
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serverErrors <- err
+			}
+		}()
+	}
 
 	// Запускаем сервер в отдельной горутине
 	go func() {
@@ -75,6 +91,12 @@ func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			zap.L().Error("metrics server shutdown did not complete", zap.Error(err))
+		}
+	}
+
 	// Пытаемся корректно завершить работу сервера
 	if err := s.server.Shutdown(ctx); err != nil {
 		zap.L().Error("graceful shutdown did not complete", zap.Error(err))
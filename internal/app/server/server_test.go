@@ -1,6 +1,7 @@
 package server
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/iubondar/url-shortener/internal/app/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -153,3 +155,40 @@ func TestServerHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "test response", w.Body.String())
 }
+
+func TestServerMetricsListener(t *testing.T) {
+	// Инициализируем логгер для тестов
+	logger, _ := zap.NewDevelopment()
+	zap.ReplaceGlobals(logger)
+
+	// Подбираем свободный порт для сервера метрик
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	metricsAddress := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	cfg := config.Config{
+		ServerAddress:  ":0",
+		BaseURLAddress: "http://localhost",
+		MetricsAddress: metricsAddress,
+	}
+	server := New(cfg, http.NewServeMux())
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Start()
+	}()
+
+	// Даем серверу время на запуск
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://" + metricsAddress + "/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	assert.NoError(t, server.Shutdown())
+
+	_, err = http.Get("http://" + metricsAddress + "/metrics")
+	assert.Error(t, err, "metrics listener should be stopped after shutdown")
+}
@@ -10,4 +10,6 @@
 //   - Получение всех URL пользователя
 //   - Удаление URL пользователя
 //   - Проверка состояния хранилища
+//   - Поиск, блокировка и безвозвратное удаление URL модератором с журналом аудита
+//   - Хранение вебхуков пользователей и outbox событий ссылок, записываемых вместе с изменениями
 package storage
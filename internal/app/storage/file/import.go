@@ -0,0 +1,42 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/strings"
+)
+
+// ImportURL сохраняет импортированную запись пользователя и дописывает её в файл.
+// Короткий идентификатор записи сохраняется, если он допустим и свободен, иначе создаётся новый.
+// Возвращает идентификатор сохранённой записи или, вместе с ErrorOriginalURLExists,
+// идентификатор ссылки, которая уже сокращает этот адрес.
+func (frepo *FileRepository) ImportURL(ctx context.Context, record models.Record) (id string, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	if existing := frepo.getRecordByOriginalURL(record.OriginalURL); existing != nil {
+		return existing.ShortURL, models.ErrorOriginalURLExists
+	}
+
+	if !models.ValidShortURL(record.ShortURL) || frepo.indexOf(record.ShortURL) >= 0 {
+		record.ShortURL = strings.RandString(8)
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	urlRecord := URLRecord{Record: record, UUID: strconv.Itoa(frepo.nextID())}
+
+	if err := frepo.appendToFile([]URLRecord{urlRecord}); err != nil {
+		return "", fmt.Errorf("failed to save URL to file: %w", err)
+	}
+	frepo.records = append(frepo.records, urlRecord)
+	if err := frepo.emit(models.EventLinkCreated, record, time.Now().UTC()); err != nil {
+		return "", err
+	}
+
+	return record.ShortURL, nil
+}
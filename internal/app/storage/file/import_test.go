@@ -0,0 +1,48 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_ImportURL(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	userID := uuid.New()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	id, err := frepo.ImportURL(ctx, models.Record{
+		ShortURL: "abc", OriginalURL: "http://example.com", UserID: userID, CreatedAt: created, Tags: []string{"a"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+
+	renamed, err := frepo.ImportURL(ctx, models.Record{ShortURL: "abc", OriginalURL: "http://example.org", UserID: userID})
+	require.NoError(t, err)
+	assert.NotEqual(t, "abc", renamed)
+
+	id, err = frepo.ImportURL(ctx, models.Record{OriginalURL: "http://example.com", UserID: userID})
+	assert.ErrorIs(t, err, models.ErrorOriginalURLExists)
+	assert.Equal(t, "abc", id)
+
+	// импортированные записи переживают перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	record, err := frepo.RetrieveByShortURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", record.OriginalURL)
+	assert.True(t, created.Equal(record.CreatedAt))
+	assert.Equal(t, []string{"a"}, record.Tags)
+	record, err = frepo.RetrieveByShortURL(ctx, renamed)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.org", record.OriginalURL)
+}
@@ -0,0 +1,25 @@
+package file
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// UpdateLink изменяет свойства ссылки пользователя и сохраняет изменения на диск.
+// Возвращает обновлённую запись или ErrorNotFound, если ссылки нет или она принадлежит другому пользователю.
+func (frepo *FileRepository) UpdateLink(ctx context.Context, userID uuid.UUID, shortURL string, update models.LinkUpdate) (record models.Record, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 || frepo.records[i].UserID != userID {
+		return models.Record{}, models.ErrorNotFound
+	}
+	update.Apply(&frepo.records[i].Record)
+	if err := frepo.rewriteFile(); err != nil {
+		return models.Record{}, err
+	}
+	return frepo.records[i].Record, nil
+}
@@ -0,0 +1,43 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_UpdateLink(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	userID := uuid.New()
+	id, _, err := frepo.SaveURL(ctx, userID, "http://example.com")
+	require.NoError(t, err)
+
+	title := "Example"
+	tags := []string{"docs"}
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = frepo.UpdateLink(ctx, userID, id, models.LinkUpdate{Title: &title, Tags: &tags, SetExpiresAt: true, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	_, err = frepo.UpdateLink(ctx, uuid.New(), id, models.LinkUpdate{Title: &title})
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+
+	// изменения и время создания сохраняются на диске
+	reloaded, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	record, err := reloaded.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, title, record.Title)
+	assert.Equal(t, tags, record.Tags)
+	require.NotNil(t, record.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*record.ExpiresAt))
+	assert.WithinDuration(t, time.Now(), record.CreatedAt, time.Minute)
+}
@@ -0,0 +1,43 @@
+package file
+
+import (
+	"context"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// SetDeleted помечает запись удаленной или снимает отметку об удалении и сохраняет изменения на диск.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (frepo *FileRepository) SetDeleted(ctx context.Context, shortURL string, deleted bool) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	frepo.records[i].SetDeleted(deleted, time.Now().UTC())
+	return frepo.rewriteFile()
+}
+
+// PurgeDeleted безвозвратно удаляет все удаленные пользователями записи вместе с историей их версий
+// из памяти и из файла хранилища. Возвращает количество удаленных записей.
+func (frepo *FileRepository) PurgeDeleted(ctx context.Context) (purged int, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	return frepo.purge(func(r URLRecord) bool { return r.IsDeleted })
+}
+
+// CountURLs возвращает сводку по записям хранилища.
+func (frepo *FileRepository) CountURLs(ctx context.Context) (counts models.URLCounts, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	records := make([]models.Record, 0, len(frepo.records))
+	for _, r := range frepo.records {
+		records = append(records, r.Record)
+	}
+	return models.CountRecords(records), nil
+}
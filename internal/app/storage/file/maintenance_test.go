@@ -0,0 +1,46 @@
+package file
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_Maintenance(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	deleted, _, err := frepo.SaveURL(ctx, userID, "http://example.com/a")
+	require.NoError(t, err)
+	kept, _, err := frepo.SaveURL(ctx, userID, "http://example.com/b")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, frepo.SetDeleted(ctx, "missing", true), models.ErrorNotFound)
+	require.NoError(t, frepo.SetDeleted(ctx, deleted, true))
+
+	// отметка об удалении переживает перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	counts, err := frepo.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.URLCounts{Total: 2, Deleted: 1, Users: 1}, counts)
+
+	purged, err := frepo.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	_, err = frepo.RetrieveByShortURL(ctx, deleted)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	record, err := frepo.RetrieveByShortURL(ctx, kept)
+	require.NoError(t, err)
+	assert.False(t, record.IsDeleted)
+}
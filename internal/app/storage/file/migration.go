@@ -0,0 +1,59 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// ScanURLs возвращает до limit записей всех пользователей с короткими идентификаторами больше after
+// в порядке возрастания идентификаторов. Используется для переноса данных между хранилищами.
+func (frepo *FileRepository) ScanURLs(ctx context.Context, after string, limit int) (records []models.Record, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	all := make([]models.Record, 0, len(frepo.records))
+	for _, r := range frepo.records {
+		all = append(all, r.Record)
+	}
+	return models.ScanPage(all, after, limit), nil
+}
+
+// CopyURLs сохраняет записи без изменений, включая идентификаторы, владельцев и флаги удаления,
+// и дописывает их в файл одной операцией.
+// Записи, чей короткий идентификатор или оригинальный URL уже есть в хранилище, пропускаются.
+// Возвращает короткие идентификаторы сохранённых записей.
+func (frepo *FileRepository) CopyURLs(ctx context.Context, records []models.Record) (copied []string, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	shortURLs := make(map[string]bool, len(frepo.records))
+	originalURLs := make(map[string]bool, len(frepo.records))
+	for _, r := range frepo.records {
+		shortURLs[r.ShortURL] = true
+		originalURLs[r.OriginalURL] = true
+	}
+
+	next := frepo.nextID()
+	added := make([]URLRecord, 0, len(records))
+	copied = make([]string, 0, len(records))
+	for _, record := range records {
+		if shortURLs[record.ShortURL] || originalURLs[record.OriginalURL] {
+			continue
+		}
+		shortURLs[record.ShortURL] = true
+		originalURLs[record.OriginalURL] = true
+		added = append(added, URLRecord{Record: record, UUID: strconv.Itoa(next)})
+		copied = append(copied, record.ShortURL)
+		next++
+	}
+
+	if err := frepo.appendToFile(added); err != nil {
+		return nil, fmt.Errorf("failed to save URLs to file: %w", err)
+	}
+	frepo.records = append(frepo.records, added...)
+
+	return copied, nil
+}
@@ -0,0 +1,57 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_CopyURLs(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	existing, _, err := frepo.SaveURL(ctx, uuid.New(), "http://example.com/b")
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	copied, err := frepo.CopyURLs(ctx, []models.Record{
+		{ShortURL: "c", OriginalURL: "http://example.com/c", UserID: userID, IsDeleted: true, CreatedAt: created},
+		{ShortURL: "x", OriginalURL: "http://example.com/b"},
+		{ShortURL: existing, OriginalURL: "http://example.org"},
+		{ShortURL: "a", OriginalURL: "http://example.com/a", UserID: userID, DisabledReason: "spam", CreatedAt: created},
+		{ShortURL: "a", OriginalURL: "http://example.com/a2"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a"}, copied)
+
+	// перенесённые записи переживают перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	page, err := frepo.ScanURLs(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	ids := make([]string, 0, len(page))
+	records := make(map[string]models.Record, len(page))
+	for _, r := range page {
+		ids = append(ids, r.ShortURL)
+		records[r.ShortURL] = r
+	}
+	assert.IsIncreasing(t, ids)
+	assert.Equal(t, "spam", records["a"].DisabledReason)
+	assert.True(t, records["c"].IsDeleted)
+	assert.Equal(t, userID, records["c"].UserID)
+	assert.True(t, created.Equal(records["c"].CreatedAt))
+
+	page, err = frepo.ScanURLs(ctx, ids[0], 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, ids[1], page[0].ShortURL)
+}
@@ -0,0 +1,247 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// auditSuffix добавляется к пути файла хранилища для получения пути к журналу аудита.
+const auditSuffix = ".audit"
+
+// SearchURLs ищет записи, удовлетворяющие фильтру модератора.
+// Возвращает массив записей и ошибку.
+func (frepo *FileRepository) SearchURLs(ctx context.Context, filter models.SearchFilter) (records []models.Record, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	records = make([]models.Record, 0)
+	for _, r := range frepo.records {
+		if filter.Limit > 0 && len(records) >= filter.Limit {
+			break
+		}
+		if filter.Match(r.Record) {
+			records = append(records, r.Record)
+		}
+	}
+	return records, nil
+}
+
+// DisableURL блокирует запись с указанной причиной и сохраняет изменения на диск
+// вместе с записью entry журнала действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (frepo *FileRepository) DisableURL(ctx context.Context, shortURL string, reason string, legal bool, entry models.AuditEntry) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	prev := frepo.records[i]
+	frepo.records[i].DisabledReason = reason
+	frepo.records[i].DisabledLegal = legal
+	if err := frepo.commitModeration(entry); err != nil {
+		frepo.records[i] = prev
+		return err
+	}
+	return nil
+}
+
+// RestoreURL снимает блокировку с записи и сохраняет изменения на диск
+// вместе с записью entry журнала действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (frepo *FileRepository) RestoreURL(ctx context.Context, shortURL string, entry models.AuditEntry) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	prev := frepo.records[i]
+	frepo.records[i].DisabledReason = ""
+	frepo.records[i].DisabledLegal = false
+	if err := frepo.commitModeration(entry); err != nil {
+		frepo.records[i] = prev
+		return err
+	}
+	return nil
+}
+
+// PurgeURL безвозвратно удаляет запись и историю её версий из памяти и из файла хранилища
+// и сохраняет запись entry журнала действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (frepo *FileRepository) PurgeURL(ctx context.Context, shortURL string, entry models.AuditEntry) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	prev, revisions := frepo.records[i], frepo.revisions[shortURL]
+	frepo.records = slices.Delete(frepo.records, i, i+1)
+	delete(frepo.revisions, shortURL)
+	if err := frepo.commitModeration(entry); err != nil {
+		frepo.records = slices.Insert(frepo.records, i, prev)
+		if revisions != nil {
+			frepo.revisions[shortURL] = revisions
+		}
+		return err
+	}
+	return nil
+}
+
+// commitModeration сохраняет на диск изменение, уже внесённое в память, вместе с записью журнала аудита.
+// Запись журнала дописывается до перезаписи файла хранилища и удаляется, если перезапись не удалась,
+// поэтому сохранённое действие модератора всегда попадает в журнал.
+// При ошибке вызывающий должен откатить изменение в памяти.
+func (frepo *FileRepository) commitModeration(entry models.AuditEntry) error {
+	size, err := frepo.appendAuditEntry(entry)
+	if err != nil {
+		return fmt.Errorf("save audit entry: %w", err)
+	}
+	if err := frepo.rewriteFile(); err != nil {
+		if err := os.Truncate(frepo.fPath+auditSuffix, size); err != nil {
+			log.Printf("Error rolling back audit entry: %v", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// appendAuditEntry дописывает запись в файл журнала действий модератора.
+// Возвращает размер файла до записи.
+func (frepo *FileRepository) appendAuditEntry(entry models.AuditEntry) (size int64, err error) {
+	file, err := os.OpenFile(frepo.fPath+auditSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing file: %v", err)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := json.NewEncoder(file).Encode(entry); err != nil {
+		if err := file.Truncate(info.Size()); err != nil {
+			log.Printf("Error rolling back audit entry: %v", err)
+		}
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// RetrieveAuditLog читает журнал действий модератора из файла.
+// Если журнал ещё не создан, возвращает пустой массив.
+func (frepo *FileRepository) RetrieveAuditLog(ctx context.Context) (entries []models.AuditEntry, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	file, err := os.Open(frepo.fPath + auditSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return []models.AuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing file: %v", err)
+		}
+	}()
+
+	entries = make([]models.AuditEntry, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning audit file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// indexOf возвращает индекс записи с указанным коротким идентификатором или -1.
+func (frepo *FileRepository) indexOf(shortURL string) int {
+	return slices.IndexFunc(frepo.records, func(r URLRecord) bool {
+		return r.ShortURL == shortURL
+	})
+}
+
+// rewriteFile полностью перезаписывает файл хранилища текущим набором записей,
+// следующими за ними записями об изменениях ссылок и записями об отзыве токенов.
+func (frepo *FileRepository) rewriteFile() error {
+	lines := make([]any, 0, len(frepo.records))
+	for _, record := range frepo.records {
+		lines = append(lines, record)
+	}
+	for _, record := range frepo.records {
+		for _, revision := range frepo.revisions[record.ShortURL] {
+			lines = append(lines, updateRecord{Update: &revision})
+		}
+	}
+	for _, userID := range frepo.revoked {
+		lines = append(lines, revocationRecord{RevokedUser: &userID})
+	}
+	return rewriteLines(frepo.fPath, lines)
+}
+
+// rewriteLines полностью перезаписывает файл fPath значениями lines, сериализованными в JSON построчно.
+// Запись выполняется в уникальный временный файл в том же каталоге, который затем атомарно заменяет основной.
+// При ошибке временный файл удаляется.
+func rewriteLines(fPath string, lines []any) (err error) {
+	dir, name := filepath.Split(fPath)
+	file, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if err := os.Remove(file.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Error removing temporary file: %v", err)
+			}
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	for _, line := range lines {
+		if err := encoder.Encode(line); err != nil {
+			if err := file.Close(); err != nil {
+				log.Printf("Error closing file: %v", err)
+			}
+			return err
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// CreateTemp создает файл с правами 0600, сохраняем права основного файла
+	if info, err := os.Stat(fPath); err == nil {
+		if err := os.Chmod(file.Name(), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(file.Name(), fPath)
+}
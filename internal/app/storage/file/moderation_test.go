@@ -0,0 +1,111 @@
+package file
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+func TestFileRepository_Moderation(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	userID := uuid.New()
+	ids, err := frepo.SaveURLs(ctx, []string{"http://example.com", "http://ya.ru"})
+	require.NoError(t, err)
+	_, _, err = frepo.SaveURL(ctx, userID, "http://avito.ru")
+	require.NoError(t, err)
+
+	entry := func(action string, shortURL string) models.AuditEntry {
+		return models.AuditEntry{
+			Time:     time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			Actor:    "alice",
+			Action:   action,
+			ShortURL: shortURL,
+		}
+	}
+
+	t.Run("Search", func(t *testing.T) {
+		got, err := frepo.SearchURLs(ctx, models.SearchFilter{UserID: userID})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "http://avito.ru", got[0].OriginalURL)
+	})
+
+	t.Run("Disable is persisted", func(t *testing.T) {
+		require.NoError(t, frepo.DisableURL(ctx, ids[0], "court order", true, entry(models.AuditActionDisable, ids[0])))
+
+		reloaded, err := NewFileRepository(fpath)
+		require.NoError(t, err)
+		record, err := reloaded.RetrieveByShortURL(ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, "court order", record.DisabledReason)
+		assert.True(t, record.DisabledLegal)
+	})
+
+	t.Run("Restore is persisted", func(t *testing.T) {
+		require.NoError(t, frepo.RestoreURL(ctx, ids[0], entry(models.AuditActionRestore, ids[0])))
+
+		reloaded, err := NewFileRepository(fpath)
+		require.NoError(t, err)
+		record, err := reloaded.RetrieveByShortURL(ctx, ids[0])
+		require.NoError(t, err)
+		assert.False(t, record.IsDisabled())
+	})
+
+	t.Run("Purge removes record from file", func(t *testing.T) {
+		require.NoError(t, frepo.PurgeURL(ctx, ids[1], entry(models.AuditActionPurge, ids[1])))
+
+		reloaded, err := NewFileRepository(fpath)
+		require.NoError(t, err)
+		_, err = reloaded.RetrieveByShortURL(ctx, ids[1])
+		assert.ErrorIs(t, err, models.ErrorNotFound)
+		assert.Len(t, reloaded.records, 2)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		assert.ErrorIs(t, frepo.DisableURL(ctx, "missing", "spam", false, entry(models.AuditActionDisable, "missing")), models.ErrorNotFound)
+		assert.ErrorIs(t, frepo.RestoreURL(ctx, "missing", entry(models.AuditActionRestore, "missing")), models.ErrorNotFound)
+		assert.ErrorIs(t, frepo.PurgeURL(ctx, "missing", entry(models.AuditActionPurge, "missing")), models.ErrorNotFound)
+	})
+
+	t.Run("Audit log", func(t *testing.T) {
+		reloaded, err := NewFileRepository(fpath)
+		require.NoError(t, err)
+		entries, err := reloaded.RetrieveAuditLog(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.AuditEntry{
+			entry(models.AuditActionDisable, ids[0]),
+			entry(models.AuditActionRestore, ids[0]),
+			entry(models.AuditActionPurge, ids[1]),
+		}, entries)
+	})
+
+	t.Run("Failed write rolls back change and audit entry", func(t *testing.T) {
+		// Непустой каталог на месте файла хранилища не даёт его перезаписать.
+		require.NoError(t, os.Rename(fpath, fpath+".bak"))
+		require.NoError(t, os.MkdirAll(fpath+"/busy", 0755))
+		defer func() {
+			require.NoError(t, os.RemoveAll(fpath))
+			require.NoError(t, os.Rename(fpath+".bak", fpath))
+		}()
+
+		assert.Error(t, frepo.DisableURL(ctx, ids[0], "spam", false, entry(models.AuditActionDisable, ids[0])))
+
+		record, err := frepo.RetrieveByShortURL(ctx, ids[0])
+		require.NoError(t, err)
+		assert.False(t, record.IsDisabled())
+		entries, err := frepo.RetrieveAuditLog(ctx)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+	})
+}
@@ -8,8 +8,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"slices"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/iubondar/url-shortener/internal/app/models"
@@ -23,15 +24,44 @@ type URLRecord struct {
 	UUID string `json:"uuid"` // внутренний идентификатор записи
 }
 
+// updateRecord представляет запись файлового хранилища об изменении целевого адреса ссылки.
+// Записи изменений дописываются в файл после записей URL и применяются к ним при загрузке.
+type updateRecord struct {
+	Update *models.Revision `json:"update,omitempty"` // новая версия ссылки
+}
+
+// revocationRecord представляет запись файлового хранилища об отзыве токенов пользователя.
+// Записи об отзыве дописываются в файл после записей об изменениях.
+type revocationRecord struct {
+	RevokedUser *uuid.UUID `json:"revoked_user,omitempty"` // пользователь, чьи токены отозваны
+}
+
+// fileLine представляет строку файла хранилища: запись URL, запись об изменении или запись об отзыве.
+type fileLine struct {
+	URLRecord
+	updateRecord
+	revocationRecord
+}
+
 // FileRepository реализует файловое хранилище URL.
 // Сохраняет все записи в JSON-файле и поддерживает их загрузку при инициализации.
+// Безопасно для одновременного использования обработчиками запросов и фоновыми задачами.
 type FileRepository struct {
-	fPath   string      // путь к файлу хранилища
-	records []URLRecord // массив записей URL
+	mu sync.RWMutex // защищает поля хранилища и файлы на диске
+
+	fPath       string                       // путь к файлу хранилища
+	records     []URLRecord                  // массив записей URL
+	revisions   map[string][]models.Revision // история целевых адресов ссылок
+	deletions   []models.DeletionJob         // задачи удаления ссылок
+	revoked     []uuid.UUID                  // пользователи, чьи токены отозваны
+	webhooks    []models.Webhook             // вебхуки пользователей
+	outbox      []models.Delivery            // недоставленные события вебхуков в порядке появления
+	outboxStale int                          // сколько строк файла outbox устарело после последней перезаписи
 }
 
 // NewFileRepository создает новый экземпляр FileRepository.
-// Создает файл хранилища, если он не существует, и загружает существующие записи.
+// Создает файл хранилища, если он не существует, и загружает существующие записи,
+// вебхуки и недоставленные события.
 // Принимает путь к файлу хранилища.
 // Возвращает указатель на FileRepository и ошибку, если она возникла.
 func NewFileRepository(fPath string) (*FileRepository, error) {
@@ -53,31 +83,52 @@ func NewFileRepository(fPath string) (*FileRepository, error) {
 		}
 	}()
 
-	var records = []URLRecord{}
+	frepo := &FileRepository{
+		fPath:     fPath,
+		records:   []URLRecord{},
+		revisions: map[string][]models.Revision{},
+	}
+	loadedAt := time.Now().UTC()
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		var record URLRecord
-		err := json.Unmarshal(scanner.Bytes(), &record)
+		var line fileLine
+		err := json.Unmarshal(scanner.Bytes(), &line)
 		if err != nil {
 			return nil, err
 		}
-		records = append(records, record)
+		if line.Update != nil {
+			frepo.applyRevision(*line.Update)
+			continue
+		}
+		if line.RevokedUser != nil {
+			frepo.revoked = append(frepo.revoked, *line.RevokedUser)
+			continue
+		}
+		if line.IsDeleted && line.DeletedAt == nil {
+			// записи, удалённые до появления времени удаления, хранятся полный срок с момента загрузки
+			line.DeletedAt = &loadedAt
+		}
+		frepo.records = append(frepo.records, line.URLRecord)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error scanning file: %w", err)
 	}
 
-	return &FileRepository{
-		fPath:   fPath,
-		records: records,
-	}, nil
+	if err := frepo.loadOutbox(); err != nil {
+		return nil, err
+	}
+
+	return frepo, nil
 }
 
 // SaveURL сохраняет URL в файловом хранилище.
 // Если URL уже существует, возвращает его короткий идентификатор.
 // Возвращает короткий идентификатор, флаг существования и ошибку.
 func (frepo *FileRepository) SaveURL(ctx context.Context, userID uuid.UUID, url string) (id string, exists bool, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
 	// Если URL уже был сохранён - возвращаем имеющееся значение
 	record := frepo.getRecordByOriginalURL(url)
 	if record != nil {
@@ -90,6 +141,9 @@ func (frepo *FileRepository) SaveURL(ctx context.Context, userID uuid.UUID, url
 	if err := frepo.appendToFile([]URLRecord{*record}); err != nil {
 		return "", false, fmt.Errorf("failed to save URL to file: %w", err)
 	}
+	if err := frepo.emit(models.EventLinkCreated, record.Record, record.CreatedAt); err != nil {
+		return "", false, err
+	}
 
 	return record.ShortURL, false, nil
 }
@@ -119,6 +173,7 @@ func (frepo *FileRepository) addRecordForURL(url string, userID uuid.UUID) *URLR
 			ShortURL:    id,
 			OriginalURL: url,
 			UserID:      userID,
+			CreatedAt:   time.Now().UTC(),
 		},
 	}
 	frepo.records = append(frepo.records, record)
@@ -128,7 +183,10 @@ func (frepo *FileRepository) addRecordForURL(url string, userID uuid.UUID) *URLR
 
 // RetrieveByShortURL получает запись по короткому идентификатору.
 // Возвращает запись и ошибку. Если запись не найдена, возвращает ошибку ErrorNotFound.
-func (frepo FileRepository) RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error) {
+func (frepo *FileRepository) RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
 	for _, rec := range frepo.records {
 		if rec.ShortURL == shortURL {
 			return rec.Record, nil
@@ -141,7 +199,7 @@ func (frepo FileRepository) RetrieveByShortURL(ctx context.Context, shortURL str
 // CheckStatus проверяет состояние файлового хранилища.
 // Проверяет доступность файла для чтения.
 // Возвращает ошибку, если файл недоступен.
-func (frepo FileRepository) CheckStatus(ctx context.Context) error {
+func (frepo *FileRepository) CheckStatus(ctx context.Context) error {
 	file, err := os.OpenFile(frepo.fPath, os.O_RDONLY|os.O_CREATE, 0666)
 	if err != nil {
 		return err
@@ -158,6 +216,9 @@ func (frepo FileRepository) CheckStatus(ctx context.Context) error {
 // SaveURLs сохраняет массив URL в файловом хранилище.
 // Возвращает массив коротких идентификаторов и ошибку.
 func (frepo *FileRepository) SaveURLs(ctx context.Context, urls []string) (ids []string, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
 	ids = make([]string, 0)
 	newRecords := make([]URLRecord, 0)
 	for _, url := range urls {
@@ -182,7 +243,7 @@ func (frepo *FileRepository) SaveURLs(ctx context.Context, urls []string) (ids [
 
 // nextID генерирует следующий внутренний идентификатор записи.
 // Возвращает целочисленный идентификатор.
-func (frepo FileRepository) nextID() int {
+func (frepo *FileRepository) nextID() int {
 	if len(frepo.records) > 0 {
 		last, err := strconv.Atoi(frepo.records[len(frepo.records)-1].UUID)
 		if err != nil {
@@ -196,8 +257,13 @@ func (frepo FileRepository) nextID() int {
 // appendToFile добавляет записи в конец файла хранилища.
 // Записи сериализуются в JSON и записываются построчно.
 // Возвращает ошибку, если запись в файл не удалась.
-func (frepo FileRepository) appendToFile(records []URLRecord) error {
-	file, err := os.OpenFile(frepo.fPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+func (frepo *FileRepository) appendToFile(records []URLRecord) error {
+	return appendLines(frepo.fPath, records)
+}
+
+// appendLines сериализует значения в JSON и построчно дописывает их в конец файла.
+func appendLines[T any](fPath string, lines []T) error {
+	file, err := os.OpenFile(fPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
 		return err
 	}
@@ -208,8 +274,8 @@ func (frepo FileRepository) appendToFile(records []URLRecord) error {
 	}()
 
 	encoder := json.NewEncoder(file)
-	for _, record := range records {
-		err = encoder.Encode(record)
+	for _, line := range lines {
+		err = encoder.Encode(line)
 		if err != nil {
 			return err
 		}
@@ -220,23 +286,83 @@ func (frepo FileRepository) appendToFile(records []URLRecord) error {
 
 // RetrieveUserURLs получает все URL пользователя.
 // Возвращает массив записей и ошибку.
-func (frepo FileRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error) {
+func (frepo *FileRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	return frepo.userURLs(userID), nil
+}
+
+// userURLs возвращает все записи пользователя. Вызывается под блокировкой.
+func (frepo *FileRepository) userURLs(userID uuid.UUID) (records []models.Record) {
 	for _, r := range frepo.records {
 		if r.UserID == userID {
 			records = append(records, r.Record)
 		}
 	}
-	return records, nil
+	return records
 }
 
-// DeleteByShortURLs помечает URL как удаленные.
+// DeleteByShortURLs помечает URL как удаленные и сохраняет изменения на диск.
 // Принимает идентификатор пользователя и массив коротких идентификаторов.
-// Обновляет записи в памяти, но не сохраняет изменения на диск.
-func (frepo FileRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) {
-	for i, r := range frepo.records {
-		if r.UserID == userID && slices.Contains(shortURLs, r.ShortURL) {
-			r.IsDeleted = true
-			frepo.records[i] = r
+// Удаление выполняется сразу, поэтому возвращаемая задача удаления уже завершена.
+// Если сохранить изменения не удалось, записи в памяти остаются неизменными.
+func (frepo *FileRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	now := time.Now().UTC()
+	job = models.NewDeletionJob(userID, shortURLs, now)
+	var deleted []*models.Record
+	for _, item := range job.Items {
+		var record *models.Record
+		if i := frepo.indexOf(item.ShortURL); i >= 0 {
+			record = &frepo.records[i].Record
+		}
+		status := models.DeletionStatusOf(record, userID)
+		if status == models.DeletionDeleted && !record.IsDeleted {
+			record.SetDeleted(true, now)
+			deleted = append(deleted, record)
+		}
+		job.Resolve(item.ShortURL, status, now)
+	}
+	if len(deleted) > 0 {
+		if err := frepo.rewriteFile(); err != nil {
+			for _, record := range deleted {
+				record.SetDeleted(false, now)
+			}
+			return models.DeletionJob{}, fmt.Errorf("failed to save deletion to file: %w", err)
 		}
 	}
+	// события дописываются в outbox одной записью и только после сохранения удаления,
+	// поэтому после перезапуска в outbox не может оказаться события об удалении,
+	// которого нет в хранилище
+	var events []outboxLine
+	for _, record := range deleted {
+		events = append(events, frepo.queueEvent(models.EventLinkDeleted, *record, now)...)
+	}
+	if err := frepo.saveEvents(events); err != nil {
+		return models.DeletionJob{}, err
+	}
+	frepo.deletions = append(models.PruneDeletionJobs(frepo.deletions, now), job)
+	return job, nil
+}
+
+// RetrieveDeletionJob возвращает задачу удаления id пользователя userID
+// или models.ErrorNotFound, если задача неизвестна, устарела или принадлежит другому пользователю.
+func (frepo *FileRepository) RetrieveDeletionJob(ctx context.Context, userID uuid.UUID, id string) (job models.DeletionJob, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	return models.FindDeletionJob(frepo.deletions, userID, id)
+}
+
+// SearchUserURLs возвращает страницу URL пользователя, отобранных и упорядоченных по условиям выборки.
+// Возвращает страницу и курсор следующей страницы или nil, если страница последняя.
+func (frepo *FileRepository) SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	records, next = query.Page(frepo.userURLs(userID))
+	return records, next, nil
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -72,9 +73,18 @@ func setupTestFile(t testing.TB) string {
 		t.Fatalf("Failed to close test file: %v", err)
 	}
 
+	// файлы outbox и журнала аудита могли остаться от прошлого запуска
+	for _, name := range []string{tempFile + outboxSuffix, tempFile + auditSuffix} {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Failed to remove test file: %v", err)
+		}
+	}
+
 	t.Cleanup(func() {
-		if err := os.Remove(tempFile); err != nil && !os.IsNotExist(err) {
-			t.Errorf("Error removing test file: %v", err)
+		for _, name := range []string{tempFile, tempFile + outboxSuffix, tempFile + auditSuffix} {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				t.Errorf("Error removing test file: %v", err)
+			}
 		}
 	})
 	return tempFile
@@ -484,13 +494,82 @@ func TestFileRepository_DeleteByShortURLs(t *testing.T) {
 				records: tt.records,
 			}
 
-			frepo.DeleteByShortURLs(context.Background(), tt.args.userID, tt.args.shortURLs)
+			_, err := frepo.DeleteByShortURLs(context.Background(), tt.args.userID, tt.args.shortURLs)
+			require.NoError(t, err)
 
+			// время удаления задается хранилищем, поэтому проверяется только его наличие
+			for i := range frepo.records {
+				assert.Equal(t, frepo.records[i].IsDeleted, frepo.records[i].DeletedAt != nil, frepo.records[i].ShortURL)
+				frepo.records[i].DeletedAt = nil
+			}
 			assert.ElementsMatch(t, tt.wantRecords, frepo.records)
 		})
 	}
 }
 
+func TestFileRepository_DeletionJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := &FileRepository{fPath: setupTestFile(t), records: []URLRecord{
+		{Record: models.Record{ShortURL: "own", OriginalURL: "http://example.com", UserID: userID}},
+		{Record: models.Record{ShortURL: "other", OriginalURL: "http://example.org", UserID: uuid.New()}},
+	}}
+
+	job, err := repo.DeleteByShortURLs(ctx, userID, []string{"own", "other", "missing", "own"})
+	require.NoError(t, err)
+
+	// удаление выполняется сразу, повторы не дублируются
+	assert.NotNil(t, job.CompletedAt)
+	assert.Equal(t, []models.DeletionItem{
+		{ShortURL: "own", Status: models.DeletionDeleted},
+		{ShortURL: "other", Status: models.DeletionNotOwned},
+		{ShortURL: "missing", Status: models.DeletionNotFound},
+	}, job.Items)
+
+	got, err := repo.RetrieveDeletionJob(ctx, userID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job, got)
+
+	_, err = repo.RetrieveDeletionJob(ctx, uuid.New(), job.ID)
+	require.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = repo.RetrieveDeletionJob(ctx, userID, uuid.NewString())
+	require.ErrorIs(t, err, models.ErrorNotFound)
+}
+
+func TestFileRepository_DeleteByShortURLs_Persisted(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	deleted, _, err := frepo.SaveURL(ctx, userID, "http://example.com/a")
+	require.NoError(t, err)
+	kept, _, err := frepo.SaveURL(ctx, userID, "http://example.com/b")
+	require.NoError(t, err)
+	_, err = frepo.DeleteByShortURLs(ctx, userID, []string{deleted})
+	require.NoError(t, err)
+	record, err := frepo.RetrieveByShortURL(ctx, deleted)
+	require.NoError(t, err)
+	require.NotNil(t, record.DeletedAt)
+
+	// удаление и его время переживают перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	reopened, err := frepo.RetrieveByShortURL(ctx, deleted)
+	require.NoError(t, err)
+	assert.True(t, reopened.IsDeleted)
+	require.NotNil(t, reopened.DeletedAt)
+	assert.True(t, record.DeletedAt.Equal(*reopened.DeletedAt))
+	other, err := frepo.RetrieveByShortURL(ctx, kept)
+	require.NoError(t, err)
+	assert.False(t, other.IsDeleted)
+
+	purged, err := frepo.PurgeExpired(ctx, reopened.DeletedAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
 func TestFileRepository_RetrieveUserURLs(t *testing.T) {
 	userID := uuid.New()
 	type args struct {
@@ -564,6 +643,38 @@ func TestFileRepository_RetrieveUserURLs(t *testing.T) {
 	}
 }
 
+func TestFileRepository_SearchUserURLs(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	urls := []string{"http://example.com/3", "http://example.com/1", "http://example.org/2"}
+	for _, url := range urls {
+		_, _, err := frepo.SaveURL(ctx, userID, url)
+		require.NoError(t, err)
+	}
+	_, _, err = frepo.SaveURL(ctx, uuid.New(), "http://example.com/other")
+	require.NoError(t, err)
+
+	// обходим выдачу постранично по одной ссылке
+	query := models.URLQuery{Sort: models.SortOriginalURL, Contains: "example.com", Limit: 1}
+	var got []string
+	for {
+		records, next, err := frepo.SearchUserURLs(ctx, userID, query)
+		require.NoError(t, err)
+		for _, r := range records {
+			got = append(got, r.OriginalURL)
+		}
+		if next == nil {
+			break
+		}
+		query.After = next
+	}
+	assert.Equal(t, []string{"http://example.com/1", "http://example.com/3"}, got)
+}
+
 // BenchmarkFileRepository_SaveURL измеряет производительность сохранения URL
 func BenchmarkFileRepository_SaveURL(b *testing.B) {
 	tempFile := setupTestFile(b)
@@ -643,7 +754,7 @@ func BenchmarkFileRepository_DeleteByShortURLs(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		repo.DeleteByShortURLs(ctx, userID, shortURLs)
+		_, _ = repo.DeleteByShortURLs(ctx, userID, shortURLs)
 	}
 }
 
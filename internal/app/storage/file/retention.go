@@ -0,0 +1,76 @@
+package file
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// PurgeExpired безвозвратно удаляет записи, удалённые пользователями или истёкшие раньше cutoff,
+// вместе с историей их версий из памяти и из файла хранилища. Возвращает количество удалённых записей.
+func (frepo *FileRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	return frepo.purge(func(r URLRecord) bool { return r.OutlivedRetention(cutoff) })
+}
+
+// DeleteAccount безвозвратно удаляет все записи и вебхуки пользователя вместе с историей версий
+// и недоставленными событиями и отзывает его токены. Изменения сохраняются на диск перезаписью файлов.
+// Возвращает количество удалённых записей.
+func (frepo *FileRepository) DeleteAccount(ctx context.Context, userID uuid.UUID) (purged int, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	purged = frepo.deleteRecords(func(r URLRecord) bool { return r.UserID == userID })
+	if !slices.Contains(frepo.revoked, userID) {
+		frepo.revoked = append(frepo.revoked, userID)
+	}
+	if err := frepo.rewriteFile(); err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	frepo.webhooks, frepo.outbox, deleted = models.DeleteWebhooks(frepo.webhooks, frepo.outbox, func(w models.Webhook) bool {
+		return w.UserID == userID
+	})
+	if deleted == 0 {
+		return purged, nil
+	}
+	return purged, frepo.rewriteOutbox()
+}
+
+// IsUserRevoked сообщает, отозваны ли токены пользователя.
+func (frepo *FileRepository) IsUserRevoked(ctx context.Context, userID uuid.UUID) (bool, error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	return slices.Contains(frepo.revoked, userID), nil
+}
+
+// purge удаляет записи, для которых del возвращает true, и перезаписывает файл, если такие нашлись.
+// Вызывается под блокировкой на запись.
+func (frepo *FileRepository) purge(del func(r URLRecord) bool) (purged int, err error) {
+	purged = frepo.deleteRecords(del)
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, frepo.rewriteFile()
+}
+
+// deleteRecords удаляет из памяти записи, для которых del возвращает true, вместе с историей их версий.
+// Возвращает количество удалённых записей.
+func (frepo *FileRepository) deleteRecords(del func(r URLRecord) bool) (deleted int) {
+	frepo.records = slices.DeleteFunc(frepo.records, func(r URLRecord) bool {
+		if del(r) {
+			delete(frepo.revisions, r.ShortURL)
+			deleted++
+			return true
+		}
+		return false
+	})
+	return deleted
+}
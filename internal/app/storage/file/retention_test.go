@@ -0,0 +1,102 @@
+package file
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	deleted, _, err := frepo.SaveURL(ctx, userID, "http://example.com/a")
+	require.NoError(t, err)
+	kept, _, err := frepo.SaveURL(ctx, userID, "http://example.com/b")
+	require.NoError(t, err)
+	_, err = frepo.UpdateOriginalURL(ctx, userID, deleted, "http://example.org")
+	require.NoError(t, err)
+	require.NoError(t, frepo.SetDeleted(ctx, deleted, true))
+
+	// время удаления переживает перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	record, err := frepo.RetrieveByShortURL(ctx, deleted)
+	require.NoError(t, err)
+	require.NotNil(t, record.DeletedAt)
+
+	purged, err := frepo.PurgeExpired(ctx, record.DeletedAt.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = frepo.PurgeExpired(ctx, record.DeletedAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	_, err = frepo.RetrieveByShortURL(ctx, deleted)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = frepo.RetrieveByShortURL(ctx, kept)
+	require.NoError(t, err)
+}
+
+func TestFileRepository_LegacyDeletedRecord(t *testing.T) {
+	fpath := setupTestFile(t)
+	line := `{"uuid":"1","short_url":"abc","original_url":"http://example.com","user_id":"` + uuid.NewString() + `","is_deleted":true}` + "\n"
+	require.NoError(t, os.WriteFile(fpath, []byte(line), 0666))
+
+	// удалённая до появления времени удаления запись хранится полный срок с момента загрузки
+	loaded := time.Now().UTC()
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	record, err := frepo.RetrieveByShortURL(context.Background(), "abc")
+	require.NoError(t, err)
+	require.NotNil(t, record.DeletedAt)
+	assert.WithinDuration(t, loaded, *record.DeletedAt, time.Second)
+}
+
+func TestFileRepository_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	own, _, err := frepo.SaveURL(ctx, userID, "http://example.com/a")
+	require.NoError(t, err)
+	_, err = frepo.UpdateOriginalURL(ctx, userID, own, "http://example.org")
+	require.NoError(t, err)
+	other, _, err := frepo.SaveURL(ctx, otherID, "http://example.com/b")
+	require.NoError(t, err)
+
+	purged, err := frepo.DeleteAccount(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// удаление и отзыв переживают перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	_, err = frepo.RetrieveByShortURL(ctx, own)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = frepo.RetrieveByShortURL(ctx, other)
+	require.NoError(t, err)
+	assert.Empty(t, frepo.revisions)
+
+	revoked, err := frepo.IsUserRevoked(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = frepo.IsUserRevoked(ctx, otherID)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
@@ -0,0 +1,75 @@
+package file
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// UpdateOriginalURL меняет целевой адрес ссылки пользователя и дописывает в файл запись об изменении.
+// Если адрес не изменился, возвращает запись без изменений.
+// Возвращает ErrorNotFound, если ссылки нет или она принадлежит другому пользователю,
+// и ErrorOriginalURLExists, если адрес уже сокращён другой ссылкой.
+func (frepo *FileRepository) UpdateOriginalURL(ctx context.Context, userID uuid.UUID, shortURL string, url string) (record models.Record, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 || frepo.records[i].UserID != userID {
+		return models.Record{}, models.ErrorNotFound
+	}
+	if frepo.records[i].OriginalURL == url {
+		return frepo.records[i].Record, nil
+	}
+	if frepo.getRecordByOriginalURL(url) != nil {
+		return models.Record{}, models.ErrorOriginalURLExists
+	}
+
+	revisions := models.NextRevisions(frepo.revisions[shortURL], frepo.records[i].Record, url, time.Now().UTC())
+	updates := make([]updateRecord, 0, len(revisions))
+	for _, revision := range revisions {
+		updates = append(updates, updateRecord{Update: &revision})
+	}
+	if err := appendLines(frepo.fPath, updates); err != nil {
+		return models.Record{}, err
+	}
+
+	for _, revision := range revisions {
+		frepo.applyRevision(revision)
+	}
+	return frepo.records[i].Record, nil
+}
+
+// RetrieveRevisions возвращает историю целевых адресов ссылки пользователя от первой версии к последней.
+// Возвращает ErrorNotFound, если ссылки нет или она принадлежит другому пользователю.
+func (frepo *FileRepository) RetrieveRevisions(ctx context.Context, userID uuid.UUID, shortURL string) (revisions []models.Revision, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	i := frepo.indexOf(shortURL)
+	if i < 0 || frepo.records[i].UserID != userID {
+		return nil, models.ErrorNotFound
+	}
+	revisions = slices.Clone(frepo.revisions[shortURL])
+	if len(revisions) == 0 {
+		revisions = []models.Revision{models.InitialRevision(frepo.records[i].Record)}
+	}
+	return revisions, nil
+}
+
+// applyRevision применяет запись об изменении к ссылке и добавляет версию в историю.
+// Записи об изменениях безвозвратно удалённых ссылок игнорируются.
+func (frepo *FileRepository) applyRevision(revision models.Revision) {
+	i := frepo.indexOf(revision.ShortURL)
+	if i < 0 {
+		return
+	}
+	if frepo.revisions == nil {
+		frepo.revisions = map[string][]models.Revision{}
+	}
+	frepo.records[i].OriginalURL = revision.OriginalURL
+	frepo.revisions[revision.ShortURL] = append(frepo.revisions[revision.ShortURL], revision)
+}
@@ -0,0 +1,67 @@
+package file
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_UpdateOriginalURL(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	userID := uuid.New()
+	id, _, err := frepo.SaveURL(ctx, userID, "http://example.com/v1")
+	require.NoError(t, err)
+	otherID, _, err := frepo.SaveURL(ctx, userID, "http://example.org")
+	require.NoError(t, err)
+
+	_, err = frepo.UpdateOriginalURL(ctx, userID, id, "http://example.com/v2")
+	require.NoError(t, err)
+	_, err = frepo.UpdateOriginalURL(ctx, userID, id, "http://example.com/v3")
+	require.NoError(t, err)
+
+	_, err = frepo.UpdateOriginalURL(ctx, userID, id, "http://example.org")
+	assert.ErrorIs(t, err, models.ErrorOriginalURLExists)
+	_, err = frepo.UpdateOriginalURL(ctx, uuid.New(), id, "http://example.com/v4")
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+
+	wantURLs := []string{"http://example.com/v1", "http://example.com/v2", "http://example.com/v3"}
+	assertRevisions := func(t *testing.T, frepo *FileRepository) {
+		record, err := frepo.RetrieveByShortURL(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "http://example.com/v3", record.OriginalURL)
+
+		revisions, err := frepo.RetrieveRevisions(ctx, userID, id)
+		require.NoError(t, err)
+		urls := make([]string, 0, len(revisions))
+		for i, revision := range revisions {
+			assert.Equal(t, i+1, revision.Revision)
+			urls = append(urls, revision.OriginalURL)
+		}
+		assert.Equal(t, wantURLs, urls)
+	}
+
+	// изменения дописываются в файл записями об изменении и применяются при загрузке
+	reloaded, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	assertRevisions(t, reloaded)
+
+	// полная перезапись файла сохраняет историю
+	require.NoError(t, reloaded.PurgeURL(ctx, otherID, models.AuditEntry{}))
+	reloaded, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	assertRevisions(t, reloaded)
+
+	// безвозвратное удаление удаляет и историю
+	require.NoError(t, reloaded.PurgeURL(ctx, id, models.AuditEntry{}))
+	reloaded, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.revisions)
+}
@@ -0,0 +1,244 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// outboxSuffix добавляется к пути файла хранилища для получения пути к файлу вебхуков и outbox.
+const outboxSuffix = ".outbox"
+
+// outboxCompactionThreshold - сколько устаревших строк должно накопиться в файле outbox,
+// чтобы он был перезаписан только актуальными вебхуками и доставками.
+const outboxCompactionThreshold = 1000
+
+// outboxLine представляет строку файла outbox: вебхук, доставку события или отметку о её выполнении.
+// Изменения дописываются в конец файла: более поздняя строка доставки заменяет предыдущую
+// с тем же идентификатором, а отметка о выполнении удаляет доставку.
+type outboxLine struct {
+	Webhook   *models.Webhook  `json:"webhook,omitempty"`   // вебхук пользователя
+	Delivery  *models.Delivery `json:"delivery,omitempty"`  // доставка события вебхуку
+	Completed string           `json:"completed,omitempty"` // идентификатор выполненной доставки
+}
+
+// CreateWebhook регистрирует вебхук пользователя и сохраняет его на диск.
+func (frepo *FileRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	frepo.webhooks = append(frepo.webhooks, webhook)
+	return frepo.appendOutbox(0, outboxLine{Webhook: &webhook})
+}
+
+// ListWebhooks возвращает вебхуки пользователя в порядке регистрации.
+func (frepo *FileRepository) ListWebhooks(ctx context.Context, userID uuid.UUID) (webhooks []models.Webhook, err error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	webhooks = make([]models.Webhook, 0)
+	for _, w := range frepo.webhooks {
+		if w.UserID == userID {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+// HasWebhooks сообщает, зарегистрированы ли у пользователя вебхуки.
+func (frepo *FileRepository) HasWebhooks(ctx context.Context, userID uuid.UUID) (bool, error) {
+	frepo.mu.RLock()
+	defer frepo.mu.RUnlock()
+
+	return slices.ContainsFunc(frepo.webhooks, func(w models.Webhook) bool { return w.UserID == userID }), nil
+}
+
+// DeleteWebhook удаляет вебхук пользователя вместе с его недоставленными событиями и сохраняет изменения на диск.
+// Возвращает ErrorNotFound, если у пользователя нет такого вебхука.
+func (frepo *FileRepository) DeleteWebhook(ctx context.Context, userID uuid.UUID, id string) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	var deleted int
+	frepo.webhooks, frepo.outbox, deleted = models.DeleteWebhooks(frepo.webhooks, frepo.outbox, func(w models.Webhook) bool {
+		return w.ID == id && w.UserID == userID
+	})
+	if deleted == 0 {
+		return models.ErrorNotFound
+	}
+	return frepo.rewriteOutbox()
+}
+
+// RecordClick добавляет в outbox событие перехода по ссылке с записью record.
+func (frepo *FileRepository) RecordClick(ctx context.Context, record models.Record) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	return frepo.emit(models.EventLinkClicked, record, time.Now().UTC())
+}
+
+// ClaimDeliveries выдает не больше limit доставок, которые пора выполнить,
+// и откладывает их следующую попытку на lease. Отсрочка не сохраняется на диск:
+// после перезапуска выданные, но не завершённые доставки выполняются снова.
+func (frepo *FileRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []models.Delivery, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	return models.ClaimDeliveries(frepo.outbox, frepo.webhooks, time.Now().UTC(), limit, lease), nil
+}
+
+// RetryDelivery сохраняет результат неудачной попытки доставки на диск.
+// Возвращает ErrorNotFound, если доставки нет, например, вебхук уже удалён.
+func (frepo *FileRepository) RetryDelivery(ctx context.Context, delivery models.Delivery) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	if err := models.UpdateDelivery(frepo.outbox, delivery); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(frepo.outbox, func(d models.Delivery) bool { return d.ID == delivery.ID })
+	// новая строка доставки заменяет предыдущую
+	return frepo.appendOutbox(1, outboxLine{Delivery: &frepo.outbox[i]})
+}
+
+// CompleteDelivery удаляет успешно выполненную доставку из outbox и сохраняет изменения на диск.
+func (frepo *FileRepository) CompleteDelivery(ctx context.Context, id string) error {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	i := slices.IndexFunc(frepo.outbox, func(d models.Delivery) bool { return d.ID == id })
+	if i < 0 {
+		return nil
+	}
+	frepo.outbox = slices.Delete(frepo.outbox, i, i+1)
+	// устаревают строка доставки и сама отметка о выполнении
+	return frepo.appendOutbox(2, outboxLine{Completed: id})
+}
+
+// PurgeAbandonedDeliveries удаляет доставки, попытки которых исчерпаны раньше cutoff,
+// и перезаписывает файл outbox, если такие нашлись. Возвращает количество удалённых доставок.
+func (frepo *FileRepository) PurgeAbandonedDeliveries(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	frepo.mu.Lock()
+	defer frepo.mu.Unlock()
+
+	frepo.outbox, purged = models.PurgeAbandoned(frepo.outbox, cutoff)
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, frepo.rewriteOutbox()
+}
+
+// emit добавляет в outbox доставки события eventType ссылки record всем вебхукам её владельца
+// и дописывает их в файл outbox, если доставки появились. Вызывается под блокировкой на запись.
+func (frepo *FileRepository) emit(eventType models.EventType, record models.Record, now time.Time) error {
+	return frepo.saveEvents(frepo.queueEvent(eventType, record, now))
+}
+
+// queueEvent добавляет в outbox доставки события eventType ссылки record всем вебхукам её владельца
+// и возвращает строки файла outbox для них, не записывая их. Вызывается под блокировкой на запись.
+func (frepo *FileRepository) queueEvent(eventType models.EventType, record models.Record, now time.Time) []outboxLine {
+	deliveries := models.NewDeliveries(frepo.webhooks, record.UserID, models.NewEvent(eventType, record, now))
+	lines := make([]outboxLine, 0, len(deliveries))
+	for _, d := range deliveries {
+		d.ID = uuid.NewString()
+		frepo.outbox = append(frepo.outbox, d)
+		lines = append(lines, outboxLine{Delivery: &d})
+	}
+	return lines
+}
+
+// saveEvents дописывает строки доставок lines в файл outbox одной записью, если они есть.
+// Вызывается под блокировкой на запись.
+func (frepo *FileRepository) saveEvents(lines []outboxLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := frepo.appendOutbox(0, lines...); err != nil {
+		return fmt.Errorf("failed to save events to file: %w", err)
+	}
+	return nil
+}
+
+// appendOutbox дописывает строки lines в конец файла outbox. stale - сколько строк файла
+// устаревает после записи. Когда устаревших строк становится больше outboxCompactionThreshold
+// и больше, чем актуальных, файл перезаписывается. Вызывается под блокировкой на запись.
+func (frepo *FileRepository) appendOutbox(stale int, lines ...outboxLine) error {
+	if err := appendLines(frepo.fPath+outboxSuffix, lines); err != nil {
+		return err
+	}
+	frepo.outboxStale += stale
+	if frepo.outboxStale < outboxCompactionThreshold || frepo.outboxStale < len(frepo.webhooks)+len(frepo.outbox) {
+		return nil
+	}
+	return frepo.rewriteOutbox()
+}
+
+// rewriteOutbox полностью перезаписывает файл outbox вебхуками и недоставленными событиями.
+func (frepo *FileRepository) rewriteOutbox() error {
+	lines := make([]any, 0, len(frepo.webhooks)+len(frepo.outbox))
+	for _, w := range frepo.webhooks {
+		lines = append(lines, outboxLine{Webhook: &w})
+	}
+	for _, d := range frepo.outbox {
+		lines = append(lines, outboxLine{Delivery: &d})
+	}
+	if err := rewriteLines(frepo.fPath+outboxSuffix, lines); err != nil {
+		return err
+	}
+	frepo.outboxStale = 0
+	return nil
+}
+
+// loadOutbox читает вебхуки и недоставленные события из файла outbox, применяя изменения доставок
+// в порядке записи. Если файл ещё не создан, outbox остаётся пустым.
+func (frepo *FileRepository) loadOutbox() error {
+	file, err := os.Open(frepo.fPath + outboxSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing file: %v", err)
+		}
+	}()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line outboxLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return err
+		}
+		lines++
+		switch {
+		case line.Webhook != nil:
+			frepo.webhooks = append(frepo.webhooks, *line.Webhook)
+		case line.Delivery != nil:
+			i := slices.IndexFunc(frepo.outbox, func(d models.Delivery) bool { return d.ID == line.Delivery.ID })
+			if i < 0 {
+				frepo.outbox = append(frepo.outbox, *line.Delivery)
+			} else {
+				frepo.outbox[i] = *line.Delivery
+			}
+		case line.Completed != "":
+			frepo.outbox = slices.DeleteFunc(frepo.outbox, func(d models.Delivery) bool { return d.ID == line.Completed })
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error scanning outbox file: %w", err)
+	}
+	frepo.outboxStale = lines - len(frepo.webhooks) - len(frepo.outbox)
+	return nil
+}
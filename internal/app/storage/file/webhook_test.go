@@ -0,0 +1,225 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRepository_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, frepo.CreateWebhook(ctx, webhook))
+
+	// вебхуки переживают перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	webhooks, err := frepo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, webhook.ID, webhooks[0].ID)
+	assert.Equal(t, webhook.Secret, webhooks[0].Secret)
+
+	exists, err := frepo.HasWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = frepo.HasWebhooks(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	webhooks, err = frepo.ListWebhooks(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	assert.ErrorIs(t, frepo.DeleteWebhook(ctx, uuid.New(), webhook.ID), models.ErrorNotFound)
+	require.NoError(t, frepo.DeleteWebhook(ctx, userID, webhook.ID))
+
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	webhooks, err = frepo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestFileRepository_Outbox(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, frepo.CreateWebhook(ctx, webhook))
+
+	// ссылки других пользователей событий вебхуку не добавляют
+	_, _, err = frepo.SaveURL(ctx, uuid.New(), "http://example.com/other")
+	require.NoError(t, err)
+
+	id, _, err := frepo.SaveURL(ctx, userID, "http://example.com")
+	require.NoError(t, err)
+	// повторное удаление события не добавляет
+	for range 2 {
+		_, err = frepo.DeleteByShortURLs(ctx, userID, []string{id})
+		require.NoError(t, err)
+	}
+
+	// недоставленные события переживают перезапуск
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	deliveries, err := frepo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, models.EventLinkCreated, deliveries[0].Event.Type)
+	assert.Equal(t, models.EventLinkDeleted, deliveries[1].Event.Type)
+	for _, d := range deliveries {
+		assert.Equal(t, webhook.URL, d.URL)
+		assert.Equal(t, webhook.Secret, d.Secret)
+		assert.Equal(t, id, d.Event.ShortURL)
+	}
+
+	// выданные доставки не выдаются повторно до истечения отсрочки
+	again, err := frepo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	require.NoError(t, frepo.CompleteDelivery(ctx, deliveries[0].ID))
+	failed := deliveries[1]
+	failed.Attempts, failed.NextAttemptAt, failed.LastError = 1, time.Now().Add(-time.Second), "status 500"
+	require.NoError(t, frepo.RetryDelivery(ctx, failed))
+
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	retried, err := frepo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, retried, 1)
+	assert.Equal(t, failed.ID, retried[0].ID)
+	assert.Equal(t, 1, retried[0].Attempts)
+	assert.Equal(t, "status 500", retried[0].LastError)
+
+	// удаление учётной записи удаляет вебхуки и их события
+	_, err = frepo.DeleteAccount(ctx, userID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, frepo.RetryDelivery(ctx, failed), models.ErrorNotFound)
+	webhooks, err := frepo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestFileRepository_DeleteEvents(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	records := func() []URLRecord {
+		return []URLRecord{
+			{Record: models.Record{ShortURL: "a", OriginalURL: "http://example.com/a", UserID: userID}},
+			{Record: models.Record{ShortURL: "b", OriginalURL: "http://example.com/b", UserID: userID}},
+		}
+	}
+
+	// удаление, которое не удалось сохранить, событий не добавляет
+	fpath := filepath.Join(t.TempDir(), "links")
+	require.NoError(t, os.Mkdir(fpath, 0755))
+	frepo := &FileRepository{fPath: fpath, records: records(), webhooks: []models.Webhook{webhook}}
+	_, err = frepo.DeleteByShortURLs(ctx, userID, []string{"a", "b"})
+	require.Error(t, err)
+	assert.Empty(t, frepo.outbox)
+	assert.NoFileExists(t, fpath+outboxSuffix)
+	for _, r := range frepo.records {
+		assert.False(t, r.IsDeleted, r.ShortURL)
+		assert.Nil(t, r.DeletedAt, r.ShortURL)
+	}
+
+	// события удаления нескольких ссылок дописываются после сохранения удаления
+	fpath = setupTestFile(t)
+	frepo = &FileRepository{fPath: fpath, records: records()}
+	require.NoError(t, frepo.CreateWebhook(ctx, webhook))
+	_, err = frepo.DeleteByShortURLs(ctx, userID, []string{"a", "b"})
+	require.NoError(t, err)
+
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	for _, r := range frepo.records {
+		assert.True(t, r.IsDeleted, r.ShortURL)
+	}
+	deliveries, err := frepo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	for i, d := range deliveries {
+		assert.Equal(t, models.EventLinkDeleted, d.Event.Type)
+		assert.Equal(t, records()[i].ShortURL, d.Event.ShortURL)
+	}
+}
+
+func TestFileRepository_OutboxAppend(t *testing.T) {
+	ctx := context.Background()
+	fpath := setupTestFile(t)
+	userID := uuid.New()
+	countLines := func() int {
+		data, err := os.ReadFile(fpath + outboxSuffix)
+		require.NoError(t, err)
+		return bytes.Count(data, []byte("\n"))
+	}
+
+	frepo, err := NewFileRepository(fpath)
+	require.NoError(t, err)
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, frepo.CreateWebhook(ctx, webhook))
+	record := models.Record{ShortURL: "abc", OriginalURL: "http://example.com", UserID: userID}
+
+	// переходы дописывают события в конец файла, не перезаписывая его
+	const clicks = outboxCompactionThreshold/2 + 10
+	for range clicks {
+		require.NoError(t, frepo.RecordClick(ctx, record))
+	}
+	assert.Equal(t, 1+clicks, countLines())
+
+	deliveries, err := frepo.ClaimDeliveries(ctx, clicks, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, clicks)
+	failed := deliveries[0]
+	failed.Attempts, failed.NextAttemptAt, failed.Abandoned = 8, time.Now().UTC().Add(-time.Hour), true
+	require.NoError(t, frepo.RetryDelivery(ctx, failed))
+	assert.Equal(t, 2+clicks, countLines())
+
+	// выполненные доставки отмечаются строками, пока устаревших строк не станет слишком много
+	for _, d := range deliveries[1:] {
+		require.NoError(t, frepo.CompleteDelivery(ctx, d.ID))
+	}
+	assert.Less(t, countLines(), 1+clicks, "outbox file must be compacted")
+
+	// после перезапуска остаётся только брошенная доставка с результатом последней попытки
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	require.Len(t, frepo.outbox, 1)
+	assert.Equal(t, failed.ID, frepo.outbox[0].ID)
+	assert.True(t, frepo.outbox[0].Abandoned)
+
+	purged, err := frepo.PurgeAbandonedDeliveries(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, 1, countLines())
+
+	frepo, err = NewFileRepository(fpath)
+	require.NoError(t, err)
+	assert.Empty(t, frepo.outbox)
+	webhooks, err := frepo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+}
@@ -2,43 +2,224 @@
 package pg
 
 import (
+	"context"
 	"database/sql"
-
 	"embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/pressly/goose/v3"
+	"github.com/iubondar/url-shortener/internal/app/config"
 )
 
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
-// DB представляет соединение с базой данных
+// Параметры соединения с базой данных по умолчанию.
+const (
+	defaultMaxOpenConns     = 25
+	defaultConnMaxLifetime  = 30 * time.Minute
+	defaultConnMaxIdleTime  = 5 * time.Minute
+	defaultStatementTimeout = 5 * time.Second
+	defaultTxTimeout        = time.Minute
+	defaultConnectTimeout   = 30 * time.Second
+)
+
+// Задержки между попытками подключения при запуске.
+const (
+	connectBackoffMin = 100 * time.Millisecond
+	connectBackoffMax = 5 * time.Second
+)
+
+// Options задаёт параметры соединения с базой данных.
+// Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	MaxOpenConns     int           // максимальное количество открытых соединений
+	MinConns         int           // сколько соединений пул держит открытыми даже без нагрузки, не больше MaxOpenConns
+	ConnMaxLifetime  time.Duration // время, после которого соединение закрывается
+	ConnMaxIdleTime  time.Duration // время простоя, после которого соединение закрывается
+	StatementTimeout time.Duration // ограничение времени одного запроса
+	TxTimeout        time.Duration // ограничение времени транзакции или пакетной загрузки из нескольких запросов
+	ConnectTimeout   time.Duration // сколько ждать доступности базы данных при подключении
+	Migrations       MigrationMode // режим миграций схемы, пустое значение - MigrationAuto
+	// Реплики для чтения: строки подключения, допустимое отставание и интервал проверки состояния
+	ReplicaDSNs          []string
+	ReplicaMaxLag        time.Duration
+	ReplicaCheckInterval time.Duration
+}
+
+// OptionsFromConfig возвращает параметры соединения из конфигурации приложения.
+// Возвращает ошибку, если режим миграций задан неверно.
+func OptionsFromConfig(c config.Config) (Options, error) {
+	mode, err := ParseMigrationMode(c.MigrationMode)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{
+		MaxOpenConns:     c.DBMaxOpenConns,
+		MinConns:         c.DBMinConns,
+		ConnMaxLifetime:  time.Duration(c.DBConnMaxLifetime) * time.Second,
+		ConnMaxIdleTime:  time.Duration(c.DBConnMaxIdleTime) * time.Second,
+		StatementTimeout: time.Duration(c.DBStatementTimeout) * time.Millisecond,
+		TxTimeout:        time.Duration(c.DBTxTimeout) * time.Millisecond,
+		ConnectTimeout:   time.Duration(c.DBConnectTimeout) * time.Second,
+		Migrations:       mode,
+		ReplicaDSNs:      ReplicaDSNs(c.DatabaseReplicaDSNs),
+		ReplicaMaxLag:    time.Duration(c.DBReplicaMaxLag) * time.Millisecond,
+	}, nil
+}
+
+// withDefaults возвращает копию с заполненными значениями по умолчанию.
+func (o Options) withDefaults() Options {
+	if o.MaxOpenConns <= 0 {
+		o.MaxOpenConns = defaultMaxOpenConns
+	}
+	o.MinConns = min(max(o.MinConns, 0), o.MaxOpenConns)
+	if o.ConnMaxLifetime <= 0 {
+		o.ConnMaxLifetime = defaultConnMaxLifetime
+	}
+	if o.ConnMaxIdleTime <= 0 {
+		o.ConnMaxIdleTime = defaultConnMaxIdleTime
+	}
+	if o.StatementTimeout <= 0 {
+		o.StatementTimeout = defaultStatementTimeout
+	}
+	if o.TxTimeout <= 0 {
+		o.TxTimeout = max(defaultTxTimeout, o.StatementTimeout)
+	}
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = defaultConnectTimeout
+	}
+	if o.ReplicaMaxLag <= 0 {
+		o.ReplicaMaxLag = defaultReplicaMaxLag
+	}
+	if o.ReplicaCheckInterval <= 0 {
+		o.ReplicaCheckInterval = defaultReplicaCheckInterval
+	}
+	return o
+}
+
+// DB представляет пул соединений с основной базой данных и, если они заданы, с репликами для чтения.
+// Изменения всегда выполняются на основной базе данных.
 type DB struct {
-	SQLDB *sql.DB // соединение с базой данных
+	Pool             *pgxpool.Pool // пул соединений с основной базой данных
+	SQLDB            *sql.DB       // интерфейс database/sql поверх Pool для миграций и инструментов
+	statementTimeout time.Duration // ограничение времени одного запроса, 0 - без ограничения
+	txTimeout        time.Duration // ограничение времени транзакции, 0 - без ограничения
+	replicas         *replicaSet   // реплики для чтения, nil - реплик нет
 }
 
-// NewDB создает новое соединение с базой данных и инициализирует репозиторий.
-// Выполняет миграции базы данных при первом запуске.
-// Принимает строку подключения к базе данных.
+// NewDB создает новое соединение с базой данных и подготавливает схему в соответствии с режимом opts.Migrations:
+// MigrationAuto применяет неприменённые миграции, MigrationCheckOnly проверяет, что схема не отстаёт
+// от встроенных миграций, MigrationOff схему не трогает.
 // Возвращает указатель на DB и ошибку, если она возникла.
-func NewDB(dsn string) (db *DB, err error) {
-	pgx, err := sql.Open("pgx", dsn)
+func NewDB(dsn string, opts Options) (db *DB, err error) {
+	db, err = Open(dsn, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	goose.SetBaseFS(embedMigrations)
+	ctx := context.Background()
+	switch opts.Migrations {
+	case MigrationAuto, "":
+		var applied []string
+		applied, err = db.MigrateUp(ctx)
+		if len(applied) > 0 {
+			zap.L().Sugar().Infow("Applied database migrations", "migrations", applied)
+		}
+	case MigrationCheckOnly:
+		err = db.CheckSchema(ctx)
+	case MigrationOff:
+	default:
+		err = fmt.Errorf("unknown migration mode %q", opts.Migrations)
+	}
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+
+	return db, nil
+}
+
+// Open создает соединение с базой данных без выполнения миграций и ждёт доступности базы данных
+// не дольше opts.ConnectTimeout, повторяя попытки подключения с экспоненциально растущей задержкой.
+// Если заданы opts.ReplicaDSNs, открывает и соединения с репликами; недоступные реплики не мешают подключению.
+// Используется инструментами, которые управляют схемой самостоятельно.
+func Open(dsn string, opts Options) (*DB, error) {
+	opts = opts.withDefaults()
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	pool, err := newPool(dsn, opts)
+	if err != nil {
 		return nil, err
 	}
+	db := &DB{
+		Pool:             pool,
+		SQLDB:            stdlib.OpenDBFromPool(pool),
+		statementTimeout: opts.StatementTimeout,
+		txTimeout:        opts.TxTimeout,
+	}
+	if err := db.waitReady(opts.ConnectTimeout); err != nil {
+		return nil, errors.Join(err, db.closePrimary())
+	}
+
+	if len(opts.ReplicaDSNs) > 0 {
+		if db.replicas, err = openReplicas(opts.ReplicaDSNs, opts); err != nil {
+			return nil, errors.Join(err, db.closePrimary())
+		}
+	}
+	return db, nil
+}
 
-	if err := goose.Up(pgx, "migrations"); err != nil {
+// newPool создает пул соединений с параметрами opts. Соединения устанавливаются по мере надобности.
+func newPool(dsn string, opts Options) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
 		return nil, err
 	}
+	config.MaxConns = int32(opts.MaxOpenConns)
+	config.MinConns = int32(opts.MinConns)
+	config.MaxConnLifetime = opts.ConnMaxLifetime
+	config.MaxConnIdleTime = opts.ConnMaxIdleTime
+	return pgxpool.NewWithConfig(context.Background(), config)
+}
 
-	return &DB{
-		SQLDB: pgx,
-	}, nil
+// Close останавливает проверку реплик и закрывает соединения с репликами и основной базой данных.
+func (db *DB) Close() error {
+	if db.replicas != nil {
+		db.replicas.close()
+	}
+	return db.closePrimary()
+}
+
+// closePrimary закрывает соединения с основной базой данных.
+func (db *DB) closePrimary() error {
+	err := db.SQLDB.Close()
+	db.Pool.Close()
+	return err
+}
+
+// waitReady проверяет доступность базы данных, повторяя попытки с экспоненциально растущей задержкой,
+// пока база данных не ответит или не истечёт timeout.
+func (db *DB) waitReady(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	backoff := connectBackoffMin
+	for attempt := 1; ; attempt++ {
+		err := db.Pool.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		zap.L().Sugar().Warnw("Database is not available", "attempt", attempt, "retryIn", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not available after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, connectBackoffMax)
+	}
 }
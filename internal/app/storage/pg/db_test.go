@@ -0,0 +1,144 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/testhelpers"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(config.Config{
+		MigrationMode:      "check-only",
+		DBMaxOpenConns:     10,
+		DBMinConns:         20,
+		DBConnMaxLifetime:  600,
+		DBStatementTimeout: 1500,
+		DBTxTimeout:        90000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, MigrationCheckOnly, opts.Migrations)
+	assert.Equal(t, 10*time.Minute, opts.ConnMaxLifetime)
+	assert.Equal(t, 1500*time.Millisecond, opts.StatementTimeout)
+	assert.Equal(t, 90*time.Second, opts.TxTimeout)
+
+	// постоянно открытых соединений не больше максимума, незаданные параметры получают значения по умолчанию
+	opts = opts.withDefaults()
+	assert.Equal(t, 10, opts.MinConns)
+	assert.Equal(t, defaultConnMaxIdleTime, opts.ConnMaxIdleTime)
+	assert.Equal(t, defaultConnectTimeout, opts.ConnectTimeout)
+
+	// тайм-аут транзакции по умолчанию не меньше тайм-аута одного запроса
+	opts = Options{StatementTimeout: 2 * time.Minute}.withDefaults()
+	assert.Equal(t, 2*time.Minute, opts.TxTimeout)
+
+	_, err = OptionsFromConfig(config.Config{MigrationMode: "sometimes"})
+	assert.Error(t, err)
+}
+
+// startProxiedDatabase запускает отдельный контейнер PostgreSQL за прокси, через который
+// тест может разорвать соединения или перенаправить их после перезапуска контейнера.
+// Возвращает контейнер, прокси и строку подключения через прокси.
+func startProxiedDatabase(t *testing.T) (*testhelpers.PostgresContainer, *testhelpers.Proxy, string) {
+	ctx := context.Background()
+	container, err := testhelpers.CreatePostgresContainer(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	endpoint, err := container.Endpoint(ctx)
+	require.NoError(t, err)
+	proxy, err := testhelpers.NewProxy(endpoint)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = proxy.Close() })
+
+	return container, proxy, fmt.Sprintf("postgres://postgres:postgres@%s/test-db?sslmode=disable", proxy.Addr())
+}
+
+func TestOpen_WaitsForDatabase(t *testing.T) {
+	_, proxy, dsn := startProxiedDatabase(t)
+
+	// база данных недоступна дольше тайм-аута подключения
+	proxy.Cut()
+	_, err := Open(dsn, Options{ConnectTimeout: time.Second})
+	require.Error(t, err)
+
+	// база данных становится доступна во время ожидания
+	time.AfterFunc(500*time.Millisecond, func() { proxy.Restore("") })
+	start := time.Now()
+	db, err := Open(dsn, Options{ConnectTimeout: 10 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestStatementTimeout(t *testing.T) {
+	ctx := context.Background()
+	_, _, dsn := startProxiedDatabase(t)
+	db, err := NewDB(dsn, Options{StatementTimeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	ctx, span := db.startQuery(ctx, "SELECT pg_sleep(1);")
+	_, err = db.Pool.Exec(ctx, "SELECT pg_sleep(1);")
+	span.End()
+	require.Error(t, err)
+	assert.False(t, isTransient(err))
+
+	// транзакция из нескольких запросов ограничена тайм-аутом транзакции, а не одного запроса
+	txCtx, span := db.startTx(context.Background(), "SELECT pg_sleep(0.06);")
+	err = pgx.BeginFunc(txCtx, db.Pool, func(tx pgx.Tx) error {
+		for range 3 {
+			if _, err := tx.Exec(txCtx, "SELECT pg_sleep(0.06);"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	span.End()
+	require.NoError(t, err)
+}
+
+func TestReadsSurviveDatabaseRestart(t *testing.T) {
+	ctx := context.Background()
+	container, proxy, dsn := startProxiedDatabase(t)
+	db, err := NewDB(dsn, Options{StatementTimeout: 2 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := NewPGRepository(db, 30*time.Millisecond)
+	require.NoError(t, err)
+
+	id, _, err := repo.SaveURL(ctx, uuid.New(), "http://example.com")
+	require.NoError(t, err)
+
+	// открытые соединения пула разорваны, чтение повторяется на новом соединении
+	proxy.Cut()
+	proxy.Restore("")
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com", record.OriginalURL)
+
+	// пока база данных остановлена, чтение завершается ошибкой, а не зависает
+	proxy.Cut()
+	_, err = repo.RetrieveByShortURL(ctx, id)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, models.ErrorNotFound)
+
+	endpoint, err := container.Restart(ctx)
+	require.NoError(t, err)
+	proxy.Restore(endpoint)
+
+	// после перезапуска пул восстанавливает соединения, данные сохранились
+	assert.Eventually(t, func() bool {
+		record, err = repo.RetrieveByShortURL(ctx, id)
+		return err == nil
+	}, 30*time.Second, 200*time.Millisecond)
+	assert.Equal(t, "http://example.com", record.OriginalURL)
+}
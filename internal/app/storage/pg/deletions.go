@@ -0,0 +1,238 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/metrics"
+)
+
+// Параметры очереди удалений.
+const (
+	defaultDeletionInterval = 5 * time.Second
+	deletionQueueCapacity   = 10_000                 // сколько ссылок может ожидать удаления
+	deletionFlushSize       = 500                    // размер пакета, при котором удаления сохраняются сразу
+	deletionFlushAttempts   = 3                      // попытки сохранить пакет до следующего срабатывания таймера
+	deletionRetryBackoff    = 100 * time.Millisecond // пауза перед повторной попыткой, растёт с каждой попыткой
+	deletionDrainTimeout    = 30 * time.Second       // сколько ждать сохранения оставшихся удалений при закрытии
+)
+
+// deleteIn представляет структуру для удаления URL.
+type deleteIn struct {
+	shortURL string    // короткий идентификатор URL
+	userID   uuid.UUID // идентификатор пользователя
+	jobID    string    // идентификатор задачи удаления
+}
+
+// flushFunc сохраняет пакет удалений и возвращает результаты удалений в том же порядке.
+type flushFunc func(ctx context.Context, deletions []deleteIn) ([]models.DeletionStatus, error)
+
+// deletionQueue накапливает удаления и сохраняет их пакетами в отдельной горутине.
+// Очередь ограничена: когда она заполнена, новые удаления отклоняются с models.ErrorDeletionQueueFull,
+// а не блокируют вызывающего. Удаления остаются в очереди, пока не будут сохранены:
+// при ошибке сохранение повторяется, а при закрытии очередь сохраняет оставшиеся удаления.
+//
+// Очередь также хранит задачи удаления и записывает в них результаты сохранённых удалений.
+// Задачи хранятся в памяти процесса, поэтому их состояние известно только экземпляру сервиса,
+// принявшему удаление, и теряется при перезапуске.
+type deletionQueue struct {
+	mu        sync.Mutex
+	pending   []deleteIn                     // удаления в порядке поступления
+	jobs      map[string]*models.DeletionJob // задачи удаления по идентификатору
+	capacity  int                            // максимальная длина очереди
+	flushSize int                            // размер пакета сохранения
+	closed    bool                           // очередь закрыта и не принимает удаления
+
+	flush    flushFunc     // сохраняет пакет удалений
+	flushNow chan struct{} // сигнал о накоплении полного пакета
+	stop     chan struct{} // закрывается, чтобы остановить очередь
+	done     chan struct{} // закрывается после остановки очереди
+	once     sync.Once
+	err      error // ошибка сохранения оставшихся удалений при закрытии
+}
+
+// newDeletionQueue создает очередь удалений, сохраняющую пакеты функцией flush,
+// и запускает сохранение с интервалом interval.
+func newDeletionQueue(flush flushFunc, interval time.Duration, capacity int, flushSize int) *deletionQueue {
+	q := &deletionQueue{
+		jobs:      make(map[string]*models.DeletionJob),
+		capacity:  capacity,
+		flushSize: flushSize,
+		flush:     flush,
+		flushNow:  make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go q.run(interval)
+	return q
+}
+
+// add добавляет удаления задачи job в очередь целиком или не добавляет ни одного.
+// Не блокируется: если очередь заполнена или закрыта, возвращает ошибку models.ErrorDeletionQueueFull.
+func (q *deletionQueue) add(job models.DeletionJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return fmt.Errorf("%w: storage is closing", models.ErrorDeletionQueueFull)
+	}
+	if len(q.pending)+len(job.Items) > q.capacity {
+		return models.ErrorDeletionQueueFull
+	}
+	// результаты записываются в копию, чтобы не менять задачу, возвращённую вызывающему
+	job.Items = slices.Clone(job.Items)
+	q.jobs[job.ID] = &job
+	for _, item := range job.Items {
+		q.pending = append(q.pending, deleteIn{shortURL: item.ShortURL, userID: job.UserID, jobID: job.ID})
+	}
+	metrics.DeleteQueueDepth.Add(float64(len(job.Items)))
+
+	if len(q.pending) >= q.flushSize {
+		select {
+		case q.flushNow <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// job возвращает копию задачи удаления id пользователя userID
+// или models.ErrorNotFound, если задача неизвестна, устарела или принадлежит другому пользователю.
+func (q *deletionQueue) job(userID uuid.UUID, id string) (models.DeletionJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.UserID != userID || job.Expired(time.Now()) {
+		return models.DeletionJob{}, models.ErrorNotFound
+	}
+	out := *job
+	out.Items = slices.Clone(job.Items)
+	return out, nil
+}
+
+// pruneJobs удаляет задачи, срок хранения которых истёк.
+func (q *deletionQueue) pruneJobs() {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for id, job := range q.jobs {
+		if job.Expired(now) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// run сохраняет удаления по таймеру и при накоплении полного пакета, пока очередь не остановлена.
+// При остановке сохраняет оставшиеся удаления, ожидая не дольше deletionDrainTimeout.
+func (q *deletionQueue) run(interval time.Duration) {
+	defer close(q.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			q.drain()
+			return
+		case <-ticker.C:
+			q.pruneJobs()
+		case <-q.flushNow:
+		}
+		if err := q.flushPending(context.Background()); err != nil {
+			// удаления остаются в очереди и будут сохранены при следующем срабатывании
+			zap.L().Sugar().Errorw("Cannot save deletions, will retry", "pending", q.size(), "error", err)
+		}
+	}
+}
+
+// drain сохраняет оставшиеся удаления при закрытии очереди.
+func (q *deletionQueue) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), deletionDrainTimeout)
+	defer cancel()
+
+	err := q.flushPending(ctx)
+	if err == nil {
+		return
+	}
+
+	q.mu.Lock()
+	lost := len(q.pending)
+	q.pending = nil
+	q.mu.Unlock()
+	metrics.DeleteQueueDepth.Sub(float64(lost))
+	q.err = fmt.Errorf("save %d pending deletions: %w", lost, err)
+}
+
+// flushPending сохраняет накопленные удаления пакетами не больше flushSize.
+// Пакет удаляется из очереди только после успешного сохранения, тогда же результаты записываются в задачи.
+func (q *deletionQueue) flushPending(ctx context.Context) error {
+	for {
+		q.mu.Lock()
+		batch := make([]deleteIn, min(len(q.pending), q.flushSize))
+		copy(batch, q.pending)
+		q.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		statuses, err := q.flushWithRetry(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		// новые удаления добавляются в конец, поэтому сохранённый пакет всё ещё в начале очереди
+		now := time.Now()
+		q.mu.Lock()
+		q.pending = q.pending[len(batch):]
+		for i, d := range batch {
+			if job, ok := q.jobs[d.jobID]; ok {
+				job.Resolve(d.shortURL, statuses[i], now)
+			}
+		}
+		q.mu.Unlock()
+		metrics.DeleteQueueDepth.Sub(float64(len(batch)))
+	}
+}
+
+// flushWithRetry сохраняет пакет, повторяя попытки с растущей паузой.
+func (q *deletionQueue) flushWithRetry(ctx context.Context, batch []deleteIn) (statuses []models.DeletionStatus, err error) {
+	for attempt := 1; ; attempt++ {
+		if statuses, err = q.flush(ctx, batch); err == nil || attempt == deletionFlushAttempts {
+			return statuses, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(time.Duration(attempt) * deletionRetryBackoff):
+		}
+	}
+}
+
+// size возвращает количество удалений в очереди.
+func (q *deletionQueue) size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// close перестаёт принимать удаления, сохраняет оставшиеся и останавливает очередь.
+// Возвращает ошибку, если оставшиеся удаления сохранить не удалось. Повторные вызовы безопасны.
+func (q *deletionQueue) close() error {
+	q.once.Do(func() {
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+
+		close(q.stop)
+		<-q.done
+	})
+	return q.err
+}
@@ -0,0 +1,199 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// flushRecorder запоминает сохранённые пакеты удалений и может завершать сохранение ошибкой.
+type flushRecorder struct {
+	mu       sync.Mutex
+	batches  [][]deleteIn
+	failures int // сколько следующих сохранений завершится ошибкой, отрицательное значение - все
+}
+
+func (r *flushRecorder) flush(ctx context.Context, deletions []deleteIn) ([]models.DeletionStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures != 0 {
+		r.failures--
+		return nil, errors.New("connection refused")
+	}
+	r.batches = append(r.batches, deletions)
+	statuses := make([]models.DeletionStatus, len(deletions))
+	for i := range statuses {
+		statuses[i] = models.DeletionDeleted
+	}
+	return statuses, nil
+}
+
+// newJob создает задачу удаления ссылок shortURLs нового пользователя.
+func newJob(shortURLs ...string) models.DeletionJob {
+	return models.NewDeletionJob(uuid.New(), shortURLs, time.Now())
+}
+
+func (r *flushRecorder) saved() (shortURLs []string, sizes []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, batch := range r.batches {
+		sizes = append(sizes, len(batch))
+		for _, d := range batch {
+			shortURLs = append(shortURLs, d.shortURL)
+		}
+	}
+	return shortURLs, sizes
+}
+
+func TestDeletionQueue_Add(t *testing.T) {
+	recorder := &flushRecorder{}
+	q := newDeletionQueue(recorder.flush, time.Hour, 3, 10)
+	t.Cleanup(func() { _ = q.close() })
+
+	require.NoError(t, q.add(newJob("a", "b")))
+	// удаления, не помещающиеся в очередь, отклоняются целиком
+	rejected := newJob("c", "d")
+	require.ErrorIs(t, q.add(rejected), models.ErrorDeletionQueueFull)
+	assert.Equal(t, 2, q.size())
+	_, err := q.job(rejected.UserID, rejected.ID)
+	require.ErrorIs(t, err, models.ErrorNotFound)
+	require.NoError(t, q.add(newJob("c")))
+	assert.Equal(t, 3, q.size())
+}
+
+func TestDeletionQueue_FlushOnThreshold(t *testing.T) {
+	recorder := &flushRecorder{}
+	q := newDeletionQueue(recorder.flush, time.Hour, 10, 2)
+	t.Cleanup(func() { _ = q.close() })
+
+	require.NoError(t, q.add(newJob("a", "b", "c")))
+	assert.Eventually(t, func() bool { return q.size() == 0 }, time.Second, 10*time.Millisecond)
+
+	shortURLs, sizes := recorder.saved()
+	assert.Equal(t, []string{"a", "b", "c"}, shortURLs)
+	assert.Equal(t, []int{2, 1}, sizes)
+}
+
+func TestDeletionQueue_Job(t *testing.T) {
+	recorder := &flushRecorder{}
+	q := newDeletionQueue(recorder.flush, time.Hour, 10, 10)
+	t.Cleanup(func() { _ = q.close() })
+
+	job := newJob("a", "b")
+	require.NoError(t, q.add(job))
+	got, err := q.job(job.UserID, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.CompletedAt)
+	assert.Equal(t, job.Items, got.Items)
+
+	// задачу видит только её владелец
+	_, err = q.job(uuid.New(), job.ID)
+	require.ErrorIs(t, err, models.ErrorNotFound)
+
+	// результаты появляются после сохранения, задача вызывающего не меняется
+	require.NoError(t, q.flushPending(context.Background()))
+	got, err = q.job(job.UserID, job.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, got.CompletedAt)
+	assert.Equal(t, []models.DeletionItem{
+		{ShortURL: "a", Status: models.DeletionDeleted},
+		{ShortURL: "b", Status: models.DeletionDeleted},
+	}, got.Items)
+	assert.Equal(t, models.DeletionPending, job.Items[0].Status)
+
+	// завершённая задача забывается по истечении срока хранения
+	completed := got.CompletedAt.Add(-models.DeletionJobTTL - time.Second)
+	q.jobs[job.ID].CompletedAt = &completed
+	q.pruneJobs()
+	_, err = q.job(job.UserID, job.ID)
+	require.ErrorIs(t, err, models.ErrorNotFound)
+}
+
+func TestDeletionQueue_RetryKeepsDeletions(t *testing.T) {
+	// первое срабатывание исчерпывает попытки, удаления сохраняются при следующем
+	recorder := &flushRecorder{failures: deletionFlushAttempts + 1}
+	q := newDeletionQueue(recorder.flush, 20*time.Millisecond, 10, 10)
+	t.Cleanup(func() { _ = q.close() })
+
+	require.NoError(t, q.add(newJob("a", "b")))
+	assert.Eventually(t, func() bool { return q.size() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	shortURLs, _ := recorder.saved()
+	assert.Equal(t, []string{"a", "b"}, shortURLs)
+}
+
+func TestDeletionQueue_Close(t *testing.T) {
+	recorder := &flushRecorder{}
+	q := newDeletionQueue(recorder.flush, time.Hour, 10, 10)
+
+	require.NoError(t, q.add(newJob("a", "b")))
+	require.NoError(t, q.close())
+	require.NoError(t, q.close())
+
+	// оставшиеся удаления сохранены, новые не принимаются
+	shortURLs, _ := recorder.saved()
+	assert.Equal(t, []string{"a", "b"}, shortURLs)
+	require.ErrorIs(t, q.add(newJob("c")), models.ErrorDeletionQueueFull)
+}
+
+func TestDeletionQueue_CloseReportsLostDeletions(t *testing.T) {
+	recorder := &flushRecorder{failures: -1}
+	q := newDeletionQueue(recorder.flush, time.Hour, 10, 10)
+
+	require.NoError(t, q.add(newJob("a", "b")))
+	err := q.close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "save 2 pending deletions")
+	assert.Equal(t, 0, q.size())
+}
+
+func TestPGRepository_CloseSavesPendingDeletions(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+	setupSeparateTest(t, "INSERT INTO urls (short_url, original_url, user_id) VALUES "+
+		"('4rSPg8ap', 'http://yandex.ru', '"+userID.String()+"'), "+
+		"('edVPg3ks', 'http://ya.ru', '"+userID.String()+"'), "+
+		"('k3Lm9QzT', 'http://example.com', '"+otherID.String()+"');")
+
+	// удаления не сохраняются по таймеру, поэтому их сохраняет только закрытие
+	ctx := context.Background()
+	r, err := NewPGRepository(repo.db, time.Hour)
+	require.NoError(t, err)
+	job, err := r.DeleteByShortURLs(ctx, userID, []string{"4rSPg8ap", "edVPg3ks", "k3Lm9QzT", "missing1"})
+	require.NoError(t, err)
+	_, err = r.DeleteByShortURLs(ctx, otherID, []string{"k3Lm9QzT"})
+	require.NoError(t, err)
+
+	pending, err := r.RetrieveDeletionJob(ctx, userID, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, pending.CompletedAt)
+	require.NoError(t, r.Close())
+
+	for _, shortURL := range []string{"4rSPg8ap", "edVPg3ks", "k3Lm9QzT"} {
+		record, err := repo.RetrieveByShortURL(ctx, shortURL)
+		require.NoError(t, err)
+		assert.True(t, record.IsDeleted, shortURL)
+	}
+
+	// результаты удалений записаны в задачу
+	done, err := r.RetrieveDeletionJob(ctx, userID, job.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, done.CompletedAt)
+	assert.Equal(t, []models.DeletionItem{
+		{ShortURL: "4rSPg8ap", Status: models.DeletionDeleted},
+		{ShortURL: "edVPg3ks", Status: models.DeletionDeleted},
+		{ShortURL: "k3Lm9QzT", Status: models.DeletionNotOwned},
+		{ShortURL: "missing1", Status: models.DeletionNotFound},
+	}, done.Items)
+
+	_, err = r.DeleteByShortURLs(ctx, userID, []string{"4rSPg8ap"})
+	require.ErrorIs(t, err, models.ErrorDeletionQueueFull)
+}
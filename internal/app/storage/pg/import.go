@@ -0,0 +1,79 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/app/strings"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ImportURL сохраняет импортированную запись пользователя и событие её создания в outbox в одной транзакции.
+// Короткий идентификатор записи сохраняется, если он допустим и свободен, иначе создаётся новый.
+// Возвращает идентификатор сохранённой записи или, вместе с ErrorOriginalURLExists,
+// идентификатор ссылки, которая уже сокращает этот адрес.
+func (repo *PGRepository) ImportURL(ctx context.Context, record models.Record) (id string, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.ImportURL)
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := repo.db.Pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	// если Commit будет раньше, то откат проигнорируется
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				zap.L().Sugar().Errorf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	err = tx.QueryRow(ctx, queries.GetShortURL, record.OriginalURL).Scan(&id)
+	if err == nil {
+		return id, models.ErrorOriginalURLExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	taken := false
+	if models.ValidShortURL(record.ShortURL) {
+		if err = tx.QueryRow(ctx, queries.ExistsShortURL, record.ShortURL).Scan(&taken); err != nil {
+			return "", err
+		}
+	}
+	if taken || !models.ValidShortURL(record.ShortURL) {
+		record.ShortURL = strings.RandString(8)
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	tags := record.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	_, err = tx.Exec(ctx, queries.ImportURL,
+		record.ShortURL, record.OriginalURL, record.UserID, record.CreatedAt, record.ExpiresAt, record.Title, tags)
+	if err != nil {
+		return "", err
+	}
+	event := models.NewEvent(models.EventLinkCreated, record, record.CreatedAt)
+	_, err = tx.Exec(ctx, queries.InsertEvent,
+		record.UserID, event.ID, string(event.Type), event.ShortURL, event.OriginalURL, event.OccurredAt)
+	if err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	repo.db.noteWrites(shortURLKey(record.ShortURL), userKey(record.UserID))
+	return record.ShortURL, nil
+}
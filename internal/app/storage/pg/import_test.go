@@ -0,0 +1,43 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportURL(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+	userID := uuid.New()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	expires := created.Add(24 * time.Hour)
+
+	id, err := repo.ImportURL(ctx, models.Record{
+		ShortURL: "abc", OriginalURL: "http://example.com", UserID: userID,
+		CreatedAt: created, ExpiresAt: &expires, Title: "Пример", Tags: []string{"a", "b"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+
+	record, err := repo.RetrieveByShortURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, created.Equal(record.CreatedAt))
+	require.NotNil(t, record.ExpiresAt)
+	assert.True(t, expires.Equal(*record.ExpiresAt))
+	assert.Equal(t, "Пример", record.Title)
+	assert.Equal(t, []string{"a", "b"}, record.Tags)
+
+	renamed, err := repo.ImportURL(ctx, models.Record{ShortURL: "abc", OriginalURL: "http://example.org", UserID: userID})
+	require.NoError(t, err)
+	assert.NotEqual(t, "abc", renamed)
+
+	id, err = repo.ImportURL(ctx, models.Record{OriginalURL: "http://example.com", UserID: userID})
+	assert.ErrorIs(t, err, models.ErrorOriginalURLExists)
+	assert.Equal(t, "abc", id)
+}
@@ -0,0 +1,57 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// UpdateLink изменяет свойства ссылки пользователя в одной транзакции.
+// Запись блокируется на время изменения, чтобы параллельные изменения не потерялись.
+// Возвращает обновлённую запись или ErrorNotFound, если ссылки нет или она принадлежит другому пользователю.
+func (repo *PGRepository) UpdateLink(ctx context.Context, userID uuid.UUID, shortURL string, update models.LinkUpdate) (record models.Record, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.UpdateLink)
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := repo.db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+	// если Commit будет раньше, то откат проигнорируется
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				zap.L().Sugar().Errorf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	record, err = scanRecord(tx.QueryRow(ctx, queries.LockByShortURL, shortURL))
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && record.UserID != userID) {
+		err = models.ErrorNotFound
+	}
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	update.Apply(&record)
+	tags := record.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	if _, err = tx.Exec(ctx, queries.UpdateLink, shortURL, record.Title, tags, record.ExpiresAt); err != nil {
+		return models.Record{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Record{}, err
+	}
+	repo.db.noteWrites(shortURLKey(shortURL), userKey(userID))
+	return record, nil
+}
@@ -0,0 +1,43 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLink(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+	userID := uuid.New()
+	id, _, err := repo.SaveURL(ctx, userID, "http://example.com")
+	require.NoError(t, err)
+
+	title := "Example"
+	tags := []string{"docs", "work"}
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = repo.UpdateLink(ctx, userID, id, models.LinkUpdate{Title: &title, Tags: &tags, SetExpiresAt: true, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, title, record.Title)
+	assert.Equal(t, tags, record.Tags)
+	require.NotNil(t, record.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*record.ExpiresAt))
+	assert.WithinDuration(t, time.Now(), record.CreatedAt, time.Minute)
+
+	record, err = repo.UpdateLink(ctx, userID, id, models.LinkUpdate{SetExpiresAt: true})
+	require.NoError(t, err)
+	assert.Nil(t, record.ExpiresAt)
+
+	_, err = repo.UpdateLink(ctx, uuid.New(), id, models.LinkUpdate{Title: &title})
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = repo.UpdateLink(ctx, userID, "unknown", models.LinkUpdate{Title: &title})
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+}
@@ -0,0 +1,40 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+)
+
+// SetDeleted помечает запись удаленной или снимает отметку об удалении.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *PGRepository) SetDeleted(ctx context.Context, shortURL string, deleted bool) error {
+	return repo.execAffectingOne(ctx, queries.SetDeleted, shortURL, deleted)
+}
+
+// PurgeDeleted безвозвратно удаляет все удаленные пользователями записи вместе с историей их версий.
+// Возвращает количество удаленных записей.
+func (repo *PGRepository) PurgeDeleted(ctx context.Context) (purged int, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.PurgeDeleted)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := repo.db.Pool.Exec(ctx, queries.PurgeDeleted)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// CountURLs возвращает сводку по записям хранилища.
+func (repo *PGRepository) CountURLs(ctx context.Context) (counts models.URLCounts, err error) {
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.CountURLs)
+		defer func() { tracing.End(span, err) }()
+
+		return repo.db.Pool.QueryRow(ctx, queries.CountURLs).
+			Scan(&counts.Total, &counts.Deleted, &counts.Disabled, &counts.Users)
+	})
+	return counts, err
+}
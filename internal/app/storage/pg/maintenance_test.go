@@ -0,0 +1,43 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+	userID := uuid.New()
+
+	deleted, _, err := repo.SaveURL(ctx, userID, "http://example.com/a")
+	require.NoError(t, err)
+	_, _, err = repo.SaveURL(ctx, userID, "http://example.com/b")
+	require.NoError(t, err)
+	_, _, err = repo.SaveURL(ctx, uuid.New(), "http://example.com/c")
+	require.NoError(t, err)
+	_, err = repo.UpdateOriginalURL(ctx, userID, deleted, "http://example.org")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, repo.SetDeleted(ctx, "missing", true), models.ErrorNotFound)
+	require.NoError(t, repo.SetDeleted(ctx, deleted, true))
+
+	counts, err := repo.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.URLCounts{Total: 3, Deleted: 1, Users: 2}, counts)
+
+	// история версий удаляется вместе с записью
+	purged, err := repo.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	_, err = repo.RetrieveByShortURL(ctx, deleted)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	var revisions int
+	require.NoError(t, repo.db.Pool.QueryRow(ctx, "SELECT count(*) FROM url_revisions;").Scan(&revisions))
+	assert.Zero(t, revisions)
+}
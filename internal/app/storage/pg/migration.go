@@ -0,0 +1,69 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ScanURLs возвращает до limit записей всех пользователей с короткими идентификаторами больше after
+// в порядке возрастания идентификаторов. Используется для переноса данных между хранилищами.
+func (repo *PGRepository) ScanURLs(ctx context.Context, after string, limit int) (records []models.Record, err error) {
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.ScanURLs)
+		defer func() { tracing.End(span, err) }()
+
+		records, err = queryRecords(ctx, repo.db.Pool, queries.ScanURLs, after, limit)
+		return err
+	})
+	return records, err
+}
+
+// CopyURLs сохраняет записи без изменений, включая идентификаторы, владельцев и флаги удаления,
+// в одной транзакции. Записи, чей короткий идентификатор или оригинальный URL уже есть в таблице,
+// пропускаются. Возвращает короткие идентификаторы сохранённых записей.
+func (repo *PGRepository) CopyURLs(ctx context.Context, records []models.Record) (copied []string, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.CopyURL)
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := repo.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// если Commit будет раньше, то откат проигнорируется
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				zap.L().Sugar().Errorf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	copied = make([]string, 0, len(records))
+	for _, r := range records {
+		tags := r.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		var id string
+		err = tx.QueryRow(ctx, queries.CopyURL,
+			r.ShortURL, r.OriginalURL, r.UserID, r.IsDeleted, r.DisabledReason, r.DisabledLegal,
+			r.CreatedAt, r.ExpiresAt, r.Title, tags, r.DeletedAt,
+		).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// запись с таким идентификатором или адресом уже есть
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		copied = append(copied, id)
+	}
+
+	return copied, tx.Commit(ctx)
+}
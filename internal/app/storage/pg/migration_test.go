@@ -0,0 +1,55 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyURLs(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+	existing, _, err := repo.SaveURL(ctx, uuid.New(), "http://example.com/b")
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := created.Add(time.Hour)
+	copied, err := repo.CopyURLs(ctx, []models.Record{
+		{ShortURL: "c", OriginalURL: "http://example.com/c", UserID: userID, IsDeleted: true, CreatedAt: created, Tags: []string{"x"}},
+		{ShortURL: "x", OriginalURL: "http://example.com/b", UserID: userID, CreatedAt: created},
+		{ShortURL: existing, OriginalURL: "http://example.org", UserID: userID, CreatedAt: created},
+		{ShortURL: "a", OriginalURL: "http://example.com/a", UserID: userID, DisabledReason: "spam", CreatedAt: created, ExpiresAt: &expires},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a"}, copied)
+
+	page, err := repo.ScanURLs(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	records := make(map[string]models.Record, len(page))
+	for _, r := range page {
+		records[r.ShortURL] = r
+	}
+	assert.Equal(t, "spam", records["a"].DisabledReason)
+	require.NotNil(t, records["a"].ExpiresAt)
+	assert.True(t, expires.Equal(*records["a"].ExpiresAt))
+	assert.True(t, records["c"].IsDeleted)
+	assert.Equal(t, userID, records["c"].UserID)
+	assert.True(t, created.Equal(records["c"].CreatedAt))
+	assert.Equal(t, []string{"x"}, records["c"].Tags)
+
+	// следующая страница начинается после последней записи предыдущей
+	first, err := repo.ScanURLs(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	rest, err := repo.ScanURLs(ctx, first[1].ShortURL, 2)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, page[2].ShortURL, rest[0].ShortURL)
+}
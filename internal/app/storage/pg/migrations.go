@@ -0,0 +1,158 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// MigrationMode задаёт, что делать со схемой базы данных при подключении.
+type MigrationMode string
+
+// Режимы миграций схемы.
+const (
+	MigrationAuto      MigrationMode = "auto"       // применить неприменённые миграции
+	MigrationCheckOnly MigrationMode = "check-only" // только проверить, что схема актуальна
+	MigrationOff       MigrationMode = "off"        // не трогать схему
+)
+
+// ParseMigrationMode разбирает режим миграций схемы. Пустая строка означает MigrationAuto.
+func ParseMigrationMode(s string) (MigrationMode, error) {
+	switch mode := MigrationMode(s); mode {
+	case "":
+		return MigrationAuto, nil
+	case MigrationAuto, MigrationCheckOnly, MigrationOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown migration mode %q: must be auto, check-only or off", s)
+	}
+}
+
+// ErrSchemaBehind возвращается, когда схема базы данных отстаёт от встроенных миграций.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// MigrationStatus представляет состояние встроенной миграции схемы.
+type MigrationStatus struct {
+	Version   int64      `json:"version"`              // версия миграции
+	Name      string     `json:"name"`                 // имя файла миграции
+	Applied   bool       `json:"applied"`              // миграция применена
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // время применения
+}
+
+// MigrateUp применяет все неприменённые миграции и возвращает имена их файлов.
+func (db *DB) MigrateUp(ctx context.Context) (applied []string, err error) {
+	provider, err := db.migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied = make([]string, 0, len(results))
+	for _, r := range results {
+		applied = append(applied, filepath.Base(r.Source.Path))
+	}
+	return applied, nil
+}
+
+// MigrateDown откатывает последнюю применённую миграцию и возвращает имя её файла
+// или пустую строку, если откатывать нечего.
+func (db *DB) MigrateDown(ctx context.Context) (reverted string, err error) {
+	provider, err := db.migrations()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(result.Source.Path), nil
+}
+
+// SchemaVersion возвращает версию последней применённой миграции или 0, если миграции не применялись.
+func (db *DB) SchemaVersion(ctx context.Context) (version int64, err error) {
+	provider, err := db.migrations()
+	if err != nil {
+		return 0, err
+	}
+	return provider.GetDBVersion(ctx)
+}
+
+// CheckSchema проверяет, что все встроенные миграции применены.
+// Возвращает ошибку, обёрнутую в ErrSchemaBehind, с текущей и требуемой версиями схемы.
+// Проверка не ждёт блокировку миграций, поэтому не зависает, пока другой экземпляр применяет миграции.
+func (db *DB) CheckSchema(ctx context.Context) error {
+	provider, err := db.migrations()
+	if err != nil {
+		return err
+	}
+
+	pending, err := provider.HasPending(ctx)
+	if err != nil {
+		return fmt.Errorf("check database schema: %w", err)
+	}
+	if !pending {
+		return nil
+	}
+	current, target, err := provider.GetVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("check database schema: %w", err)
+	}
+	return fmt.Errorf("%w: version %d, required %d; apply migrations with \"shortenerctl migrate up\" or use migration mode auto",
+		ErrSchemaBehind, current, target)
+}
+
+// MigrationStatus возвращает состояние всех встроенных миграций в порядке версий.
+func (db *DB) MigrationStatus(ctx context.Context) (statuses []MigrationStatus, err error) {
+	provider, err := db.migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses = make([]MigrationStatus, 0, len(results))
+	for _, r := range results {
+		status := MigrationStatus{
+			Version: r.Source.Version,
+			Name:    filepath.Base(r.Source.Path),
+			Applied: r.State == goose.StateApplied,
+		}
+		if status.Applied {
+			status.AppliedAt = &r.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// migrations создает провайдер встроенных миграций для соединения.
+// Применение и откат миграций выполняются под рекомендательной блокировкой PostgreSQL,
+// поэтому при одновременном запуске нескольких экземпляров миграции применяет только один из них,
+// а остальные дожидаются его завершения.
+// Провайдер не закрывается: он закрыл бы соединение с базой данных.
+func (db *DB) migrations() (*goose.Provider, error) {
+	migrations, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db.SQLDB, migrations, goose.WithSessionLocker(locker))
+}
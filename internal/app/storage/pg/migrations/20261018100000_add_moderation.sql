@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE urls ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE urls ADD COLUMN disabled_legal BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS admin_audit (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor TEXT NOT NULL,
+    remote_addr TEXT NOT NULL DEFAULT '',
+    action VARCHAR(16) NOT NULL,
+    short_url VARCHAR(10) NOT NULL,
+    details TEXT NOT NULL DEFAULT '');
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS admin_audit;
+
+ALTER TABLE urls DROP COLUMN disabled_legal;
+
+ALTER TABLE urls DROP COLUMN disabled_reason;
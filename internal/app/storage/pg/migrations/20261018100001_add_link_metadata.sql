@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE urls ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+ALTER TABLE urls ADD COLUMN expires_at TIMESTAMPTZ;
+
+ALTER TABLE urls ADD COLUMN title TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE urls ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE urls DROP COLUMN tags;
+
+ALTER TABLE urls DROP COLUMN title;
+
+ALTER TABLE urls DROP COLUMN expires_at;
+
+ALTER TABLE urls DROP COLUMN created_at;
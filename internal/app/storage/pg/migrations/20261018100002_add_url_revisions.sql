@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS url_revisions (
+    id SERIAL PRIMARY KEY,
+    short_url VARCHAR(10) NOT NULL REFERENCES urls (short_url) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    original_url VARCHAR(2048) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (short_url, revision));
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS url_revisions;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE INDEX IF NOT EXISTS user_created_at_index ON urls (user_id, created_at, short_url);
+
+CREATE INDEX IF NOT EXISTS user_original_url_index ON urls (user_id, original_url, short_url);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS user_original_url_index;
+
+DROP INDEX IF EXISTS user_created_at_index;
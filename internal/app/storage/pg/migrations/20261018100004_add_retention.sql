@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- записи, удалённые до появления времени удаления, хранятся полный срок с момента миграции
+UPDATE urls SET deleted_at = now() WHERE is_deleted AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS deleted_at_index ON urls (deleted_at) WHERE is_deleted;
+
+CREATE INDEX IF NOT EXISTS expires_at_index ON urls (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS revoked_users (
+    user_id UUID PRIMARY KEY,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS revoked_users;
+
+DROP INDEX IF EXISTS expires_at_index;
+
+DROP INDEX IF EXISTS deleted_at_index;
+
+ALTER TABLE urls DROP COLUMN IF EXISTS deleted_at;
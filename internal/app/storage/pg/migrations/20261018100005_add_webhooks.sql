@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_user_id_index ON webhooks (user_id);
+
+-- события пишутся в outbox в той же транзакции, что и изменения ссылок, по строке на каждый вебхук владельца
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    short_url VARCHAR(10) NOT NULL,
+    original_url VARCHAR(2048) NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT NOT NULL DEFAULT '',
+    abandoned BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS webhook_outbox_due_index ON webhook_outbox (next_attempt_at) WHERE NOT abandoned;
+
+CREATE INDEX IF NOT EXISTS webhook_outbox_webhook_id_index ON webhook_outbox (webhook_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS webhook_outbox;
+
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- брошенные доставки удаляются по времени последней попытки
+CREATE INDEX IF NOT EXISTS webhook_outbox_abandoned_index ON webhook_outbox (next_attempt_at) WHERE abandoned;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS webhook_outbox_abandoned_index;
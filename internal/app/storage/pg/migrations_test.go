@@ -0,0 +1,75 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	statuses, err := repo.db.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, s.Name)
+		assert.NotNil(t, s.AppliedAt, s.Name)
+	}
+	last := statuses[len(statuses)-1]
+
+	require.NoError(t, repo.db.CheckSchema(ctx))
+	version, err := repo.db.SchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last.Version, version)
+
+	reverted, err := repo.db.MigrateDown(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last.Name, reverted)
+
+	// в режиме check-only отставшая схема не даёт запустить сервис
+	err = repo.db.CheckSchema(ctx)
+	require.ErrorIs(t, err, ErrSchemaBehind)
+	assert.Contains(t, err.Error(), fmt.Sprint(last.Version))
+
+	statuses, err = repo.db.MigrationStatus(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	applied, err := repo.db.MigrateUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{last.Name}, applied)
+
+	applied, err = repo.db.MigrateUp(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestParseMigrationMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    MigrationMode
+		wantErr bool
+	}{
+		{value: "", want: MigrationAuto},
+		{value: "auto", want: MigrationAuto},
+		{value: "check-only", want: MigrationCheckOnly},
+		{value: "off", want: MigrationOff},
+		{value: "manual", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mode, err := ParseMigrationMode(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// SearchURLs ищет записи, удовлетворяющие фильтру модератора.
+// Возвращает массив записей и ошибку.
+func (repo *PGRepository) SearchURLs(ctx context.Context, filter models.SearchFilter) (records []models.Record, err error) {
+	var userID, limit any
+	if filter.UserID != uuid.Nil {
+		userID = filter.UserID.String()
+	}
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.SearchURLs)
+		defer func() { tracing.End(span, err) }()
+
+		records, err = queryRecords(ctx, repo.db.Pool, queries.SearchURLs, filter.OriginalURL, userID, filter.ShortURL, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// DisableURL блокирует запись с указанной причиной
+// и в той же транзакции добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *PGRepository) DisableURL(ctx context.Context, shortURL string, reason string, legal bool, entry models.AuditEntry) error {
+	return repo.moderate(ctx, entry, queries.DisableURL, shortURL, reason, legal)
+}
+
+// RestoreURL снимает блокировку с записи
+// и в той же транзакции добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *PGRepository) RestoreURL(ctx context.Context, shortURL string, entry models.AuditEntry) error {
+	return repo.moderate(ctx, entry, queries.RestoreURL, shortURL)
+}
+
+// PurgeURL безвозвратно удаляет запись из базы данных
+// и в той же транзакции добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *PGRepository) PurgeURL(ctx context.Context, shortURL string, entry models.AuditEntry) error {
+	return repo.moderate(ctx, entry, queries.PurgeURL, shortURL)
+}
+
+// RetrieveAuditLog возвращает журнал действий модератора в порядке добавления.
+func (repo *PGRepository) RetrieveAuditLog(ctx context.Context) (entries []models.AuditEntry, err error) {
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		entries, err = repo.retrieveAuditLog(ctx)
+		return err
+	})
+	return entries, err
+}
+
+// retrieveAuditLog читает журнал действий модератора.
+func (repo *PGRepository) retrieveAuditLog(ctx context.Context) (entries []models.AuditEntry, err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.GetAuditLog)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := repo.db.Pool.Query(ctx, queries.GetAuditLog)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries = make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		err = rows.Scan(&entry.Time, &entry.Actor, &entry.RemoteAddr, &entry.Action, &entry.ShortURL, &entry.Details)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error processing rows: %s", err.Error())
+	}
+
+	return entries, nil
+}
+
+// moderate выполняет запрос изменения query по короткому идентификатору shortURL,
+// который передаётся первым параметром запроса, за ним следуют args,
+// и в той же транзакции добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запрос не затронул ни одной строки.
+func (repo *PGRepository) moderate(ctx context.Context, entry models.AuditEntry, query string, shortURL string, args ...any) (err error) {
+	ctx, span := repo.db.startTx(ctx, query)
+	defer func() { tracing.End(span, err) }()
+
+	err = pgx.BeginFunc(ctx, repo.db.Pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, append([]any{shortURL}, args...)...)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return models.ErrorNotFound
+		}
+		_, err = tx.Exec(ctx, queries.InsertAuditEntry,
+			entry.Time, entry.Actor, entry.RemoteAddr, entry.Action, entry.ShortURL, entry.Details)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.db.noteWrites(shortURLKey(shortURL))
+	return nil
+}
+
+// execAffectingOne выполняет запрос изменения по короткому идентификатору shortURL,
+// который передаётся первым параметром запроса, за ним следуют args.
+// Возвращает ErrorNotFound, если запрос не затронул ни одной строки.
+func (repo *PGRepository) execAffectingOne(ctx context.Context, query string, shortURL string, args ...any) (err error) {
+	ctx, span := repo.db.startQuery(ctx, query)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := repo.db.Pool.Exec(ctx, query, append([]any{shortURL}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+
+	repo.db.noteWrites(shortURLKey(shortURL))
+	return nil
+}
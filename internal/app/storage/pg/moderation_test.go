@@ -0,0 +1,103 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+func TestSearchURLs(t *testing.T) {
+	userID := uuid.New()
+	setupSeparateTest(t, "INSERT INTO urls (short_url, original_url, user_id)"+
+		" VALUES ('4rSPg8ap', 'http://yandex.ru', '"+userID.String()+"'),"+
+		" ('edVPg3ks', 'http://ya.ru', '"+userID.String()+"'),"+
+		" ('dG56Hqxm', 'http://practicum.yandex.ru', '"+uuid.New().String()+"')")
+
+	tests := []struct {
+		name   string
+		filter models.SearchFilter
+		want   []string
+	}{
+		{
+			name:   "By substring",
+			filter: models.SearchFilter{OriginalURL: "yandex"},
+			want:   []string{"4rSPg8ap", "dG56Hqxm"},
+		},
+		{
+			name:   "By owner",
+			filter: models.SearchFilter{UserID: userID},
+			want:   []string{"4rSPg8ap", "edVPg3ks"},
+		},
+		{
+			name:   "By short URL",
+			filter: models.SearchFilter{ShortURL: "edVPg3ks"},
+			want:   []string{"edVPg3ks"},
+		},
+		{
+			name:   "With limit",
+			filter: models.SearchFilter{Limit: 1},
+			want:   []string{"4rSPg8ap"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := repo.SearchURLs(context.Background(), tt.filter)
+			require.NoError(t, err)
+			got := make([]string, 0, len(records))
+			for _, r := range records {
+				got = append(got, r.ShortURL)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestModeration(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "TRUNCATE TABLE admin_audit;")
+	id, _, err := repo.SaveURL(ctx, uuid.New(), "http://example.com")
+	require.NoError(t, err)
+
+	entry := func(action string) models.AuditEntry {
+		return models.AuditEntry{
+			Time:       time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			Actor:      "alice",
+			RemoteAddr: "127.0.0.1:1234",
+			Action:     action,
+			ShortURL:   id,
+		}
+	}
+
+	require.NoError(t, repo.DisableURL(ctx, id, "court order", true, entry(models.AuditActionDisable)))
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "court order", record.DisabledReason)
+	assert.True(t, record.DisabledLegal)
+
+	require.NoError(t, repo.RestoreURL(ctx, id, entry(models.AuditActionRestore)))
+	record, err = repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, record.IsDisabled())
+
+	require.NoError(t, repo.PurgeURL(ctx, id, entry(models.AuditActionPurge)))
+	_, err = repo.RetrieveByShortURL(ctx, id)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	assert.ErrorIs(t, repo.PurgeURL(ctx, id, entry(models.AuditActionPurge)), models.ErrorNotFound)
+
+	// Неудавшаяся операция не оставляет записи в журнале.
+	entries, err := repo.RetrieveAuditLog(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, action := range []string{models.AuditActionDisable, models.AuditActionRestore, models.AuditActionPurge} {
+		assert.True(t, entries[i].Time.Equal(entry(action).Time))
+		assert.Equal(t, "alice", entries[i].Actor)
+		assert.Equal(t, action, entries[i].Action)
+		assert.Equal(t, id, entries[i].ShortURL)
+	}
+}
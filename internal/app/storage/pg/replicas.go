@@ -0,0 +1,286 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/metrics"
+)
+
+// Параметры реплик по умолчанию.
+const (
+	defaultReplicaMaxLag        = 5 * time.Second
+	defaultReplicaCheckInterval = 5 * time.Second
+)
+
+// Причины выбора места чтения для метрики metrics.DBReads.
+const (
+	readReplica      = "replica"       // чтение выполнено на реплике
+	readNoReplica    = "no_replica"    // реплики не настроены или все нездоровы
+	readRecentWrite  = "recent_write"  // данные недавно изменены и могли не дойти до реплик
+	readNotFound     = "not_found"     // реплика не нашла запись, возможно из-за отставания
+	readReplicaError = "replica_error" // реплика вернула ошибку соединения
+)
+
+// ReplicaDSNs разбирает строку подключения к репликам из конфигурации:
+// строки подключения перечисляются через запятую, пустые элементы пропускаются.
+func ReplicaDSNs(s string) []string {
+	var dsns []string
+	for _, dsn := range strings.Split(s, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
+	}
+	return dsns
+}
+
+// replica представляет реплику базы данных, доступную только для чтения.
+type replica struct {
+	name    string        // имя реплики для журнала, строка подключения не выводится из-за пароля
+	pool    *pgxpool.Pool // пул соединений с репликой
+	healthy atomic.Bool   // реплика отвечает и отстаёт не больше допустимого
+}
+
+// replicaSet распределяет чтения между здоровыми репликами и следит за их состоянием.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64 // счётчик для выбора реплик по кругу
+	maxLag   time.Duration // допустимое отставание реплики
+	recent   *recentWrites // недавно изменённые данные, которые читаются с основной базы данных
+	stop     chan struct{} // закрывается, чтобы остановить проверку реплик
+	done     chan struct{} // закрывается после остановки проверки реплик
+}
+
+// openReplicas открывает соединения с репликами с теми же параметрами пула, что и у основной базы данных,
+// проверяет их и запускает периодическую проверку. Недоступные при запуске реплики не мешают запуску:
+// они начнут принимать чтения после успешной проверки.
+func openReplicas(dsns []string, opts Options) (*replicaSet, error) {
+	set := &replicaSet{
+		maxLag: opts.ReplicaMaxLag,
+		// реплика может отстать на maxLag к моменту проверки и продолжить отставать до следующей
+		recent: newRecentWrites(opts.ReplicaMaxLag + opts.ReplicaCheckInterval),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for i, dsn := range dsns {
+		pool, err := newPool(dsn, opts)
+		if err != nil {
+			set.closePools()
+			return nil, fmt.Errorf("open replica %d: %w", i+1, err)
+		}
+		set.replicas = append(set.replicas, &replica{name: fmt.Sprintf("replica-%d", i+1), pool: pool})
+	}
+
+	set.check(opts.StatementTimeout)
+	go set.run(opts.ReplicaCheckInterval, opts.StatementTimeout)
+	return set, nil
+}
+
+// run периодически проверяет реплики и удаляет устаревшие отметки о записях, пока проверка не остановлена.
+func (s *replicaSet) run(interval time.Duration, timeout time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.check(timeout)
+			s.recent.prune()
+		}
+	}
+}
+
+// check проверяет все реплики параллельно и обновляет их состояние.
+func (s *replicaSet) check(timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, r := range s.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			s.setHealthy(r, s.checkReplica(ctx, r))
+		}()
+	}
+	wg.Wait()
+
+	healthy := 0
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			healthy++
+		}
+	}
+	metrics.HealthyReplicas.Set(float64(healthy))
+}
+
+// checkReplica возвращает ошибку, если реплика не отвечает или отстаёт больше допустимого.
+func (s *replicaSet) checkReplica(ctx context.Context, r *replica) error {
+	var lag float64
+	if err := r.pool.QueryRow(ctx, queries.ReplicaLag).Scan(&lag); err != nil {
+		return err
+	}
+	if d := time.Duration(lag * float64(time.Second)); d > s.maxLag {
+		return fmt.Errorf("replica lag %s exceeds %s", d.Round(time.Millisecond), s.maxLag)
+	}
+	return nil
+}
+
+// setHealthy обновляет состояние реплики по результату проверки и сообщает в журнал об изменениях.
+func (s *replicaSet) setHealthy(r *replica, err error) {
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		zap.L().Sugar().Infow("Database replica is healthy", "replica", r.name)
+	} else {
+		zap.L().Sugar().Warnw("Database replica is unhealthy", "replica", r.name, "error", err)
+	}
+}
+
+// pick возвращает следующую по кругу здоровую реплику или nil, если здоровых реплик нет.
+func (s *replicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := range n {
+		if r := s.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// close останавливает проверку реплик и закрывает соединения с ними.
+func (s *replicaSet) close() {
+	close(s.stop)
+	<-s.done
+	s.closePools()
+}
+
+// closePools закрывает пулы соединений с репликами.
+func (s *replicaSet) closePools() {
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
+
+// recentWrites хранит ключи недавно изменённых данных: пока изменение могло не дойти до реплик,
+// такие данные читаются с основной базы данных, чтобы пользователь видел свои изменения.
+type recentWrites struct {
+	mu     sync.Mutex
+	window time.Duration        // сколько после изменения читать данные с основной базы данных
+	keys   map[string]time.Time // момент, до которого ключ читается с основной базы данных
+}
+
+// newRecentWrites создает пустой набор недавних изменений с окном window.
+func newRecentWrites(window time.Duration) *recentWrites {
+	return &recentWrites{window: window, keys: make(map[string]time.Time)}
+}
+
+// add отмечает ключи изменёнными сейчас.
+func (w *recentWrites) add(keys ...string) {
+	until := time.Now().Add(w.window)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range keys {
+		w.keys[key] = until
+	}
+}
+
+// has сообщает, изменялся ли какой-нибудь из ключей в пределах окна.
+func (w *recentWrites) has(keys ...string) bool {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range keys {
+		if until, ok := w.keys[key]; ok && now.Before(until) {
+			return true
+		}
+	}
+	return false
+}
+
+// prune удаляет ключи, окно которых истекло.
+func (w *recentWrites) prune() {
+	now := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, until := range w.keys {
+		if !now.Before(until) {
+			delete(w.keys, key)
+		}
+	}
+}
+
+// shortURLKey возвращает ключ недавних изменений записи с коротким идентификатором shortURL.
+func shortURLKey(shortURL string) string {
+	return "url:" + shortURL
+}
+
+// userKey возвращает ключ недавних изменений ссылок пользователя.
+func userKey(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// webhooksKey возвращает ключ недавних изменений вебхуков пользователя.
+func webhooksKey(userID uuid.UUID) string {
+	return "webhooks:" + userID.String()
+}
+
+// noteWrites отмечает изменение данных с ключами keys, чтобы ближайшие чтения этих данных
+// выполнялись на основной базе данных. Без реплик ничего не делает.
+func (db *DB) noteWrites(keys ...string) {
+	if db.replicas != nil {
+		db.replicas.recent.add(keys...)
+	}
+}
+
+// readRouted выполняет идемпотентное чтение read на здоровой реплике, если данные с ключами keys
+// не изменялись недавно, иначе на основной базе данных. Чтение повторяется на основной базе данных,
+// если реплика не нашла запись (pgx.ErrNoRows) - она могла ещё не получить её, - или вернула
+// ошибку соединения; в последнем случае реплика исключается до следующей успешной проверки.
+// На основной базе данных чтение повторяется при временных ошибках так же, как retryRead.
+func (db *DB) readRouted(ctx context.Context, keys []string, read func(ctx context.Context, q queryer) error) error {
+	primary := func(reason string) error {
+		metrics.DBReads.WithLabelValues("primary", reason).Inc()
+		return retryRead(ctx, func(ctx context.Context) error {
+			return read(ctx, db.Pool)
+		})
+	}
+
+	if db.replicas == nil {
+		return primary(readNoReplica)
+	}
+	if db.replicas.recent.has(keys...) {
+		return primary(readRecentWrite)
+	}
+	r := db.replicas.pick()
+	if r == nil {
+		return primary(readNoReplica)
+	}
+
+	err := read(ctx, r.pool)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return primary(readNotFound)
+	case isTransient(err):
+		db.replicas.setHealthy(r, err)
+		return primary(readReplicaError)
+	}
+	metrics.DBReads.WithLabelValues("replica", readReplica).Inc()
+	return err
+}
@@ -0,0 +1,162 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaDSNs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{name: "Empty", in: "", want: nil},
+		{name: "Single", in: "host=replica1", want: []string{"host=replica1"}},
+		{name: "Spaces and empty items", in: " host=replica1 ,, postgres://replica2/db ,", want: []string{"host=replica1", "postgres://replica2/db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ReplicaDSNs(tt.in))
+		})
+	}
+
+	opts, err := OptionsFromConfig(config.Config{DatabaseReplicaDSNs: "host=replica1,host=replica2", DBReplicaMaxLag: 250})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host=replica1", "host=replica2"}, opts.ReplicaDSNs)
+	assert.Equal(t, 250*time.Millisecond, opts.ReplicaMaxLag)
+	assert.Equal(t, defaultReplicaCheckInterval, opts.withDefaults().ReplicaCheckInterval)
+}
+
+func TestRecentWrites(t *testing.T) {
+	w := newRecentWrites(50 * time.Millisecond)
+	w.add(shortURLKey("abc"), userKey(uuid.Nil))
+
+	assert.True(t, w.has(shortURLKey("abc")))
+	assert.True(t, w.has(shortURLKey("other"), userKey(uuid.Nil)))
+	assert.False(t, w.has(shortURLKey("other")))
+
+	// по истечении окна данные снова читаются с реплик, а устаревшие ключи удаляются
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, w.has(shortURLKey("abc")))
+	w.prune()
+	assert.Empty(t, w.keys)
+}
+
+func TestReplicaSet_Pick(t *testing.T) {
+	set := &replicaSet{replicas: []*replica{{name: "replica-1"}, {name: "replica-2"}, {name: "replica-3"}}}
+	assert.Nil(t, set.pick())
+
+	set.replicas[0].healthy.Store(true)
+	set.replicas[2].healthy.Store(true)
+	picked := map[string]int{}
+	for range 10 {
+		picked[set.pick().name]++
+	}
+	assert.Equal(t, 0, picked["replica-2"])
+	assert.Positive(t, picked["replica-1"])
+	assert.Positive(t, picked["replica-3"])
+}
+
+func TestReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	_, _, primaryDSN := startProxiedDatabase(t)
+	_, replicaProxy, replicaDSN := startProxiedDatabase(t)
+
+	// у реплики та же схема, но данные в неё пишутся отдельно: так видно, откуда выполнено чтение,
+	// а записи, которых на реплике нет, имитируют отставание репликации
+	replicaDB, err := NewDB(replicaDSN, Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = replicaDB.Close() })
+
+	db, err := NewDB(primaryDSN, Options{
+		ReplicaDSNs:          []string{replicaDSN},
+		ReplicaCheckInterval: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := NewPGRepository(db, 30*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, db.replicas.replicas[0].healthy.Load())
+
+	insert := func(db *DB, id string, url string, userID uuid.UUID) {
+		t.Helper()
+		_, err := db.Pool.Exec(ctx, "INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3);", id, url, userID)
+		require.NoError(t, err)
+	}
+	userID := uuid.New()
+	insert(db, "shared01", "http://primary.example.com", userID)
+	insert(replicaDB, "shared01", "http://replica.example.com", userID)
+
+	// переходы и списки ссылок читаются с реплики
+	record, err := repo.RetrieveByShortURL(ctx, "shared01")
+	require.NoError(t, err)
+	assert.Equal(t, "http://replica.example.com", record.OriginalURL)
+	records, err := repo.RetrieveUserURLs(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "http://replica.example.com", records[0].OriginalURL)
+
+	// запись, которая ещё не дошла до реплики, читается с основной базы данных
+	insert(db, "primary1", "http://lagging.example.com", uuid.New())
+	record, err = repo.RetrieveByShortURL(ctx, "primary1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://lagging.example.com", record.OriginalURL)
+
+	// после изменения пользователь читает свои данные с основной базы данных
+	id, _, err := repo.SaveURL(ctx, userID, "http://new.example.com")
+	require.NoError(t, err)
+	records, err = repo.RetrieveUserURLs(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Contains(t, []string{records[0].ShortURL, records[1].ShortURL}, id)
+
+	require.NoError(t, repo.SetDeleted(ctx, "shared01", true))
+	record, err = repo.RetrieveByShortURL(ctx, "shared01")
+	require.NoError(t, err)
+	assert.Equal(t, "http://primary.example.com", record.OriginalURL)
+	assert.True(t, record.IsDeleted)
+
+	// недоступная реплика исключается, чтения продолжаются на основной базе данных
+	insert(replicaDB, "replica1", "http://replica-only.example.com", uuid.New())
+	replicaProxy.Cut()
+	_, err = repo.RetrieveByShortURL(ctx, "primary1")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return !db.replicas.replicas[0].healthy.Load()
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// после восстановления реплика снова принимает чтения
+	replicaProxy.Restore("")
+	assert.Eventually(t, func() bool {
+		record, err := repo.RetrieveByShortURL(ctx, "replica1")
+		return err == nil && record.OriginalURL == "http://replica-only.example.com"
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestReplicaSet_LagMarksUnhealthy(t *testing.T) {
+	ctx := context.Background()
+	_, _, dsn := startProxiedDatabase(t)
+	db, err := Open(dsn, Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	// основная база данных сообщает нулевое отставание
+	set := &replicaSet{maxLag: time.Second}
+	r := &replica{name: "replica-1", pool: db.Pool}
+	require.NoError(t, set.checkReplica(ctx, r))
+
+	// отставание больше допустимого исключает реплику
+	set.maxLag = -time.Second
+	err = set.checkReplica(ctx, r)
+	require.Error(t, err)
+	r.healthy.Store(true)
+	set.setHealthy(r, err)
+	assert.False(t, r.healthy.Load())
+}
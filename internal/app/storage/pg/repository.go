@@ -3,7 +3,7 @@ package pg
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -12,27 +12,23 @@ import (
 	"github.com/iubondar/url-shortener/internal/app/models"
 	"github.com/iubondar/url-shortener/internal/app/storage/queries"
 	"github.com/iubondar/url-shortener/internal/app/strings"
+	"github.com/iubondar/url-shortener/internal/tracing"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 )
 
-// deleteIn представляет структуру для удаления URL.
-type deleteIn struct {
-	shortURL string    // короткий идентификатор URL
-	userID   uuid.UUID // идентификатор пользователя
-}
-
-const defaultDeletionInterval = 5 * time.Second
+// copyBatchThreshold - размер пакета URL, начиная с которого SaveURLs загружает его через COPY
+// во временную таблицу: для больших пакетов это быстрее передачи массивов параметрами запроса.
+const copyBatchThreshold = 1000
 
 // PGRepository реализует хранилище URL на базе PostgreSQL.
-// Поддерживает асинхронное удаление URL через очередь.
+// Поддерживает асинхронное удаление URL через ограниченную очередь, которую нужно закрыть методом Close.
+// Запросы выполняются через пул pgx, который сам подготавливает и кэширует их на каждом соединении.
 type PGRepository struct {
-	db          *DB           // соединение с базой данных
-	deleteQueue chan deleteIn // очередь для удаления URL
-	insertStmt  *sql.Stmt     // подготовленный запрос для вставки URL
-	getURLStmt  *sql.Stmt     // подготовленный запрос для получения URL
-	deleteStmt  *sql.Stmt     // подготовленный запрос для удаления URL
+	db        *DB            // соединение с базой данных
+	deletions *deletionQueue // очередь для удаления URL
 }
 
 // NewPGRepository создает новый экземпляр PGRepository.
@@ -43,31 +39,8 @@ func NewPGRepository(db *DB, deletionInterval time.Duration) (*PGRepository, err
 		deletionInterval = defaultDeletionInterval
 	}
 
-	// Подготавливаем запросы
-	insertStmt, err := db.SQLDB.Prepare(queries.InsertURL)
-	if err != nil {
-		return nil, fmt.Errorf("prepare insert statement: %w", err)
-	}
-
-	getURLStmt, err := db.SQLDB.Prepare(queries.GetShortURL)
-	if err != nil {
-		return nil, fmt.Errorf("prepare get URL statement: %w", err)
-	}
-
-	deleteStmt, err := db.SQLDB.Prepare(queries.DeleteUserURL)
-	if err != nil {
-		return nil, fmt.Errorf("prepare delete statement: %w", err)
-	}
-
-	instance := &PGRepository{
-		db:          db,
-		deleteQueue: make(chan deleteIn, 64),
-		insertStmt:  insertStmt,
-		getURLStmt:  getURLStmt,
-		deleteStmt:  deleteStmt,
-	}
-
-	go instance.flushDeletions(deletionInterval)
+	instance := &PGRepository{db: db}
+	instance.deletions = newDeletionQueue(instance.markAsDeleted, deletionInterval, deletionQueueCapacity, deletionFlushSize)
 
 	return instance, nil
 }
@@ -78,7 +51,7 @@ func NewPGRepository(db *DB, deletionInterval time.Duration) (*PGRepository, err
 func (repo *PGRepository) SaveURL(ctx context.Context, userID uuid.UUID, url string) (id string, exists bool, err error) {
 	// создаём идентификатор и добавляем запись
 	id = strings.RandString(8)
-	_, err = repo.insertStmt.ExecContext(ctx, id, url, userID)
+	err = repo.insert(ctx, id, url, userID)
 	if err != nil {
 		// Если URL уже был сохранён - возвращаем имеющееся значение
 		var pgErr *pgconn.PgError
@@ -99,15 +72,31 @@ func (repo *PGRepository) SaveURL(ctx context.Context, userID uuid.UUID, url str
 		return "", false, err
 	}
 
+	repo.db.noteWrites(shortURLKey(id), userKey(userID))
 	return id, false, nil
 }
 
+// insert выполняет запрос InsertURL в отдельном спане.
+// Вместе с записью в outbox добавляется событие создания ссылки.
+func (repo *PGRepository) insert(ctx context.Context, id string, url string, userID uuid.UUID) (err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.InsertURL)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = repo.db.Pool.Exec(ctx, queries.InsertURL, id, url, userID, uuid.NewString(), string(models.EventLinkCreated))
+	return err
+}
+
 // getShortURLByOriginalURL получает короткий идентификатор по оригинальному URL.
 // Возвращает короткий идентификатор и ошибку. Если URL не найден, возвращает пустую строку и nil.
 func (repo *PGRepository) getShortURLByOriginalURL(ctx context.Context, url string) (shortURL string, err error) {
-	err = repo.getURLStmt.QueryRowContext(ctx, url).Scan(&shortURL)
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.GetShortURL)
+		defer func() { tracing.End(span, err) }()
+
+		return repo.db.Pool.QueryRow(ctx, queries.GetShortURL, url).Scan(&shortURL)
+	})
 
-	if errors.Is(err, sql.ErrNoRows) {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return "", nil
 	}
 
@@ -115,97 +104,184 @@ func (repo *PGRepository) getShortURLByOriginalURL(ctx context.Context, url stri
 }
 
 // RetrieveByShortURL получает запись по короткому идентификатору.
+// Если настроены реплики, запись читается с реплики, а недавно изменённые и не найденные на реплике
+// записи - с основной базы данных.
 // Возвращает запись и ошибку. Если запись не найдена, возвращает ошибку ErrorNotFound.
 func (repo *PGRepository) RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error) {
-	row := repo.db.SQLDB.QueryRowContext(ctx, queries.GetByShortURL, shortURL)
+	err = repo.db.readRouted(ctx, []string{shortURLKey(shortURL)}, func(ctx context.Context, q queryer) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.GetByShortURL)
+		defer func() { tracing.End(span, err) }()
 
-	err = row.Scan(&record.UserID, &record.ShortURL, &record.OriginalURL, &record.IsDeleted)
+		record, err = scanRecord(q.QueryRow(ctx, queries.GetByShortURL, shortURL))
+		return err
+	})
 
-	if errors.Is(err, sql.ErrNoRows) {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return models.Record{}, models.ErrorNotFound
 	}
 
 	return
 }
 
+// scanRecord читает запись из строки результата запроса со столбцами queries.recordColumns.
+// Принимает pgx.Row и pgx.Rows.
+func scanRecord(row pgx.Row) (record models.Record, err error) {
+	var tags []byte
+	err = row.Scan(
+		&record.UserID, &record.ShortURL, &record.OriginalURL, &record.IsDeleted, &record.DeletedAt,
+		&record.DisabledReason, &record.DisabledLegal, &record.CreatedAt, &record.ExpiresAt,
+		&record.Title, &tags,
+	)
+	if err != nil {
+		return models.Record{}, err
+	}
+	if err := json.Unmarshal(tags, &record.Tags); err != nil {
+		return models.Record{}, fmt.Errorf("decode tags: %w", err)
+	}
+	return record, nil
+}
+
 // CheckStatus проверяет состояние хранилища.
 // Возвращает ошибку, если база данных недоступна.
 func (repo *PGRepository) CheckStatus(ctx context.Context) error {
-	return repo.db.SQLDB.PingContext(ctx)
+	return repo.db.Pool.Ping(ctx)
 }
 
-// SaveURLs сохраняет массив URL в базе данных в одной транзакции.
-// Если хотя бы один URL невалиден, откатывает транзакцию.
-// Возвращает массив коротких идентификаторов и ошибку.
+// SaveURLs сохраняет массив URL в базе данных одним запросом.
+// Для уже сокращённых URL возвращает имеющиеся идентификаторы, повторяющийся в массиве URL сохраняется один раз.
+// Пакеты от copyBatchThreshold URL загружаются через COPY во временную таблицу.
+// Если хотя бы один URL не удалось сохранить, не сохраняется ни один.
+// Возвращает короткие идентификаторы в порядке URL и ошибку.
 func (repo *PGRepository) SaveURLs(ctx context.Context, urls []string) (ids []string, err error) {
-	tx, err := repo.db.SQLDB.Begin()
+	if len(urls) == 0 {
+		return []string{}, nil
+	}
+
+	candidates := make([]string, len(urls))
+	for i := range urls {
+		candidates[i] = strings.RandString(8)
+	}
+
+	var saved map[string]string
+	if len(urls) < copyBatchThreshold {
+		saved, err = repo.insertURLs(ctx, candidates, urls)
+	} else {
+		saved, err = repo.copyURLs(ctx, candidates, urls)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids = make([]string, len(urls))
+	keys := make([]string, 0, len(urls))
+	for i, url := range urls {
+		id, ok := saved[url]
+		if !ok {
+			return nil, fmt.Errorf("no short URL returned for %q", url)
+		}
+		ids[i] = id
+		keys = append(keys, shortURLKey(id))
+	}
+	repo.db.noteWrites(keys...)
+	return ids, nil
+}
+
+// insertURLs сохраняет пакет URL с предложенными идентификаторами, передавая их массивами параметров.
+// Возвращает идентификаторы по оригинальным URL.
+func (repo *PGRepository) insertURLs(ctx context.Context, ids []string, urls []string) (saved map[string]string, err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.InsertURLs)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := repo.db.Pool.Query(ctx, queries.InsertURLs, ids, urls, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	return collectSaved(rows, len(urls))
+}
+
+// copyURLs сохраняет пакет URL с предложенными идентификаторами в одной транзакции:
+// загружает их через COPY во временную таблицу и переносит в urls одним запросом.
+// Возвращает идентификаторы по оригинальным URL.
+func (repo *PGRepository) copyURLs(ctx context.Context, ids []string, urls []string) (saved map[string]string, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.InsertURLBatch)
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := repo.db.Pool.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
 	// если Commit будет раньше, то откат проигнорируется
 	defer func() {
 		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
 				zap.L().Sugar().Errorf("error rolling back transaction: %v", rbErr)
 			}
 		}
 	}()
 
-	stmt, err := tx.PrepareContext(ctx, queries.InsertURL)
+	if _, err = tx.Exec(ctx, queries.CreateURLBatch); err != nil {
+		return nil, err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"url_batch"}, []string{"short_url", "original_url", "ord"},
+		pgx.CopyFromSlice(len(urls), func(i int) ([]any, error) {
+			return []any{ids[i], urls[i], i}, nil
+		}))
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := stmt.Close(); err != nil {
-			zap.L().Sugar().Errorf("error closing statement: %v", err)
-		}
-	}()
-
-	ids = make([]string, 0)
-	for _, url := range urls {
-		// Ищем в БД сохранённый URL
-		existedURL, err := repo.getShortURLByOriginalURL(ctx, url)
-		if err != nil {
-			return nil, err
-		}
-		if len(existedURL) > 0 {
-			ids = append(ids, existedURL)
-			continue
-		}
 
-		// Сохраняем URL
-		id := strings.RandString(8)
-		ids = append(ids, id)
-		_, err = stmt.ExecContext(ctx, id, url, uuid.Nil)
-		if err != nil {
-			return nil, err
-		}
+	rows, err := tx.Query(ctx, queries.InsertURLBatch, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	if saved, err = collectSaved(rows, len(urls)); err != nil {
+		return nil, err
 	}
+	return saved, tx.Commit(ctx)
+}
 
-	return ids, tx.Commit()
+// collectSaved читает пары короткого и оригинального URL, которые возвращают InsertURLs и InsertURLBatch.
+func collectSaved(rows pgx.Rows, size int) (map[string]string, error) {
+	saved := make(map[string]string, size)
+	var id, url string
+	_, err := pgx.ForEachRow(rows, []any{&id, &url}, func() error {
+		saved[url] = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
 }
 
 // RetrieveUserURLs получает все URL пользователя.
+// Если настроены реплики и пользователь недавно не менял ссылки, список читается с реплики.
 // Возвращает массив записей и ошибку.
 func (repo *PGRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error) {
-	rows, err := repo.db.SQLDB.QueryContext(ctx, queries.GetUserUrls, userID.String())
+	err = repo.db.readRouted(ctx, []string{userKey(userID)}, func(ctx context.Context, q queryer) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.GetUserUrls)
+		defer func() { tracing.End(span, err) }()
+
+		records, err = queryRecords(ctx, q, queries.GetUserUrls, userID.String())
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return []models.Record{}, nil
-		} else {
-			return nil, err
-		}
+		return nil, err
 	}
+	return records, nil
+}
 
-	defer func() {
-		if err := rows.Close(); err != nil {
-			zap.L().Sugar().Errorf("error closing rows: %v", err)
-		}
-	}()
+// queryRecords выполняет запрос, возвращающий записи со столбцами queries.recordColumns, и читает все записи.
+func queryRecords(ctx context.Context, q queryer, query string, args ...any) (records []models.Record, err error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	records = make([]models.Record, 0)
 	for rows.Next() {
-		var record models.Record
-		err = rows.Scan(&record.UserID, &record.ShortURL, &record.OriginalURL, &record.IsDeleted)
+		record, err := scanRecord(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -213,77 +289,161 @@ func (repo *PGRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error processing rows: %s", err.Error())
+		return nil, fmt.Errorf("error processing rows: %w", err)
 	}
 
 	return records, nil
 }
 
 // DeleteByShortURLs помечает URL как удаленные.
-// Добавляет URL в очередь для асинхронного удаления.
-func (repo *PGRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) {
-	for _, shortURL := range shortURLs {
-		repo.deleteQueue <- deleteIn{shortURL: shortURL, userID: userID}
+// Добавляет URL в очередь для асинхронного удаления: удаления сохраняются периодически
+// или сразу, когда накопится полный пакет. Не блокируется: если очередь заполнена или хранилище
+// закрывается, возвращает ошибку models.ErrorDeletionQueueFull и не добавляет ни одного URL.
+// Возвращает задачу удаления, результаты которой появятся после сохранения (см. RetrieveDeletionJob).
+func (repo *PGRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error) {
+	job = models.NewDeletionJob(userID, shortURLs, time.Now().UTC())
+	if err := repo.deletions.add(job); err != nil {
+		return models.DeletionJob{}, err
 	}
+	return job, nil
 }
 
-// flushDeletions периодически сохраняет накопленные в очереди удаления в базе данных.
-// Запускается в отдельной горутине при создании репозитория.
-func (repo *PGRepository) flushDeletions(deletionInterval time.Duration) {
-	ticker := time.NewTicker(deletionInterval)
-
-	var deletions []deleteIn
-
-	for {
-		select {
-		case deleteIn := <-repo.deleteQueue:
-			// добавим сообщение в слайс для последующего удаления
-			deletions = append(deletions, deleteIn)
-		case <-ticker.C:
-			// подождём, пока придёт хотя бы одно сообщение
-			if len(deletions) == 0 {
-				continue
-			}
-			// сохраним все пришедшие сообщения одновременно
-			err := repo.markAsDeleted(context.Background(), deletions...)
-			if err != nil {
-				zap.L().Sugar().Debugln("cannot mark deletions:", err.Error())
-			}
-			// сотрём успешно отосланные сообщения
-			deletions = nil
+// RetrieveDeletionJob возвращает задачу удаления id пользователя userID
+// или models.ErrorNotFound, если задача неизвестна, устарела или принадлежит другому пользователю.
+// Задачи хранятся в памяти экземпляра сервиса, принявшего удаление.
+func (repo *PGRepository) RetrieveDeletionJob(ctx context.Context, userID uuid.UUID, id string) (job models.DeletionJob, err error) {
+	return repo.deletions.job(userID, id)
+}
+
+// markAsDeleted помечает URL как удаленные в базе данных и возвращает результаты удалений в порядке deletions.
+// Удаления группируются по пользователям: для каждого пользователя выполняется один запрос,
+// а ссылки, которые не удалось удалить, проверяются одним запросом, чтобы отличить чужие от отсутствующих.
+// Все запросы отправляются одним пакетом в рамках транзакции, в ней же в outbox добавляются события удаления.
+func (repo *PGRepository) markAsDeleted(ctx context.Context, deletions []deleteIn) (statuses []models.DeletionStatus, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.DeleteUserURLs)
+	defer func() { tracing.End(span, err) }()
+
+	type userURL struct {
+		userID   uuid.UUID
+		shortURL string
+	}
+	var users []uuid.UUID
+	byUser := make(map[uuid.UUID][]string)
+	shortURLs := make([]string, 0, len(deletions))
+	keys := make([]string, 0, 2*len(deletions))
+	for _, deleteIn := range deletions {
+		if _, ok := byUser[deleteIn.userID]; !ok {
+			users = append(users, deleteIn.userID)
+			keys = append(keys, userKey(deleteIn.userID))
 		}
+		byUser[deleteIn.userID] = append(byUser[deleteIn.userID], deleteIn.shortURL)
+		shortURLs = append(shortURLs, deleteIn.shortURL)
+		keys = append(keys, shortURLKey(deleteIn.shortURL))
 	}
-}
 
-// markAsDeleted помечает URL как удаленные в базе данных.
-// Выполняется в рамках транзакции.
-func (repo *PGRepository) markAsDeleted(ctx context.Context, deletions ...deleteIn) error {
-	tx, err := repo.db.SQLDB.Begin()
-	if err != nil {
+	deleted := make(map[userURL]bool, len(deletions))
+	existing := make(map[string]bool, len(deletions))
+	var shortURL string
+	batch := &pgx.Batch{}
+	for _, userID := range users {
+		batch.Queue(queries.DeleteUserURLs, userID, byUser[userID], string(models.EventLinkDeleted)).Query(func(rows pgx.Rows) error {
+			_, err := pgx.ForEachRow(rows, []any{&shortURL}, func() error {
+				deleted[userURL{userID, shortURL}] = true
+				return nil
+			})
+			return err
+		})
+	}
+	batch.Queue(queries.ExistingShortURLs, shortURLs).Query(func(rows pgx.Rows) error {
+		_, err := pgx.ForEachRow(rows, []any{&shortURL}, func() error {
+			existing[shortURL] = true
+			return nil
+		})
 		return err
+	})
+
+	err = pgx.BeginFunc(ctx, repo.db.Pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err != nil {
+		return nil, err
 	}
-	// если Commit будет раньше, то откат проигнорируется
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				zap.L().Sugar().Errorf("error rolling back transaction: %v", rbErr)
-			}
+	repo.db.noteWrites(keys...)
+
+	statuses = make([]models.DeletionStatus, len(deletions))
+	for i, deleteIn := range deletions {
+		switch {
+		case deleted[userURL{deleteIn.userID, deleteIn.shortURL}]:
+			statuses[i] = models.DeletionDeleted
+		case existing[deleteIn.shortURL]:
+			statuses[i] = models.DeletionNotOwned
+		default:
+			statuses[i] = models.DeletionNotFound
 		}
-	}()
+	}
+	return statuses, nil
+}
 
-	stmt := tx.Stmt(repo.deleteStmt)
-	defer func() {
-		if err := stmt.Close(); err != nil {
-			zap.L().Sugar().Errorf("error closing statement: %v", err)
-		}
-	}()
+// Close сохраняет ожидающие удаления и останавливает очередь удалений.
+// Должен быть вызван до закрытия соединения с базой данных.
+func (repo *PGRepository) Close() error {
+	return repo.deletions.close()
+}
 
-	for _, deleteIn := range deletions {
-		_, err = stmt.ExecContext(ctx, deleteIn.userID, deleteIn.shortURL)
-		if err != nil {
-			return err
+// userURLsQueries сопоставляет порядок выдачи ссылок пользователя с SQL-запросом.
+var userURLsQueries = map[models.URLSort][2]string{
+	models.SortCreatedAt:   {queries.UserURLsByCreatedAt, queries.UserURLsByCreatedAtDesc},
+	models.SortOriginalURL: {queries.UserURLsByOriginalURL, queries.UserURLsByOriginalURLDesc},
+}
+
+// SearchUserURLs возвращает страницу URL пользователя, отобранных и упорядоченных по условиям выборки.
+// Фильтрация, сортировка и ограничение выполняются в базе данных с использованием индексов
+// (user_id, created_at, short_url) и (user_id, original_url, short_url).
+// Возвращает страницу и курсор следующей страницы или nil, если страница последняя.
+func (repo *PGRepository) SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error) {
+	desc := 0
+	if query.Desc {
+		desc = 1
+	}
+	q := userURLsQueries[query.SortField()][desc]
+
+	var deleted, createdFrom, createdTo, afterKey, limit any
+	var afterID string
+	switch query.Deleted {
+	case models.DeletedExclude:
+		deleted = false
+	case models.DeletedOnly:
+		deleted = true
+	}
+	if !query.CreatedFrom.IsZero() {
+		createdFrom = query.CreatedFrom
+	}
+	if !query.CreatedTo.IsZero() {
+		createdTo = query.CreatedTo
+	}
+	if query.After != nil {
+		afterID = query.After.ShortURL
+		afterKey = query.After.CreatedAt
+		if query.SortField() == models.SortOriginalURL {
+			afterKey = query.After.OriginalURL
 		}
 	}
+	// запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	if query.Limit > 0 {
+		limit = query.Limit + 1
+	}
+
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		ctx, span := repo.db.startQuery(ctx, q)
+		defer func() { tracing.End(span, err) }()
+
+		records, err = queryRecords(ctx, repo.db.Pool, q, userID.String(), query.Contains, deleted, createdFrom, createdTo, afterKey, afterID, limit)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return tx.Commit()
+	records, next = query.Cut(records)
+	return records, next, nil
 }
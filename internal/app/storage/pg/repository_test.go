@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose"
 
 	"github.com/iubondar/url-shortener/internal/app/models"
@@ -27,7 +26,7 @@ var (
 
 func cleanupResources(db *DB, container *testhelpers.PostgresContainer, ctx context.Context) {
 	if db != nil {
-		if err := db.SQLDB.Close(); err != nil {
+		if err := db.Close(); err != nil {
 			log.Printf("Failed to close database connection: %v", err)
 		}
 	}
@@ -52,7 +51,7 @@ func init() {
 		log.Fatalf("Failed to create postgres container: %v", err)
 	}
 
-	db, err := NewDB(pgContainer.ConnectionString)
+	db, err := NewDB(pgContainer.ConnectionString, Options{})
 	handleError(err, db, pgContainer, ctx, "Failed to create database connection")
 
 	err = goose.SetDialect("postgres")
@@ -65,8 +64,8 @@ func init() {
 	handleError(err, db, pgContainer, ctx, "Failed to create repository")
 
 	cleanup = func() {
-		if repo != nil && repo.db != nil && repo.db.SQLDB != nil {
-			_, err := repo.db.SQLDB.ExecContext(context.Background(), "TRUNCATE TABLE urls;")
+		if repo != nil && repo.db != nil && repo.db.Pool != nil {
+			_, err := repo.db.Pool.Exec(context.Background(), "TRUNCATE TABLE urls;")
 			if err != nil {
 				log.Printf("Failed to clear urls table: %v", err)
 			}
@@ -80,6 +79,9 @@ func TestMain(m *testing.M) {
 
 	// Очищаем ресурсы после завершения всех тестов
 	if repo != nil && repo.db != nil {
+		if err := repo.Close(); err != nil {
+			log.Printf("Failed to close repository: %v", err)
+		}
 		cleanupResources(repo.db, pgContainer, context.Background())
 	}
 
@@ -153,7 +155,7 @@ func setupSeparateTest(t *testing.T, execStatement string) {
 	cleanup()
 
 	if len(execStatement) > 0 {
-		_, err := repo.db.SQLDB.ExecContext(context.Background(), execStatement)
+		_, err := repo.db.Pool.Exec(context.Background(), execStatement)
 		require.NoError(t, err)
 	}
 }
@@ -330,6 +332,42 @@ func TestSaveURLs(t *testing.T) {
 	}
 }
 
+func TestSaveURLs_Batches(t *testing.T) {
+	ctx := context.Background()
+	for _, size := range []int{10, copyBatchThreshold + 10} {
+		t.Run(fmt.Sprintf("%d URLs", size), func(t *testing.T) {
+			setupSeparateTest(t, "INSERT INTO urls (short_url, original_url) VALUES ('4rSPg8ap', 'http://example.com/0');")
+
+			// URL с номером 0 уже сокращён, каждый пятый URL повторяет предыдущий
+			urls := make([]string, size)
+			for i := range urls {
+				urls[i] = fmt.Sprintf("http://example.com/%d", i)
+				if i%5 == 4 {
+					urls[i] = urls[i-1]
+				}
+			}
+
+			ids, err := repo.SaveURLs(ctx, urls)
+			require.NoError(t, err)
+			require.Len(t, ids, size)
+			assert.Equal(t, "4rSPg8ap", ids[0])
+			for i, id := range ids {
+				record, err := repo.RetrieveByShortURL(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, urls[i], record.OriginalURL)
+				if i%5 == 4 {
+					assert.Equal(t, ids[i-1], id)
+				}
+			}
+
+			// повторное сохранение возвращает те же идентификаторы
+			again, err := repo.SaveURLs(ctx, urls)
+			require.NoError(t, err)
+			assert.Equal(t, ids, again)
+		})
+	}
+}
+
 func TestDeleteByShortURLs(t *testing.T) {
 	userID := uuid.New()
 	type args struct {
@@ -381,7 +419,8 @@ func TestDeleteByShortURLs(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			setupSeparateTest(t, tt.execStatement)
 
-			repo.DeleteByShortURLs(context.Background(), tt.args.userID, tt.args.shortURLs)
+			_, err := repo.DeleteByShortURLs(context.Background(), tt.args.userID, tt.args.shortURLs)
+			require.NoError(t, err)
 
 			time.Sleep(50 * time.Millisecond)
 
@@ -429,6 +468,7 @@ func TestRetrieveUserURLs(t *testing.T) {
 					ShortURL:    "4rSPg8ap",
 					OriginalURL: "http://yandex.ru",
 					UserID:      userID,
+					Tags:        []string{},
 				},
 			},
 		},
@@ -444,6 +484,7 @@ func TestRetrieveUserURLs(t *testing.T) {
 					ShortURL:    "4rSPg8ap",
 					OriginalURL: "http://yandex.ru",
 					UserID:      userID,
+					Tags:        []string{},
 				},
 			},
 		},
@@ -455,11 +496,75 @@ func TestRetrieveUserURLs(t *testing.T) {
 			records, err := repo.RetrieveUserURLs(context.TODO(), tt.args.userID)
 
 			require.NoError(t, err)
+			// время создания проставляет база данных
+			for i := range records {
+				assert.False(t, records[i].CreatedAt.IsZero())
+				records[i].CreatedAt = time.Time{}
+			}
 			assert.ElementsMatch(t, tt.wantRecords, records)
 		})
 	}
 }
 
+func TestSearchUserURLs(t *testing.T) {
+	userID := uuid.New()
+	setupSeparateTest(t, "INSERT INTO urls (short_url, original_url, user_id, created_at, is_deleted) VALUES "+
+		"('c', 'http://b.example/docs', '"+userID.String()+"', '2025-03-01T14:00:00Z', false), "+
+		"('a', 'http://c.example/docs', '"+userID.String()+"', '2025-03-01T12:00:00Z', false), "+
+		"('b', 'http://a.example/blog', '"+userID.String()+"', '2025-03-01T12:00:00Z', true), "+
+		"('d', 'http://d.example/docs', '"+uuid.NewString()+"', '2025-03-01T12:00:00Z', false);")
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		query models.URLQuery
+		want  []string
+	}{
+		{name: "Default order", want: []string{"a", "b", "c"}},
+		{name: "Descending creation time", query: models.URLQuery{Desc: true}, want: []string{"c", "b", "a"}},
+		{name: "Sort by original URL", query: models.URLQuery{Sort: models.SortOriginalURL}, want: []string{"b", "c", "a"}},
+		{name: "Substring of original URL", query: models.URLQuery{Contains: "docs"}, want: []string{"a", "c"}},
+		{name: "Exclude deleted", query: models.URLQuery{Deleted: models.DeletedExclude}, want: []string{"a", "c"}},
+		{name: "Only deleted", query: models.URLQuery{Deleted: models.DeletedOnly}, want: []string{"b"}},
+		{
+			name:  "Created range",
+			query: models.URLQuery{CreatedFrom: created.Add(time.Hour), CreatedTo: created.Add(3 * time.Hour)},
+			want:  []string{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, next, err := repo.SearchUserURLs(context.Background(), userID, tt.query)
+			require.NoError(t, err)
+			assert.Nil(t, next)
+			ids := make([]string, 0, len(records))
+			for _, r := range records {
+				ids = append(ids, r.ShortURL)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	t.Run("Pages follow each other", func(t *testing.T) {
+		for _, sort := range []models.URLSort{models.SortCreatedAt, models.SortOriginalURL} {
+			query := models.URLQuery{Sort: sort, Desc: true, Limit: 1}
+			var ids []string
+			for {
+				records, next, err := repo.SearchUserURLs(context.Background(), userID, query)
+				require.NoError(t, err)
+				for _, r := range records {
+					ids = append(ids, r.ShortURL)
+				}
+				if next == nil {
+					break
+				}
+				query.After = next
+			}
+			assert.Len(t, ids, 3, sort)
+		}
+	})
+}
+
 // BenchmarkPGRepository_SaveURL измеряет производительность сохранения URL
 func BenchmarkPGRepository_SaveURL(b *testing.B) {
 	cleanup()
@@ -519,7 +624,7 @@ func BenchmarkPGRepository_DeleteByShortURLs(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		repo.DeleteByShortURLs(ctx, userID, shortURLs)
+		_, _ = repo.DeleteByShortURLs(ctx, userID, shortURLs)
 		// Ждем завершения асинхронных операций
 		time.Sleep(50 * time.Millisecond)
 	}
@@ -541,6 +646,66 @@ func BenchmarkPGRepository_SaveURLs(b *testing.B) {
 	}
 }
 
+// BenchmarkPGRepository_SaveURLs_10k измеряет пропускную способность сохранения пакетов из 10 000 новых URL
+// запросом с массивами параметров и через COPY во временную таблицу, а также повторного сохранения
+// уже сокращённых URL. Результат выводится в URL в секунду.
+func BenchmarkPGRepository_SaveURLs_10k(b *testing.B) {
+	const size = 10_000
+	ctx := context.Background()
+	batch := func(n int) ([]string, []string) {
+		ids := make([]string, size)
+		urls := make([]string, size)
+		for i := range urls {
+			ids[i] = fmt.Sprintf("%02d%06d", n%100, i)
+			urls[i] = fmt.Sprintf("http://example.com/%d/%d", n, i)
+		}
+		return ids, urls
+	}
+	run := func(b *testing.B, save func(ids []string, urls []string) error) {
+		cleanup()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			if i%100 == 99 {
+				cleanup()
+			}
+			ids, urls := batch(i)
+			b.StartTimer()
+			if err := save(ids, urls); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "urls/s")
+	}
+
+	b.Run("params", func(b *testing.B) {
+		run(b, func(ids []string, urls []string) error {
+			_, err := repo.insertURLs(ctx, ids, urls)
+			return err
+		})
+	})
+	b.Run("copy", func(b *testing.B) {
+		run(b, func(ids []string, urls []string) error {
+			_, err := repo.copyURLs(ctx, ids, urls)
+			return err
+		})
+	})
+	b.Run("existing", func(b *testing.B) {
+		cleanup()
+		_, urls := batch(0)
+		if _, err := repo.SaveURLs(ctx, urls); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.SaveURLs(ctx, urls); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(size*b.N)/b.Elapsed().Seconds(), "urls/s")
+	})
+}
+
 // BenchmarkPGRepository_CheckStatus измеряет производительность проверки состояния хранилища
 func BenchmarkPGRepository_CheckStatus(b *testing.B) {
 	cleanup()
@@ -0,0 +1,95 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// purgeBatchSize - сколько записей PurgeExpired удаляет одним запросом,
+// чтобы не блокировать таблицу надолго при большом количестве устаревших записей.
+const purgeBatchSize = 1000
+
+// PurgeExpired безвозвратно удаляет записи, удалённые пользователями или истёкшие раньше cutoff,
+// вместе с историей их версий. Записи удаляются пакетами по purgeBatchSize.
+// Возвращает количество удалённых записей.
+func (repo *PGRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	for {
+		shortURLs, err := repo.purgeExpiredBatch(ctx, cutoff)
+		purged += len(shortURLs)
+		if err != nil || len(shortURLs) < purgeBatchSize {
+			return purged, err
+		}
+	}
+}
+
+// purgeExpiredBatch удаляет один пакет устаревших записей и возвращает их короткие идентификаторы.
+func (repo *PGRepository) purgeExpiredBatch(ctx context.Context, cutoff time.Time) (shortURLs []string, err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.PurgeExpired)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := repo.db.Pool.Query(ctx, queries.PurgeExpired, cutoff, purgeBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	shortURLs, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		keys = append(keys, shortURLKey(shortURL))
+	}
+	repo.db.noteWrites(keys...)
+	return shortURLs, nil
+}
+
+// DeleteAccount безвозвратно удаляет все записи и вебхуки пользователя вместе с историей версий
+// и недоставленными событиями и отзывает его токены в одной транзакции. Возвращает количество удалённых записей.
+func (repo *PGRepository) DeleteAccount(ctx context.Context, userID uuid.UUID) (purged int, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.PurgeUserURLs)
+	defer func() { tracing.End(span, err) }()
+
+	var shortURLs []string
+	err = pgx.BeginFunc(ctx, repo.db.Pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, queries.PurgeUserURLs, userID)
+		if err != nil {
+			return err
+		}
+		if shortURLs, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, queries.DeleteUserWebhooks, userID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, queries.RevokeUser, userID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	keys := []string{userKey(userID)}
+	for _, shortURL := range shortURLs {
+		keys = append(keys, shortURLKey(shortURL))
+	}
+	repo.db.noteWrites(keys...)
+	return len(shortURLs), nil
+}
+
+// IsUserRevoked сообщает, отозваны ли токены пользователя.
+// Отзыв читается с основной базы данных, чтобы действовать сразу после удаления учётной записи.
+func (repo *PGRepository) IsUserRevoked(ctx context.Context, userID uuid.UUID) (revoked bool, err error) {
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.IsUserRevoked)
+		defer func() { tracing.End(span, err) }()
+
+		return repo.db.Pool.QueryRow(ctx, queries.IsUserRevoked, userID).Scan(&revoked)
+	})
+	return revoked, err
+}
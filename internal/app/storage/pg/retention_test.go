@@ -0,0 +1,97 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New().String()
+	setupSeparateTest(t, "INSERT INTO urls (short_url, original_url, user_id, is_deleted, deleted_at, expires_at) VALUES "+
+		"('deleted1', 'http://example.com/a', '"+userID+"', true, now() - interval '2 days', NULL), "+
+		"('deleted2', 'http://example.com/b', '"+userID+"', true, now(), NULL), "+
+		"('expired1', 'http://example.com/c', '"+userID+"', false, NULL, now() - interval '2 days'), "+
+		"('active01', 'http://example.com/d', '"+userID+"', false, NULL, now() + interval '1 day'), "+
+		"('kept0001', 'http://example.com/e', '"+userID+"', false, NULL, NULL);")
+	_, err := repo.db.Pool.Exec(ctx, "INSERT INTO url_revisions (short_url, revision, original_url, created_at) VALUES ('deleted1', 1, 'http://example.org', now());")
+	require.NoError(t, err)
+
+	purged, err := repo.PurgeExpired(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	for shortURL, wantFound := range map[string]bool{"deleted1": false, "deleted2": true, "expired1": false, "active01": true, "kept0001": true} {
+		_, err := repo.RetrieveByShortURL(ctx, shortURL)
+		if wantFound {
+			assert.NoError(t, err, shortURL)
+		} else {
+			assert.ErrorIs(t, err, models.ErrorNotFound, shortURL)
+		}
+	}
+	var revisions int
+	require.NoError(t, repo.db.Pool.QueryRow(ctx, "SELECT count(*) FROM url_revisions;").Scan(&revisions))
+	assert.Zero(t, revisions)
+}
+
+func TestSetDeleted_DeletedAt(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+
+	id, _, err := repo.SaveURL(ctx, uuid.New(), "http://example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SetDeleted(ctx, id, true))
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, record.DeletedAt)
+
+	require.NoError(t, repo.SetDeleted(ctx, id, false))
+	record, err = repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, record.DeletedAt)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	own, _, err := repo.SaveURL(ctx, userID, "http://example.com/a")
+	require.NoError(t, err)
+	_, err = repo.UpdateOriginalURL(ctx, userID, own, "http://example.org")
+	require.NoError(t, err)
+	_, _, err = repo.SaveURL(ctx, userID, "http://example.com/b")
+	require.NoError(t, err)
+	other, _, err := repo.SaveURL(ctx, otherID, "http://example.com/c")
+	require.NoError(t, err)
+
+	purged, err := repo.DeleteAccount(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	records, err := repo.RetrieveUserURLs(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	_, err = repo.RetrieveByShortURL(ctx, other)
+	require.NoError(t, err)
+
+	revoked, err := repo.IsUserRevoked(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsUserRevoked(ctx, otherID)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// повторное удаление ничего не удаляет
+	purged, err = repo.DeleteAccount(ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
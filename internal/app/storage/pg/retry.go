@@ -0,0 +1,69 @@
+package pg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// Повторы идемпотентных чтений при временных ошибках.
+const (
+	readAttempts   = 3                     // количество попыток чтения
+	readBackoffMin = 50 * time.Millisecond // задержка перед первым повтором, далее удваивается
+)
+
+// retryRead выполняет идемпотентное чтение read и повторяет его при временных ошибках базы данных,
+// например после разрыва соединения или перезапуска сервера. Каждая попытка выполняется
+// со своим тайм-аутом запроса; отмена ctx прекращает повторы.
+func retryRead(ctx context.Context, read func(ctx context.Context) error) error {
+	backoff := readBackoffMin
+	for attempt := 1; ; attempt++ {
+		err := read(ctx)
+		if err == nil || attempt == readAttempts || !isTransient(err) {
+			return err
+		}
+		zap.L().Sugar().Debugw("Retrying read after transient database error", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransient сообщает, вызвана ли ошибка временной недоступностью базы данных,
+// после которой запрос можно повторить на другом соединении.
+// Истечение тайм-аута запроса и отмена контекста временными не считаются:
+// повтор медленного запроса только увеличит нагрузку.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgerrcode.SerializationFailure, pgerrcode.DeadlockDetected,
+			pgerrcode.AdminShutdown, pgerrcode.CrashShutdown, pgerrcode.CannotConnectNow,
+			pgerrcode.TooManyConnections:
+			return true
+		}
+		return pgerrcode.IsConnectionException(pgErr.Code)
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr) ||
+		pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,72 @@
+package pg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection failure", err: &pgconn.PgError{Code: pgerrcode.ConnectionFailure}, want: true},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: pgerrcode.AdminShutdown}, want: true},
+		{name: "cannot connect now", err: &pgconn.PgError{Code: pgerrcode.CannotConnectNow}, want: true},
+		{name: "serialization failure", err: fmt.Errorf("read: %w", &pgconn.PgError{Code: pgerrcode.SerializationFailure}), want: true},
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: pgerrcode.UniqueViolation}, want: false},
+		{name: "syntax error", err: &pgconn.PgError{Code: pgerrcode.SyntaxError}, want: false},
+		{name: "no rows", err: pgx.ErrNoRows, want: false},
+		{name: "statement timeout", err: context.DeadlineExceeded, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTransient(tt.err))
+		})
+	}
+}
+
+func TestRetryRead(t *testing.T) {
+	transient := &pgconn.PgError{Code: pgerrcode.AdminShutdown}
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "success", errs: []error{nil}, wantAttempts: 1},
+		{name: "recovers after transient error", errs: []error{transient, nil}, wantAttempts: 2},
+		{name: "gives up after all attempts", errs: []error{transient, transient, transient, nil}, wantErr: transient, wantAttempts: readAttempts},
+		{name: "permanent error", errs: []error{pgx.ErrNoRows, nil}, wantErr: pgx.ErrNoRows, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryRead(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
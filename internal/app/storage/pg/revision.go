@@ -0,0 +1,144 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// queryer - общий интерфейс пула соединений и транзакции для выполнения запросов.
+type queryer interface {
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
+}
+
+// UpdateOriginalURL меняет целевой адрес ссылки пользователя и добавляет новую версию в историю
+// в одной транзакции. Запись блокируется на время изменения, чтобы параллельные изменения
+// не нарушили нумерацию версий. Если адрес не изменился, возвращает запись без изменений.
+// Возвращает ErrorNotFound, если ссылки нет или она принадлежит другому пользователю,
+// и ErrorOriginalURLExists, если адрес уже сокращён другой ссылкой.
+func (repo *PGRepository) UpdateOriginalURL(ctx context.Context, userID uuid.UUID, shortURL string, url string) (record models.Record, err error) {
+	ctx, span := repo.db.startTx(ctx, queries.UpdateOriginalURL)
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := repo.db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+	// если Commit будет раньше, то откат проигнорируется
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				zap.L().Sugar().Errorf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	record, err = scanRecord(tx.QueryRow(ctx, queries.LockByShortURL, shortURL))
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && record.UserID != userID) {
+		err = models.ErrorNotFound
+	}
+	if err != nil {
+		return models.Record{}, err
+	}
+	if record.OriginalURL == url {
+		return record, tx.Commit(ctx)
+	}
+
+	var existing string
+	err = tx.QueryRow(ctx, queries.GetShortURL, url).Scan(&existing)
+	if err == nil {
+		err = models.ErrorOriginalURLExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.Record{}, err
+	}
+
+	history, err := repo.retrieveRevisions(ctx, tx, shortURL)
+	if err != nil {
+		return models.Record{}, err
+	}
+	for _, revision := range models.NextRevisions(history, record, url, time.Now().UTC()) {
+		_, err = tx.Exec(ctx, queries.InsertRevision, revision.ShortURL, revision.Revision, revision.OriginalURL, revision.CreatedAt)
+		if err != nil {
+			return models.Record{}, err
+		}
+	}
+
+	if _, err = tx.Exec(ctx, queries.UpdateOriginalURL, shortURL, url); err != nil {
+		// адрес мог быть сокращён параллельным запросом после проверки
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			err = models.ErrorOriginalURLExists
+		}
+		return models.Record{}, err
+	}
+
+	record.OriginalURL = url
+	if err = tx.Commit(ctx); err != nil {
+		return models.Record{}, err
+	}
+	repo.db.noteWrites(shortURLKey(shortURL), userKey(userID))
+	return record, nil
+}
+
+// RetrieveRevisions возвращает историю целевых адресов ссылки пользователя от первой версии к последней.
+// Возвращает ErrorNotFound, если ссылки нет или она принадлежит другому пользователю.
+func (repo *PGRepository) RetrieveRevisions(ctx context.Context, userID uuid.UUID, shortURL string) (revisions []models.Revision, err error) {
+	record, err := repo.RetrieveByShortURL(ctx, shortURL)
+	if err == nil && record.UserID != userID {
+		err = models.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = retryRead(ctx, func(ctx context.Context) (err error) {
+		revisions, err = repo.retrieveRevisions(ctx, repo.db.Pool, shortURL)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		revisions = []models.Revision{models.InitialRevision(record)}
+	}
+	return revisions, nil
+}
+
+// retrieveRevisions читает сохранённые версии ссылки в порядке их номеров.
+func (repo *PGRepository) retrieveRevisions(ctx context.Context, q queryer, shortURL string) (revisions []models.Revision, err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.GetRevisions)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := q.Query(ctx, queries.GetRevisions, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions = make([]models.Revision, 0)
+	for rows.Next() {
+		var revision models.Revision
+		if err := rows.Scan(&revision.ShortURL, &revision.Revision, &revision.OriginalURL, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error processing rows: %w", err)
+	}
+
+	return revisions, nil
+}
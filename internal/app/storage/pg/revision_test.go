@@ -0,0 +1,54 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOriginalURL(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "")
+	userID := uuid.New()
+	id, _, err := repo.SaveURL(ctx, userID, "http://example.com/v1")
+	require.NoError(t, err)
+	_, _, err = repo.SaveURL(ctx, userID, "http://example.org")
+	require.NoError(t, err)
+
+	revisions, err := repo.RetrieveRevisions(ctx, userID, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "http://example.com/v1", revisions[0].OriginalURL)
+
+	record, err := repo.UpdateOriginalURL(ctx, userID, id, "http://example.com/v2")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/v2", record.OriginalURL)
+	_, err = repo.UpdateOriginalURL(ctx, userID, id, "http://example.com/v1")
+	require.NoError(t, err)
+
+	revisions, err = repo.RetrieveRevisions(ctx, userID, id)
+	require.NoError(t, err)
+	urls := make([]string, 0, len(revisions))
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision.Revision)
+		urls = append(urls, revision.OriginalURL)
+	}
+	assert.Equal(t, []string{"http://example.com/v1", "http://example.com/v2", "http://example.com/v1"}, urls)
+
+	_, err = repo.UpdateOriginalURL(ctx, userID, id, "http://example.org")
+	assert.ErrorIs(t, err, models.ErrorOriginalURLExists)
+	_, err = repo.UpdateOriginalURL(ctx, uuid.New(), id, "http://example.com/v3")
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = repo.RetrieveRevisions(ctx, uuid.New(), id)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+
+	// история удаляется вместе со ссылкой
+	require.NoError(t, repo.PurgeURL(ctx, id, models.AuditEntry{}))
+	history, err := repo.retrieveRevisions(ctx, repo.db.Pool, id)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
@@ -0,0 +1,56 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startQuery начинает спан выполнения SQL-запроса, названный по имени запроса из пакета queries,
+// и ограничивает время выполнения запроса тайм-аутом одного запроса.
+// Спан нужно завершить вызовом tracing.End: завершение спана снимает и тайм-аут.
+func (db *DB) startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	return db.start(ctx, query, db.statementTimeout)
+}
+
+// startTx начинает спан транзакции или пакетной загрузки, названный по имени основного запроса,
+// и ограничивает её время тайм-аутом транзакции, а не тайм-аутом одного запроса:
+// транзакция состоит из нескольких запросов, а COPY загружает тысячи строк.
+// Спан нужно завершить вызовом tracing.End: завершение спана снимает и тайм-аут.
+func (db *DB) startTx(ctx context.Context, query string) (context.Context, trace.Span) {
+	return db.start(ctx, query, db.txTimeout)
+}
+
+// start начинает спан запроса query с тайм-аутом timeout, 0 - без ограничения.
+func (db *DB) start(ctx context.Context, query string, timeout time.Duration) (context.Context, trace.Span) {
+	name := queries.Name(query)
+	ctx, span := tracing.Start(ctx, "pg."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement.name", name),
+		),
+	)
+	if timeout <= 0 {
+		return ctx, span
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, querySpan{Span: span, cancel: cancel}
+}
+
+// querySpan - спан запроса, завершение которого освобождает контекст с тайм-аутом запроса.
+type querySpan struct {
+	trace.Span
+	cancel context.CancelFunc // отмена контекста с тайм-аутом
+}
+
+// End завершает спан и освобождает контекст с тайм-аутом запроса.
+func (s querySpan) End(options ...trace.SpanEndOption) {
+	s.Span.End(options...)
+	s.cancel()
+}
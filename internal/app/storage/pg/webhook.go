@@ -0,0 +1,168 @@
+package pg
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/storage/queries"
+	"github.com/iubondar/url-shortener/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateWebhook регистрирует вебхук пользователя.
+func (repo *PGRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) (err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.InsertWebhook)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = repo.db.Pool.Exec(ctx, queries.InsertWebhook,
+		webhook.ID, webhook.UserID, webhook.URL, webhook.Secret, webhook.CreatedAt)
+	if err != nil {
+		return err
+	}
+	repo.db.noteWrites(webhooksKey(webhook.UserID))
+	return nil
+}
+
+// ListWebhooks возвращает вебхуки пользователя в порядке регистрации.
+// Если настроены реплики и пользователь недавно не менял вебхуки, список читается с реплики.
+func (repo *PGRepository) ListWebhooks(ctx context.Context, userID uuid.UUID) (webhooks []models.Webhook, err error) {
+	err = repo.db.readRouted(ctx, []string{webhooksKey(userID)}, func(ctx context.Context, q queryer) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.GetUserWebhooks)
+		defer func() { tracing.End(span, err) }()
+
+		rows, err := q.Query(ctx, queries.GetUserWebhooks, userID)
+		if err != nil {
+			return err
+		}
+		webhooks, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (w models.Webhook, err error) {
+			err = row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &w.CreatedAt)
+			return w, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = make([]models.Webhook, 0)
+	}
+	return webhooks, nil
+}
+
+// HasWebhooks сообщает, зарегистрированы ли у пользователя вебхуки.
+// Если настроены реплики и пользователь недавно не менял вебхуки, проверка выполняется на реплике.
+func (repo *PGRepository) HasWebhooks(ctx context.Context, userID uuid.UUID) (exists bool, err error) {
+	err = repo.db.readRouted(ctx, []string{webhooksKey(userID)}, func(ctx context.Context, q queryer) (err error) {
+		ctx, span := repo.db.startQuery(ctx, queries.ExistsUserWebhook)
+		defer func() { tracing.End(span, err) }()
+
+		return q.QueryRow(ctx, queries.ExistsUserWebhook, userID).Scan(&exists)
+	})
+	return exists, err
+}
+
+// DeleteWebhook удаляет вебхук пользователя вместе с его недоставленными событиями.
+// Возвращает ErrorNotFound, если у пользователя нет такого вебхука.
+func (repo *PGRepository) DeleteWebhook(ctx context.Context, userID uuid.UUID, id string) (err error) {
+	webhookID, err := uuid.Parse(id)
+	if err != nil {
+		return models.ErrorNotFound
+	}
+
+	ctx, span := repo.db.startQuery(ctx, queries.DeleteWebhook)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := repo.db.Pool.Exec(ctx, queries.DeleteWebhook, webhookID, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+	repo.db.noteWrites(webhooksKey(userID))
+	return nil
+}
+
+// RecordClick добавляет в outbox событие перехода по ссылке с записью record.
+// Если у владельца ссылки нет вебхуков, ничего не добавляется.
+func (repo *PGRepository) RecordClick(ctx context.Context, record models.Record) (err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.InsertEvent)
+	defer func() { tracing.End(span, err) }()
+
+	event := models.NewEvent(models.EventLinkClicked, record, time.Now().UTC())
+	_, err = repo.db.Pool.Exec(ctx, queries.InsertEvent,
+		record.UserID, event.ID, string(event.Type), event.ShortURL, event.OriginalURL, event.OccurredAt)
+	return err
+}
+
+// ClaimDeliveries выдает не больше limit доставок, которые пора выполнить,
+// и откладывает их следующую попытку на lease. Доставки, выданные другим экземплярам сервиса,
+// не выдаются, пока не истечёт их отсрочка.
+func (repo *PGRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []models.Delivery, err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.ClaimDeliveries)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := repo.db.Pool.Query(ctx, queries.ClaimDeliveries, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (d models.Delivery, err error) {
+		var eventType string
+		err = row.Scan(&d.ID, &d.WebhookID, &d.Event.ID, &eventType, &d.Event.ShortURL, &d.Event.OriginalURL,
+			&d.Event.OccurredAt, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.URL, &d.Secret)
+		d.Event.Type = models.EventType(eventType)
+		return d, err
+	})
+}
+
+// RetryDelivery сохраняет результат неудачной попытки доставки.
+// Возвращает ErrorNotFound, если доставки нет, например, вебхук уже удалён.
+func (repo *PGRepository) RetryDelivery(ctx context.Context, delivery models.Delivery) (err error) {
+	id, err := strconv.ParseInt(delivery.ID, 10, 64)
+	if err != nil {
+		return models.ErrorNotFound
+	}
+
+	ctx, span := repo.db.startQuery(ctx, queries.RetryDelivery)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := repo.db.Pool.Exec(ctx, queries.RetryDelivery,
+		id, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.Abandoned)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+	return nil
+}
+
+// CompleteDelivery удаляет выполненную доставку из outbox.
+func (repo *PGRepository) CompleteDelivery(ctx context.Context, id string) (err error) {
+	deliveryID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	ctx, span := repo.db.startQuery(ctx, queries.CompleteDelivery)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = repo.db.Pool.Exec(ctx, queries.CompleteDelivery, deliveryID)
+	return err
+}
+
+// PurgeAbandonedDeliveries удаляет из outbox доставки, попытки которых исчерпаны раньше cutoff.
+// Возвращает количество удалённых доставок.
+func (repo *PGRepository) PurgeAbandonedDeliveries(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	ctx, span := repo.db.startQuery(ctx, queries.PurgeAbandonedDeliveries)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := repo.db.Pool.Exec(ctx, queries.PurgeAbandonedDeliveries, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
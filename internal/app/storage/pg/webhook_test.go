@@ -0,0 +1,136 @@
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "TRUNCATE TABLE webhooks CASCADE;")
+	userID := uuid.New()
+
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateWebhook(ctx, webhook))
+
+	webhooks, err := repo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, webhook.ID, webhooks[0].ID)
+	assert.Equal(t, webhook.Secret, webhooks[0].Secret)
+
+	exists, err := repo.HasWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = repo.HasWebhooks(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	webhooks, err = repo.ListWebhooks(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, uuid.New(), webhook.ID), models.ErrorNotFound)
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, userID, "not-a-uuid"), models.ErrorNotFound)
+	require.NoError(t, repo.DeleteWebhook(ctx, userID, webhook.ID))
+	webhooks, err = repo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "TRUNCATE TABLE webhooks CASCADE;")
+	userID := uuid.New()
+
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateWebhook(ctx, webhook))
+
+	// ссылки других пользователей событий вебхуку не добавляют
+	_, _, err = repo.SaveURL(ctx, uuid.New(), "http://example.com/other")
+	require.NoError(t, err)
+
+	id, _, err := repo.SaveURL(ctx, userID, "http://example.com")
+	require.NoError(t, err)
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, repo.RecordClick(ctx, record))
+
+	deliveries, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, models.EventLinkCreated, deliveries[0].Event.Type)
+	assert.Equal(t, models.EventLinkClicked, deliveries[1].Event.Type)
+	for _, d := range deliveries {
+		assert.Equal(t, webhook.ID, d.WebhookID)
+		assert.Equal(t, webhook.URL, d.URL)
+		assert.Equal(t, webhook.Secret, d.Secret)
+		assert.Equal(t, id, d.Event.ShortURL)
+	}
+
+	// выданные доставки не выдаются повторно до истечения отсрочки
+	again, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	require.NoError(t, repo.CompleteDelivery(ctx, deliveries[0].ID))
+	failed := deliveries[1]
+	failed.Attempts, failed.NextAttemptAt, failed.LastError = 1, time.Now().Add(-time.Second), "status 500"
+	require.NoError(t, repo.RetryDelivery(ctx, failed))
+
+	retried, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, retried, 1)
+	assert.Equal(t, failed.ID, retried[0].ID)
+	assert.Equal(t, 1, retried[0].Attempts)
+	assert.Equal(t, "status 500", retried[0].LastError)
+
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, models.Delivery{ID: "0"}), models.ErrorNotFound)
+
+	// брошенные доставки удаляются, если последняя попытка была раньше границы
+	failed.Attempts, failed.NextAttemptAt, failed.Abandoned = 8, time.Now().Add(-time.Hour), true
+	require.NoError(t, repo.RetryDelivery(ctx, failed))
+	purged, err := repo.PurgeAbandonedDeliveries(ctx, time.Now().Add(-2*time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = repo.PurgeAbandonedDeliveries(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, failed), models.ErrorNotFound)
+}
+
+func TestOutbox_Deletion(t *testing.T) {
+	ctx := context.Background()
+	setupSeparateTest(t, "TRUNCATE TABLE webhooks CASCADE;")
+	userID := uuid.New()
+
+	id, _, err := repo.SaveURL(ctx, userID, "http://example.com")
+	require.NoError(t, err)
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateWebhook(ctx, webhook))
+
+	// повторное удаление события не добавляет
+	for range 2 {
+		statuses, err := repo.markAsDeleted(ctx, []deleteIn{{userID: userID, shortURL: id}})
+		require.NoError(t, err)
+		assert.Equal(t, []models.DeletionStatus{models.DeletionDeleted}, statuses)
+	}
+
+	deliveries, err := repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.EventLinkDeleted, deliveries[0].Event.Type)
+
+	// удаление вебхука удаляет его недоставленные события
+	require.NoError(t, repo.DeleteWebhook(ctx, userID, webhook.ID))
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, deliveries[0]), models.ErrorNotFound)
+}
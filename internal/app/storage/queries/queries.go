@@ -1,20 +1,123 @@
 // Package queries содержит SQL-запросы для работы с таблицей urls.
 // Включает в себя запросы для:
-// - Добавления новых URL
+// - Добавления новых URL, в том числе импортированных
 // - Получения короткого URL по оригинальному
 // - Получения информации по короткому URL
 // - Получения всех URL пользователя
+// - Постраничной выдачи URL пользователя с фильтрами и сортировкой
 // - Мягкого удаления URL пользователя
+// - Изменения свойств ссылки пользователем
+// - Изменения целевого адреса ссылки и ведения истории его версий
+// - Модерации URL и ведения журнала аудита
+// - Переноса записей между хранилищами
+// - Обслуживания хранилища администратором
+// - Безвозвратного удаления записей по истечении срока хранения и удаления учётных записей
+// - Проверки отставания реплик
+// - Регистрации вебхуков и доставки событий ссылок через outbox
+//
+// Функция Name возвращает имя запроса для спанов трассировки.
 package queries
 
+// recordColumns - столбцы таблицы urls, из которых собирается models.Record.
+// Теги передаются массивом JSON, чтобы их можно было прочитать через database/sql.
+const recordColumns = "user_id, short_url, original_url, is_deleted, deleted_at, disabled_reason, disabled_legal, created_at, expires_at, title, to_json(tags)"
+
+// userURLsFilter - условия постраничной выдачи URL пользователя.
+// Пустые и NULL-параметры не участвуют в фильтрации.
+// Параметры:
+// $1 - ID пользователя
+// $2 - подстрока оригинального URL
+// $3 - признак удаления или NULL для выдачи всех ссылок
+// $4 - нижняя граница времени создания (включительно) или NULL
+// $5 - верхняя граница времени создания (не включительно) или NULL
+const userURLsFilter = " FROM urls WHERE user_id = $1" +
+	" AND ($2 = '' OR strpos(original_url, $2) > 0)" +
+	" AND ($3::boolean IS NULL OR is_deleted = $3::boolean)" +
+	" AND ($4::timestamptz IS NULL OR created_at >= $4::timestamptz)" +
+	" AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)"
+
 // SQL-запросы для работы с таблицей urls.
 const (
-	// InsertURL добавляет новую запись в таблицу urls.
+	// InsertURL добавляет новую запись в таблицу urls и в том же запросе - событие её создания
+	// в outbox для каждого вебхука пользователя.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - оригинальный URL
+	// $3 - ID пользователя
+	// $4 - ID события
+	// $5 - тип события
+	InsertURL string = "WITH inserted AS (INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3) " +
+		"RETURNING short_url, original_url, user_id, created_at) " +
+		"INSERT INTO webhook_outbox (webhook_id, event_id, event_type, short_url, original_url, occurred_at) " +
+		"SELECT w.id, $4::uuid, $5::varchar, i.short_url, i.original_url, i.created_at FROM inserted i JOIN webhooks w ON w.user_id = i.user_id;"
+
+	// InsertURLs добавляет пакет URL и возвращает идентификаторы всех URL пакета, включая сокращённые ранее.
+	// Повторяющийся в пакете URL сохраняется один раз с первым предложенным для него идентификатором.
+	// Изменение в ON CONFLICT ничего не меняет и нужно только для того, чтобы RETURNING вернул имеющиеся записи.
+	// Параметры:
+	// $1 - массив коротких URL
+	// $2 - массив оригинальных URL того же размера
+	// $3 - ID пользователя
+	InsertURLs string = "INSERT INTO urls (short_url, original_url, user_id) " +
+		"SELECT DISTINCT ON (original_url) short_url, original_url, $3::uuid " +
+		"FROM unnest($1::varchar[], $2::varchar[]) WITH ORDINALITY AS batch (short_url, original_url, ord) " +
+		"ORDER BY original_url, ord " +
+		"ON CONFLICT (original_url) DO UPDATE SET original_url = EXCLUDED.original_url " +
+		"RETURNING short_url, original_url;"
+
+	// CreateURLBatch создает временную таблицу для загрузки большого пакета URL через COPY.
+	// Таблица удаляется при завершении транзакции.
+	CreateURLBatch string = "CREATE TEMP TABLE url_batch (short_url varchar(10), original_url varchar(2048), ord bigint) ON COMMIT DROP;"
+
+	// InsertURLBatch переносит URL из временной таблицы url_batch так же, как InsertURLs.
+	// Параметры:
+	// $1 - ID пользователя
+	InsertURLBatch string = "INSERT INTO urls (short_url, original_url, user_id) " +
+		"SELECT DISTINCT ON (original_url) short_url, original_url, $1::uuid " +
+		"FROM url_batch " +
+		"ORDER BY original_url, ord " +
+		"ON CONFLICT (original_url) DO UPDATE SET original_url = EXCLUDED.original_url " +
+		"RETURNING short_url, original_url;"
+
+	// ImportURL добавляет импортированную запись со свойствами, заданными пользователем.
 	// Параметры:
 	// $1 - короткий URL
 	// $2 - оригинальный URL
 	// $3 - ID пользователя
-	InsertURL string = "INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3);"
+	// $4 - время создания
+	// $5 - время окончания действия или NULL
+	// $6 - заголовок
+	// $7 - теги
+	ImportURL string = "INSERT INTO urls (short_url, original_url, user_id, created_at, expires_at, title, tags) VALUES ($1, $2, $3, $4, $5, $6, $7);"
+
+	// ExistsShortURL проверяет, занят ли короткий URL.
+	// Параметры:
+	// $1 - короткий URL
+	ExistsShortURL string = "SELECT EXISTS (SELECT 1 FROM urls WHERE short_url = $1);"
+
+	// CopyURL добавляет перенесённую из другого хранилища запись без изменений.
+	// Запись, чей короткий или оригинальный URL уже есть в таблице, пропускается;
+	// запрос возвращает короткий URL только для добавленной записи.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - оригинальный URL
+	// $3 - ID пользователя
+	// $4 - флаг удаления
+	// $5 - причина блокировки
+	// $6 - блокировка по юридическим основаниям
+	// $7 - время создания
+	// $8 - время окончания действия или NULL
+	// $9 - заголовок
+	// $10 - теги
+	// $11 - время удаления или NULL; для удалённой записи без времени удаления используется текущее время
+	CopyURL string = "INSERT INTO urls (short_url, original_url, user_id, is_deleted, disabled_reason, disabled_legal, created_at, expires_at, title, tags, deleted_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CASE WHEN $4 THEN COALESCE($11, now()) END) ON CONFLICT DO NOTHING RETURNING short_url;"
+
+	// ScanURLs возвращает страницу записей всех пользователей в порядке возрастания короткого URL.
+	// Параметры:
+	// $1 - короткий URL, после которого начинается страница
+	// $2 - размер страницы
+	ScanURLs string = "SELECT " + recordColumns + " FROM urls WHERE short_url > $1 ORDER BY short_url LIMIT $2;"
 
 	// GetShortURL возвращает короткий URL по оригинальному URL.
 	// Параметры:
@@ -24,16 +127,310 @@ const (
 	// GetByShortURL возвращает полную информацию о URL по его короткой версии.
 	// Параметры:
 	// $1 - короткий URL
-	GetByShortURL string = "SELECT user_id, short_url, original_url, is_deleted from urls WHERE short_url = $1;"
+	GetByShortURL string = "SELECT " + recordColumns + " from urls WHERE short_url = $1;"
+
+	// LockByShortURL возвращает полную информацию о URL и блокирует запись до конца транзакции.
+	// Параметры:
+	// $1 - короткий URL
+	LockByShortURL string = "SELECT " + recordColumns + " from urls WHERE short_url = $1 FOR UPDATE;"
 
 	// GetUserUrls возвращает все URL, принадлежащие пользователю.
 	// Параметры:
 	// $1 - ID пользователя
-	GetUserUrls string = "SELECT user_id, short_url, original_url, is_deleted FROM urls WHERE user_id = $1;"
+	GetUserUrls string = "SELECT " + recordColumns + " FROM urls WHERE user_id = $1;"
+
+	// UserURLsByCreatedAt возвращает страницу URL пользователя по возрастанию времени создания.
+	// Параметры $1-$5 описаны в userURLsFilter.
+	// $6 - время создания последней выданной записи или NULL для первой страницы
+	// $7 - короткий URL последней выданной записи
+	// $8 - максимальное количество записей или NULL
+	UserURLsByCreatedAt string = "SELECT " + recordColumns + userURLsFilter +
+		" AND ($6::timestamptz IS NULL OR (created_at, short_url) > ($6::timestamptz, $7))" +
+		" ORDER BY created_at, short_url LIMIT $8;"
+
+	// UserURLsByCreatedAtDesc возвращает страницу URL пользователя по убыванию времени создания.
+	// Параметры совпадают с UserURLsByCreatedAt.
+	UserURLsByCreatedAtDesc string = "SELECT " + recordColumns + userURLsFilter +
+		" AND ($6::timestamptz IS NULL OR (created_at, short_url) < ($6::timestamptz, $7))" +
+		" ORDER BY created_at DESC, short_url DESC LIMIT $8;"
+
+	// UserURLsByOriginalURL возвращает страницу URL пользователя по возрастанию оригинального URL.
+	// Параметры $1-$5 описаны в userURLsFilter.
+	// $6 - оригинальный URL последней выданной записи или NULL для первой страницы
+	// $7 - короткий URL последней выданной записи
+	// $8 - максимальное количество записей или NULL
+	UserURLsByOriginalURL string = "SELECT " + recordColumns + userURLsFilter +
+		" AND ($6::text IS NULL OR (original_url, short_url) > ($6::text, $7))" +
+		" ORDER BY original_url, short_url LIMIT $8;"
 
-	// DeleteUserURL выполняет мягкое удаление URL пользователя.
+	// UserURLsByOriginalURLDesc возвращает страницу URL пользователя по убыванию оригинального URL.
+	// Параметры совпадают с UserURLsByOriginalURL.
+	UserURLsByOriginalURLDesc string = "SELECT " + recordColumns + userURLsFilter +
+		" AND ($6::text IS NULL OR (original_url, short_url) < ($6::text, $7))" +
+		" ORDER BY original_url DESC, short_url DESC LIMIT $8;"
+
+	// DeleteUserURLs выполняет мягкое удаление URL пользователя и возвращает короткие URL удалённых записей.
+	// Время удаления уже удалённых записей не меняется. Для записей, удалённых этим запросом,
+	// в outbox добавляется событие удаления для каждого вебхука пользователя.
 	// Параметры:
 	// $1 - ID пользователя
-	// $2 - короткий URL
-	DeleteUserURL string = "UPDATE urls SET is_deleted = true WHERE user_id = $1 AND short_url = $2;"
+	// $2 - массив коротких URL
+	// $3 - тип события
+	DeleteUserURLs string = "WITH targets AS (SELECT short_url, is_deleted AS was_deleted FROM urls " +
+		"WHERE user_id = $1 AND short_url = ANY($2) FOR UPDATE), " +
+		"deleted AS (UPDATE urls u SET is_deleted = true, deleted_at = COALESCE(u.deleted_at, now()) FROM targets t " +
+		"WHERE u.short_url = t.short_url " +
+		"RETURNING u.short_url, u.original_url, u.user_id, u.deleted_at, t.was_deleted, gen_random_uuid() AS event_id), " +
+		"events AS (INSERT INTO webhook_outbox (webhook_id, event_id, event_type, short_url, original_url, occurred_at) " +
+		"SELECT w.id, d.event_id, $3::varchar, d.short_url, d.original_url, d.deleted_at FROM deleted d " +
+		"JOIN webhooks w ON w.user_id = d.user_id WHERE NOT d.was_deleted) " +
+		"SELECT short_url FROM deleted;"
+
+	// ExistingShortURLs возвращает те из коротких URL, для которых есть записи.
+	// Параметры:
+	// $1 - массив коротких URL
+	ExistingShortURLs string = "SELECT short_url FROM urls WHERE short_url = ANY($1);"
+
+	// UpdateLink изменяет свойства ссылки, заданные пользователем.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - заголовок
+	// $3 - теги
+	// $4 - время окончания действия или NULL
+	UpdateLink string = "UPDATE urls SET title = $2, tags = $3, expires_at = $4 WHERE short_url = $1;"
+
+	// UpdateOriginalURL меняет целевой адрес ссылки.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - новый оригинальный URL
+	UpdateOriginalURL string = "UPDATE urls SET original_url = $2 WHERE short_url = $1;"
+
+	// InsertRevision добавляет версию целевого адреса ссылки в историю.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - номер версии
+	// $3 - оригинальный URL
+	// $4 - время появления версии
+	InsertRevision string = "INSERT INTO url_revisions (short_url, revision, original_url, created_at) VALUES ($1, $2, $3, $4);"
+
+	// GetRevisions возвращает историю целевых адресов ссылки в порядке версий.
+	// Параметры:
+	// $1 - короткий URL
+	GetRevisions string = "SELECT short_url, revision, original_url, created_at FROM url_revisions WHERE short_url = $1 ORDER BY revision;"
+
+	// SearchURLs ищет записи для модератора. Пустые параметры не участвуют в фильтрации.
+	// Параметры:
+	// $1 - подстрока оригинального URL
+	// $2 - ID пользователя или NULL
+	// $3 - короткий URL
+	// $4 - максимальное количество записей или NULL
+	SearchURLs string = "SELECT " + recordColumns + " FROM urls" +
+		" WHERE ($1 = '' OR strpos(original_url, $1) > 0)" +
+		" AND ($2::uuid IS NULL OR user_id = $2::uuid)" +
+		" AND ($3 = '' OR short_url = $3)" +
+		" ORDER BY id LIMIT $4;"
+
+	// DisableURL блокирует URL модератором.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - причина блокировки
+	// $3 - признак блокировки по юридическим основаниям
+	DisableURL string = "UPDATE urls SET disabled_reason = $2, disabled_legal = $3 WHERE short_url = $1;"
+
+	// RestoreURL снимает блокировку модератора с URL.
+	// Параметры:
+	// $1 - короткий URL
+	RestoreURL string = "UPDATE urls SET disabled_reason = '', disabled_legal = false WHERE short_url = $1;"
+
+	// PurgeURL безвозвратно удаляет URL.
+	// Параметры:
+	// $1 - короткий URL
+	PurgeURL string = "DELETE FROM urls WHERE short_url = $1;"
+
+	// SetDeleted помечает URL удаленным или снимает отметку об удалении вместе со временем удаления.
+	// Параметры:
+	// $1 - короткий URL
+	// $2 - флаг удаления
+	SetDeleted string = "UPDATE urls SET is_deleted = $2::boolean, " +
+		"deleted_at = CASE WHEN $2::boolean THEN COALESCE(deleted_at, now()) END WHERE short_url = $1;"
+
+	// PurgeDeleted безвозвратно удаляет все удаленные URL; история версий удаляется каскадно.
+	PurgeDeleted string = "DELETE FROM urls WHERE is_deleted;"
+
+	// PurgeExpired безвозвратно удаляет не больше заданного количества URL, удалённых пользователями
+	// или истёкших раньше заданного времени, и возвращает их короткие URL; история версий удаляется каскадно.
+	// Параметры:
+	// $1 - граница срока хранения
+	// $2 - максимальное количество удаляемых записей
+	PurgeExpired string = "DELETE FROM urls WHERE short_url IN (SELECT short_url FROM urls " +
+		"WHERE (is_deleted AND deleted_at < $1) OR expires_at < $1 LIMIT $2) RETURNING short_url;"
+
+	// PurgeUserURLs безвозвратно удаляет все URL пользователя и возвращает их короткие URL;
+	// история версий удаляется каскадно.
+	// Параметры:
+	// $1 - ID пользователя
+	PurgeUserURLs string = "DELETE FROM urls WHERE user_id = $1 RETURNING short_url;"
+
+	// RevokeUser отзывает токены пользователя. Повторный отзыв ничего не меняет.
+	// Параметры:
+	// $1 - ID пользователя
+	RevokeUser string = "INSERT INTO revoked_users (user_id) VALUES ($1) ON CONFLICT DO NOTHING;"
+
+	// IsUserRevoked проверяет, отозваны ли токены пользователя.
+	// Параметры:
+	// $1 - ID пользователя
+	IsUserRevoked string = "SELECT EXISTS (SELECT 1 FROM revoked_users WHERE user_id = $1);"
+
+	// DeleteUserWebhooks удаляет все вебхуки пользователя; их недоставленные события удаляются каскадно.
+	// Параметры:
+	// $1 - ID пользователя
+	DeleteUserWebhooks string = "DELETE FROM webhooks WHERE user_id = $1;"
+
+	// CountURLs возвращает количество URL: всего, удаленных, заблокированных и количество пользователей.
+	CountURLs string = "SELECT count(*), count(*) FILTER (WHERE is_deleted), count(*) FILTER (WHERE disabled_reason <> ''), " +
+		"count(DISTINCT user_id) FROM urls;"
+
+	// ReplicaLag возвращает отставание реплики от основной базы данных в секундах.
+	// На основной базе данных и на реплике, применившей все полученные изменения, возвращает 0.
+	ReplicaLag string = "SELECT CASE WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+		"ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END::float8;"
+
+	// InsertAuditEntry добавляет запись в журнал действий модератора.
+	// Параметры:
+	// $1 - время действия
+	// $2 - кто выполнил действие
+	// $3 - адрес клиента
+	// $4 - тип действия
+	// $5 - короткий URL
+	// $6 - дополнительные сведения
+	InsertAuditEntry string = "INSERT INTO admin_audit (created_at, actor, remote_addr, action, short_url, details) VALUES ($1, $2, $3, $4, $5, $6);"
+
+	// GetAuditLog возвращает журнал действий модератора в порядке добавления.
+	GetAuditLog string = "SELECT created_at, actor, remote_addr, action, short_url, details FROM admin_audit ORDER BY id;"
+
+	// InsertWebhook регистрирует вебхук пользователя.
+	// Параметры:
+	// $1 - ID вебхука
+	// $2 - ID пользователя
+	// $3 - адрес получателя
+	// $4 - ключ подписи
+	// $5 - время регистрации
+	InsertWebhook string = "INSERT INTO webhooks (id, user_id, url, secret, created_at) VALUES ($1, $2, $3, $4, $5);"
+
+	// GetUserWebhooks возвращает вебхуки пользователя в порядке регистрации.
+	// Параметры:
+	// $1 - ID пользователя
+	GetUserWebhooks string = "SELECT id::text, user_id, url, secret, created_at FROM webhooks WHERE user_id = $1 ORDER BY created_at, id;"
+
+	// DeleteWebhook удаляет вебхук пользователя; его недоставленные события удаляются каскадно.
+	// Параметры:
+	// $1 - ID вебхука
+	// $2 - ID пользователя
+	DeleteWebhook string = "DELETE FROM webhooks WHERE id = $1 AND user_id = $2;"
+
+	// ExistsUserWebhook проверяет, зарегистрированы ли у пользователя вебхуки.
+	// Параметры:
+	// $1 - ID пользователя
+	ExistsUserWebhook string = "SELECT EXISTS (SELECT 1 FROM webhooks WHERE user_id = $1);"
+
+	// InsertEvent добавляет событие ссылки в outbox для каждого вебхука её владельца.
+	// Параметры:
+	// $1 - ID владельца ссылки
+	// $2 - ID события
+	// $3 - тип события
+	// $4 - короткий URL
+	// $5 - оригинальный URL
+	// $6 - время события
+	InsertEvent string = "INSERT INTO webhook_outbox (webhook_id, event_id, event_type, short_url, original_url, occurred_at) " +
+		"SELECT id, $2::uuid, $3::varchar, $4::varchar, $5::varchar, $6::timestamptz FROM webhooks WHERE user_id = $1;"
+
+	// ClaimDeliveries выдаёт доставки, которые пора выполнить, и откладывает их следующую попытку,
+	// чтобы другие экземпляры сервиса не выдали их повторно. Заблокированные доставки пропускаются.
+	// Параметры:
+	// $1 - максимальное количество доставок
+	// $2 - отсрочка следующей попытки в секундах
+	ClaimDeliveries string = "WITH due AS (SELECT id FROM webhook_outbox WHERE NOT abandoned AND next_attempt_at <= now() " +
+		"ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED), " +
+		"claimed AS (UPDATE webhook_outbox o SET next_attempt_at = now() + make_interval(secs => $2::float8) FROM due " +
+		"WHERE o.id = due.id RETURNING o.*) " +
+		"SELECT c.id::text, c.webhook_id::text, c.event_id::text, c.event_type, c.short_url, c.original_url, c.occurred_at, " +
+		"c.attempts, c.next_attempt_at, c.last_error, w.url, w.secret " +
+		"FROM claimed c JOIN webhooks w ON w.id = c.webhook_id ORDER BY c.id;"
+
+	// RetryDelivery сохраняет результат неудачной попытки доставки.
+	// Параметры:
+	// $1 - ID доставки
+	// $2 - количество неудачных попыток
+	// $3 - время следующей попытки
+	// $4 - ошибка последней попытки
+	// $5 - признак исчерпания попыток
+	RetryDelivery string = "UPDATE webhook_outbox SET attempts = $2, next_attempt_at = $3, last_error = $4, abandoned = $5 WHERE id = $1;"
+
+	// CompleteDelivery удаляет выполненную доставку из outbox.
+	// Параметры:
+	// $1 - ID доставки
+	CompleteDelivery string = "DELETE FROM webhook_outbox WHERE id = $1;"
+
+	// PurgeAbandonedDeliveries удаляет доставки, попытки которых исчерпаны раньше заданного момента.
+	// Параметры:
+	// $1 - граница срока хранения брошенных доставок
+	PurgeAbandonedDeliveries string = "DELETE FROM webhook_outbox WHERE abandoned AND next_attempt_at < $1;"
 )
+
+// names сопоставляет текст запроса с именем его константы.
+var names = map[string]string{
+	InsertURL:                 "InsertURL",
+	InsertURLs:                "InsertURLs",
+	CreateURLBatch:            "CreateURLBatch",
+	InsertURLBatch:            "InsertURLBatch",
+	ImportURL:                 "ImportURL",
+	ExistsShortURL:            "ExistsShortURL",
+	CopyURL:                   "CopyURL",
+	ScanURLs:                  "ScanURLs",
+	GetShortURL:               "GetShortURL",
+	GetByShortURL:             "GetByShortURL",
+	LockByShortURL:            "LockByShortURL",
+	GetUserUrls:               "GetUserUrls",
+	UserURLsByCreatedAt:       "UserURLsByCreatedAt",
+	UserURLsByCreatedAtDesc:   "UserURLsByCreatedAtDesc",
+	UserURLsByOriginalURL:     "UserURLsByOriginalURL",
+	UserURLsByOriginalURLDesc: "UserURLsByOriginalURLDesc",
+	DeleteUserURLs:            "DeleteUserURLs",
+	ExistingShortURLs:         "ExistingShortURLs",
+	UpdateLink:                "UpdateLink",
+	UpdateOriginalURL:         "UpdateOriginalURL",
+	InsertRevision:            "InsertRevision",
+	GetRevisions:              "GetRevisions",
+	SearchURLs:                "SearchURLs",
+	DisableURL:                "DisableURL",
+	RestoreURL:                "RestoreURL",
+	PurgeURL:                  "PurgeURL",
+	SetDeleted:                "SetDeleted",
+	PurgeDeleted:              "PurgeDeleted",
+	PurgeExpired:              "PurgeExpired",
+	PurgeUserURLs:             "PurgeUserURLs",
+	RevokeUser:                "RevokeUser",
+	IsUserRevoked:             "IsUserRevoked",
+	CountURLs:                 "CountURLs",
+	ReplicaLag:                "ReplicaLag",
+	InsertAuditEntry:          "InsertAuditEntry",
+	GetAuditLog:               "GetAuditLog",
+	DeleteUserWebhooks:        "DeleteUserWebhooks",
+	InsertWebhook:             "InsertWebhook",
+	ExistsUserWebhook:         "ExistsUserWebhook",
+	GetUserWebhooks:           "GetUserWebhooks",
+	DeleteWebhook:             "DeleteWebhook",
+	InsertEvent:               "InsertEvent",
+	ClaimDeliveries:           "ClaimDeliveries",
+	RetryDelivery:             "RetryDelivery",
+	CompleteDelivery:          "CompleteDelivery",
+	PurgeAbandonedDeliveries:  "PurgeAbandonedDeliveries",
+}
+
+// Name возвращает имя SQL-запроса для трассировки или "unknown", если запрос не из этого пакета.
+func Name(query string) string {
+	if name, ok := names[query]; ok {
+		return name
+	}
+	return "unknown"
+}
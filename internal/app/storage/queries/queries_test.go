@@ -0,0 +1,13 @@
+package queries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "GetByShortURL", Name(GetByShortURL))
+	assert.Equal(t, "InsertAuditEntry", Name(InsertAuditEntry))
+	assert.Equal(t, "unknown", Name("SELECT 1;"))
+}
@@ -0,0 +1,33 @@
+package simple
+
+import (
+	"context"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/strings"
+)
+
+// ImportURL сохраняет импортированную запись пользователя.
+// Короткий идентификатор записи сохраняется, если он допустим и свободен, иначе создаётся новый.
+// Возвращает идентификатор сохранённой записи или, вместе с ErrorOriginalURLExists,
+// идентификатор ссылки, которая уже сокращает этот адрес.
+func (repo *SimpleRepository) ImportURL(ctx context.Context, record models.Record) (id string, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if id, err := repo.idOf(record.OriginalURL); err == nil {
+		return id, models.ErrorOriginalURLExists
+	}
+
+	if !models.ValidShortURL(record.ShortURL) || repo.indexOf(record.ShortURL) >= 0 {
+		record.ShortURL = strings.RandString(idLength)
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	repo.Records = append(repo.Records, record)
+	repo.emit(models.EventLinkCreated, record, time.Now().UTC())
+
+	return record.ShortURL, nil
+}
@@ -0,0 +1,45 @@
+package simple
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleRepository_ImportURL(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSimpleRepository()
+	userID := uuid.New()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// свободный идентификатор и свойства записи сохраняются
+	id, err := repo.ImportURL(ctx, models.Record{
+		ShortURL: "abc", OriginalURL: "http://example.com", UserID: userID, CreatedAt: created, Title: "Пример", Tags: []string{"a"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	record, err := repo.RetrieveByShortURL(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, created, record.CreatedAt)
+	assert.Equal(t, "Пример", record.Title)
+	assert.Equal(t, []string{"a"}, record.Tags)
+
+	// занятый и недопустимый идентификаторы заменяются новыми
+	id, err = repo.ImportURL(ctx, models.Record{ShortURL: "abc", OriginalURL: "http://example.org", UserID: userID})
+	require.NoError(t, err)
+	assert.NotEqual(t, "abc", id)
+	id, err = repo.ImportURL(ctx, models.Record{ShortURL: "a/b", OriginalURL: "http://example.net", UserID: userID})
+	require.NoError(t, err)
+	assert.NotEqual(t, "a/b", id)
+
+	// уже сокращённый адрес не импортируется
+	id, err = repo.ImportURL(ctx, models.Record{ShortURL: "xyz", OriginalURL: "http://example.com", UserID: userID})
+	assert.ErrorIs(t, err, models.ErrorOriginalURLExists)
+	assert.Equal(t, "abc", id)
+	assert.Len(t, repo.Records, 3)
+}
@@ -0,0 +1,22 @@
+package simple
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// UpdateLink изменяет свойства ссылки пользователя.
+// Возвращает обновлённую запись или ErrorNotFound, если ссылки нет или она принадлежит другому пользователю.
+func (repo *SimpleRepository) UpdateLink(ctx context.Context, userID uuid.UUID, shortURL string, update models.LinkUpdate) (record models.Record, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 || repo.Records[i].UserID != userID {
+		return models.Record{}, models.ErrorNotFound
+	}
+	update.Apply(&repo.Records[i])
+	return repo.Records[i], nil
+}
@@ -0,0 +1,39 @@
+package simple
+
+import (
+	"context"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// SetDeleted помечает запись удаленной или снимает отметку об удалении.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *SimpleRepository) SetDeleted(ctx context.Context, shortURL string, deleted bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	repo.Records[i].SetDeleted(deleted, time.Now().UTC())
+	return nil
+}
+
+// PurgeDeleted безвозвратно удаляет все удаленные пользователями записи вместе с историей их версий.
+// Возвращает количество удаленных записей.
+func (repo *SimpleRepository) PurgeDeleted(ctx context.Context) (purged int, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.purge(func(r models.Record) bool { return r.IsDeleted }), nil
+}
+
+// CountURLs возвращает сводку по записям хранилища.
+func (repo *SimpleRepository) CountURLs(ctx context.Context) (counts models.URLCounts, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return models.CountRecords(repo.Records), nil
+}
@@ -0,0 +1,44 @@
+package simple
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleRepository_Maintenance(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := &SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "a", OriginalURL: "http://example.com/a", UserID: userID},
+			{ShortURL: "b", OriginalURL: "http://example.com/b", UserID: userID, DisabledReason: "spam"},
+			{ShortURL: "c", OriginalURL: "http://example.com/c", UserID: uuid.New()},
+		},
+		Revisions: []models.Revision{{ShortURL: "a", Revision: 1, OriginalURL: "http://example.org"}},
+	}
+
+	require.ErrorIs(t, repo.SetDeleted(ctx, "x", true), models.ErrorNotFound)
+	require.NoError(t, repo.SetDeleted(ctx, "a", true))
+	require.NoError(t, repo.SetDeleted(ctx, "c", true))
+	require.NoError(t, repo.SetDeleted(ctx, "c", false))
+
+	counts, err := repo.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.URLCounts{Total: 3, Deleted: 1, Disabled: 1, Users: 2}, counts)
+
+	purged, err := repo.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Empty(t, repo.Revisions)
+	_, err = repo.RetrieveByShortURL(ctx, "a")
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+
+	counts, err = repo.CountURLs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.URLCounts{Total: 2, Disabled: 1, Users: 2}, counts)
+}
@@ -0,0 +1,34 @@
+package simple
+
+import (
+	"context"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// ScanURLs возвращает до limit записей всех пользователей с короткими идентификаторами больше after
+// в порядке возрастания идентификаторов. Используется для переноса данных между хранилищами.
+func (repo *SimpleRepository) ScanURLs(ctx context.Context, after string, limit int) (records []models.Record, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return models.ScanPage(repo.Records, after, limit), nil
+}
+
+// CopyURLs сохраняет записи без изменений, включая идентификаторы, владельцев и флаги удаления.
+// Записи, чей короткий идентификатор или оригинальный URL уже есть в хранилище, пропускаются.
+// Возвращает короткие идентификаторы сохранённых записей.
+func (repo *SimpleRepository) CopyURLs(ctx context.Context, records []models.Record) (copied []string, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	copied = make([]string, 0, len(records))
+	for _, record := range records {
+		if _, err := repo.idOf(record.OriginalURL); err == nil || repo.indexOf(record.ShortURL) >= 0 {
+			continue
+		}
+		repo.Records = append(repo.Records, record)
+		copied = append(copied, record.ShortURL)
+	}
+	return copied, nil
+}
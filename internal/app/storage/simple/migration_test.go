@@ -0,0 +1,44 @@
+package simple
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleRepository_CopyURLs(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := &SimpleRepository{
+		Records: []models.Record{{ShortURL: "b", OriginalURL: "http://example.com/b"}},
+	}
+
+	copied, err := repo.CopyURLs(ctx, []models.Record{
+		{ShortURL: "c", OriginalURL: "http://example.com/c", UserID: userID, IsDeleted: true},
+		{ShortURL: "b", OriginalURL: "http://example.org"},
+		{ShortURL: "x", OriginalURL: "http://example.com/b"},
+		{ShortURL: "a", OriginalURL: "http://example.com/a", UserID: userID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a"}, copied)
+
+	record, err := repo.RetrieveByShortURL(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, userID, record.UserID)
+	assert.True(t, record.IsDeleted)
+
+	// обход идёт по возрастанию идентификаторов страницами
+	page, err := repo.ScanURLs(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "a", page[0].ShortURL)
+	assert.Equal(t, "b", page[1].ShortURL)
+	page, err = repo.ScanURLs(ctx, "b", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "c", page[0].ShortURL)
+}
@@ -0,0 +1,92 @@
+package simple
+
+import (
+	"context"
+	"slices"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// SearchURLs ищет записи, удовлетворяющие фильтру модератора.
+// Возвращает массив записей и ошибку.
+func (repo *SimpleRepository) SearchURLs(ctx context.Context, filter models.SearchFilter) (records []models.Record, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	records = make([]models.Record, 0)
+	for _, r := range repo.Records {
+		if filter.Limit > 0 && len(records) >= filter.Limit {
+			break
+		}
+		if filter.Match(r) {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+// DisableURL блокирует запись с указанной причиной и добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *SimpleRepository) DisableURL(ctx context.Context, shortURL string, reason string, legal bool, entry models.AuditEntry) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	repo.Records[i].DisabledReason = reason
+	repo.Records[i].DisabledLegal = legal
+	repo.AuditLog = append(repo.AuditLog, entry)
+	return nil
+}
+
+// RestoreURL снимает блокировку с записи и добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *SimpleRepository) RestoreURL(ctx context.Context, shortURL string, entry models.AuditEntry) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	repo.Records[i].DisabledReason = ""
+	repo.Records[i].DisabledLegal = false
+	repo.AuditLog = append(repo.AuditLog, entry)
+	return nil
+}
+
+// PurgeURL безвозвратно удаляет запись и историю её версий из хранилища
+// и добавляет entry в журнал действий модератора.
+// Возвращает ErrorNotFound, если запись не найдена.
+func (repo *SimpleRepository) PurgeURL(ctx context.Context, shortURL string, entry models.AuditEntry) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 {
+		return models.ErrorNotFound
+	}
+	repo.Records = slices.Delete(repo.Records, i, i+1)
+	repo.Revisions = slices.DeleteFunc(repo.Revisions, func(r models.Revision) bool {
+		return r.ShortURL == shortURL
+	})
+	repo.AuditLog = append(repo.AuditLog, entry)
+	return nil
+}
+
+// RetrieveAuditLog возвращает журнал действий модератора в порядке добавления.
+func (repo *SimpleRepository) RetrieveAuditLog(ctx context.Context) (entries []models.AuditEntry, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return slices.Clone(repo.AuditLog), nil
+}
+
+// indexOf возвращает индекс записи с указанным коротким идентификатором или -1.
+func (repo *SimpleRepository) indexOf(shortURL string) int {
+	return slices.IndexFunc(repo.Records, func(r models.Record) bool {
+		return r.ShortURL == shortURL
+	})
+}
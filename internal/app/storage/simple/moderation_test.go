@@ -0,0 +1,70 @@
+package simple
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+func TestSimpleRepository_SearchURLs(t *testing.T) {
+	userID := uuid.New()
+	repo := SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "123", OriginalURL: "http://example.com/a", UserID: userID},
+			{ShortURL: "456", OriginalURL: "http://example.com/b", UserID: uuid.New()},
+			{ShortURL: "789", OriginalURL: "http://ya.ru", UserID: userID},
+		},
+	}
+
+	got, err := repo.SearchURLs(context.Background(), models.SearchFilter{OriginalURL: "example", UserID: userID})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "123", got[0].ShortURL)
+
+	got, err = repo.SearchURLs(context.Background(), models.SearchFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
+
+func TestSimpleRepository_Moderation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSimpleRepository()
+	id, _, err := repo.SaveURL(ctx, uuid.New(), "http://example.com")
+	require.NoError(t, err)
+
+	entry := func(action string) models.AuditEntry {
+		return models.AuditEntry{Actor: "alice", Action: action, ShortURL: id}
+	}
+
+	require.NoError(t, repo.DisableURL(ctx, id, "spam", false, entry(models.AuditActionDisable)))
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.True(t, record.IsDisabled())
+	assert.Equal(t, "spam", record.DisabledReason)
+
+	require.NoError(t, repo.RestoreURL(ctx, id, entry(models.AuditActionRestore)))
+	record, err = repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, record.IsDisabled())
+
+	require.NoError(t, repo.PurgeURL(ctx, id, entry(models.AuditActionPurge)))
+	_, err = repo.RetrieveByShortURL(ctx, id)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+
+	assert.ErrorIs(t, repo.DisableURL(ctx, id, "spam", false, entry(models.AuditActionDisable)), models.ErrorNotFound)
+	assert.ErrorIs(t, repo.RestoreURL(ctx, id, entry(models.AuditActionRestore)), models.ErrorNotFound)
+	assert.ErrorIs(t, repo.PurgeURL(ctx, id, entry(models.AuditActionPurge)), models.ErrorNotFound)
+
+	entries, err := repo.RetrieveAuditLog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEntry{
+		entry(models.AuditActionDisable),
+		entry(models.AuditActionRestore),
+		entry(models.AuditActionPurge),
+	}, entries)
+}
@@ -3,7 +3,8 @@ package simple
 
 import (
 	"context"
-	"slices"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/iubondar/url-shortener/internal/app/models"
@@ -14,8 +15,17 @@ const idLength int = 8
 
 // SimpleRepository реализует in-memory хранилище URL.
 // Хранит все записи в памяти и не сохраняет их между запусками приложения.
+// Безопасно для одновременного использования обработчиками запросов и фоновыми задачами.
 type SimpleRepository struct {
-	Records []models.Record // массив записей URL
+	mu sync.RWMutex // защищает поля хранилища
+
+	Records   []models.Record      // массив записей URL
+	AuditLog  []models.AuditEntry  // журнал действий модератора
+	Revisions []models.Revision    // история целевых адресов ссылок
+	Deletions []models.DeletionJob // задачи удаления ссылок
+	Revoked   []uuid.UUID          // пользователи, чьи токены отозваны
+	Webhooks  []models.Webhook     // вебхуки пользователей
+	Outbox    []models.Delivery    // недоставленные события вебхуков в порядке появления
 }
 
 // NewSimpleRepository создает новый экземпляр SimpleRepository.
@@ -30,28 +40,36 @@ func NewSimpleRepository() *SimpleRepository {
 // Если URL уже существует, возвращает его короткий идентификатор.
 // Возвращает короткий идентификатор, флаг существования и ошибку.
 func (repo *SimpleRepository) SaveURL(ctx context.Context, userID uuid.UUID, url string) (id string, exists bool, err error) {
-	id, err = repo.RetrieveID(url)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.saveURL(userID, url)
+}
+
+// saveURL сохраняет URL в хранилище. Вызывается под блокировкой на запись.
+func (repo *SimpleRepository) saveURL(userID uuid.UUID, url string) (id string, exists bool, err error) {
+	id, err = repo.idOf(url)
 	if err == nil && len(id) > 0 {
 		return id, true, nil
 	}
 
 	// создаём идентификатор и сохраняем URL
 	id = strings.RandString(idLength)
-	repo.Records = append(
-		repo.Records,
-		models.Record{
-			ShortURL:    id,
-			OriginalURL: url,
-			UserID:      userID,
-		},
-	)
+	record := models.Record{
+		ShortURL:    id,
+		OriginalURL: url,
+		UserID:      userID,
+		CreatedAt:   time.Now().UTC(),
+	}
+	repo.Records = append(repo.Records, record)
+	repo.emit(models.EventLinkCreated, record, record.CreatedAt)
 
 	return id, false, nil
 }
 
 // CheckStatus проверяет состояние хранилища.
 // Для in-memory хранилища всегда возвращает nil.
-func (repo SimpleRepository) CheckStatus(ctx context.Context) error {
+func (repo *SimpleRepository) CheckStatus(ctx context.Context) error {
 	// Статус всегда ок
 	return nil
 }
@@ -59,9 +77,12 @@ func (repo SimpleRepository) CheckStatus(ctx context.Context) error {
 // SaveURLs сохраняет массив URL в хранилище.
 // Возвращает массив коротких идентификаторов и ошибку.
 func (repo *SimpleRepository) SaveURLs(ctx context.Context, urls []string) (ids []string, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	ids = make([]string, 0)
 	for _, url := range urls {
-		id, _, err := repo.SaveURL(ctx, uuid.Nil, url)
+		id, _, err := repo.saveURL(uuid.Nil, url)
 		if err != nil {
 			return nil, err
 		}
@@ -72,7 +93,10 @@ func (repo *SimpleRepository) SaveURLs(ctx context.Context, urls []string) (ids
 
 // RetrieveByShortURL получает запись по короткому идентификатору.
 // Возвращает запись и ошибку.
-func (repo SimpleRepository) RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error) {
+func (repo *SimpleRepository) RetrieveByShortURL(ctx context.Context, shortURL string) (record models.Record, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	for _, r := range repo.Records {
 		if r.ShortURL == shortURL {
 			return r, nil
@@ -84,7 +108,15 @@ func (repo SimpleRepository) RetrieveByShortURL(ctx context.Context, shortURL st
 
 // RetrieveID получает короткий идентификатор по оригинальному URL.
 // Возвращает короткий идентификатор и ошибку.
-func (repo SimpleRepository) RetrieveID(url string) (id string, err error) {
+func (repo *SimpleRepository) RetrieveID(url string) (id string, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return repo.idOf(url)
+}
+
+// idOf получает короткий идентификатор по оригинальному URL. Вызывается под блокировкой.
+func (repo *SimpleRepository) idOf(url string) (id string, err error) {
 	for _, r := range repo.Records {
 		if r.OriginalURL == url {
 			return r.ShortURL, nil
@@ -96,23 +128,64 @@ func (repo SimpleRepository) RetrieveID(url string) (id string, err error) {
 
 // RetrieveUserURLs получает все URL пользователя.
 // Возвращает массив записей и ошибку.
-func (repo SimpleRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error) {
-	records = make([]models.Record, 0)
+func (repo *SimpleRepository) RetrieveUserURLs(ctx context.Context, userID uuid.UUID) (records []models.Record, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return repo.userURLs(userID), nil
+}
+
+// userURLs возвращает все записи пользователя. Вызывается под блокировкой.
+func (repo *SimpleRepository) userURLs(userID uuid.UUID) []models.Record {
+	records := make([]models.Record, 0)
 	for _, r := range repo.Records {
 		if r.UserID == userID {
 			records = append(records, r)
 		}
 	}
-	return records, nil
+	return records
 }
 
 // DeleteByShortURLs помечает URL как удаленные.
 // Принимает идентификатор пользователя и массив коротких идентификаторов.
-func (repo *SimpleRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) {
-	for i, r := range repo.Records {
-		if r.UserID == userID && slices.Contains(shortURLs, r.ShortURL) {
-			r.IsDeleted = true
-			repo.Records[i] = r
+// Удаление выполняется сразу, поэтому возвращаемая задача удаления уже завершена.
+func (repo *SimpleRepository) DeleteByShortURLs(ctx context.Context, userID uuid.UUID, shortURLs []string) (job models.DeletionJob, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now().UTC()
+	job = models.NewDeletionJob(userID, shortURLs, now)
+	for _, item := range job.Items {
+		var record *models.Record
+		if i := repo.indexOf(item.ShortURL); i >= 0 {
+			record = &repo.Records[i]
+		}
+		status := models.DeletionStatusOf(record, userID)
+		if status == models.DeletionDeleted && !record.IsDeleted {
+			record.SetDeleted(true, now)
+			repo.emit(models.EventLinkDeleted, *record, now)
 		}
+		job.Resolve(item.ShortURL, status, now)
 	}
+	repo.Deletions = append(models.PruneDeletionJobs(repo.Deletions, now), job)
+	return job, nil
+}
+
+// RetrieveDeletionJob возвращает задачу удаления id пользователя userID
+// или models.ErrorNotFound, если задача неизвестна, устарела или принадлежит другому пользователю.
+func (repo *SimpleRepository) RetrieveDeletionJob(ctx context.Context, userID uuid.UUID, id string) (job models.DeletionJob, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return models.FindDeletionJob(repo.Deletions, userID, id)
+}
+
+// SearchUserURLs возвращает страницу URL пользователя, отобранных и упорядоченных по условиям выборки.
+// Возвращает страницу и курсор следующей страницы или nil, если страница последняя.
+func (repo *SimpleRepository) SearchUserURLs(ctx context.Context, userID uuid.UUID, query models.URLQuery) (records []models.Record, next *models.Cursor, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	records, next = query.Page(repo.userURLs(userID))
+	return records, next, nil
 }
@@ -3,6 +3,7 @@ package simple
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -465,13 +466,48 @@ func TestSimpleRepository_DeleteByShortURLs(t *testing.T) {
 			repo := SimpleRepository{
 				Records: tt.records,
 			}
-			repo.DeleteByShortURLs(context.Background(), tt.args.userID, tt.args.shortURLs)
+			_, err := repo.DeleteByShortURLs(context.Background(), tt.args.userID, tt.args.shortURLs)
+			require.NoError(t, err)
 
+			// время удаления задается хранилищем, поэтому проверяется только его наличие
+			for i := range repo.Records {
+				assert.Equal(t, repo.Records[i].IsDeleted, repo.Records[i].DeletedAt != nil, repo.Records[i].ShortURL)
+				repo.Records[i].DeletedAt = nil
+			}
 			assert.ElementsMatch(t, tt.wantRecords, repo.Records)
 		})
 	}
 }
 
+func TestSimpleRepository_DeletionJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := &SimpleRepository{Records: []models.Record{
+		{ShortURL: "own", OriginalURL: "http://example.com", UserID: userID},
+		{ShortURL: "other", OriginalURL: "http://example.org", UserID: uuid.New()},
+	}}
+
+	job, err := repo.DeleteByShortURLs(ctx, userID, []string{"own", "other", "missing", "own"})
+	require.NoError(t, err)
+
+	// удаление выполняется сразу, повторы не дублируются
+	assert.NotNil(t, job.CompletedAt)
+	assert.Equal(t, []models.DeletionItem{
+		{ShortURL: "own", Status: models.DeletionDeleted},
+		{ShortURL: "other", Status: models.DeletionNotOwned},
+		{ShortURL: "missing", Status: models.DeletionNotFound},
+	}, job.Items)
+
+	got, err := repo.RetrieveDeletionJob(ctx, userID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job, got)
+
+	_, err = repo.RetrieveDeletionJob(ctx, uuid.New(), job.ID)
+	require.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = repo.RetrieveDeletionJob(ctx, userID, uuid.NewString())
+	require.ErrorIs(t, err, models.ErrorNotFound)
+}
+
 func TestSimpleRepository_RetrieveUserURLs(t *testing.T) {
 	userID := uuid.New()
 	type args struct {
@@ -538,6 +574,92 @@ func TestSimpleRepository_RetrieveUserURLs(t *testing.T) {
 	}
 }
 
+func TestSimpleRepository_SearchUserURLs(t *testing.T) {
+	userID := uuid.New()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "c", OriginalURL: "http://b.example/docs", UserID: userID, CreatedAt: created.Add(2 * time.Hour)},
+			{ShortURL: "a", OriginalURL: "http://c.example/docs", UserID: userID, CreatedAt: created},
+			{ShortURL: "b", OriginalURL: "http://a.example/blog", UserID: userID, CreatedAt: created, IsDeleted: true},
+			{ShortURL: "d", OriginalURL: "http://d.example/docs", UserID: uuid.New(), CreatedAt: created},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query models.URLQuery
+		want  []string
+	}{
+		{
+			name: "Default order is creation time then short URL",
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name:  "Descending creation time",
+			query: models.URLQuery{Desc: true},
+			want:  []string{"c", "b", "a"},
+		},
+		{
+			name:  "Sort by original URL",
+			query: models.URLQuery{Sort: models.SortOriginalURL},
+			want:  []string{"b", "c", "a"},
+		},
+		{
+			name:  "Substring of original URL",
+			query: models.URLQuery{Contains: "docs"},
+			want:  []string{"a", "c"},
+		},
+		{
+			name:  "Exclude deleted",
+			query: models.URLQuery{Deleted: models.DeletedExclude},
+			want:  []string{"a", "c"},
+		},
+		{
+			name:  "Only deleted",
+			query: models.URLQuery{Deleted: models.DeletedOnly},
+			want:  []string{"b"},
+		},
+		{
+			name:  "Created range",
+			query: models.URLQuery{CreatedFrom: created.Add(time.Hour), CreatedTo: created.Add(3 * time.Hour)},
+			want:  []string{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, next, err := repo.SearchUserURLs(context.Background(), userID, tt.query)
+			require.NoError(t, err)
+			assert.Nil(t, next)
+			ids := make([]string, 0, len(records))
+			for _, r := range records {
+				ids = append(ids, r.ShortURL)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	t.Run("Pages follow each other", func(t *testing.T) {
+		query := models.URLQuery{Sort: models.SortOriginalURL, Desc: true, Limit: 2}
+		records, next, err := repo.SearchUserURLs(context.Background(), userID, query)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "a", records[0].ShortURL)
+		assert.Equal(t, "c", records[1].ShortURL)
+		require.NotNil(t, next)
+
+		// курсор переживает кодирование для передачи клиенту
+		after, err := models.DecodeCursor(next.Encode())
+		require.NoError(t, err)
+		query.After = &after
+		records, next, err = repo.SearchUserURLs(context.Background(), userID, query)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "b", records[0].ShortURL)
+		assert.Nil(t, next)
+	})
+}
+
 // BenchmarkSimpleRepository_SaveURL измеряет производительность сохранения URL
 func BenchmarkSimpleRepository_SaveURL(b *testing.B) {
 	repo := NewSimpleRepository()
@@ -597,7 +719,7 @@ func BenchmarkSimpleRepository_DeleteByShortURLs(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		repo.DeleteByShortURLs(ctx, userID, shortURLs)
+		_, _ = repo.DeleteByShortURLs(ctx, userID, shortURLs)
 	}
 }
 
@@ -0,0 +1,60 @@
+package simple
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// PurgeExpired безвозвратно удаляет записи, удалённые пользователями или истёкшие раньше cutoff,
+// вместе с историей их версий. Возвращает количество удалённых записей.
+func (repo *SimpleRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.purge(func(r models.Record) bool { return r.OutlivedRetention(cutoff) }), nil
+}
+
+// DeleteAccount безвозвратно удаляет все записи и вебхуки пользователя вместе с историей версий
+// и недоставленными событиями и отзывает его токены. Возвращает количество удалённых записей.
+func (repo *SimpleRepository) DeleteAccount(ctx context.Context, userID uuid.UUID) (purged int, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	purged = repo.purge(func(r models.Record) bool { return r.UserID == userID })
+	repo.Webhooks, repo.Outbox, _ = models.DeleteWebhooks(repo.Webhooks, repo.Outbox, func(w models.Webhook) bool {
+		return w.UserID == userID
+	})
+	if !slices.Contains(repo.Revoked, userID) {
+		repo.Revoked = append(repo.Revoked, userID)
+	}
+	return purged, nil
+}
+
+// IsUserRevoked сообщает, отозваны ли токены пользователя.
+func (repo *SimpleRepository) IsUserRevoked(ctx context.Context, userID uuid.UUID) (bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return slices.Contains(repo.Revoked, userID), nil
+}
+
+// purge удаляет записи, для которых del возвращает true, вместе с историей их версий.
+// Возвращает количество удалённых записей. Вызывается под блокировкой на запись.
+func (repo *SimpleRepository) purge(del func(r models.Record) bool) (purged int) {
+	deleted := make(map[string]bool)
+	repo.Records = slices.DeleteFunc(repo.Records, func(r models.Record) bool {
+		if del(r) {
+			deleted[r.ShortURL] = true
+			return true
+		}
+		return false
+	})
+	repo.Revisions = slices.DeleteFunc(repo.Revisions, func(r models.Revision) bool {
+		return deleted[r.ShortURL]
+	})
+	return len(deleted)
+}
@@ -0,0 +1,80 @@
+package simple
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleRepository_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	cutoff := time.Now().UTC()
+	before := cutoff.Add(-time.Hour)
+	after := cutoff.Add(time.Hour)
+	repo := &SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "deleted", IsDeleted: true, DeletedAt: &before},
+			{ShortURL: "fresh", IsDeleted: true, DeletedAt: &after},
+			{ShortURL: "expired", ExpiresAt: &before},
+			{ShortURL: "active", ExpiresAt: &after},
+		},
+		Revisions: []models.Revision{{ShortURL: "expired", Revision: 1}},
+	}
+
+	purged, err := repo.PurgeExpired(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Empty(t, repo.Revisions)
+	for _, shortURL := range []string{"deleted", "expired"} {
+		_, err = repo.RetrieveByShortURL(ctx, shortURL)
+		assert.ErrorIs(t, err, models.ErrorNotFound, shortURL)
+	}
+	assert.Len(t, repo.Records, 2)
+
+	// время удаления запоминается при удалении и не меняется при повторном
+	require.NoError(t, repo.SetDeleted(ctx, "active", true))
+	deletedAt := repo.Records[1].DeletedAt
+	require.NotNil(t, deletedAt)
+	require.NoError(t, repo.SetDeleted(ctx, "active", true))
+	assert.Equal(t, deletedAt, repo.Records[1].DeletedAt)
+	require.NoError(t, repo.SetDeleted(ctx, "active", false))
+	assert.Nil(t, repo.Records[1].DeletedAt)
+}
+
+func TestSimpleRepository_DeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	otherID := uuid.New()
+	repo := &SimpleRepository{
+		Records: []models.Record{
+			{ShortURL: "a", UserID: userID},
+			{ShortURL: "b", UserID: userID, IsDeleted: true},
+			{ShortURL: "c", UserID: otherID},
+		},
+		Revisions: []models.Revision{{ShortURL: "a", Revision: 1}, {ShortURL: "c", Revision: 1}},
+	}
+
+	purged, err := repo.DeleteAccount(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, []models.Record{{ShortURL: "c", UserID: otherID}}, repo.Records)
+	assert.Equal(t, []models.Revision{{ShortURL: "c", Revision: 1}}, repo.Revisions)
+
+	revoked, err := repo.IsUserRevoked(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsUserRevoked(ctx, otherID)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// повторное удаление ничего не удаляет и не дублирует отзыв
+	purged, err = repo.DeleteAccount(ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.Len(t, repo.Revoked, 1)
+}
@@ -0,0 +1,62 @@
+package simple
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// UpdateOriginalURL меняет целевой адрес ссылки пользователя и добавляет новую версию в историю.
+// Если адрес не изменился, возвращает запись без изменений.
+// Возвращает ErrorNotFound, если ссылки нет или она принадлежит другому пользователю,
+// и ErrorOriginalURLExists, если адрес уже сокращён другой ссылкой.
+func (repo *SimpleRepository) UpdateOriginalURL(ctx context.Context, userID uuid.UUID, shortURL string, url string) (record models.Record, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 || repo.Records[i].UserID != userID {
+		return models.Record{}, models.ErrorNotFound
+	}
+	if repo.Records[i].OriginalURL == url {
+		return repo.Records[i], nil
+	}
+	if _, err := repo.idOf(url); err == nil {
+		return models.Record{}, models.ErrorOriginalURLExists
+	}
+
+	history := repo.revisionsOf(shortURL)
+	repo.Revisions = append(repo.Revisions, models.NextRevisions(history, repo.Records[i], url, time.Now().UTC())...)
+	repo.Records[i].OriginalURL = url
+	return repo.Records[i], nil
+}
+
+// RetrieveRevisions возвращает историю целевых адресов ссылки пользователя от первой версии к последней.
+// Возвращает ErrorNotFound, если ссылки нет или она принадлежит другому пользователю.
+func (repo *SimpleRepository) RetrieveRevisions(ctx context.Context, userID uuid.UUID, shortURL string) (revisions []models.Revision, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	i := repo.indexOf(shortURL)
+	if i < 0 || repo.Records[i].UserID != userID {
+		return nil, models.ErrorNotFound
+	}
+	revisions = repo.revisionsOf(shortURL)
+	if len(revisions) == 0 {
+		revisions = []models.Revision{models.InitialRevision(repo.Records[i])}
+	}
+	return revisions, nil
+}
+
+// revisionsOf возвращает сохранённые версии ссылки в порядке добавления.
+func (repo *SimpleRepository) revisionsOf(shortURL string) []models.Revision {
+	revisions := make([]models.Revision, 0)
+	for _, r := range repo.Revisions {
+		if r.ShortURL == shortURL {
+			revisions = append(revisions, r)
+		}
+	}
+	return revisions
+}
@@ -0,0 +1,60 @@
+package simple
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleRepository_UpdateOriginalURL(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSimpleRepository()
+	userID := uuid.New()
+	id, _, err := repo.SaveURL(ctx, userID, "http://example.com/v1")
+	require.NoError(t, err)
+	_, _, err = repo.SaveURL(ctx, userID, "http://example.org")
+	require.NoError(t, err)
+
+	// до первого изменения история состоит из исходного адреса
+	revisions, err := repo.RetrieveRevisions(ctx, userID, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "http://example.com/v1", revisions[0].OriginalURL)
+
+	record, err := repo.UpdateOriginalURL(ctx, userID, id, "http://example.com/v2")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/v2", record.OriginalURL)
+
+	// повтор того же адреса не создаёт новую версию
+	_, err = repo.UpdateOriginalURL(ctx, userID, id, "http://example.com/v2")
+	require.NoError(t, err)
+
+	revisions, err = repo.RetrieveRevisions(ctx, userID, id)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, "http://example.com/v1", revisions[0].OriginalURL)
+	assert.Equal(t, 2, revisions[1].Revision)
+	assert.Equal(t, "http://example.com/v2", revisions[1].OriginalURL)
+
+	// старый адрес освободился, а занятый другой ссылкой - нет
+	newID, exists, err := repo.SaveURL(ctx, userID, "http://example.com/v1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.NotEqual(t, id, newID)
+	_, err = repo.UpdateOriginalURL(ctx, userID, id, "http://example.org")
+	assert.ErrorIs(t, err, models.ErrorOriginalURLExists)
+
+	_, err = repo.UpdateOriginalURL(ctx, uuid.New(), id, "http://example.com/v3")
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+	_, err = repo.RetrieveRevisions(ctx, uuid.New(), id)
+	assert.ErrorIs(t, err, models.ErrorNotFound)
+
+	// безвозвратное удаление удаляет и историю
+	require.NoError(t, repo.PurgeURL(ctx, id, models.AuditEntry{}))
+	assert.Empty(t, repo.Revisions)
+}
@@ -0,0 +1,112 @@
+package simple
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+)
+
+// CreateWebhook регистрирует вебхук пользователя.
+func (repo *SimpleRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.Webhooks = append(repo.Webhooks, webhook)
+	return nil
+}
+
+// ListWebhooks возвращает вебхуки пользователя в порядке регистрации.
+func (repo *SimpleRepository) ListWebhooks(ctx context.Context, userID uuid.UUID) (webhooks []models.Webhook, err error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	webhooks = make([]models.Webhook, 0)
+	for _, w := range repo.Webhooks {
+		if w.UserID == userID {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+// HasWebhooks сообщает, зарегистрированы ли у пользователя вебхуки.
+func (repo *SimpleRepository) HasWebhooks(ctx context.Context, userID uuid.UUID) (bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return slices.ContainsFunc(repo.Webhooks, func(w models.Webhook) bool { return w.UserID == userID }), nil
+}
+
+// DeleteWebhook удаляет вебхук пользователя вместе с его недоставленными событиями.
+// Возвращает ErrorNotFound, если у пользователя нет такого вебхука.
+func (repo *SimpleRepository) DeleteWebhook(ctx context.Context, userID uuid.UUID, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var deleted int
+	repo.Webhooks, repo.Outbox, deleted = models.DeleteWebhooks(repo.Webhooks, repo.Outbox, func(w models.Webhook) bool {
+		return w.ID == id && w.UserID == userID
+	})
+	if deleted == 0 {
+		return models.ErrorNotFound
+	}
+	return nil
+}
+
+// RecordClick добавляет в outbox событие перехода по ссылке с записью record.
+func (repo *SimpleRepository) RecordClick(ctx context.Context, record models.Record) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.emit(models.EventLinkClicked, record, time.Now().UTC())
+	return nil
+}
+
+// ClaimDeliveries выдает не больше limit доставок, которые пора выполнить,
+// и откладывает их следующую попытку на lease.
+func (repo *SimpleRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []models.Delivery, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return models.ClaimDeliveries(repo.Outbox, repo.Webhooks, time.Now().UTC(), limit, lease), nil
+}
+
+// RetryDelivery сохраняет результат неудачной попытки доставки.
+// Возвращает ErrorNotFound, если доставки нет, например, вебхук уже удалён.
+func (repo *SimpleRepository) RetryDelivery(ctx context.Context, delivery models.Delivery) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return models.UpdateDelivery(repo.Outbox, delivery)
+}
+
+// CompleteDelivery удаляет успешно выполненную доставку из outbox.
+func (repo *SimpleRepository) CompleteDelivery(ctx context.Context, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.Outbox = slices.DeleteFunc(repo.Outbox, func(d models.Delivery) bool { return d.ID == id })
+	return nil
+}
+
+// PurgeAbandonedDeliveries удаляет доставки, попытки которых исчерпаны раньше cutoff.
+// Возвращает количество удалённых доставок.
+func (repo *SimpleRepository) PurgeAbandonedDeliveries(ctx context.Context, cutoff time.Time) (purged int, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.Outbox, purged = models.PurgeAbandoned(repo.Outbox, cutoff)
+	return purged, nil
+}
+
+// emit добавляет в outbox доставки события eventType ссылки record всем вебхукам её владельца.
+// Вызывается под блокировкой на запись.
+func (repo *SimpleRepository) emit(eventType models.EventType, record models.Record, now time.Time) {
+	for _, d := range models.NewDeliveries(repo.Webhooks, record.UserID, models.NewEvent(eventType, record, now)) {
+		d.ID = uuid.NewString()
+		repo.Outbox = append(repo.Outbox, d)
+	}
+}
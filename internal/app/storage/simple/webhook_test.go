@@ -0,0 +1,102 @@
+package simple
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleRepository_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := NewSimpleRepository()
+
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateWebhook(ctx, webhook))
+
+	webhooks, err := repo.ListWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Webhook{webhook}, webhooks)
+
+	exists, err := repo.HasWebhooks(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = repo.HasWebhooks(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	webhooks, err = repo.ListWebhooks(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.NotNil(t, webhooks)
+	assert.Empty(t, webhooks)
+
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, uuid.New(), webhook.ID), models.ErrorNotFound)
+	require.NoError(t, repo.DeleteWebhook(ctx, userID, webhook.ID))
+	assert.Empty(t, repo.Webhooks)
+}
+
+func TestSimpleRepository_Outbox(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := NewSimpleRepository()
+
+	webhook, err := models.NewWebhook(userID, "https://example.com/hook", time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateWebhook(ctx, webhook))
+
+	// ссылки других пользователей событий вебхуку не добавляют
+	_, _, err = repo.SaveURL(ctx, uuid.New(), "http://example.com/other")
+	require.NoError(t, err)
+
+	id, _, err := repo.SaveURL(ctx, userID, "http://example.com")
+	require.NoError(t, err)
+	record, err := repo.RetrieveByShortURL(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, repo.RecordClick(ctx, record))
+	// повторное удаление события не добавляет
+	for range 2 {
+		_, err = repo.DeleteByShortURLs(ctx, userID, []string{id})
+		require.NoError(t, err)
+	}
+
+	deliveries, err := repo.ClaimDeliveries(ctx, 2, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, models.EventLinkCreated, deliveries[0].Event.Type)
+	assert.Equal(t, models.EventLinkClicked, deliveries[1].Event.Type)
+
+	deliveries, err = repo.ClaimDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.EventLinkDeleted, deliveries[0].Event.Type)
+	assert.Equal(t, webhook.URL, deliveries[0].URL)
+	assert.Equal(t, webhook.Secret, deliveries[0].Secret)
+
+	require.NoError(t, repo.CompleteDelivery(ctx, deliveries[0].ID))
+	assert.Len(t, repo.Outbox, 2)
+
+	failed := repo.Outbox[0]
+	failed.Attempts, failed.NextAttemptAt, failed.Abandoned = 8, time.Now().UTC(), true
+	require.NoError(t, repo.RetryDelivery(ctx, failed))
+	assert.True(t, repo.Outbox[0].Abandoned)
+
+	// брошенные доставки удаляются, если последняя попытка была раньше границы
+	purged, err := repo.PurgeAbandonedDeliveries(ctx, failed.NextAttemptAt)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = repo.PurgeAbandonedDeliveries(ctx, failed.NextAttemptAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Len(t, repo.Outbox, 1)
+
+	// удаление вебхука удаляет его недоставленные события
+	require.NoError(t, repo.DeleteWebhook(ctx, userID, webhook.ID))
+	assert.Empty(t, repo.Outbox)
+	assert.ErrorIs(t, repo.RetryDelivery(ctx, failed), models.ErrorNotFound)
+}
@@ -5,6 +5,7 @@ package testhelpers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
@@ -59,3 +60,27 @@ func CreatePostgresContainer(ctx context.Context) (*PostgresContainer, error) {
 		ConnectionString:  connStr,
 	}, nil
 }
+
+// Endpoint возвращает адрес host:port, по которому доступен PostgreSQL в контейнере.
+func (c *PostgresContainer) Endpoint(ctx context.Context) (string, error) {
+	return c.PortEndpoint(ctx, "5432/tcp", "")
+}
+
+// Restart останавливает и снова запускает контейнер, сохраняя данные базы данных.
+// После перезапуска Docker может назначить другой порт, поэтому функция обновляет ConnectionString
+// и возвращает новый адрес host:port.
+func (c *PostgresContainer) Restart(ctx context.Context) (string, error) {
+	if err := c.Stop(ctx, nil); err != nil {
+		return "", fmt.Errorf("stop container: %w", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		return "", fmt.Errorf("start container: %w", err)
+	}
+
+	connStr, err := c.PostgresContainer.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		return "", err
+	}
+	c.ConnectionString = connStr
+	return c.Endpoint(ctx)
+}
@@ -0,0 +1,136 @@
+package testhelpers
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// Proxy пересылает TCP-соединения на адрес базы данных и позволяет имитировать её недоступность:
+// разрывать открытые соединения и отклонять новые, не трогая саму базу данных.
+type Proxy struct {
+	listener net.Listener
+	mu       sync.Mutex
+	target   string                // адрес, на который пересылаются соединения
+	down     bool                  // новые соединения сразу закрываются
+	conns    map[net.Conn]struct{} // открытые соединения обеих сторон
+}
+
+// NewProxy запускает прокси на свободном локальном порту, пересылающий соединения на target.
+func NewProxy(target string) (*Proxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Proxy{
+		listener: listener,
+		target:   target,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	go p.serve()
+	return p, nil
+}
+
+// Addr возвращает адрес host:port прокси.
+func (p *Proxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// Cut разрывает все открытые соединения и отклоняет новые до вызова Restore.
+func (p *Proxy) Cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.down = true
+	p.closeConns()
+}
+
+// Restore снова принимает соединения. Непустой target меняет адрес, на который они пересылаются,
+// например после перезапуска контейнера.
+func (p *Proxy) Restore(target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.down = false
+	if target != "" {
+		p.target = target
+	}
+}
+
+// Close останавливает прокси и разрывает все соединения.
+func (p *Proxy) Close() error {
+	err := p.listener.Close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConns()
+	return err
+}
+
+// serve принимает соединения, пока прокси не остановлен.
+func (p *Proxy) serve() {
+	for {
+		client, err := p.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		go p.forward(client)
+	}
+}
+
+// forward пересылает данные между клиентом и базой данных, пока одна из сторон не закроет соединение.
+func (p *Proxy) forward(client net.Conn) {
+	p.mu.Lock()
+	down, target := p.down, p.target
+	p.mu.Unlock()
+	if down {
+		_ = client.Close()
+		return
+	}
+
+	server, err := net.Dial("tcp", target)
+	if err != nil {
+		_ = client.Close()
+		return
+	}
+
+	p.mu.Lock()
+	if p.down {
+		p.mu.Unlock()
+		_ = client.Close()
+		_ = server.Close()
+		return
+	}
+	p.conns[client] = struct{}{}
+	p.conns[server] = struct{}{}
+	p.mu.Unlock()
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go pipe(server, client)
+	go pipe(client, server)
+	<-done
+
+	p.mu.Lock()
+	delete(p.conns, client)
+	delete(p.conns, server)
+	p.mu.Unlock()
+	_ = client.Close()
+	_ = server.Close()
+}
+
+// closeConns закрывает все открытые соединения. Вызывается под блокировкой.
+func (p *Proxy) closeConns() {
+	for conn := range p.conns {
+		_ = conn.Close()
+		delete(p.conns, conn)
+	}
+}
@@ -0,0 +1,69 @@
+package testhelpers
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startEcho запускает TCP-сервер, возвращающий клиенту полученные строки.
+func startEcho(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// roundTrip отправляет строку через соединение и читает ответ.
+func roundTrip(conn net.Conn, line string) (string, error) {
+	if _, err := conn.Write([]byte(line + "\n")); err != nil {
+		return "", err
+	}
+	return bufio.NewReader(conn).ReadString('\n')
+}
+
+func TestProxy(t *testing.T) {
+	proxy, err := NewProxy(startEcho(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = proxy.Close() })
+
+	conn, err := net.Dial("tcp", proxy.Addr())
+	require.NoError(t, err)
+	reply, err := roundTrip(conn, "ping")
+	require.NoError(t, err)
+	assert.Equal(t, "ping\n", reply)
+
+	// разрыв закрывает открытые соединения и отклоняет новые
+	proxy.Cut()
+	_, err = roundTrip(conn, "ping")
+	assert.Error(t, err)
+	refused, err := net.Dial("tcp", proxy.Addr())
+	require.NoError(t, err)
+	_, err = roundTrip(refused, "ping")
+	assert.Error(t, err)
+
+	// после восстановления соединения пересылаются на новый адрес
+	proxy.Restore(startEcho(t))
+	conn, err = net.Dial("tcp", proxy.Addr())
+	require.NoError(t, err)
+	reply, err = roundTrip(conn, "pong")
+	require.NoError(t, err)
+	assert.Equal(t, "pong\n", reply)
+}
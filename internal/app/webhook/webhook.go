@@ -0,0 +1,307 @@
+// Package webhook доставляет события жизненного цикла ссылок на вебхуки пользователей.
+// Хранилище записывает события в outbox вместе с изменениями ссылок, а Dispatcher периодически
+// выбирает доставки, которые пора выполнить, и отправляет событие POST-запросом в формате JSON,
+// подписанным HMAC-SHA256 ключом вебхука. Неудачные доставки повторяются с экспоненциально
+// растущей задержкой, после maxAttempts попыток доставка прекращается. Брошенные доставки
+// хранятся abandonedRetention для разбора и затем удаляются. Подключения к внутренним адресам
+// отклоняются при каждой доставке, даже если имя хоста вебхука стало на них указывать.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/iubondar/url-shortener/internal/app/policy"
+	"github.com/iubondar/url-shortener/internal/metrics"
+)
+
+// Заголовки запроса доставки события.
+const (
+	SignatureHeader = "X-Webhook-Signature" // подпись "sha256=<hex>", см. Sign
+	TimestampHeader = "X-Webhook-Timestamp" // время отправки в секундах Unix, входит в подпись
+	EventHeader     = "X-Webhook-Event"     // тип события
+	IDHeader        = "X-Webhook-ID"        // идентификатор события для отбрасывания повторов
+)
+
+// errBlockedAddress возвращается при попытке доставки на внутренний адрес.
+var errBlockedAddress = errors.New("webhook address is not allowed")
+
+// DefaultInterval задает период выборки доставок из outbox.
+const DefaultInterval = 2 * time.Second
+
+const (
+	batchSize      = 100              // сколько доставок выбирается за один раз
+	maxAttempts    = 8                // после стольких неудачных попыток доставка прекращается
+	initialBackoff = time.Second      // задержка перед первой повторной попыткой
+	maxBackoff     = time.Hour        // наибольшая задержка между попытками
+	lease          = time.Minute      // на сколько откладывается выданная доставка
+	requestTimeout = 10 * time.Second // ограничивает время ожидания ответа получателя
+	maxErrorLength = 256              // ограничивает длину сохраняемой ошибки попытки
+
+	abandonedRetention = 7 * 24 * time.Hour // сколько хранятся доставки, попытки которых исчерпаны
+	purgeInterval      = time.Hour          // как часто удаляются устаревшие брошенные доставки
+)
+
+// Store хранит доставки событий вебхукам.
+type Store interface {
+	// ClaimDeliveries выдает не больше limit доставок, которые пора выполнить,
+	// и откладывает их следующую попытку на lease.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Delivery, error)
+	// RetryDelivery сохраняет результат неудачной попытки доставки.
+	RetryDelivery(ctx context.Context, delivery models.Delivery) error
+	// CompleteDelivery удаляет выполненную доставку.
+	CompleteDelivery(ctx context.Context, id string) error
+	// PurgeAbandonedDeliveries удаляет доставки, попытки которых исчерпаны раньше cutoff.
+	PurgeAbandonedDeliveries(ctx context.Context, cutoff time.Time) (purged int, err error)
+}
+
+// Sign возвращает подпись тела body, отправленного в момент timestamp, ключом secret:
+// "sha256=" и HMAC-SHA256 строки "<timestamp>.<body>" в шестнадцатеричном виде.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись signature тела body, отправленного в момент timestamp, ключом secret.
+// Получатели используют её, чтобы убедиться, что событие отправлено сервисом.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Dispatcher периодически доставляет события из outbox на вебхуки.
+// Dispatcher нужно остановить методом Close до закрытия хранилища.
+type Dispatcher struct {
+	store  Store
+	client *http.Client       // HTTP-клиент с таймаутом, не следующий редиректам
+	cancel context.CancelFunc // прерывает выполняющиеся доставки при остановке
+	done   chan struct{}      // закрывается после остановки
+	once   sync.Once
+}
+
+// Start запускает доставку событий из хранилища store: сразу и далее с интервалом interval.
+func Start(store Store, interval time.Duration) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		store:  store,
+		client: newClient(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go d.run(ctx, interval)
+	return d
+}
+
+// newClient создает HTTP-клиент доставок с таймаутом, который не следует редиректам
+// и не подключается к адресам, запрещённым policy.IsBlockedIP.
+// Адрес проверяется при каждом подключении уже после разрешения имени, поэтому имя хоста,
+// которое после регистрации вебхука стало указывать на внутренний адрес, не даёт к нему доступа.
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || policy.IsBlockedIP(ip) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // прокси подключался бы к адресу получателя в обход проверки
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   requestTimeout,
+		// редирект на другой адрес обходил бы проверку адреса при регистрации вебхука
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// RunOnce выполняет одну выборку доставок и отправляет их одновременно.
+// Результаты попыток сохраняются в хранилище после завершения всех отправок.
+// Возвращает количество выбранных доставок.
+func (d *Dispatcher) RunOnce(ctx context.Context) (claimed int, err error) {
+	deliveries, err := d.store.ClaimDeliveries(ctx, batchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	results := make([]error, len(deliveries))
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = d.send(ctx, deliveries[i])
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// прерванные доставки будут выданы повторно по истечении отсрочки
+			break
+		}
+		if err := d.record(ctx, delivery, results[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(deliveries), errors.Join(errs...)
+}
+
+// PurgeAbandoned удаляет доставки, попытки которых исчерпаны больше abandonedRetention назад.
+// Возвращает количество удалённых доставок.
+func (d *Dispatcher) PurgeAbandoned(ctx context.Context) (purged int, err error) {
+	return d.store.PurgeAbandonedDeliveries(ctx, time.Now().UTC().Add(-abandonedRetention))
+}
+
+// record сохраняет результат sendErr попытки доставки delivery.
+func (d *Dispatcher) record(ctx context.Context, delivery models.Delivery, sendErr error) error {
+	if sendErr == nil {
+		metrics.WebhookDeliveries.WithLabelValues(metrics.WebhookDelivered).Inc()
+		return d.store.CompleteDelivery(ctx, delivery.ID)
+	}
+
+	delivery.Attempts++
+	delivery.LastError = sendErr.Error()
+	if len(delivery.LastError) > maxErrorLength {
+		delivery.LastError = delivery.LastError[:maxErrorLength]
+	}
+	delivery.NextAttemptAt = time.Now().UTC().Add(backoff(delivery.Attempts))
+	delivery.Abandoned = delivery.Attempts >= maxAttempts
+	if delivery.Abandoned {
+		metrics.WebhookDeliveries.WithLabelValues(metrics.WebhookAbandoned).Inc()
+		zap.L().Sugar().Warnw("Webhook delivery abandoned",
+			"delivery", delivery.ID, "webhook", delivery.WebhookID, "attempts", delivery.Attempts, "error", sendErr)
+	} else {
+		metrics.WebhookDeliveries.WithLabelValues(metrics.WebhookFailed).Inc()
+	}
+
+	err := d.store.RetryDelivery(ctx, delivery)
+	if errors.Is(err, models.ErrorNotFound) {
+		// вебхук удалён во время доставки
+		return nil
+	}
+	return err
+}
+
+// send отправляет событие доставки delivery на адрес вебхука.
+// Доставка считается выполненной, если получатель ответил статусом 2xx.
+func (d *Dispatcher) send(ctx context.Context, delivery models.Delivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event.Type))
+	req.Header.Set(IDHeader, delivery.Event.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			zap.L().Sugar().Errorf("error closing response body: %v", err)
+		}
+	}()
+	// тело ответа вычитывается, чтобы соединение можно было переиспользовать
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff возвращает задержку перед попыткой, следующей за attempts неудачными:
+// initialBackoff, удваивающуюся с каждой попыткой, но не больше maxBackoff.
+func backoff(attempts int) time.Duration {
+	delay := initialBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// run доставляет события с интервалом interval, пока Dispatcher не остановлен.
+// Если выборка заполнена целиком, следующая выполняется сразу.
+// Раз в purgeInterval удаляет устаревшие брошенные доставки.
+func (d *Dispatcher) run(ctx context.Context, interval time.Duration) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var purgedAt time.Time
+	for {
+		if time.Since(purgedAt) >= purgeInterval {
+			purged, err := d.PurgeAbandoned(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				zap.L().Sugar().Errorw("Cannot purge abandoned webhook deliveries, will retry", "error", err)
+			case purged > 0:
+				zap.L().Sugar().Infow("Purged abandoned webhook deliveries", "purged", purged)
+			}
+			purgedAt = time.Now()
+		}
+
+		claimed, err := d.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			// доставки, результат которых не сохранён, будут выданы повторно по истечении отсрочки
+			zap.L().Sugar().Errorw("Cannot dispatch webhooks, will retry", "claimed", claimed, "error", err)
+		}
+		if claimed == batchSize && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close прерывает выполняющиеся доставки и останавливает Dispatcher. Повторные вызовы безопасны.
+func (d *Dispatcher) Close() error {
+	d.once.Do(func() {
+		d.cancel()
+		<-d.done
+	})
+	return nil
+}
@@ -0,0 +1,277 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	simple_storage "github.com/iubondar/url-shortener/internal/app/storage/simple"
+)
+
+// receiver - httptest-получатель событий, проверяющий подпись и отвечающий статусами из statuses по очереди.
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	statuses []int
+	events   []models.Event
+	invalid  int // запросы с неверной подписью
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	if !Verify(r.secret, req.Header.Get(TimestampHeader), body, req.Header.Get(SignatureHeader)) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var event models.Event
+	if err := json.Unmarshal(body, &event); err != nil || req.Header.Get(EventHeader) != string(event.Type) ||
+		req.Header.Get(IDHeader) != event.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if status/100 == 2 {
+		r.events = append(r.events, event)
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []models.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.Event(nil), r.events...)
+}
+
+// setup создает хранилище с вебхуком пользователя, ведущим на получатель rcv, и ссылкой этого пользователя.
+func setup(t *testing.T, rcv *receiver) (*simple_storage.SimpleRepository, *Dispatcher, string) {
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+
+	userID := uuid.New()
+	repo := simple_storage.NewSimpleRepository()
+	webhook, err := models.NewWebhook(userID, srv.URL, time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateWebhook(context.Background(), webhook))
+	rcv.secret = webhook.Secret
+
+	id, _, err := repo.SaveURL(context.Background(), userID, "http://example.com")
+	require.NoError(t, err)
+	return repo, &Dispatcher{store: repo, client: srv.Client()}, id
+}
+
+func TestDispatcher_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	rcv := &receiver{}
+	repo, d, id := setup(t, rcv)
+
+	claimed, err := d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+
+	events := rcv.received()
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventLinkCreated, events[0].Type)
+	assert.Equal(t, id, events[0].ShortURL)
+	assert.Equal(t, "http://example.com", events[0].OriginalURL)
+	assert.Empty(t, repo.Outbox)
+
+	claimed, err = d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	ctx := context.Background()
+	rcv := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusFound}}
+	repo, d, _ := setup(t, rcv)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		claimed, err := d.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, claimed)
+
+		require.Len(t, repo.Outbox, 1)
+		delivery := repo.Outbox[0]
+		assert.Equal(t, attempt, delivery.Attempts)
+		assert.False(t, delivery.Abandoned)
+		assert.Contains(t, delivery.LastError, "unexpected status")
+		assert.WithinDuration(t, time.Now().Add(backoff(attempt)), delivery.NextAttemptAt, time.Second)
+
+		// следующая попытка выполняется после задержки
+		claimed, err = d.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, claimed)
+		repo.Outbox[0].NextAttemptAt = time.Now().UTC()
+	}
+
+	claimed, err := d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Len(t, rcv.received(), 1)
+	assert.Empty(t, repo.Outbox)
+}
+
+func TestDispatcher_BlockedAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		host string // имя, под которым получатель зарегистрирован вместо 127.0.0.1
+	}{
+		{name: "Hostname resolving to loopback", host: "localhost"},
+		{name: "Loopback address", host: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			rcv := &receiver{}
+			repo, d, _ := setup(t, rcv)
+			repo.Webhooks[0].URL = strings.Replace(repo.Webhooks[0].URL, "127.0.0.1", tt.host, 1)
+			d.client = newClient()
+
+			claimed, err := d.RunOnce(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, claimed)
+
+			assert.Empty(t, rcv.received())
+			require.Len(t, repo.Outbox, 1)
+			assert.Equal(t, 1, repo.Outbox[0].Attempts)
+			assert.Contains(t, repo.Outbox[0].LastError, errBlockedAddress.Error())
+		})
+	}
+}
+
+func TestDispatcher_Abandon(t *testing.T) {
+	ctx := context.Background()
+	rcv := &receiver{}
+	repo, d, _ := setup(t, rcv)
+	// ключ подписи изменился у получателя: все доставки отклоняются
+	rcv.secret = "other"
+
+	for range maxAttempts {
+		claimed, err := d.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, claimed)
+		repo.Outbox[0].NextAttemptAt = time.Now().UTC()
+	}
+
+	require.Len(t, repo.Outbox, 1)
+	assert.True(t, repo.Outbox[0].Abandoned)
+	assert.Equal(t, maxAttempts, repo.Outbox[0].Attempts)
+	assert.Equal(t, maxAttempts, rcv.invalid)
+
+	claimed, err := d.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, claimed)
+
+	// брошенная доставка хранится abandonedRetention после последней попытки
+	purged, err := d.PurgeAbandoned(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	repo.Outbox[0].NextAttemptAt = time.Now().UTC().Add(-abandonedRetention - time.Minute)
+	purged, err = d.PurgeAbandoned(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Empty(t, repo.Outbox)
+}
+
+// countingStore выдает одну доставку на каждую выборку и считает выборки и удаления брошенных доставок.
+type countingStore struct {
+	url    string
+	claims atomic.Int32
+	purges atomic.Int32
+}
+
+func (s *countingStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Delivery, error) {
+	n := s.claims.Add(1)
+	return []models.Delivery{{ID: fmt.Sprint(n), URL: s.url, Event: models.Event{ID: uuid.NewString()}}}, nil
+}
+
+func (s *countingStore) RetryDelivery(ctx context.Context, delivery models.Delivery) error {
+	return nil
+}
+
+func (s *countingStore) CompleteDelivery(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s *countingStore) PurgeAbandonedDeliveries(ctx context.Context, cutoff time.Time) (int, error) {
+	s.purges.Add(1)
+	return 0, nil
+}
+
+func TestStart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	store := &countingStore{url: srv.URL}
+
+	d := Start(store, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return store.claims.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, d.Close())
+	require.NoError(t, d.Close())
+
+	claims := store.claims.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, claims, store.claims.Load())
+	// брошенные доставки удаляются при запуске и далее раз в purgeInterval
+	assert.Equal(t, int32(1), store.purges.Load())
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"link.created"}`)
+	signature := Sign("secret", "1700000000", body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("secret", "1700000000", body, signature))
+	assert.False(t, Verify("other", "1700000000", body, signature))
+	assert.False(t, Verify("secret", "1700000001", body, signature))
+	assert.False(t, Verify("secret", "1700000000", []byte(`{}`), signature))
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 12, want: 2048 * time.Second},
+		{attempts: 13, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempts), func(t *testing.T) {
+			assert.Equal(t, tt.want, backoff(tt.attempts))
+		})
+	}
+}
+
+func ExampleVerify() {
+	// получатель проверяет подпись запроса по заголовкам X-Webhook-Timestamp и X-Webhook-Signature
+	body := []byte(`{"id":"1","type":"link.clicked"}`)
+	timestamp := "1700000000"
+	signature := Sign("secret", timestamp, body)
+
+	fmt.Println(Verify("secret", timestamp, body, signature))
+	// Output: true
+}
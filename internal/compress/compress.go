@@ -0,0 +1,402 @@
+// Пакет compress предоставляет middleware для сжатия HTTP-трафика.
+// Поддерживает кодирования br, zstd, gzip и deflate: выбирает кодирование ответа
+// по заголовку Accept-Encoding с учётом весов q и распаковывает тела запросов
+// в любом из поддерживаемых кодирований.
+package compress
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+)
+
+// compressingContentTypes содержит список типов контента, которые должны сжиматься.
+// По умолчанию сжимаются JSON и HTML.
+var compressingContentTypes []string = []string{"application/json", "text/html"}
+
+// Ограничения распаковки тел запросов по умолчанию, если они не заданы в конфигурации.
+const (
+	DefaultMaxDecompressedSize int64 = 10 << 20 // размер распакованного тела запроса в байтах
+	DefaultMaxCompressionRatio int64 = 100      // отношение размера распакованного тела запроса к сжатому
+)
+
+// Options задаёт ограничения распаковки тел запросов.
+// Нулевые и отрицательные значения заменяются значениями по умолчанию.
+type Options struct {
+	MaxDecompressedSize int64 // максимальный размер распакованного тела запроса в байтах
+	MaxCompressionRatio int64 // максимально допустимое отношение размера распакованного тела к сжатому
+}
+
+// withDefaults возвращает копию параметров, в которой незаданные ограничения заменены значениями по умолчанию.
+func (o Options) withDefaults() Options {
+	if o.MaxDecompressedSize <= 0 {
+		o.MaxDecompressedSize = DefaultMaxDecompressedSize
+	}
+	if o.MaxCompressionRatio <= 0 {
+		o.MaxCompressionRatio = DefaultMaxCompressionRatio
+	}
+	return o
+}
+
+// ratioCheckSize - размер распакованных данных, после которого проверяется степень сжатия.
+// Небольшие тела с высокой степенью сжатия, например из повторяющихся символов, безопасны.
+const ratioCheckSize = 64 << 10
+
+// minSize - минимальный размер тела ответа в байтах, начиная с которого ответ сжимается.
+// Короткие ответы, например тела редиректов, сжатие только увеличивает.
+var minSize = 256
+
+const (
+	acceptEncoding  = "Accept-Encoding"
+	contentEncoding = "Content-Encoding"
+	contentLength   = "Content-Length"
+	contentType     = "Content-Type"
+	vary            = "Vary"
+)
+
+// shouldCompress проверяет, нужно ли сжимать контент указанного типа.
+// Возвращает true, если тип контента входит в список compressingContentTypes.
+func shouldCompress(ct string) bool {
+	for _, contentType := range compressingContentTypes {
+		if strings.Contains(ct, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyAllowed проверяет, может ли ответ с указанным кодом статуса содержать тело.
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// compressWriter реализует интерфейс http.ResponseWriter и позволяет прозрачно для сервера
+// сжимать передаваемые данные и выставлять правильные HTTP-заголовки.
+//
+// Заголовки и начало тела накапливаются, пока размер тела не достигнет minSize,
+// обработчик не вызовет Flush или не завершит работу. Только после этого принимается решение
+// о сжатии и отправляются заголовки, поэтому Content-Encoding всегда согласован с телом.
+// Реализует http.Flusher и http.Hijacker, если их реализует оригинальный http.ResponseWriter
+// или любая из его обёрток, доступных через Unwrap.
+type compressWriter struct {
+	w        http.ResponseWriter // оригинальный http.ResponseWriter
+	encoding string              // кодирование, выбранное по Accept-Encoding
+	status   int                 // код статуса, ожидающий отправки
+	buf      []byte              // начало тела ответа до принятия решения о сжатии
+	decided  bool                // решение о сжатии принято, заголовки отправлены
+	hijacked bool                // соединение передано обработчику
+	zw       compressor          // сжимающий писатель из пула или nil, если ответ не сжимается
+}
+
+// newCompressWriter создает новый экземпляр compressWriter для указанного кодирования.
+func newCompressWriter(w http.ResponseWriter, encoding string) *compressWriter {
+	return &compressWriter{
+		w:        w,
+		encoding: encoding,
+	}
+}
+
+// Header возвращает HTTP-заголовки ответа.
+func (c *compressWriter) Header() http.Header {
+	return c.w.Header()
+}
+
+// WriteHeader запоминает код статуса ответа.
+// Информационные ответы 1xx отправляются сразу, остальные - после принятия решения о сжатии.
+// Повторные вызовы игнорируются.
+func (c *compressWriter) WriteHeader(statusCode int) {
+	if statusCode >= 100 && statusCode < http.StatusOK && statusCode != http.StatusSwitchingProtocols {
+		c.w.WriteHeader(statusCode)
+		return
+	}
+	if c.decided || c.status != 0 {
+		return
+	}
+	c.status = statusCode
+}
+
+// Write записывает данные в ответ, сжимая их если необходимо.
+// Пока решение о сжатии не принято, данные накапливаются в буфере.
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.decided {
+		c.buf = append(c.buf, p...)
+		if len(c.buf) < minSize {
+			return len(p), nil
+		}
+		if err := c.decide(false); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if c.zw != nil {
+		return c.zw.Write(p)
+	}
+	return c.w.Write(p)
+}
+
+// decide решает, сжимать ли ответ, отправляет заголовки и накопленное начало тела.
+// Если обработчик не задал Content-Type, тип определяется по накопленному началу тела.
+// Ответ сжимается, если он может содержать тело, ещё не закодирован обработчиком,
+// имеет сжимаемый тип контента и достаточно велик. Потоковые ответы (streaming = true)
+// сжимаются независимо от размера, так как их итоговая длина неизвестна.
+func (c *compressWriter) decide(streaming bool) error {
+	c.decided = true
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	header := c.w.Header()
+	// определяем тип по несжатому началу тела, иначе net/http определит его по сжатым данным
+	if _, ok := header[contentType]; !ok && len(c.buf) > 0 {
+		header.Set(contentType, http.DetectContentType(c.buf))
+	}
+	if (streaming || len(c.buf) >= minSize) && bodyAllowed(c.status) &&
+		len(header.Get(contentEncoding)) == 0 && shouldCompress(header.Get(contentType)) {
+		header.Set(contentEncoding, c.encoding)
+		// длина сжатого тела заранее неизвестна
+		header.Del(contentLength)
+		c.zw = encoders[c.encoding].getWriter(c.w)
+	}
+	c.w.WriteHeader(c.status)
+
+	buf := c.buf
+	c.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if c.zw != nil {
+		_, err := c.zw.Write(buf)
+		return err
+	}
+	_, err := c.w.Write(buf)
+	return err
+}
+
+// Flush отправляет клиенту все записанные на данный момент данные.
+// Используется потоковыми обработчиками; при первом вызове принимает решение о сжатии.
+func (c *compressWriter) Flush() {
+	if c.hijacked {
+		return
+	}
+	if !c.decided {
+		if err := c.decide(true); err != nil {
+			log.Printf("Error flushing compressed response: %v", err)
+			return
+		}
+	}
+	if c.zw != nil {
+		if err := c.zw.Flush(); err != nil {
+			log.Printf("Error flushing compressed response: %v", err)
+			return
+		}
+	}
+	if err := http.NewResponseController(c.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Error flushing compressed response: %v", err)
+	}
+}
+
+// Hijack передаёт обработчику управление соединением.
+// Возвращает ошибку, если оригинальный http.ResponseWriter не поддерживает перехват
+// или заголовки ответа уже отправлены.
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if c.decided {
+		return nil, nil, errors.New("compress: response headers already sent")
+	}
+
+	conn, rw, err := http.NewResponseController(c.w).Hijack()
+	if err == nil {
+		c.hijacked = true
+		c.decided = true
+		c.buf = nil
+	}
+	return conn, rw, err
+}
+
+// Unwrap возвращает оригинальный http.ResponseWriter для http.ResponseController.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.w
+}
+
+// Close отправляет накопленные данные, завершает сжатый поток и возвращает писатель в пул.
+func (c *compressWriter) Close() error {
+	if c.hijacked {
+		return nil
+	}
+	if !c.decided {
+		if err := c.decide(false); err != nil {
+			return err
+		}
+	}
+	if c.zw == nil {
+		return nil
+	}
+
+	zw := c.zw
+	c.zw = nil
+	err := zw.Close()
+	encoders[c.encoding].putWriter(zw)
+	return err
+}
+
+// countingReader считает прочитанные из источника байты.
+type countingReader struct {
+	r io.Reader
+	n int64 // прочитано байт
+}
+
+// Read читает данные из источника и увеличивает счётчик.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodingReader реализует интерфейс io.ReadCloser и позволяет прозрачно для сервера
+// распаковывать тело запроса, сжатое одним или несколькими кодированиями.
+// Защищает от «бомб» сжатия: прерывает чтение с ошибкой *http.MaxBytesError,
+// если распакованные данные превышают MaxDecompressedSize или во много раз больше сжатых.
+type decodingReader struct {
+	opts    Options         // ограничения распаковки
+	r       io.Reader       // распакованный поток
+	src     *countingReader // сжатое тело запроса
+	n       int64           // распаковано байт
+	err     error           // ошибка превышения ограничений
+	closers []io.Closer     // распаковщики и исходное тело запроса
+}
+
+// newDecodingReader создает новый экземпляр decodingReader.
+// Кодирования передаются в порядке применения и снимаются в обратном порядке.
+func newDecodingReader(body io.ReadCloser, encodings []string, opts Options) (*decodingReader, error) {
+	src := &countingReader{r: body}
+	dr := &decodingReader{
+		opts:    opts,
+		r:       src,
+		src:     src,
+		closers: []io.Closer{body},
+	}
+
+	for i := len(encodings) - 1; i >= 0; i-- {
+		zr, err := encoders[encodings[i]].newReader(dr.r)
+		if err != nil {
+			return nil, errors.Join(err, dr.Close())
+		}
+		dr.r = zr
+		dr.closers = append(dr.closers, zr)
+	}
+
+	return dr, nil
+}
+
+// Read читает и распаковывает данные из запроса, проверяя ограничения размера и степени сжатия.
+func (c *decodingReader) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	if c.n > c.opts.MaxDecompressedSize {
+		c.err = &http.MaxBytesError{Limit: c.opts.MaxDecompressedSize}
+		return 0, c.err
+	}
+	if limit := c.src.n * c.opts.MaxCompressionRatio; c.n > ratioCheckSize && c.n > limit {
+		c.err = &http.MaxBytesError{Limit: limit}
+		return 0, c.err
+	}
+
+	return n, err
+}
+
+// Close закрывает распаковщики и исходное тело запроса.
+func (c *decodingReader) Close() error {
+	var errs []error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		errs = append(errs, c.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// WithCompression - middleware сжатия ответов и распаковки запросов с ограничениями по умолчанию.
+func WithCompression(h http.Handler) http.Handler {
+	return New(Options{})(h)
+}
+
+// New создает middleware для сжатия ответов и распаковки запросов с ограничениями opts.
+// Кодирование ответа выбирается по заголовку Accept-Encoding; ответы короче minSize
+// и несжимаемых типов отправляются как есть. Все ответы получают заголовок Vary: Accept-Encoding.
+//
+// Тело запроса распаковывается по заголовку Content-Encoding. На неподдерживаемое кодирование
+// middleware отвечает 415 Unsupported Media Type, на повреждённые данные - 400 Bad Request.
+// Чтение распакованного тела сверх opts.MaxDecompressedSize или opts.MaxCompressionRatio
+// завершается ошибкой *http.MaxBytesError, как и при превышении http.MaxBytesReader.
+func New(opts Options) func(http.Handler) http.Handler {
+	opts = opts.withDefaults()
+	return func(h http.Handler) http.Handler {
+		return withCompression(h, opts)
+	}
+}
+
+// withCompression оборачивает обработчик h в middleware сжатия с ограничениями opts.
+func withCompression(h http.Handler, opts Options) http.Handler {
+	compressFn := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(vary, acceptEncoding)
+
+		// распаковываем тело запроса, если клиент отправил сжатые данные
+		if len(r.Header.Get(contentEncoding)) > 0 {
+			encodings, ok := parseContentEncoding(r.Header.Get(contentEncoding))
+			if !ok {
+				w.Header().Set(acceptEncoding, strings.Join(preference, ", "))
+				apierror.Write(w, r, apierror.New(apierror.CodeUnsupportedMediaType, "Unsupported content encoding"))
+				return
+			}
+
+			if len(encodings) > 0 {
+				cr, err := newDecodingReader(r.Body, encodings, opts)
+				if err != nil {
+					apierror.Write(w, r, apierror.Wrap(apierror.CodeInvalidRequest, "Error reading compressed request", err))
+					return
+				}
+				// меняем тело запроса на распакованное
+				r.Body = cr
+				r.ContentLength = -1
+				r.Header.Del(contentEncoding)
+				r.Header.Del(contentLength)
+				defer func() {
+					if err := cr.Close(); err != nil {
+						// Log the error but don't expose it to the client
+						// since the response has already been sent
+						log.Printf("Error closing request decoder: %v", err)
+					}
+				}()
+			}
+		}
+
+		// по умолчанию передаём следующей функции оригинальный http.ResponseWriter;
+		// ответы на HEAD не содержат тела, и сжимать в них нечего
+		ow := w
+		if encoding := negotiate(r.Header.Get(acceptEncoding)); len(encoding) > 0 && r.Method != http.MethodHead {
+			cw := newCompressWriter(w, encoding)
+			ow = cw
+			// не забываем отправить клиенту все сжатые данные после завершения middleware
+			defer func() {
+				if err := cw.Close(); err != nil {
+					// Log the error but don't expose it to the client
+					// since the response has already been sent
+					log.Printf("Error closing compress writer: %v", err)
+				}
+			}()
+		}
+
+		// передаём управление хендлеру
+		h.ServeHTTP(ow, r)
+	}
+	return http.HandlerFunc(compressFn)
+}
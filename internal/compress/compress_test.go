@@ -0,0 +1,350 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipCompression(t *testing.T) {
+	requestBody := `
+		<html><body><h1>Hello world!</h1></body></html>
+	`
+
+	// тело ответа длиннее minSize, чтобы оно сжималось
+	successBody := `{
+		"result": "https://127.0.0.1/abcdef11",
+		"comment": "` + strings.Repeat("compressible ", 30) + `"
+	}`
+
+	withoutGzip := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/json")
+		_, err := io.WriteString(w, successBody)
+		if err != nil {
+			panic(err)
+		}
+	})
+	handler := WithCompression(withoutGzip)
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	t.Run("sends_gzip", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		zb := gzip.NewWriter(buf)
+		_, err := zb.Write([]byte(requestBody))
+		require.NoError(t, err)
+		err = zb.Close()
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("POST", srv.URL, buf)
+		r.RequestURI = ""
+		r.Header.Set(contentEncoding, "gzip")
+		r.Header.Set(acceptEncoding, "")
+
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Errorf("Error closing response body: %v", err)
+			}
+		}()
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, successBody, string(b))
+	})
+
+	t.Run("accepts_gzip", func(t *testing.T) {
+		buf := bytes.NewBufferString(requestBody)
+		r := httptest.NewRequest("POST", srv.URL, buf)
+		r.RequestURI = ""
+		r.Header.Set(contentType, "text/html")
+		r.Header.Set(acceptEncoding, "gzip")
+
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Errorf("Error closing response body: %v", err)
+			}
+		}()
+
+		zr, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		defer func() {
+			if err := zr.Close(); err != nil {
+				t.Errorf("Error closing gzip reader: %v", err)
+			}
+		}()
+
+		b, err := io.ReadAll(zr)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, successBody, string(b))
+	})
+}
+
+func BenchmarkGzipCompression(b *testing.B) {
+	// Тестовые данные разной длины и типов
+	testCases := []struct {
+		name        string
+		contentType string
+		content     string
+	}{
+		{
+			name:        "small_json",
+			contentType: "application/json",
+			content:     `{"message": "Hello, World!"}`,
+		},
+		{
+			name:        "medium_json",
+			contentType: "application/json",
+			content:     `{"items": [` + strings.Repeat(`{"id": 1, "name": "test"},`, 100) + `]}`,
+		},
+		{
+			name:        "small_html",
+			contentType: "text/html",
+			content:     `<html><body><h1>Hello world!</h1></body></html>`,
+		},
+		{
+			name:        "medium_html",
+			contentType: "text/html",
+			content:     `<html><body>` + strings.Repeat(`<p>Test paragraph</p>`, 100) + `</body></html>`,
+		},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, r.Header.Get(contentType))
+		_, err := io.WriteString(w, r.Header.Get("X-Test-Content"))
+		if err != nil {
+			b.Fatal(err)
+		}
+	})
+
+	compressedHandler := WithCompression(handler)
+	srv := httptest.NewServer(compressedHandler)
+	defer srv.Close()
+
+	for _, tc := range testCases {
+		b.Run(tc.name, func(b *testing.B) {
+			req, err := http.NewRequest("GET", srv.URL, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			req.Header.Set(acceptEncoding, "gzip")
+			req.Header.Set(contentType, tc.contentType)
+			req.Header.Set("X-Test-Content", tc.content)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_, err = io.ReadAll(resp.Body)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := resp.Body.Close(); err != nil {
+					b.Errorf("Error closing response body: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// encode сжимает данные указанным кодированием.
+func encode(t *testing.T, encoding string, data string) []byte {
+	t.Helper()
+	buf := bytes.NewBuffer(nil)
+	zw := encoders[encoding].getWriter(buf)
+	defer encoders[encoding].putWriter(zw)
+	_, err := zw.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// decode распаковывает данные указанного кодирования.
+func decode(t *testing.T, encoding string, r io.Reader) string {
+	t.Helper()
+	zr, err := encoders[encoding].newReader(r)
+	require.NoError(t, err)
+	defer func() {
+		if err := zr.Close(); err != nil {
+			t.Errorf("Error closing reader: %v", err)
+		}
+	}()
+	b, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestWithCompression_Negotiation(t *testing.T) {
+	largeBody := `{"items": [` + strings.Repeat(`{"id": 1, "name": "test"},`, 20) + `{"id": 2}]}`
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		wantEncoding   string
+	}{
+		{name: "br", acceptEncoding: "br", body: largeBody, wantEncoding: encodingBrotli},
+		{name: "zstd", acceptEncoding: "zstd", body: largeBody, wantEncoding: encodingZstd},
+		{name: "gzip", acceptEncoding: "gzip", body: largeBody, wantEncoding: encodingGzip},
+		{name: "deflate", acceptEncoding: "deflate", body: largeBody, wantEncoding: encodingDeflate},
+		{name: "preference on equal weights", acceptEncoding: "gzip, deflate, br, zstd", body: largeBody, wantEncoding: encodingBrotli},
+		{name: "highest q wins", acceptEncoding: "br;q=0.5, gzip;q=0.9, zstd;q=0.1", body: largeBody, wantEncoding: encodingGzip},
+		{name: "q=0 excludes", acceptEncoding: "br;q=0, gzip", body: largeBody, wantEncoding: encodingGzip},
+		{name: "wildcard", acceptEncoding: "*", body: largeBody, wantEncoding: encodingBrotli},
+		{name: "no accept-encoding", acceptEncoding: "", body: largeBody, wantEncoding: ""},
+		{name: "unknown only", acceptEncoding: "compress", body: largeBody, wantEncoding: ""},
+		{name: "below threshold", acceptEncoding: "gzip", body: `{"id": 1}`, wantEncoding: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(contentType, "application/json")
+				_, err := io.WriteString(w, tt.body)
+				require.NoError(t, err)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(acceptEncoding, tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, acceptEncoding, w.Header().Get(vary))
+			assert.Equal(t, tt.wantEncoding, w.Header().Get(contentEncoding))
+			if len(tt.wantEncoding) == 0 {
+				assert.Equal(t, tt.body, w.Body.String())
+				return
+			}
+			assert.Equal(t, tt.body, decode(t, tt.wantEncoding, w.Body))
+		})
+	}
+}
+
+func TestWithCompression_RequestDecoding(t *testing.T) {
+	requestBody := `{"url": "https://practicum.yandex.ru"}`
+
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, err = w.Write(body)
+		require.NoError(t, err)
+	}))
+
+	for _, encoding := range preference {
+		t.Run(encoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encode(t, encoding, requestBody)))
+			req.Header.Set(contentEncoding, encoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, requestBody, w.Body.String())
+		})
+	}
+
+	t.Run("stacked encodings", func(t *testing.T) {
+		gzipped := encode(t, encodingGzip, requestBody)
+		body := encode(t, encodingBrotli, string(gzipped))
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(contentEncoding, "gzip, br")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, requestBody, w.Body.String())
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(requestBody))
+		req.Header.Set(contentEncoding, "compress")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, "br, zstd, gzip, deflate", w.Header().Get(acceptEncoding))
+	})
+
+	t.Run("corrupted body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(requestBody))
+		req.Header.Set(contentEncoding, encodingGzip)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestNew_DecompressionLimits(t *testing.T) {
+	read := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, strconv.FormatInt(tooLarge.Limit, 10), http.StatusRequestEntityTooLarge)
+			return
+		}
+		require.NoError(t, err)
+	})
+
+	send := func(opts Options, body []byte) *httptest.ResponseRecorder {
+		handler := New(opts)(read)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(contentEncoding, encodingGzip)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ordinary body", func(t *testing.T) {
+		w := send(Options{}, encode(t, encodingGzip, strings.Repeat(`{"url": "https://practicum.yandex.ru"}`, 100)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("decompressed size over default limit", func(t *testing.T) {
+		body := encode(t, encodingGzip, strings.Repeat("0", int(DefaultMaxDecompressedSize)+1))
+		w := send(Options{MaxCompressionRatio: 1 << 20}, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, strconv.FormatInt(DefaultMaxDecompressedSize, 10), strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("decompressed size over configured limit", func(t *testing.T) {
+		body := encode(t, encodingGzip, strings.Repeat("0", 1<<10))
+		w := send(Options{MaxDecompressedSize: 512, MaxCompressionRatio: 1 << 20}, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, "512", strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("compression ratio over limit", func(t *testing.T) {
+		// мегабайт нулей сжимается примерно в тысячу раз
+		w := send(Options{}, encode(t, encodingGzip, strings.Repeat("0", 1<<20)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("compression ratio within configured limit", func(t *testing.T) {
+		w := send(Options{MaxCompressionRatio: 10000}, encode(t, encodingGzip, strings.Repeat("0", 1<<20)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,175 @@
+package compress
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Поддерживаемые кодирования содержимого.
+const (
+	encodingBrotli   = "br"
+	encodingZstd     = "zstd"
+	encodingGzip     = "gzip"
+	encodingDeflate  = "deflate"
+	encodingIdentity = "identity"
+)
+
+// compressor - сжимающий писатель, который можно переиспользовать для разных ответов.
+type compressor interface {
+	io.WriteCloser
+	// Flush отправляет в нижележащий писатель все сжатые на данный момент данные.
+	Flush() error
+	// Reset сбрасывает состояние писателя и направляет вывод в w.
+	Reset(w io.Writer)
+}
+
+// encoder описывает кодирование содержимого: пул сжимающих писателей и создание распаковывающего читателя.
+type encoder struct {
+	writers   sync.Pool // переиспользуемые сжимающие писатели
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// newEncoder создает кодирование с пулом писателей, создаваемых функцией newWriter.
+func newEncoder(newWriter func() compressor, newReader func(r io.Reader) (io.ReadCloser, error)) *encoder {
+	return &encoder{
+		writers:   sync.Pool{New: func() any { return newWriter() }},
+		newReader: newReader,
+	}
+}
+
+// getWriter берёт писатель из пула и направляет его вывод в w.
+func (e *encoder) getWriter(w io.Writer) compressor {
+	zw := e.writers.Get().(compressor)
+	zw.Reset(w)
+	return zw
+}
+
+// putWriter возвращает закрытый писатель в пул.
+// Писатель отвязывается от ответа, чтобы не удерживать его в памяти.
+func (e *encoder) putWriter(zw compressor) {
+	zw.Reset(io.Discard)
+	e.writers.Put(zw)
+}
+
+// must возвращает значение, если ошибки нет.
+// Используется для писателей с постоянными параметрами, ошибка для которых невозможна.
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// encoders содержит поддерживаемые кодирования. Все реализации написаны на чистом Go.
+var encoders = map[string]*encoder{
+	encodingBrotli: newEncoder(
+		func() compressor {
+			return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+		},
+		func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(brotli.NewReader(r)), nil
+		},
+	),
+	encodingZstd: newEncoder(
+		func() compressor {
+			return must(zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1)))
+		},
+		func(r io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return zr.IOReadCloser(), nil
+		},
+	),
+	encodingGzip: newEncoder(
+		func() compressor {
+			return must(gzip.NewWriterLevel(io.Discard, gzip.BestSpeed))
+		},
+		func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	),
+	// deflate в HTTP означает поток в формате zlib (RFC 9110)
+	encodingDeflate: newEncoder(
+		func() compressor {
+			return must(zlib.NewWriterLevel(io.Discard, zlib.BestSpeed))
+		},
+		func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	),
+}
+
+// preference задаёт порядок выбора кодирования при одинаковом весе в Accept-Encoding.
+var preference = []string{encodingBrotli, encodingZstd, encodingGzip, encodingDeflate}
+
+// parseAcceptEncoding разбирает заголовок Accept-Encoding.
+// Возвращает веса кодирований; отсутствующий или некорректный q считается равным 1.
+func parseAcceptEncoding(header string) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && v >= 0 && v <= 1 {
+				q = v
+			}
+		}
+		weights[name] = q
+	}
+	return weights
+}
+
+// negotiate выбирает кодирование ответа по заголовку Accept-Encoding.
+// Учитывает веса q и маску "*"; при равных весах предпочитает br, zstd, gzip, deflate.
+// Возвращает пустую строку, если ответ нужно отправить без сжатия.
+func negotiate(header string) string {
+	weights := parseAcceptEncoding(header)
+
+	best, bestQ := "", 0.0
+	for _, name := range preference {
+		q, ok := weights[name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// parseContentEncoding разбирает заголовок Content-Encoding запроса.
+// Возвращает кодирования в порядке применения без identity
+// и false, если среди них есть неподдерживаемое.
+func parseContentEncoding(header string) ([]string, bool) {
+	var names []string
+	for _, part := range strings.Split(header, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if len(name) == 0 || name == encodingIdentity {
+			continue
+		}
+		if _, ok := encoders[name]; !ok {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
@@ -0,0 +1,47 @@
+package compress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptEncoding(t *testing.T) {
+	got := parseAcceptEncoding("GZIP;q=0.8, br ; q=1.0, zstd;q=abc, deflate;q=2, identity;q=0, ")
+	assert.Equal(t, map[string]float64{
+		"gzip":     0.8,
+		"br":       1,
+		"zstd":     1, // некорректный вес считается равным 1
+		"deflate":  1, // вес вне диапазона считается равным 1
+		"identity": 0,
+	}, got)
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "identity", want: ""},
+		{header: "gzip", want: "gzip"},
+		{header: "deflate, gzip", want: "gzip"},
+		{header: "gzip;q=0.5, deflate", want: "deflate"},
+		{header: "*;q=0.1, br;q=0", want: "zstd"},
+		{header: "gzip;q=0, *;q=0", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiate(tt.header))
+		})
+	}
+}
+
+func TestParseContentEncoding(t *testing.T) {
+	names, ok := parseContentEncoding("gzip, identity, BR")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"gzip", "br"}, names)
+
+	_, ok = parseContentEncoding("gzip, compress")
+	assert.False(t, ok)
+}
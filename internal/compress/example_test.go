@@ -22,7 +22,7 @@ func Example() {
 	})
 
 	// Оборачиваем обработчик в middleware для сжатия
-	compressedHandler := WithGzipCompression(handler)
+	compressedHandler := WithCompression(handler)
 
 	// Создаем тестовый сервер
 	server := httptest.NewServer(compressedHandler)
@@ -89,7 +89,7 @@ func Example_compressedRequest() {
 	})
 
 	// Оборачиваем обработчик в middleware для сжатия
-	compressedHandler := WithGzipCompression(handler)
+	compressedHandler := WithCompression(handler)
 
 	// Создаем тестовый сервер
 	server := httptest.NewServer(compressedHandler)
@@ -0,0 +1,246 @@
+package compress
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeJSON - тело ответа длиннее minSize.
+var largeJSON = `{"urls": [` + strings.Repeat(`"https://practicum.yandex.ru/",`, 20) + `""]}`
+
+func TestCompressWriter_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantEncoding string
+	}{
+		{name: "200 large body", status: http.StatusOK, body: largeJSON, wantEncoding: encodingGzip},
+		{name: "201 large body", status: http.StatusCreated, body: largeJSON, wantEncoding: encodingGzip},
+		{name: "409 large body", status: http.StatusConflict, body: largeJSON, wantEncoding: encodingGzip},
+		{name: "500 large body", status: http.StatusInternalServerError, body: largeJSON, wantEncoding: encodingGzip},
+		{name: "307 small body", status: http.StatusTemporaryRedirect, body: `{"to": "/"}`, wantEncoding: ""},
+		{name: "204 no body", status: http.StatusNoContent, body: "", wantEncoding: ""},
+		{name: "304 no body", status: http.StatusNotModified, body: "", wantEncoding: ""},
+		{name: "200 empty body", status: http.StatusOK, body: "", wantEncoding: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(contentType, "application/json")
+				w.Header().Set(contentLength, strconv.Itoa(len(tt.body)))
+				w.WriteHeader(tt.status)
+				_, err := io.WriteString(w, tt.body)
+				require.NoError(t, err)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(acceptEncoding, "gzip")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.wantEncoding, w.Header().Get(contentEncoding))
+			if len(tt.wantEncoding) == 0 {
+				assert.Equal(t, tt.body, w.Body.String())
+				assert.Equal(t, strconv.Itoa(len(tt.body)), w.Header().Get(contentLength))
+				return
+			}
+			assert.Empty(t, w.Header().Get(contentLength), "Content-Length of the original body must be dropped")
+			assert.Equal(t, tt.body, decode(t, tt.wantEncoding, w.Body))
+		})
+	}
+}
+
+func TestCompressWriter_Head(t *testing.T) {
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/json")
+		_, err := io.WriteString(w, largeJSON)
+		require.NoError(t, err)
+	}))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodHead, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(acceptEncoding, "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("Error closing response body: %v", err)
+		}
+	}()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(contentEncoding))
+	assert.Equal(t, acceptEncoding, resp.Header.Get(vary))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Empty(t, body)
+}
+
+func TestCompressWriter_HeaderAfterWriteIgnored(t *testing.T) {
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/json")
+		_, err := io.WriteString(w, largeJSON)
+		require.NoError(t, err)
+		// заголовки уже отправлены, поздние изменения не должны ломать ответ
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(acceptEncoding, "br")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, largeJSON, decode(t, encodingBrotli, w.Body))
+}
+
+func TestCompressWriter_AlreadyEncoded(t *testing.T) {
+	precompressed := encode(t, encodingGzip, largeJSON)
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/json")
+		w.Header().Set(contentEncoding, encodingGzip)
+		_, err := w.Write(precompressed)
+		require.NoError(t, err)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(acceptEncoding, "zstd, gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, encodingGzip, w.Header().Get(contentEncoding))
+	assert.Equal(t, precompressed, w.Body.Bytes())
+}
+
+func TestCompressWriter_Flush(t *testing.T) {
+	chunks := []string{`{"event": 1}`, `{"event": 2}`}
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/json")
+		for _, chunk := range chunks {
+			_, err := io.WriteString(w, chunk+"\n")
+			require.NoError(t, err)
+			w.(http.Flusher).Flush()
+		}
+	}))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(acceptEncoding, "zstd")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("Error closing response body: %v", err)
+		}
+	}()
+
+	// потоковый ответ сжимается, хотя каждая часть меньше minSize
+	require.Equal(t, encodingZstd, resp.Header.Get(contentEncoding))
+	zr, err := encoders[encodingZstd].newReader(resp.Body)
+	require.NoError(t, err)
+	defer func() {
+		if err := zr.Close(); err != nil {
+			t.Errorf("Error closing reader: %v", err)
+		}
+	}()
+
+	// первая часть доступна клиенту до завершения ответа
+	line, err := bufio.NewReader(zr).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, chunks[0]+"\n", line)
+}
+
+func TestCompressWriter_Hijack(t *testing.T) {
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer func() {
+			if err := conn.Close(); err != nil {
+				t.Errorf("Error closing connection: %v", err)
+			}
+		}()
+
+		_, err = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+		require.NoError(t, err)
+		require.NoError(t, rw.Flush())
+	}))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(acceptEncoding, "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("Error closing response body: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestCompressWriter_HijackNotSupported(t *testing.T) {
+	cw := newCompressWriter(httptest.NewRecorder(), encodingGzip)
+	_, _, err := cw.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestCompressWriter_PoolReuse(t *testing.T) {
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/json")
+		_, err := io.WriteString(w, r.URL.Query().Get("prefix")+largeJSON)
+		require.NoError(t, err)
+	}))
+
+	// писатели из пула должны выдавать независимые потоки для последовательных ответов
+	for _, prefix := range []string{"a", "b", "c"} {
+		req := httptest.NewRequest(http.MethodGet, "/?prefix="+prefix, nil)
+		req.Header.Set(acceptEncoding, "deflate")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, encodingDeflate, w.Header().Get(contentEncoding))
+		assert.Equal(t, prefix+largeJSON, decode(t, encodingDeflate, w.Body))
+	}
+}
+
+func TestCompressWriter_SniffsContentType(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("<p>Hello</p>", 30) + "</body></html>"
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, page)
+		require.NoError(t, err)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(acceptEncoding, "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get(contentType))
+	assert.Equal(t, encodingGzip, w.Header().Get(contentEncoding))
+	assert.Equal(t, page, decode(t, encodingGzip, w.Body))
+}
@@ -0,0 +1,68 @@
+// Пакет limits ограничивает размер входящих HTTP-запросов.
+// Предоставляет middleware, ограничивающий размер тела запроса для группы маршрутов,
+// и единый ответ 413 Request Entity Too Large в формате application/problem+json.
+package limits
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+)
+
+// Ограничения по умолчанию, если они не заданы в конфигурации.
+const (
+	DefaultBodySize      int64 = 64 << 10 // тело запроса на создание одной ссылки
+	DefaultBatchBodySize int64 = 4 << 20  // тело пакетного запроса
+	DefaultBatchItems          = 1000     // количество элементов пакетного запроса
+)
+
+// Problem представляет тело ответа 413 Request Entity Too Large:
+// описание проблемы, дополненное значением превышенного ограничения.
+type Problem struct {
+	apierror.Problem
+	Limit int64 `json:"limit"` // значение ограничения: байты или количество элементов
+}
+
+// Body возвращает middleware, ограничивающий тело запроса limit байтами.
+// Запросы с заявленным Content-Length больше лимита отклоняются сразу,
+// остальные прерываются при чтении тела с ошибкой *http.MaxBytesError.
+// Если limit не положителен, используется DefaultBodySize.
+func Body(limit int64) func(http.Handler) http.Handler {
+	if limit <= 0 {
+		limit = DefaultBodySize
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				WriteTooLarge(w, r, "request body is too large", limit)
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TooLarge проверяет, вызвана ли ошибка превышением размера тела запроса.
+// Возвращает значение превышенного ограничения и true, если это так.
+func TooLarge(err error) (limit int64, ok bool) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return tooLarge.Limit, true
+	}
+	return 0, false
+}
+
+// WriteTooLarge отвечает статусом 413 Request Entity Too Large с кодом too_large
+// и значением ограничения в формате application/problem+json.
+func WriteTooLarge(w http.ResponseWriter, r *http.Request, message string, limit int64) {
+	problem := Problem{
+		Problem: apierror.NewProblem(r, apierror.New(apierror.CodeTooLarge, message)),
+		Limit:   limit,
+	}
+	apierror.WriteProblem(w, problem.Status, problem)
+}
@@ -0,0 +1,81 @@
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler возвращает тело запроса или 413, если оно превышает лимит.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if limit, ok := TooLarge(err); ok {
+		WriteTooLarge(w, r, "request body is too large", limit)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = w.Write(body)
+})
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		wantStatus    int
+	}{
+		{name: "Within limit", body: "0123456789", contentLength: 10, wantStatus: http.StatusOK},
+		{name: "Declared length over limit", body: strings.Repeat("a", 11), contentLength: 11, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "Unknown length over limit", body: strings.Repeat("a", 11), contentLength: -1, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			w := httptest.NewRecorder()
+			Body(10)(echoHandler).ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.body, w.Body.String())
+				return
+			}
+
+			var out Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+			assert.Equal(t, apierror.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, apierror.CodeTooLarge, out.Code)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, out.Status)
+			assert.Equal(t, "request body is too large", out.Detail)
+			assert.Equal(t, int64(10), out.Limit)
+		})
+	}
+}
+
+func TestBody_Default(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", int(DefaultBodySize)+1)))
+	w := httptest.NewRecorder()
+	Body(0)(echoHandler).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestTooLarge(t *testing.T) {
+	limit, ok := TooLarge(fmt.Errorf("read body: %w", &http.MaxBytesError{Limit: 42}))
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), limit)
+
+	_, ok = TooLarge(io.ErrUnexpectedEOF)
+	assert.False(t, ok)
+}
@@ -0,0 +1,44 @@
+// Пакет logctx передаёт через контекст логгер и идентификатор запроса.
+// Вынесен из пакета logging, чтобы им могли пользоваться пакеты,
+// от которых зависит middleware журналирования.
+package logctx
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// contextKey - тип ключей контекста пакета.
+type contextKey int
+
+const (
+	loggerKey    contextKey = iota // ключ логгера запроса
+	requestIDKey                   // ключ идентификатора запроса
+)
+
+// NewContext возвращает копию контекста с логгером запроса.
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext возвращает логгер запроса из контекста.
+// Логгер запроса уже содержит идентификаторы запроса и трейса.
+// Если логгера в контексте нет, возвращает глобальный логгер.
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
+
+// WithRequestID возвращает копию контекста с идентификатором запроса.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
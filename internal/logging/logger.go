@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Форматы журнала.
+const (
+	FormatJSON    = "json"    // JSON-строки для сборщиков журналов
+	FormatConsole = "console" // человекочитаемый формат для локальной разработки
+)
+
+// NewLogger создает логгер по настройкам журнала из конфигурации.
+// По умолчанию пишет в stderr JSON-записи уровня info и выше.
+// Если задан файл журнала, пишет в него с ротацией по размеру, количеству и возрасту файлов.
+// Возвращает ошибку, если уровень или формат журнала неизвестны.
+func NewLogger(c config.Config) (*zap.Logger, error) {
+	level := zapcore.InfoLevel
+	if len(c.LogLevel) > 0 {
+		var err error
+		level, err = zapcore.ParseLevel(c.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var encoder zapcore.Encoder
+	switch c.LogFormat {
+	case "", FormatJSON:
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		return nil, fmt.Errorf("unknown log format: %q", c.LogFormat)
+	}
+
+	output := zapcore.Lock(os.Stderr)
+	if len(c.LogFile) > 0 {
+		output = zapcore.AddSync(&lumberjack.Logger{
+			Filename:   c.LogFile,
+			MaxSize:    c.LogMaxSize,
+			MaxBackups: c.LogMaxBackups,
+			MaxAge:     c.LogMaxAge,
+		})
+	}
+
+	core := zapcore.NewCore(encoder, output, level)
+	return zap.New(core, zap.AddCaller(), zap.ErrorOutput(zapcore.Lock(os.Stderr))), nil
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iubondar/url-shortener/internal/app/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.Config
+		level   zapcore.Level
+		wantErr bool
+	}{
+		{name: "Defaults", config: config.Config{}, level: zapcore.InfoLevel},
+		{name: "Debug console", config: config.Config{LogLevel: "debug", LogFormat: FormatConsole}, level: zapcore.DebugLevel},
+		{name: "Unknown level", config: config.Config{LogLevel: "verbose"}, wantErr: true},
+		{name: "Unknown format", config: config.Config{LogFormat: "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, err := NewLogger(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, logger.Core().Enabled(tt.level))
+			assert.False(t, logger.Core().Enabled(tt.level-1))
+		})
+	}
+}
+
+func TestNewLogger_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shortener.log")
+
+	logger, err := NewLogger(config.Config{LogFile: path, LogMaxSize: 1, LogMaxBackups: 1})
+	require.NoError(t, err)
+
+	logger.Info("request", zap.String("request_id", "req-1"), zap.Int("status", 200))
+	require.NoError(t, logger.Sync())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(data, &line))
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, 200.0, line["status"])
+}
@@ -1,25 +1,29 @@
-// Пакет logging предоставляет middleware для логирования HTTP-запросов.
-// Использует zap для структурированного логирования с информацией о запросе,
-// ответе и времени выполнения.
+// Пакет logging предоставляет логгер сервиса и middleware для журналирования HTTP-запросов.
+// Логгер настраивается из конфигурации: уровень, формат и файл журнала с ротацией.
+// Middleware пишет структурированную запись о каждом запросе с идентификатором запроса,
+// идентификатором трейса, пользователем, адресом клиента и шаблоном маршрута,
+// а также передаёт обработчикам логгер запроса через контекст.
 package logging
 
 import (
-	"log"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"github.com/iubondar/url-shortener/internal/tracing"
 	"go.uber.org/zap"
 )
 
-var globalLogger *zap.Logger
+// RequestIDHeader - заголовок с идентификатором запроса.
+// Идентификатор принимается от клиента или прокси и возвращается в ответе.
+const RequestIDHeader = "X-Request-ID"
 
-func init() {
-	var err error
-	globalLogger, err = zap.NewDevelopment()
-	if err != nil {
-		log.Fatalf("can't initialize zap logger: %v", err)
-	}
-}
+// maxRequestIDLength ограничивает длину идентификатора запроса, принятого от клиента.
+const maxRequestIDLength = 128
 
 type (
 	// responseData хранит информацию об ответе сервера
@@ -52,21 +56,44 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
+// Flush передаёт клиенту буферизованные данные, если это поддерживает оригинальный http.ResponseWriter.
+// Реализует интерфейс http.Flusher, чтобы потоковые ответы не буферизовались целиком.
+func (r *loggingResponseWriter) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает оригинальный http.ResponseWriter для http.ResponseController.
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // WithLogging создает middleware для логирования HTTP-запросов.
 // Логирует следующую информацию о каждом запросе:
-// - URI запроса
-// - HTTP метод
+// - Идентификатор запроса и идентификатор трейса
+// - URI запроса, HTTP метод и шаблон маршрута
 // - Код статуса ответа
 // - Время выполнения запроса
 // - Размер ответа в байтах
+// - Идентификатор пользователя, IP-адрес клиента и User-Agent
 //
-// Использует zap для структурированного логирования в режиме разработки.
+// Идентификатор запроса берётся из заголовка X-Request-ID или генерируется и возвращается в ответе.
+// Обработчики получают логгер запроса через logctx.FromContext.
+// Записи пишутся глобальным логгером zap.
 func WithLogging(h http.Handler) http.Handler {
-	sugar := globalLogger.Sugar()
-
 	logFn := func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		requestID := requestIDFrom(r)
+		w.Header().Set(RequestIDHeader, requestID)
+
+		logger := zap.L().With(zap.String("request_id", requestID))
+		if traceID := tracing.TraceID(r.Context()); len(traceID) > 0 {
+			logger = logger.With(zap.String("trace_id", traceID))
+		}
+
+		ctx := logctx.WithRequestID(r.Context(), requestID)
+		ctx = logctx.NewContext(ctx, logger)
+
 		responseData := &responseData{
 			status: 0,
 			size:   0,
@@ -75,17 +102,79 @@ func WithLogging(h http.Handler) http.Handler {
 			ResponseWriter: w, // встраиваем оригинальный http.ResponseWriter
 			responseData:   responseData,
 		}
-		h.ServeHTTP(&lw, r) // внедряем реализацию http.ResponseWriter
+		h.ServeHTTP(&lw, r.WithContext(ctx)) // внедряем реализацию http.ResponseWriter
 
-		duration := time.Since(start)
+		status := responseData.status
+		if status == 0 {
+			status = http.StatusOK
+		}
 
-		sugar.Infoln(
-			"uri", r.RequestURI,
-			"method", r.Method,
-			"status", responseData.status, // получаем перехваченный код статуса ответа
-			"duration", duration,
-			"size", responseData.size, // получаем перехваченный размер ответа
+		logger.Info("request",
+			zap.String("method", r.Method),
+			zap.String("uri", r.RequestURI),
+			zap.String("route", routePattern(r)),
+			zap.Int("status", status),
+			zap.Duration("duration", time.Since(start)),
+			zap.Int("size", responseData.size),
+			zap.String("user_id", userID(r, w.Header())),
+			zap.String("remote_ip", remoteIP(r)),
+			zap.String("user_agent", r.UserAgent()),
 		)
 	}
 	return http.HandlerFunc(logFn)
 }
+
+// requestIDFrom возвращает идентификатор запроса из заголовка X-Request-ID,
+// если он непустой, не слишком длинный и состоит из видимых ASCII-символов.
+// Иначе генерирует новый идентификатор.
+func requestIDFrom(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return uuid.New().String()
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return uuid.New().String()
+		}
+	}
+	return id
+}
+
+// routePattern возвращает шаблон маршрута chi или пустую строку, если маршрут не найден.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// userID возвращает идентификатор пользователя из cookie авторизации запроса,
+// а если её нет - из cookie, выданной обработчиком в ответе.
+// Возвращает пустую строку для анонимных запросов.
+func userID(r *http.Request, header http.Header) string {
+	if cookie, err := r.Cookie(auth.AuthCookieName); err == nil {
+		if id, err := auth.GetUserID(cookie.Value); err == nil {
+			return id.String()
+		}
+	}
+
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		if cookie.Name != auth.AuthCookieName {
+			continue
+		}
+		if id, err := auth.GetUserID(cookie.Value); err == nil {
+			return id.String()
+		}
+	}
+
+	return ""
+}
+
+// remoteIP возвращает IP-адрес клиента из RemoteAddr.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -5,9 +5,25 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/logging/logctx"
+	"github.com/iubondar/url-shortener/internal/tracing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+// observeLogs подменяет глобальный логгер на логгер, сохраняющий записи в памяти.
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zap.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(restore)
+	return logs
+}
+
 func TestWithLogging(t *testing.T) {
 	// Создаем тестовый обработчик
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -32,3 +48,119 @@ func TestWithLogging(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "test response", w.Body.String())
 }
+
+func TestWithLogging_AccessLine(t *testing.T) {
+	logs := observeLogs(t)
+
+	userID := uuid.New()
+	cookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(WithLogging)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logctx.FromContext(r.Context()).Info("handler")
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.RemoteAddr = "192.0.2.10:5555"
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set(RequestIDHeader, "req-42")
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+	require.Equal(t, 2, logs.Len())
+
+	handlerLine := logs.All()[0]
+	assert.Equal(t, "handler", handlerLine.Message)
+	assert.Equal(t, "req-42", handlerLine.ContextMap()["request_id"])
+
+	accessLine := logs.All()[1].ContextMap()
+	assert.Equal(t, "req-42", accessLine["request_id"])
+	assert.Equal(t, http.MethodGet, accessLine["method"])
+	assert.Equal(t, "/abc", accessLine["uri"])
+	assert.Equal(t, "/{id}", accessLine["route"])
+	assert.Equal(t, int64(http.StatusTemporaryRedirect), accessLine["status"])
+	assert.Equal(t, userID.String(), accessLine["user_id"])
+	assert.Equal(t, "192.0.2.10", accessLine["remote_ip"])
+	assert.Equal(t, "test-agent", accessLine["user_agent"])
+}
+
+func TestWithLogging_RequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "Missing", requestID: "", keep: false},
+		{name: "Valid", requestID: "0f8fad5b-d9cb-469f-a165-70867728950e", keep: true},
+		{name: "With spaces", requestID: "bad id", keep: false},
+		{name: "Too long", requestID: string(make([]byte, maxRequestIDLength+1)), keep: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = logctx.RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(tt.requestID) > 0 {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, fromContext)
+			if tt.keep {
+				assert.Equal(t, tt.requestID, got)
+			} else {
+				assert.NotEqual(t, tt.requestID, got)
+			}
+		})
+	}
+}
+
+func TestWithLogging_UserIDFromNewCookie(t *testing.T) {
+	logs := observeLogs(t)
+
+	var userID uuid.UUID
+	handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		userID, err = auth.GetUserIDFromAuthCookieOrSetNew(w, r)
+		require.NoError(t, err)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	access := logs.FilterMessage("request")
+	require.Equal(t, 1, access.Len())
+	assert.Equal(t, userID.String(), access.All()[0].ContextMap()["user_id"])
+}
+
+func TestWithLogging_TraceID(t *testing.T) {
+	logs := observeLogs(t)
+
+	tracing.SetupInMemory()
+	handler := tracing.WithTracing(WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logs.All()[0].ContextMap()["trace_id"])
+}
+
+func TestFromContext_Default(t *testing.T) {
+	logs := observeLogs(t)
+
+	logctx.FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()).Info("global")
+	assert.Equal(t, 1, logs.FilterMessage("global").Len())
+}
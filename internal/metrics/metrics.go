@@ -0,0 +1,202 @@
+// Пакет metrics предоставляет метрики сервиса в формате Prometheus.
+// Включает метрики HTTP-запросов с разбивкой по шаблону маршрута и статусу,
+// счётчики переходов по коротким ссылкам, длительность операций хранилища,
+// глубину очереди асинхронного удаления, количество записей, удалённых по истечении срока хранения,
+// результаты доставки вебхуков,
+// распределение чтений между основной базой данных
+// и репликами и метрики среды выполнения Go.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shortener"
+
+// Результаты перехода по короткой ссылке.
+const (
+	RedirectHit  = "hit"  // ссылка найдена, выполнен редирект
+	RedirectMiss = "miss" // ссылка не найдена
+	RedirectGone = "gone" // ссылка удалена или заблокирована
+)
+
+// Результаты попытки доставки вебхука.
+const (
+	WebhookDelivered = "delivered" // получатель принял событие
+	WebhookFailed    = "failed"    // попытка не удалась, доставка будет повторена
+	WebhookAbandoned = "abandoned" // попытки исчерпаны, доставка прекращена
+)
+
+// Registry содержит все метрики сервиса.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests считает обработанные HTTP-запросы.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration измеряет длительность обработки HTTP-запросов.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// Redirects считает переходы по коротким ссылкам по результату.
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Number of short link lookups by result.",
+	}, []string{"result"})
+
+	// RepositoryDuration измеряет длительность операций хранилища.
+	RepositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Storage operation latency.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"backend", "operation", "result"})
+
+	// DBReads считает чтения базы данных по месту выполнения (primary или replica)
+	// и причине выбора основной базы данных.
+	DBReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_reads_total",
+		Help:      "Number of routed database reads by target and reason.",
+	}, []string{"target", "reason"})
+
+	// HealthyReplicas показывает количество реплик базы данных, принимающих чтения.
+	HealthyReplicas = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_healthy_replicas",
+		Help:      "Number of database replicas currently serving reads.",
+	})
+
+	// DeleteQueueDepth показывает количество ссылок, ожидающих асинхронного удаления.
+	DeleteQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "delete_queue_depth",
+		Help:      "Number of deletions waiting in the asynchronous queue.",
+	})
+
+	// RetentionPurged считает записи, безвозвратно удалённые по истечении срока хранения.
+	RetentionPurged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retention_purged_urls_total",
+		Help:      "Number of deleted and expired URLs purged after the retention period.",
+	})
+
+	// WebhookDeliveries считает попытки доставки вебхуков по результату.
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of webhook delivery attempts by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		HTTPRequests,
+		HTTPDuration,
+		Redirects,
+		RepositoryDuration,
+		DeleteQueueDepth,
+		RetentionPurged,
+		WebhookDeliveries,
+		DBReads,
+		HealthyReplicas,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler возвращает HTTP-обработчик, отдающий метрики в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRepository фиксирует длительность операции хранилища, начатой в момент start.
+// Результат операции попадает в метку result: ok, not_found или error.
+func ObserveRepository(backend string, operation string, start time.Time, err error) {
+	result := "ok"
+	if errors.Is(err, models.ErrorNotFound) {
+		result = "not_found"
+	} else if err != nil {
+		result = "error"
+	}
+	RepositoryDuration.WithLabelValues(backend, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// statusRecorder перехватывает код статуса ответа.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader сохраняет код статуса и передаёт его дальше.
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write фиксирует статус 200 OK, если он не был установлен явно.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush фиксирует статус 200 OK, если он не был установлен явно,
+// и передаёт клиенту буферизованные данные, если это поддерживает оригинальный http.ResponseWriter.
+func (r *statusRecorder) Flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает оригинальный http.ResponseWriter для http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// WithMetrics создает middleware, считающий запросы и их длительность.
+// В качестве метки route используется шаблон маршрута chi, например "/{id}",
+// чтобы количество рядов не зависело от конкретных идентификаторов.
+func WithMetrics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		h.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		labels := []string{route, r.Method, strconv.Itoa(status)}
+		HTTPRequests.WithLabelValues(labels...).Inc()
+		HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
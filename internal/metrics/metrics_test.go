@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(WithMetrics)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("/{id}", http.MethodGet, "307"))
+	for _, id := range []string{"abc", "def"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+id, nil))
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	}
+
+	after := testutil.ToFloat64(HTTPRequests.WithLabelValues("/{id}", http.MethodGet, "307"))
+	assert.Equal(t, 2.0, after-before)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/nowhere/at/all", nil))
+	assert.Equal(t, 1.0, testutil.ToFloat64(HTTPRequests.WithLabelValues("unmatched", http.MethodPost, "404")))
+}
+
+func TestHandler(t *testing.T) {
+	Redirects.WithLabelValues(RedirectHit).Inc()
+	ObserveRepository("memory", "SaveURL", time.Now(), nil)
+	ObserveRepository("memory", "SaveURL", time.Now(), errors.New("boom"))
+	DeleteQueueDepth.Set(3)
+
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("Error closing response body: %v", err)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	assert.Contains(t, text, `shortener_redirects_total{result="hit"}`)
+	assert.Contains(t, text, `shortener_repository_operation_duration_seconds_count{backend="memory",operation="SaveURL",result="ok"} 1`)
+	assert.Contains(t, text, `shortener_repository_operation_duration_seconds_count{backend="memory",operation="SaveURL",result="error"} 1`)
+	assert.Contains(t, text, "shortener_delete_queue_depth 3")
+	assert.Contains(t, text, "go_goroutines")
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval задает период удаления неиспользуемых корзин.
+const sweepInterval = time.Minute
+
+// bucket хранит состояние одной корзины токенов.
+type bucket struct {
+	tokens float64   // текущее количество токенов
+	last   time.Time // время последнего обновления
+	full   time.Time // время, когда корзина снова заполнится
+}
+
+// MemoryStore хранит корзины токенов в памяти процесса.
+// Подходит для сервиса, запущенного в одном экземпляре.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore создает новое хранилище корзин в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take пытается списать n токенов из корзины с ключом key.
+// Запрос стоимостью больше ёмкости корзины всегда отклоняется с признаком Exceeded
+// и без RetryAfter, а токены корзины не расходуются.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	// пополняем корзину за прошедшее время
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = now
+
+	res := Result{}
+	switch {
+	case n > limit.Burst:
+		res.Exceeded = true
+	case float64(n) <= b.tokens:
+		b.tokens -= float64(n)
+		res.Allowed = true
+	default:
+		res.RetryAfter = seconds((float64(n) - b.tokens) / limit.Rate)
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+// sweep удаляет корзины, которые уже полностью восстановились,
+// так как их состояние совпадает с новой корзиной.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// seconds переводит дробное количество секунд в time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.Take(ctx, "k", limit, 1)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, err := store.Take(ctx, "k", limit, 1)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	// другой ключ не затронут
+	res, err = store.Take(ctx, "other", limit, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// через две секунды доступно два токена
+	now = now.Add(2 * time.Second)
+	res, err = store.Take(ctx, "k", limit, 2)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// запрос дороже ёмкости корзины всегда отклоняется без предложения повторить и не расходует токены
+	now = now.Add(time.Hour)
+	res, err = store.Take(ctx, "k", limit, 4)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.True(t, res.Exceeded)
+	assert.Zero(t, res.RetryAfter)
+	assert.Equal(t, 3, res.Remaining)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, err := store.Take(context.Background(), "k", limit, 1)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+
+	now = now.Add(2 * sweepInterval)
+	_, err = store.Take(context.Background(), "other", limit, 0)
+	require.NoError(t, err)
+	assert.NotContains(t, store.buckets, "k")
+}
@@ -0,0 +1,234 @@
+// Пакет ratelimit предоставляет middleware для ограничения частоты запросов
+// по алгоритму token bucket. Каждый запрос расходует корзину IP-адреса клиента,
+// а запрос с cookie авторизации - ещё и корзину пользователя.
+//
+// Состояние ограничителей хранится в Store. MemoryStore подходит для одного экземпляра
+// сервиса; для нескольких экземпляров нужна реализация Store поверх общего хранилища.
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iubondar/url-shortener/internal/api/apierror"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/iubondar/url-shortener/internal/limits"
+	"go.uber.org/zap"
+)
+
+// Заголовки ответа с информацией об ограничении.
+const (
+	HeaderLimit     = "X-RateLimit-Limit"     // ёмкость корзины
+	HeaderRemaining = "X-RateLimit-Remaining" // оставшиеся токены
+	HeaderReset     = "X-RateLimit-Reset"     // секунды до полного восстановления корзины
+	HeaderRetry     = "Retry-After"           // секунды до повторной попытки
+)
+
+// Limit описывает параметры корзины токенов.
+type Limit struct {
+	Rate  float64 // скорость пополнения, токенов в секунду
+	Burst int     // ёмкость корзины
+}
+
+// Enabled сообщает, задано ли ограничение.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result представляет результат попытки списать токены.
+type Result struct {
+	Allowed    bool          // запрос разрешён
+	Exceeded   bool          // стоимость запроса больше ёмкости корзины, повторять его бессмысленно
+	Remaining  int           // оставшиеся целые токены
+	RetryAfter time.Duration // время до возможности повторить запрос, если он отклонён
+	Reset      time.Duration // время до полного восстановления корзины
+}
+
+// Store определяет хранилище состояния корзин токенов.
+// Реализация должна быть безопасна для конкурентного использования;
+// для работы нескольких экземпляров сервиса её нужно реализовать поверх общего хранилища.
+type Store interface {
+	// Take пытается списать n токенов из корзины с ключом key.
+	Take(ctx context.Context, key string, limit Limit, n int) (Result, error)
+}
+
+// KeyFunc возвращает ключ ограничения для запроса.
+type KeyFunc func(r *http.Request) string
+
+// CostFunc возвращает количество токенов, которое стоит запрос.
+type CostFunc func(r *http.Request) int
+
+// Limiter ограничивает частоту запросов к группе маршрутов.
+type Limiter struct {
+	name  string   // имя группы, добавляется к ключу
+	store Store    // хранилище корзин
+	limit Limit    // параметры корзины
+	key   KeyFunc  // функция получения ключа
+	cost  CostFunc // функция стоимости запроса
+}
+
+// NewLimiter создает ограничитель для группы маршрутов с указанным именем.
+// По умолчанию запрос стоит один токен, а ключом служит пользователь или IP-адрес;
+// корзина IP-адреса расходуется в любом случае.
+func NewLimiter(name string, store Store, limit Limit) *Limiter {
+	return &Limiter{
+		name:  name,
+		store: store,
+		limit: limit,
+		key:   UserOrIPKey,
+		cost:  func(*http.Request) int { return 1 },
+	}
+}
+
+// WithCost задает функцию стоимости запроса.
+func (l *Limiter) WithCost(cost CostFunc) *Limiter {
+	l.cost = cost
+	return l
+}
+
+// WithKey задает функцию получения ключа ограничения.
+func (l *Limiter) WithKey(key KeyFunc) *Limiter {
+	l.key = key
+	return l
+}
+
+// Middleware возвращает middleware, отклоняющий запросы сверх лимита
+// со статусом 429 Too Many Requests и заголовком Retry-After.
+// Запросы стоимостью больше ёмкости корзины никогда не будут разрешены, поэтому отклоняются
+// со статусом 413 Request Entity Too Large без Retry-After и без обращения к хранилищу.
+// Запрос списывает токены из корзины IP-адреса клиента, а если ключ запроса другой, например,
+// пользователь, - ещё и из его корзины: сменой или удалением cookie нельзя получить больше
+// запросов, чем разрешено одному IP-адресу. Заголовки X-RateLimit-* описывают более строгую корзину.
+// Все ответы содержат заголовки X-RateLimit-*.
+// Если хранилище недоступно, запрос пропускается.
+func (l *Limiter) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cost := l.cost(r)
+		if cost > l.limit.Burst {
+			w.Header().Set(HeaderLimit, strconv.Itoa(l.limit.Burst))
+			limits.WriteTooLarge(w, r, fmt.Sprintf("Request costs %d tokens, more than the rate limit allows", cost),
+				int64(l.limit.Burst))
+			return
+		}
+
+		keys := []string{IPKey(r)}
+		if key := l.key(r); key != keys[0] {
+			keys = append(keys, key)
+		}
+		var res Result
+		for i, key := range keys {
+			taken, err := l.store.Take(r.Context(), l.name+":"+key, l.limit, cost)
+			if err != nil {
+				zap.L().Sugar().Errorf("rate limit store error: %v", err)
+				h.ServeHTTP(w, r)
+				return
+			}
+			if i == 0 || !taken.Allowed || taken.Remaining < res.Remaining {
+				res = taken
+			}
+			if !taken.Allowed {
+				// отклонённый запрос не расходует следующие корзины
+				break
+			}
+		}
+
+		w.Header().Set(HeaderLimit, strconv.Itoa(l.limit.Burst))
+		w.Header().Set(HeaderRemaining, strconv.Itoa(res.Remaining))
+		w.Header().Set(HeaderReset, strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			w.Header().Set(HeaderRetry, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			apierror.Write(w, r, apierror.New(apierror.CodeRateLimited, "Too Many Requests"))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// UserOrIPKey возвращает ключ по идентификатору пользователя из валидной cookie авторизации,
+// а при её отсутствии - по IP-адресу клиента.
+func UserOrIPKey(r *http.Request) string {
+	if cookie, err := r.Cookie(auth.AuthCookieName); err == nil {
+		if userID, err := auth.GetUserID(cookie.Value); err == nil {
+			return "user:" + userID.String()
+		}
+	}
+	return IPKey(r)
+}
+
+// IPKey возвращает ключ по IP-адресу клиента.
+func IPKey(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// ClientIP возвращает IP-адрес клиента из RemoteAddr.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// JSONArrayCost возвращает стоимость запроса, равную количеству элементов
+// JSON-массива в теле запроса, но не меньше одного токена.
+// Тело запроса вычитывается и подменяется копией для следующих обработчиков.
+func JSONArrayCost(r *http.Request) int {
+	if r.Body == nil {
+		return 1
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return 1
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil || len(items) == 0 {
+		return 1
+	}
+	return len(items)
+}
+
+// RecordsCost возвращает стоимость запроса, равную количеству записей в теле:
+// элементов JSON-массива или непустых строк для построчных форматов (CSV, NDJSON),
+// но не меньше одного токена. Заголовок CSV считается записью, это завышает стоимость на один токен.
+// Тело запроса вычитывается и подменяется копией для следующих обработчиков.
+func RecordsCost(r *http.Request) int {
+	if r.Body == nil {
+		return 1
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return 1
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err == nil {
+		return max(len(items), 1)
+	}
+	count := 0
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			count++
+		}
+	}
+	return max(count, 1)
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
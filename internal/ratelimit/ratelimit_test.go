@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iubondar/url-shortener/internal/app/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	limiter := NewLimiter("create", NewMemoryStore(), Limit{Rate: 0.5, Burst: 2})
+	handler := limiter.Middleware(next)
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		return w
+	}
+
+	w := do("10.0.0.1:1000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderLimit))
+	assert.Equal(t, "1", w.Header().Get(HeaderRemaining))
+	assert.Equal(t, "2", w.Header().Get(HeaderReset))
+
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:1001").Code)
+
+	w = do("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderRetry))
+	assert.Equal(t, "0", w.Header().Get(HeaderRemaining))
+
+	// другой IP ограничивается отдельно
+	assert.Equal(t, http.StatusOK, do("10.0.0.2:1000").Code)
+}
+
+func TestLimiter_UserAndIPBuckets(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	store := NewMemoryStore()
+	handler := NewLimiter("create", store, Limit{Rate: 0.5, Burst: 2}).Middleware(next)
+
+	do := func(remoteAddr string, userID uuid.UUID) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.RemoteAddr = remoteAddr
+		cookie, err := auth.NewAuthCookie(userID)
+		require.NoError(t, err)
+		request.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		return w
+	}
+
+	// новая cookie на каждый запрос не даёт обойти ограничение IP-адреса
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:1000", uuid.New()).Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:1001", uuid.New()).Code)
+	w := do("10.0.0.1:1002", uuid.New())
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get(HeaderRetry))
+
+	// пользователь ограничивается и при смене IP-адреса
+	userID := uuid.New()
+	assert.Equal(t, http.StatusOK, do("10.0.0.2:1000", userID).Code)
+	w = do("10.0.0.3:1000", userID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(HeaderRemaining), "headers must describe the stricter bucket")
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.4:1000", userID).Code)
+	// отклонённый корзиной пользователя запрос расходует только корзину IP-адреса
+	assert.Equal(t, http.StatusOK, do("10.0.0.4:1001", uuid.New()).Code)
+}
+
+func TestLimiter_CostExceedsBurst(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	limiter := NewLimiter("batch", NewMemoryStore(), Limit{Rate: 1, Burst: 2}).WithCost(JSONArrayCost)
+	handler := limiter.Middleware(next)
+
+	do := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewBufferString(body))
+		request.RemoteAddr = "10.0.0.1:1000"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		return w
+	}
+
+	w := do(`[1, 2, 3]`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderRetry), "request that can never succeed must not be retried")
+	assert.Equal(t, "2", w.Header().Get(HeaderLimit))
+	var problem struct {
+		Limit int64 `json:"limit"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, int64(2), problem.Limit)
+
+	// отклонённый запрос не расходует токены
+	w = do(`[1, 2]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(HeaderRemaining))
+}
+
+func TestLimiter_StoreFailureAllowsRequest(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewLimiter("create", failingStore{}, Limit{Rate: 1, Burst: 1}).Middleware(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUserOrIPKey(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "ip:192.0.2.1", UserOrIPKey(request))
+
+	request.AddCookie(&http.Cookie{Name: auth.AuthCookieName, Value: "invalid"})
+	assert.Equal(t, "ip:192.0.2.1", UserOrIPKey(request))
+
+	userID := uuid.New()
+	cookie, err := auth.NewAuthCookie(userID)
+	require.NoError(t, err)
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(cookie)
+	assert.Equal(t, "user:"+userID.String(), UserOrIPKey(request))
+}
+
+func TestJSONArrayCost(t *testing.T) {
+	body := `[{"original_url": "http://a.ru"}, {"original_url": "http://b.ru"}, {"original_url": "http://c.ru"}]`
+	request := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewReader([]byte(body)))
+
+	assert.Equal(t, 3, JSONArrayCost(request))
+
+	// тело запроса доступно следующему обработчику
+	got, err := io.ReadAll(request.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got))
+
+	request = httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewReader([]byte("not json")))
+	assert.Equal(t, 1, JSONArrayCost(request))
+}
+
+func TestRecordsCost(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "JSON array", body: `[{"original_url": "http://a.ru"}, {"original_url": "http://b.ru"}]`, want: 2},
+		{name: "NDJSON", body: "{\"original_url\": \"http://a.ru\"}\n\n{\"original_url\": \"http://b.ru\"}\n", want: 2},
+		{name: "CSV with header", body: "id,original_url\n1,http://a.ru\n2,http://b.ru\n", want: 3},
+		{name: "Empty body", body: "", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/user/urls/import", bytes.NewReader([]byte(tt.body)))
+			assert.Equal(t, tt.want, RecordsCost(request))
+
+			got, err := io.ReadAll(request.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(got))
+		})
+	}
+}
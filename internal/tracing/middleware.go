@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusRecorder перехватывает код статуса ответа.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int // HTTP-код ответа
+}
+
+// WriteHeader сохраняет код статуса и передаёт его дальше.
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.status = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush передаёт клиенту буферизованные данные, если это поддерживает оригинальный http.ResponseWriter.
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает оригинальный http.ResponseWriter для http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// WithTracing создает middleware трассировки HTTP-запросов.
+// Извлекает контекст трассировки из заголовка traceparent запроса, начинает серверный спан
+// и возвращает traceparent этого спана в заголовках ответа.
+// После обработки спан получает имя по шаблону маршрута chi и код статуса ответа.
+func WithTracing(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		// Передаём клиенту контекст трассировки для сопоставления запросов
+		propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+	})
+}
+
+// Handler оборачивает обработчик в спан с указанным именем.
+func Handler(name string, fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := Start(r.Context(), name)
+		defer span.End()
+
+		fn(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func TestWithTracing(t *testing.T) {
+	exporter := SetupInMemory()
+
+	r := chi.NewRouter()
+	r.Use(WithTracing)
+	r.Get("/{id}", Handler("RetrieveURL", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "repository.RetrieveByShortURL")
+		span.End()
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("traceparent"), "00-"+parentTraceID+"-"),
+		"response should carry the incoming trace ID")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	// Спаны завершаются от вложенного к внешнему
+	repoSpan, handlerSpan, serverSpan := spans[0], spans[1], spans[2]
+	assert.Equal(t, "repository.RetrieveByShortURL", repoSpan.Name)
+	assert.Equal(t, "RetrieveURL", handlerSpan.Name)
+	assert.Equal(t, "GET /{id}", serverSpan.Name)
+
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind)
+	assert.Equal(t, parentTraceID, serverSpan.SpanContext.TraceID().String())
+	assert.Equal(t, parentSpanID, serverSpan.Parent.SpanID().String())
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), handlerSpan.Parent.SpanID())
+	assert.Equal(t, handlerSpan.SpanContext.SpanID(), repoSpan.Parent.SpanID())
+	assert.Contains(t, serverSpan.Attributes, attribute.Int("http.response.status_code", http.StatusTemporaryRedirect))
+	assert.Contains(t, serverSpan.Attributes, attribute.String("http.route", "/{id}"))
+}
+
+func TestWithTracing_ServerError(t *testing.T) {
+	exporter := SetupInMemory()
+
+	h := WithTracing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/shorten", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.NotEmpty(t, w.Header().Get("traceparent"), "new trace should be started without incoming traceparent")
+}
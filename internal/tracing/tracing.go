@@ -0,0 +1,102 @@
+// Пакет tracing предоставляет распределённую трассировку на базе OpenTelemetry.
+// Настраивает глобальный провайдер трейсов с экспортом по OTLP или в память для тестов,
+// пропагатор W3C Trace Context и вспомогательные функции для создания спанов.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/iubondar/url-shortener"
+	serviceName         = "shortener"
+)
+
+// Setup настраивает глобальный провайдер трейсов с экспортом спанов в коллектор по OTLP/HTTP
+// и пропагатор W3C Trace Context.
+// Если endpoint пуст, спаны не экспортируются, но контекст трассировки передаётся дальше.
+// Возвращает функцию, которая отправляет накопленные спаны и останавливает провайдер.
+func Setup(ctx context.Context, endpoint string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if len(endpoint) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := newProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// SetupInMemory настраивает глобальный провайдер трейсов, сохраняющий спаны в памяти.
+// Используется в тестах для проверки созданных спанов.
+func SetupInMemory() *tracetest.InMemoryExporter {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(newProvider(sdktrace.WithSyncer(exporter)))
+
+	return exporter
+}
+
+// newProvider создает провайдер трейсов сервиса с указанным способом экспорта.
+func newProvider(export sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		export,
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
+
+// Tracer возвращает трассировщик сервиса из глобального провайдера.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start начинает дочерний спан с указанным именем.
+// Возвращает контекст с новым спаном и сам спан.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End завершает спан. Если операция завершилась ошибкой, отмечает её в спане.
+// Отсутствие записи не считается ошибкой.
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, models.ErrorNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject добавляет в заголовки исходящего запроса контекст трассировки в формате W3C traceparent.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID возвращает идентификатор трейса из контекста или пустую строку, если трейса нет.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/iubondar/url-shortener/internal/app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{name: "Success", err: nil, want: codes.Unset},
+		{name: "Not found is not an error", err: fmt.Errorf("lookup: %w", models.ErrorNotFound), want: codes.Unset},
+		{name: "Error", err: errors.New("connection refused"), want: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := SetupInMemory()
+
+			_, span := Start(context.Background(), "operation")
+			End(span, tt.err)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.want, spans[0].Status.Code)
+		})
+	}
+}
+
+func TestInjectAndTraceID(t *testing.T) {
+	SetupInMemory()
+
+	assert.Empty(t, TraceID(context.Background()))
+
+	ctx, span := Start(context.Background(), "outgoing")
+	defer span.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+
+	traceID := TraceID(ctx)
+	assert.Len(t, traceID, 32)
+	assert.Contains(t, header.Get("traceparent"), traceID)
+}
+
+func TestSetup_WithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}